@@ -5,18 +5,45 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v4/pgxpool"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
 	"net"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"rest-api-tutorial/docs"
+	"rest-api-tutorial/internal/account"
+	"rest-api-tutorial/internal/apikey"
+	"rest-api-tutorial/internal/auth"
 	"rest-api-tutorial/internal/config"
+	"rest-api-tutorial/internal/events"
 	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/graphqlapi"
+	"rest-api-tutorial/internal/grpcapi"
+	"rest-api-tutorial/internal/idempotency"
+	"rest-api-tutorial/internal/me"
+	"rest-api-tutorial/internal/ratelimit"
+	"rest-api-tutorial/internal/recommend"
+	"rest-api-tutorial/internal/routes"
+	"rest-api-tutorial/internal/session"
+	"rest-api-tutorial/internal/sso"
+	"rest-api-tutorial/internal/stats"
+	"rest-api-tutorial/internal/tenant"
 	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/internal/webhook"
+	"rest-api-tutorial/pkg/apiversion"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/cache"
 	"rest-api-tutorial/pkg/client/postgres"
+	"rest-api-tutorial/pkg/compress"
+	"rest-api-tutorial/pkg/deadline"
+	"rest-api-tutorial/pkg/format"
 	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/mail"
+	"rest-api-tutorial/pkg/oidc"
+	"rest-api-tutorial/pkg/openapi"
+	"rest-api-tutorial/pkg/stream"
+	"strings"
 	"time"
 )
 
@@ -35,9 +62,13 @@ import (
 // @schemes http https
 
 // @securityDefinitions.apikey BearerAuth
-// @in query
+// @in header
 // @name Authorization
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
 func main() {
 	logger := logging.GetLogger()
 	cfg := config.LoadConfigEnv()
@@ -56,7 +87,10 @@ func main() {
 	}
 
 	// Подключаемся к PostgreSQL с повторными попытками
-	pool, err := postgres.NewClient(initCtx, pgCfg, 5)
+	// Каждое соединение получает арендатора запроса, для которого оно берется из пула
+	pool, err := postgres.NewClient(initCtx, pgCfg, 5, func(c *pgxpool.Config) {
+		c.BeforeAcquire = tenant.BeforeAcquire
+	})
 	if err != nil {
 		logger.Fatalf("Failed to connect to PostgreSQL: %v", err)
 	}
@@ -68,24 +102,219 @@ func main() {
 	}
 
 	// Инициализация слоев приложения
+	batchLimits := batch.Limits{
+		MaxOperations: cfg.Batch.MaxOperations,
+		MaxBodyBytes:  cfg.Batch.MaxBodyBytes,
+	}
+
 	userStorage := user.NewUserStorage(pool, logger)
-	userHandler := user.NewHandler(userStorage, logger)
+	userHandler := user.NewHandler(userStorage, batchLimits, logger)
 
+	// Кеш чтения каталога сбрасывается при любом изменении фильмов
+	filmCache, err := newCache(initCtx, cfg)
+	if err != nil {
+		logger.Fatalf("Invalid cache configuration: %v", err)
+	}
 	filmStorage := films.NewFilmStorage(pool, logger)
-	filmHandler := films.NewHandler(filmStorage, logger)
+	var filmRepo films.FilmRepository = filmStorage
+	var cacheFilms gin.HandlerFunc
+	if filmCache != nil {
+		filmRepo = films.WithInvalidation(filmStorage, func(ctx context.Context) {
+			if err := filmCache.Invalidate(ctx, filmsCacheNamespace); err != nil {
+				logger.Errorf("Failed to invalidate films cache: %v", err)
+			}
+		})
+		cacheFilms = cache.Middleware(filmCache, cache.Options{
+			Namespace: filmsCacheNamespace,
+			TTL:       cfg.Cache.TTL,
+			MaxAge:    cfg.Cache.MaxAge,
+			Skip:      stream.Requested,
+			Partition: func(c *gin.Context) string {
+				id, _ := tenant.FromContext(c.Request.Context())
+				return id
+			},
+		}, logger)
+	}
+	filmHandler := films.NewHandler(filmRepo, batchLimits, logger)
+
+	// Статистика считается агрегатами по всему каталогу, поэтому кешируется
+	// дольше и не сбрасывается при изменениях
+	var cacheStats gin.HandlerFunc
+	if filmCache != nil {
+		cacheStats = cache.Middleware(filmCache, cache.Options{
+			Namespace: statsCacheNamespace,
+			TTL:       cfg.Stats.CacheTTL,
+			MaxAge:    cfg.Cache.MaxAge,
+			Partition: func(c *gin.Context) string {
+				id, _ := tenant.FromContext(c.Request.Context())
+				return id
+			},
+		}, logger)
+	}
+	statsHandler := stats.NewHandler(stats.NewStorage(pool, logger), logger)
+
+	userHandlerV2 := user.NewHandlerV2(userStorage, logger)
+	filmHandlerV2 := films.NewHandlerV2(filmRepo, logger)
+	deprecateV1, err := newDeprecation(cfg)
+	if err != nil {
+		logger.Fatalf("Invalid versioning configuration: %v", err)
+	}
+
+	idempotencyStorage := idempotency.NewStorage(pool, logger)
+	idempotent := idempotency.Middleware(idempotencyStorage, idempotency.Options{
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Idempotency.LockTimeout,
+		StaleAfter:  cfg.Idempotency.StaleAfter,
+		// Идемпотентны создание и пакетные операции, их тело ограничено лимитом batch
+		MaxBodyBytes: cfg.Batch.MaxBodyBytes,
+	}, logger)
+	go idempotencyStorage.Run(context.Background())
+	apiKeyStorage := apikey.NewStorage(pool, logger)
+	apiKeyHandler := apikey.NewHandler(apiKeyStorage, logger)
+	anonymousScopes, err := auth.ParseScopes(cfg.Auth.AnonymousScopes)
+	if err != nil {
+		logger.Fatalf("Invalid auth configuration: %v", err)
+	}
+	userScopes, err := auth.ParseScopes(cfg.Auth.UserScopes)
+	if err != nil {
+		logger.Fatalf("Invalid auth configuration: %v", err)
+	}
+	if cfg.Auth.TokenSecret == "" {
+		logger.Warn("AUTH_TOKEN_SECRET is not set, access tokens will not survive a restart")
+	}
+	tokens, err := auth.NewTokens(cfg.Auth.TokenSecret, cfg.Auth.TokenTTL, userScopes)
+	if err != nil {
+		logger.Fatalf("Invalid auth configuration: %v", err)
+	}
+	// Токен доступа действует, пока не отозван его сеанс
+	sessionStorage := session.NewStorage(pool, logger)
+	sessionOpts := session.Options{TTL: cfg.Auth.SessionTTL}
+	if err := sessionOpts.Validate(); err != nil {
+		logger.Fatalf("Invalid auth configuration: %v", err)
+	}
+	sessionManager := session.NewManager(sessionStorage, tokens, sessionOpts)
+	sessionHandler := session.NewHandler(sessionManager, sessionStorage, logger)
+	authOpts := auth.Options{
+		AdminKey:        cfg.Auth.AdminKey,
+		AnonymousScopes: anonymousScopes,
+		Tokens:          tokens,
+		Sessions:        sessionStorage,
+	}
+	authenticate := auth.Middleware(apiKeyStorage, authOpts, logger)
+
+	// Вход пользователей через корпоративный SSO начинает сеанс
+	ssoHandler, err := sso.NewHandler(newSSOProviders(cfg), sso.NewStorage(pool, logger), sessionManager, sso.Options{
+		StateTTL:   cfg.OIDC.StateTTL,
+		ReturnURLs: strings.FieldsFunc(cfg.OIDC.ReturnURLs, func(r rune) bool { return r == ',' || r == ' ' }),
+	}, logger)
+	if err != nil {
+		logger.Fatalf("Invalid OIDC configuration: %v", err)
+	}
+
+	// Арендатор определяется по ключу, заголовку X-Tenant-ID или поддомену
+	tenantStorage := tenant.NewStorage(pool, logger)
+	tenantHandler := tenant.NewHandler(tenantStorage, logger)
+	tenantResolver := tenant.NewResolver(tenantStorage, tenant.Options{
+		BaseDomain: cfg.Tenancy.BaseDomain,
+		Default:    cfg.Tenancy.Default,
+		// Регистрация, восстановление пароля и вход открыты без ключа и должны
+		// работать в каталоге поддомена
+		AnonymousPaths: []string{"/api/account", "/api/auth"},
+	})
+	// Суперпользователь и роли с BYPASSRLS видят строки всех арендаторов
+	if bypass, err := tenantStorage.BypassesRLS(initCtx); err != nil {
+		logger.Fatalf("Failed to check tenant isolation: %v", err)
+	} else if bypass {
+		logger.Fatalf("Database role %q bypasses row-level security, tenant catalogs are not isolated; connect as movies_app (migrations/013_app_role.sql)", cfg.PostgreSQL.Username)
+	}
+	resolveTenant := tenant.Middleware(tenantResolver, logger)
+
+	// Письма подтверждения email и восстановления пароля
+	mailer, err := newMailer(cfg)
+	if err != nil {
+		logger.Fatalf("Invalid mail configuration: %v", err)
+	}
+	notifier, err := account.NewNotifier(mailer, cfg.Account.DefaultLanguage)
+	if err != nil {
+		logger.Fatalf("Invalid account configuration: %v", err)
+	}
+	accountOpts := account.Options{
+		VerifyTTL: cfg.Account.VerifyTTL,
+		ResetTTL:  cfg.Account.ResetTTL,
+		VerifyURL: cfg.Account.VerifyURL,
+		ResetURL:  cfg.Account.ResetURL,
+	}
+	if err := accountOpts.Validate(); err != nil {
+		logger.Fatalf("Invalid account configuration: %v", err)
+	}
+	accountHandler := account.NewHandler(account.NewStorage(pool, logger), notifier, sessionManager, accountOpts, logger)
+	meHandler := me.NewHandler(me.NewStorage(pool, logger), me.Options{DefaultLanguage: cfg.Account.DefaultLanguage}, logger)
+
+	// Изменения каталога приходят через LISTEN/NOTIFY от любой реплики
+	eventStorage := events.NewStorage(pool, logger)
+	eventBroker := events.NewBroker()
+	eventHandler := events.NewHandler(eventStorage, eventBroker, cfg.Events.Heartbeat, logger)
+	go events.NewListener(pool, eventStorage, eventBroker, cfg.Events.Retention, logger).Run(context.Background())
+	if memoryCache, ok := filmCache.(*cache.MemoryStore); ok {
+		go invalidateOnEvents(eventBroker, memoryCache, logger)
+	}
+
+	// Сообщения outbox пишутся триггером в транзакции изменения, диспетчер доставляет их подпискам
+	webhookStorage := webhook.NewStorage(pool, logger)
+	webhookHandler := webhook.NewHandler(webhookStorage, logger)
+	if cfg.Webhooks.Enabled {
+		dispatcherOpts := webhook.DispatcherOptions{
+			PollInterval: cfg.Webhooks.PollInterval,
+			Timeout:      cfg.Webhooks.Timeout,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			Backoff:      cfg.Webhooks.Backoff,
+			MaxBackoff:   cfg.Webhooks.MaxBackoff,
+			Retention:    cfg.Webhooks.Retention,
+		}
+		if err := dispatcherOpts.Validate(); err != nil {
+			logger.Fatalf("Invalid webhook configuration: %v", err)
+		}
+		go webhook.NewDispatcher(webhookStorage, dispatcherOpts, logger).Run(context.Background())
+	}
+
+	// Похожесть фильмов для рекомендаций пересчитывается в фоне, запросы только читают ее
+	recommendStorage := recommend.NewStorage(pool, logger)
+	recommendHandler := recommend.NewHandler(recommendStorage, logger)
+	if cfg.Recommend.Enabled {
+		builderOpts := recommend.BuilderOptions{
+			Interval:       cfg.Recommend.Interval,
+			MinCommonUsers: cfg.Recommend.MinCommonUsers,
+			Neighbours:     cfg.Recommend.Neighbours,
+		}
+		if err := builderOpts.Validate(); err != nil {
+			logger.Fatalf("Invalid recommendation configuration: %v", err)
+		}
+		go recommend.NewBuilder(recommendStorage, builderOpts, logger).Run(context.Background())
+	}
+
+	writeTimeoutRoutes, err := deadline.ParseRoutes(cfg.HTTP.WriteTimeoutRoutes)
+	if err != nil {
+		logger.Fatalf("Invalid HTTP configuration: %v", err)
+	}
+
+	rateLimiter, ipRateLimiter, err := newRateLimiter(cfg, pool, logger)
+	if err != nil {
+		logger.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+
 	// Настройка роутера
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.Default()
 
-	// Настройка Swagger
-	router.StaticFile("/swagger.json", "./docs/swagger.json") // Явное указание JSON-файла
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(
-		swaggerFiles.Handler,
-		ginSwagger.URL("/swagger.json"), // Указываем прямой путь к файлу
-		ginSwagger.DefaultModelsExpandDepth(-1),
-		ginSwagger.PersistAuthorization(true),
-	))
+	// Документация API: спецификация встроена в бинарник и отдается как OpenAPI 3.1
+	apiSpec, err := openapi.NewSpec(docs.SwaggerJSON)
+	if err != nil {
+		logger.Fatalf("Failed to build OpenAPI spec: %v", err)
+	}
+	apiSpec.Register(router, docs.SwaggerInfo.Title, "/swagger")
+	// Ответы в MessagePack, CSV и NDJSON по Accept; обработчики по-прежнему отвечают JSON
+	router.Use(format.Middleware(format.Options{MaxBufferBytes: cfg.Format.MaxBufferBytes}))
 	// Middleware для добавления пула соединений в контекст
 	router.Use(func(c *gin.Context) {
 		c.Set("postgres_pool", pool)
@@ -97,29 +326,238 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"message": "Сервер запущен!", "status": "ok"})
 	})
 
-	api := router.Group("/api")
-	{
-		api.GET("/users", userHandler.GetList)
-		api.GET("/users/:uuid", userHandler.GetUser)
-		api.POST("/users", userHandler.CreateUser)
-		api.PUT("/users/:uuid", userHandler.UpdateUser)
-		api.PATCH("/users/:uuid", userHandler.PartiallyUpdateUser)
-		api.DELETE("/users/:uuid", userHandler.DeleteUser)
+	routes.Register(router.Group("/api"), routes.Handlers{
+		Users:     userHandler,
+		Films:     filmHandler,
+		UsersV2:   userHandlerV2,
+		FilmsV2:   filmHandlerV2,
+		APIKeys:   apiKeyHandler,
+		Events:    eventHandler,
+		Webhooks:  webhookHandler,
+		Tenants:   tenantHandler,
+		Account:   accountHandler,
+		SSO:       ssoHandler,
+		Sessions:  sessionHandler,
+		Me:        meHandler,
+		Recommend: recommendHandler,
+		Stats:     statsHandler,
+	}, routes.Middleware{
+		Authenticate: authenticate,
+		Tenant:       resolveTenant,
+		WriteTimeout: deadline.Middleware(writeTimeoutRoutes, logger),
+		RateLimitIP:  ipRateLimiter,
+		RateLimit:    rateLimiter,
+		Idempotent:   idempotent,
+		DeprecateV1:  deprecateV1,
+		CacheFilms:   cacheFilms,
+		CacheStats:   cacheStats,
+	})
+
+	graphqlHandler, err := graphqlapi.NewHandler(userStorage, filmRepo, graphqlapi.Options{
+		MaxDepth:         cfg.GraphQL.MaxDepth,
+		MaxComplexity:    cfg.GraphQL.MaxComplexity,
+		PersistedQueries: cfg.GraphQL.PersistedQueries,
+	}, logger)
+	if err != nil {
+		logger.Fatalf("Failed to build GraphQL schema: %v", err)
+	}
+	routes.RegisterGraphQL(router, graphqlHandler, routes.Middleware{
+		Authenticate: authenticate,
+		Tenant:       resolveTenant,
+		RateLimitIP:  ipRateLimiter,
+		RateLimit:    rateLimiter,
+	})
+
+	// Пути без версии (/api/films) направляются в версию из Accept или версию по умолчанию
+	versionOpts := apiversion.Options{
+		Prefix:      "/api",
+		Vendor:      apiVendor,
+		Default:     apiversion.Version(cfg.Versioning.DefaultVersion),
+		Supported:   []apiversion.Version{apiversion.V1, apiversion.V2},
+		Unversioned: []string{"/admin", "/events", "/webhooks", "/account", "/auth", "/me", "/stats"},
+	}
+	if err := versionOpts.Validate(); err != nil {
+		logger.Fatalf("Invalid versioning configuration: %v", err)
+	}
+	handler := apiversion.Negotiate(router, versionOpts)
 
-		api.POST("/films", filmHandler.CreateFilm)
-		api.GET("/films", filmHandler.GetList)
-		api.GET("/films/sort", filmHandler.GetListSort)
-		api.GET("/films/:uuid", filmHandler.GetUserFilm)
-		api.PATCH("/films/:uuid", filmHandler.PartiallyUpdateFilm)
-		api.DELETE("/films/:uuid", filmHandler.DeleteFilm)
+	// Сжатие по Accept-Encoding для всех HTTP-ответов, включая документацию
+	if cfg.Compression.Enabled {
+		compressOpts := compress.Options{
+			MinSize:   cfg.Compression.MinSize,
+			Encodings: strings.FieldsFunc(cfg.Compression.Encodings, func(r rune) bool { return r == ',' || r == ' ' }),
+		}
+		if err := compressOpts.Validate(); err != nil {
+			logger.Fatalf("Invalid compression configuration: %v", err)
+		}
+		handler = compress.Handler(handler, compressOpts)
+	}
+
+	// gRPC API работает рядом с REST на тех же хранилищах и ключах
+	if cfg.GRPC.Enabled {
+		grpcServer := grpcapi.NewServer(grpcapi.Options{
+			Users:   userStorage,
+			Films:   filmRepo,
+			Keys:    apiKeyStorage,
+			Auth:    authOpts,
+			Tenants: tenantResolver,
+		}, logger)
+		go func() {
+			if err := startGRPCServer(grpcServer, cfg, logger); err != nil {
+				logger.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
 	}
 
 	// Запуск сервера
-	if err := startServer(router, cfg, logger); err != nil {
+	if err := startServer(handler, cfg, logger); err != nil {
 		logger.Fatalf("Failed to start server: %v", err)
 	}
 }
 
+// apiVendor vendor-тип для выбора версии через Accept: application/vnd.movies.v2+json
+const apiVendor = "application/vnd.movies"
+
+// newDeprecation собирает middleware с заголовками Deprecation и Sunset для API v1
+func newDeprecation(cfg *config.Config) (gin.HandlerFunc, error) {
+	deprecatedAt, err := apiversion.ParseDate(cfg.Versioning.V1DeprecatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sunset, err := apiversion.ParseDate(cfg.Versioning.V1Sunset)
+	if err != nil {
+		return nil, err
+	}
+	return apiversion.Deprecate(apiversion.Deprecation{
+		DeprecatedAt: deprecatedAt,
+		Sunset:       sunset,
+		Successor:    "/api/v2",
+	}), nil
+}
+
+// Пространства имен кеша ответов
+const (
+	// filmsCacheNamespace чтение каталога фильмов
+	filmsCacheNamespace = "films"
+	// statsCacheNamespace статистика каталога
+	statsCacheNamespace = "stats"
+)
+
+// newCache создает хранилище кеша из конфигурации. Возвращает nil, если кеш выключен.
+func newCache(ctx context.Context, cfg *config.Config) (cache.Store, error) {
+	if !cfg.Cache.Enabled {
+		return nil, nil
+	}
+	switch cfg.Cache.Backend {
+	case "memory":
+		if cfg.Cache.MaxEntries < 1 {
+			return nil, fmt.Errorf("cache max entries must be positive, got %d", cfg.Cache.MaxEntries)
+		}
+		return cache.NewMemoryStore(cfg.Cache.MaxEntries), nil
+	case "redis":
+		return cache.NewRedisStore(ctx, cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB, "movies:cache:")
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q, expected memory or redis", cfg.Cache.Backend)
+	}
+}
+
+// newMailer создает отправителя писем из конфигурации
+func newMailer(cfg *config.Config) (mail.Mailer, error) {
+	switch cfg.Mail.Backend {
+	case "smtp":
+		return mail.NewSMTPMailer(cfg.Mail.From, mail.SMTPOptions{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			Timeout:  cfg.Mail.SMTPTimeout,
+		})
+	case "file":
+		return mail.NewFileMailer(cfg.Mail.From, cfg.Mail.Dir)
+	case "stdout":
+		return mail.NewWriterMailer(cfg.Mail.From, os.Stdout)
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q, expected smtp, file or stdout", cfg.Mail.Backend)
+	}
+}
+
+// newSSOProviders собирает провайдеров входа из конфигурации
+func newSSOProviders(cfg *config.Config) []sso.ProviderConfig {
+	providers := make([]sso.ProviderConfig, 0, len(cfg.OIDC.Providers))
+	for _, p := range cfg.OIDC.Providers {
+		providers = append(providers, sso.ProviderConfig{
+			Name: p.Name,
+			Config: oidc.Config{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				RedirectURL:  p.RedirectURL,
+				Scopes:       strings.Fields(p.Scopes),
+			},
+			TrustEmail: p.TrustEmail,
+			JIT:        p.JIT,
+		})
+	}
+	return providers
+}
+
+// invalidateOnEvents сбрасывает локальный кеш фильмов при изменениях, сделанных
+// другими репликами. Если подписка закрыта из-за отставания, часть событий могла
+// потеряться, поэтому кеш сбрасывается и подписка создается заново.
+func invalidateOnEvents(broker *events.Broker, store cache.Store, logger *logging.Logger) {
+	ctx := context.Background()
+	for {
+		for event := range broker.Subscribe() {
+			if event.Resource() != events.ResourceFilm {
+				continue
+			}
+			if err := store.Invalidate(ctx, filmsCacheNamespace); err != nil {
+				logger.Errorf("Failed to invalidate films cache: %v", err)
+			}
+		}
+		if err := store.Invalidate(ctx, filmsCacheNamespace); err != nil {
+			logger.Errorf("Failed to invalidate films cache: %v", err)
+		}
+	}
+}
+
+// newRateLimiter собирает middleware ограничения частоты запросов из конфигурации:
+// по клиенту и по IP-адресу до аутентификации. Возвращает nil, если ограничение выключено.
+func newRateLimiter(cfg *config.Config, pool *pgxpool.Pool, logger *logging.Logger) (perClient, perIP gin.HandlerFunc, err error) {
+	if !cfg.RateLimit.Enabled {
+		return nil, nil, nil
+	}
+
+	defaultLimit, err := ratelimit.ParseLimit(cfg.RateLimit.Default)
+	if err != nil {
+		return nil, nil, err
+	}
+	routes, err := ratelimit.ParseRoutes(cfg.RateLimit.Routes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var store ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = ratelimit.NewStorage(pool, logger)
+	default:
+		return nil, nil, fmt.Errorf("unknown rate limit store %q, expected memory or postgres", cfg.RateLimit.Store)
+	}
+
+	perClient = ratelimit.Middleware(store, ratelimit.Options{Default: defaultLimit, Routes: routes}, logger)
+	if cfg.RateLimit.IP != "" {
+		ipLimit, err := ratelimit.ParseLimit(cfg.RateLimit.IP)
+		if err != nil {
+			return nil, nil, err
+		}
+		perIP = ratelimit.IPMiddleware(store, ipLimit, logger)
+	}
+	return perClient, perIP, nil
+}
+
 func checkPostgreSQLConnection(ctx context.Context, pool *pgxpool.Pool) error {
 	conn, err := pool.Acquire(ctx)
 	if err != nil {
@@ -134,44 +572,64 @@ func checkPostgreSQLConnection(ctx context.Context, pool *pgxpool.Pool) error {
 	return nil
 }
 
-func startServer(router *gin.Engine, cfg *config.Config, logger *logging.Logger) error {
+func startServer(handler http.Handler, cfg *config.Config, logger *logging.Logger) error {
+	listener, err := listen(cfg, "app.sock", cfg.Listen.Port, logger)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+
+	logger.Info("Application started successfully")
+	return server.Serve(listener)
+}
+
+func startGRPCServer(server *grpc.Server, cfg *config.Config, logger *logging.Logger) error {
+	listener, err := listen(cfg, "grpc.sock", cfg.GRPC.Port, logger)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("gRPC server started successfully")
+	return server.Serve(listener)
+}
+
+// listen открывает unix-сокет socketName рядом с бинарником в режиме sock
+// или TCP-порт port на BindIP в остальных случаях
+func listen(cfg *config.Config, socketName, port string, logger *logging.Logger) (net.Listener, error) {
 	var listener net.Listener
 	var listenErr error
 
 	if cfg.Listen.Type == "sock" {
 		appDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 		if err != nil {
-			return fmt.Errorf("failed to get app directory: %w", err)
+			return nil, fmt.Errorf("failed to get app directory: %w", err)
 		}
 
-		socketPath := path.Join(appDir, "app.sock")
+		socketPath := path.Join(appDir, socketName)
 
 		// Удаляем старый сокет, если существует
 		if _, err := os.Stat(socketPath); err == nil {
 			if err := os.Remove(socketPath); err != nil {
-				return fmt.Errorf("failed to remove old socket: %w", err)
+				return nil, fmt.Errorf("failed to remove old socket: %w", err)
 			}
 		}
 
 		listener, listenErr = net.Listen("unix", socketPath)
 		logger.Infof("Server is listening unix socket: %s", socketPath)
 	} else {
-		address := fmt.Sprintf("%s:%s", cfg.Listen.BindIP, cfg.Listen.Port)
+		address := fmt.Sprintf("%s:%s", cfg.Listen.BindIP, port)
 		listener, listenErr = net.Listen("tcp", address)
 		logger.Infof("Server is listening on %s", address)
 	}
 
 	if listenErr != nil {
-		return fmt.Errorf("failed to create listener: %w", listenErr)
-	}
-
-	server := &http.Server{
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		return nil, fmt.Errorf("failed to create listener: %w", listenErr)
 	}
-
-	logger.Info("Application started successfully")
-	return server.Serve(listener)
+	return listener, nil
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"rest-api-tutorial/internal/oidctest"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Мок-провайдер OpenID Connect для локальной разработки: страница входа сразу
+// «входит» пользователем из MOCK_OIDC_USER_*. Для приложения:
+//
+//	OIDC_PROVIDERS=mock
+//	OIDC_MOCK_ISSUER=http://localhost:9400
+//	OIDC_MOCK_CLIENT_ID=movies
+//	OIDC_MOCK_CLIENT_SECRET=secret
+//	OIDC_MOCK_REDIRECT_URL=http://localhost:8080/api/auth/oidc/mock/callback
+func main() {
+	logger := logging.GetLogger()
+
+	addr := getEnv("MOCK_OIDC_ADDR", "localhost:9400")
+	verified, _ := strconv.ParseBool(getEnv("MOCK_OIDC_USER_EMAIL_VERIFIED", "true"))
+	issuer, err := oidctest.New(oidctest.Options{
+		URL:          getEnv("MOCK_OIDC_ISSUER", "http://"+addr),
+		ClientID:     getEnv("MOCK_OIDC_CLIENT_ID", "movies"),
+		ClientSecret: getEnv("MOCK_OIDC_CLIENT_SECRET", "secret"),
+		RedirectURLs: strings.FieldsFunc(getEnv("MOCK_OIDC_REDIRECT_URLS", ""), func(r rune) bool { return r == ',' || r == ' ' }),
+		Algorithm:    getEnv("MOCK_OIDC_ALGORITHM", "RS256"),
+		User: oidctest.User{
+			Subject:       getEnv("MOCK_OIDC_USER_SUBJECT", "mock-user-1"),
+			Email:         getEnv("MOCK_OIDC_USER_EMAIL", "user@example.com"),
+			EmailVerified: verified,
+			Name:          getEnv("MOCK_OIDC_USER_NAME", "Иван Петров"),
+			Birthdate:     getEnv("MOCK_OIDC_USER_BIRTHDATE", "1990-01-01"),
+			Gender:        getEnv("MOCK_OIDC_USER_GENDER", "male"),
+		},
+	})
+	if err != nil {
+		logger.Fatalf("Invalid mock OIDC configuration: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
+	logger.Infof("Mock OIDC issuer %s is listening on %s", issuer.URL(), addr)
+	server := &http.Server{
+		Handler:           issuer,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.Serve(listener); err != nil {
+		logger.Fatalf("Mock OIDC issuer stopped: %v", err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
@@ -22,323 +22,266 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/films": {
-            "get": {
-                "description": "Retrieve a list of all films with optional sorting",
-                "produces": [
+        "/account/forgot-password": {
+            "post": {
+                "description": "Email a password reset link. The response is the same whether or not the address is registered.",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "films"
+                    "account"
                 ],
-                "summary": "Get all films",
+                "summary": "Request a password reset",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Field to sort by (title, rating, release_date)",
-                        "name": "sort_by",
-                        "in": "query"
+                        "example": "en",
+                        "description": "Language of the email",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Email address",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_account.EmailRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "List of films",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/internal_films.Film"
-                            }
-                        }
+                    "202": {
+                        "description": "Request accepted"
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "400": {
+                        "description": "Invalid request body",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/account/reset-password": {
             "post": {
-                "description": "Create a new film with the provided details",
+                "description": "Set a new password with the token from the reset link. The token works once and also confirms the email address. All sessions of the user are ended.",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "films"
+                    "account"
                 ],
-                "summary": "Create a new film",
+                "summary": "Reset the password",
                 "parameters": [
                     {
-                        "description": "Film data to create",
-                        "name": "film",
+                        "description": "Token from the link and the new password",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_films.Film"
+                            "$ref": "#/definitions/internal_account.ResetPassword"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Successfully created film",
-                        "schema": {
-                            "$ref": "#/definitions/internal_films.Film"
-                        }
+                    "204": {
+                        "description": "Password changed"
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Invalid request body, or invalid or expired token",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/films/sorted": {
-            "get": {
-                "description": "Retrieve a list of films sorted by specified criteria",
+        "/account/signup": {
+            "post": {
+                "description": "Register a user with a password. A confirmation link is emailed to the address in the language of Accept-Language (ru or en); the email stays unverified until the link is used.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "films"
+                    "account"
                 ],
-                "summary": "Get sorted films list",
+                "summary": "Sign up",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Field to sort by (title, rating, release_date)",
-                        "name": "sort_by",
-                        "in": "query"
+                        "example": "en",
+                        "description": "Language of the email",
+                        "name": "Accept-Language",
+                        "in": "header"
                     },
                     {
-                        "type": "string",
-                        "default": "asc",
-                        "description": "Sort order (asc, desc)",
-                        "name": "order",
-                        "in": "query"
+                        "description": "User data and password",
+                        "name": "account",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_account.Signup"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Sorted list of films",
+                    "201": {
+                        "description": "Registered account",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/internal_films.Film"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_account_Account"
                         }
                     },
                     "400": {
-                        "description": "Invalid sort parameters",
+                        "description": "Invalid request body",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/films/user/{uuid}": {
-            "get": {
-                "description": "Retrieve all films associated with specific user",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "films"
-                ],
-                "summary": "Get films by user ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "No films found for user",
+                    "409": {
+                        "description": "User with this email already exists",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/films/{uuid}": {
-            "delete": {
-                "description": "Remove a film by its UUID",
-                "produces": [
+        "/account/verify-email": {
+            "post": {
+                "description": "Confirm the email address with the token from the confirmation link. The token works once; it stops working when a newer link is sent or the email is changed.",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "films"
+                    "account"
                 ],
-                "summary": "Delete a film",
+                "summary": "Confirm the email address",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Film ID (UUID)",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "description": "Token from the link",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_account.VerifyEmail"
+                        }
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "Film deleted successfully"
+                        "description": "Email confirmed"
                     },
-                    "404": {
-                        "description": "Film not found",
+                    "400": {
+                        "description": "Invalid request body, or invalid or expired token",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update specific fields of a film",
+            }
+        },
+        "/account/verify-email/resend": {
+            "post": {
+                "description": "Email a new confirmation link; earlier links stop working. The response is the same whether or not the address is registered or already verified.",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "films"
+                    "account"
                 ],
-                "summary": "Partially update film",
+                "summary": "Resend the email confirmation link",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Film ID (UUID)",
-                        "name": "uuid",
-                        "in": "path",
-                        "required": true
+                        "example": "en",
+                        "description": "Language of the email",
+                        "name": "Accept-Language",
+                        "in": "header"
                     },
                     {
-                        "description": "Fields to update",
-                        "name": "updates",
+                        "description": "Email address",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_films.UpdateFilm"
+                            "$ref": "#/definitions/internal_account.EmailRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Film updated successfully"
+                    "202": {
+                        "description": "Request accepted"
                     },
                     "400": {
                         "description": "Invalid request body",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Film not found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/users": {
+        "/admin/api-keys": {
             "get": {
-                "description": "Retrieve a list of all users",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Retrieve all API keys including revoked ones, without secrets",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Get all users",
+                "summary": "List API keys",
                 "responses": {
                     "200": {
-                        "description": "List of users",
+                        "description": "List of API keys",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/internal_user.User"
+                                "$ref": "#/definitions/internal_apikey.APIKey"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Missing api_keys:admin scope",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -351,7 +294,12 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new user with the provided details",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a key for a service client. The full key is returned only in this response; only its hash is stored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -359,29 +307,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Create a new user",
+                "summary": "Issue an API key",
                 "parameters": [
                     {
-                        "description": "User data to create",
-                        "name": "user",
+                        "description": "Key name, scopes and optional expiry",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_user.User"
+                            "$ref": "#/definitions/internal_apikey.CreateAPIKey"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Successfully created user",
+                        "description": "Issued key with secret",
                         "schema": {
-                            "$ref": "#/definitions/internal_user.User"
+                            "$ref": "#/definitions/internal_apikey.IssuedAPIKey"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Invalid request body, unknown scope or tenant, or platform scope for a tenant key",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -389,6 +337,20 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Missing api_keys:admin scope",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -401,37 +363,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{uuid}": {
-            "get": {
-                "description": "Retrieve a single user by their UUID",
-                "consumes": [
-                    "application/json"
+        "/admin/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Permanently disable a key. The record is kept for auditing.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Get a user by ID",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "uuid",
+                        "description": "API key ID (UUID)",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Requested user",
+                    "204": {
+                        "description": "API key revoked"
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
                         "schema": {
-                            "$ref": "#/definitions/internal_user.User"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Missing api_keys:admin scope",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "API key not found or already revoked",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -449,52 +424,55 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Replace all user data with the provided values",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/admin/api-keys/{id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Replace the secret of an active key. The old secret stops working immediately; the new one is returned only in this response.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Fully update a user",
+                "summary": "Rotate an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "uuid",
+                        "description": "API key ID (UUID)",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Updated user data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_user.User"
-                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "User updated successfully"
+                    "200": {
+                        "description": "Key with the new secret",
+                        "schema": {
+                            "$ref": "#/definitions/internal_apikey.IssuedAPIKey"
+                        }
                     },
-                    "400": {
-                        "description": "Invalid request body",
+                    "401": {
+                        "description": "Missing or invalid credentials",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Missing api_keys:admin scope",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "API key not found or revoked",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -512,9 +490,103 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/sessions/{id}": {
             "delete": {
-                "description": "Remove a user by their UUID",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Forced logout of one device of any user in the tenant catalog",
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "End a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Session ended"
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing sessions:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tenants": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve all tenants",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tenants"
+                ],
+                "summary": "List tenants",
+                "responses": {
+                    "200": {
+                        "description": "List of tenants",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_tenant_Tenant"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing tenants:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create an independent catalog. Its films and users are reached with the X-Tenant-ID header (id or slug), the \u003cslug\u003e.TENANT_BASE_DOMAIN subdomain or an API key issued for the tenant.",
                 "consumes": [
                     "application/json"
                 ],
@@ -522,44 +594,162 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "tenants"
                 ],
-                "summary": "Delete a user",
+                "summary": "Create a tenant",
+                "parameters": [
+                    {
+                        "description": "Slug and name",
+                        "name": "tenant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_tenant.CreateTenant"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created tenant",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_tenant_Tenant"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or slug",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing tenants:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Tenant with this slug already exists",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tenants/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a tenant by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tenants"
+                ],
+                "summary": "Get a tenant",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "uuid",
+                        "description": "Tenant ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tenant",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_tenant_Tenant"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing tenants:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Tenant not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete an empty tenant together with its API keys. A tenant that still has films, users or webhooks cannot be deleted; neither can the default tenant.",
+                "tags": [
+                    "tenants"
+                ],
+                "summary": "Delete a tenant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant ID (UUID)",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "User deleted successfully"
+                        "description": "Tenant deleted"
+                    },
+                    "403": {
+                        "description": "Missing tenants:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Tenant not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Tenant still has data or is the default tenant",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     }
                 }
             },
             "patch": {
-                "description": "Update specific fields of a user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change the display name of a tenant. The slug cannot be changed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -567,198 +757,5416 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "tenants"
                 ],
-                "summary": "Partially update a user",
+                "summary": "Rename a tenant",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "uuid",
+                        "description": "Tenant ID (UUID)",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Fields to update",
-                        "name": "updates",
+                        "description": "New name",
+                        "name": "tenant",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_user.Update"
+                            "$ref": "#/definitions/internal_tenant.UpdateTenant"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "User updated successfully"
+                    "200": {
+                        "description": "Updated tenant",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_tenant_Tenant"
+                        }
                     },
                     "400": {
                         "description": "Invalid request body",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing tenants:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Tenant not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_films.Film": {
-            "description": "Модель фильма с рейтингом и датой выпуска",
-            "type": "object",
-            "required": [
-                "title"
-            ],
-            "properties": {
-                "created_at": {
-                    "description": "@format date",
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "film_id": {
-                    "description": "@format uuid",
-                    "type": "string"
-                },
-                "rating": {
-                    "description": "@minimum 0\n@maximum 10",
-                    "type": "number"
-                },
-                "release_date": {
-                    "description": "@format date",
-                    "type": "string"
-                },
-                "title": {
-                    "description": "@minLength 1\n@maxLength 255",
-                    "type": "string"
-                },
-                "updated_at": {
-                    "description": "@format date",
-                    "type": "string"
-                }
-            }
         },
-        "internal_films.UpdateFilm": {
-            "description": "Модель фильма с необходимым базисом для обновления",
-            "type": "object",
-            "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "rating": {
-                    "type": "number"
-                },
-                "release_date": {
-                    "type": "string"
+        "/admin/users/{id}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the active sessions of a user in the tenant catalog",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "List a user's sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Active sessions",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_session_Session"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing sessions:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Forced logout: end all active sessions of a user in the tenant catalog. Access tokens stop working immediately.",
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Sign a user out everywhere",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Sessions ended"
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing sessions:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Sign in with the email and password set at signup or by a password reset. The email must be confirmed first. A session is started for the device; the access token is refreshed through /auth/refresh.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Sign in with a password",
+                "parameters": [
+                    {
+                        "description": "Email and password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_account.Login"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed in",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-rest-api-tutorial_internal_session_Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid email or password",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email is not confirmed",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "The provider redirects the browser here. The identity is linked to the user with the same verified email or, if allowed, a new user is created from the name, email, birthdate and gender claims. A session is started for the device. Without return_to the tokens are returned as JSON; with return_to the browser is redirected there with access_token and refresh_token (or error) in the URL fragment.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish signing in with an identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Error returned by the provider",
+                        "name": "error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed in",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_sso_Token"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to return_to"
+                    },
+                    "400": {
+                        "description": "Invalid or expired login state, or the oidc_state cookie does not match",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Login denied by the provider or invalid ID token",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "No account for this identity",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email belongs to another account",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Provider did not return the data required for a new user",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Identity provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "Redirect the browser to the provider's login page (authorization code flow with PKCE). The tenant of this request is remembered for the callback. An HttpOnly oidc_state cookie binds the login to this browser: the callback is accepted only in the browser that started the login.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start signing in with an identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client page to return to after login; must match OIDC_RETURN_URLS. Tokens are passed in the URL fragment.",
+                        "name": "return_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the provider"
+                    },
+                    "400": {
+                        "description": "return_to is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown provider",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Identity provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/providers": {
+            "get": {
+                "description": "List the configured OpenID Connect providers users can sign in with",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "List identity providers",
+                "responses": {
+                    "200": {
+                        "description": "Providers",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_sso_Provider"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once: presenting a used one again ends the whole session, because it means the token was copied.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh the access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_session.Refresh"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New tokens",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_session_Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events stream of film and user create, update and delete events. Each event has an id, the event type as the SSE event name and the Event object as data. To resume after a disconnect send the last received id in the Last-Event-ID header (browsers do this automatically) or the last_event_id query parameter; events are kept for EVENTS_RETENTION. Without either only new events are sent. Only events of the request tenant are sent, and events of resources the caller has no read scope for are skipped. A comment line is sent every EVENTS_HEARTBEAT to keep the connection open",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream catalog changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated event types (film.created) or resources (film) to receive; all readable events by default",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event id",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this event id, for clients that cannot set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "$ref": "#/definitions/internal_events.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid types or event id",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing read scope for the requested events",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the profile of the signed-in user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Get my profile",
+                "responses": {
+                    "200": {
+                        "description": "Profile",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_me_Profile"
+                        }
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User was deleted",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change name, email, date of birth or gender of the signed-in user. Fields that are not sent stay unchanged. A new email has to be verified again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Update my profile",
+                "parameters": [
+                    {
+                        "description": "Profile fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_me.UpdateProfile"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated profile",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_me_Profile"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User was deleted",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already taken",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/films": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the films in the signed-in user's list, most recently added first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "List my films",
+                "responses": {
+                    "200": {
+                        "description": "Films",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_me_OwnedFilm"
+                        }
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a catalog film to the signed-in user's list. Adding a film that is already in the list changes nothing and returns it with 200.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Add a film to my list",
+                "parameters": [
+                    {
+                        "description": "Film to add",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_me.AddFilm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Film already in the list",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_me_OwnedFilm"
+                        }
+                    },
+                    "201": {
+                        "description": "Film added",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_me_OwnedFilm"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User was deleted",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Film does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/films/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a film from the signed-in user's list. The film stays in the catalog.",
+                "tags": [
+                    "me"
+                ],
+                "summary": "Remove a film from my list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Film ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Film removed"
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Film is not in the list",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get language, favourite genres and email subscriptions of the signed-in user. A user who never changed them gets the defaults.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Get my preferences",
+                "responses": {
+                    "200": {
+                        "description": "Preferences",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_me_Preferences"
+                        }
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change language, favourite genres or email subscriptions of the signed-in user. Fields that are not sent stay unchanged; genres replaces the whole list, an empty list clears it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Update my preferences",
+                "parameters": [
+                    {
+                        "description": "Preferences to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_me.UpdatePreferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated preferences",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_me_Preferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User was deleted",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the active sessions of the signed-in user with device, IP address and last activity. The session of this request is marked current.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "List my sessions",
+                "responses": {
+                    "200": {
+                        "description": "Active sessions",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_session_Session"
+                        }
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sign out a device. Its access token stops working immediately and its refresh token can no longer be used. Ending the current session signs out this client.",
+                "tags": [
+                    "me"
+                ],
+                "summary": "End one of my sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Session ended"
+                    },
+                    "401": {
+                        "description": "User access token required",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/films/most-added": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Films added to the most user lists. Results are cached for STATS_CACHE_TTL.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Most added films",
+                "parameters": [
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of films",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Films, most added first",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_stats_AddedFilm"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing stats:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/films/ratings": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Number of films per rating interval of one point: [0, 1), [1, 2) ... [9, 10]. Films without a rating are counted in a last row with empty from and to. Results are cached for STATS_CACHE_TTL.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Rating distribution",
+                "responses": {
+                    "200": {
+                        "description": "Rating intervals from low to high",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_stats_RatingBucket"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing stats:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/films/release-years": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Number of films and their average rating for every release year that has films. Results are cached for STATS_CACHE_TTL.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Films per release year",
+                "responses": {
+                    "200": {
+                        "description": "Release years, oldest first",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_stats_ReleaseYear"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing stats:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/growth": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "New users and films per period by created_at, with running totals. Periods without new users or films are omitted. Results are cached for STATS_CACHE_TTL.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Catalog growth",
+                "parameters": [
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month",
+                            "year"
+                        ],
+                        "type": "string",
+                        "default": "month",
+                        "description": "Period length",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Periods, oldest first",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_stats_Growth"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid interval",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing stats:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/users/demographics": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Number of users by gender and age group; the age is calculated from date_of_birth as of today. Empty groups are omitted. Results are cached for STATS_CACHE_TTL.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "User demographics",
+                "responses": {
+                    "200": {
+                        "description": "Groups by gender, youngest first",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_stats_Demographic"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing stats:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/films": {
+            "get": {
+                "description": "Retrieve a list of all films. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Get all films",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Stream rows as they are read from the database; a failure mid-stream leaves the JSON array unterminated",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of films, null if there are none",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_films.Film"
+                            }
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+                            },
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT when served from the response cache, MISS otherwise"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new film with the provided details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Create a new film",
+                "parameters": [
+                    {
+                        "description": "Film data to create",
+                        "name": "film",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_films.Film"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully created film",
+                        "schema": {
+                            "$ref": "#/definitions/internal_films.Film"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Film with this title already exists, or request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request body with an Idempotency-Key exceeds the configured limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with a different request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/films/batch": {
+            "post": {
+                "description": "Apply a list of operations in a single transaction. Each operation is validated like the single-film endpoint and gets its own status and error.\nIn atomic mode (default) any failure rolls back the whole batch and the remaining operations get status 424; in best_effort mode only failed operations are skipped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Create, update and delete films in one request",
+                "parameters": [
+                    {
+                        "description": "Operations; data is Film for create and UpdateFilm for update",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Request"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Batch committed, per-operation results",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Batch exceeds the configured limits",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Atomic batch rolled back (per-operation results), or Idempotency-Key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/films/export": {
+            "get": {
+                "description": "Stream all films as CSV, a JSON array or NDJSON",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Export films",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "Output format (csv, json, ndjson)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Films in the requested format",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_films.Film"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/films/import": {
+            "post": {
+                "description": "Bulk load films from CSV (with header row), a JSON array or NDJSON.\nRows are streamed into PostgreSQL with COPY; each row is validated like CreateFilm and rejected rows are reported with their number.\nIn upsert mode films with an existing title are updated, otherwise they are reported as conflicts.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Import films",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input format (csv, json, ndjson); defaults to the Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "insert",
+                        "description": "Import mode (insert, upsert)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Validate and report without saving",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import report",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_bulk.Result"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters or malformed input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Film id conflicts with another film",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "No rows could be imported",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_bulk.Result"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/films/sort": {
+            "get": {
+                "description": "Retrieve a list of films sorted by title, then rating, then release date. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Get sorted films list",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Stream rows as they are read from the database; a failure mid-stream leaves the JSON array unterminated",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sorted list of films, null if there are none",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_films.Film"
+                            }
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+                            },
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT when served from the response cache, MISS otherwise"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/films/{uuid}": {
+            "get": {
+                "description": "Retrieve all films associated with specific user.\nIf the user has no films, the response is {\"message\": \"No films found for this user\"} instead of an array; GET /v2/users/{uuid}/films returns an empty list.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Get films by user ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of user's films",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_films.Film"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a film by its UUID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Delete a film",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Film ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Film deleted successfully"
+                    },
+                    "404": {
+                        "description": "Film not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update specific fields of a film.\nWith Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902)\napplied atomically to the current film, e.g. [{\"op\":\"test\",\"path\":\"/rating\",\"value\":5.4},{\"op\":\"replace\",\"path\":\"/rating\",\"value\":6}]",
+                "consumes": [
+                    "application/json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v1"
+                ],
+                "summary": "Partially update film",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Film ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "updates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_films.UpdateFilm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Film updated successfully"
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Film not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Film with this title already exists, or JSON Patch test operation failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "JSON Patch document exceeds 1 MiB",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "JSON Patch cannot be applied or result is invalid",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users": {
+            "get": {
+                "description": "Retrieve a list of all users. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Stream rows as they are read from the database; a failure mid-stream leaves the JSON array unterminated",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of users, null if there are none",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_user.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new user with the provided details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data to create",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.User"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully created user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists, or request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request body with an Idempotency-Key exceeds the configured limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Linked film does not exist, or Idempotency-Key reused with a different request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/batch": {
+            "post": {
+                "description": "Apply a list of operations in a single transaction. Each operation is validated like the single-user endpoint and gets its own status and error.\nIn atomic mode (default) any failure rolls back the whole batch and the remaining operations get status 424; in best_effort mode only failed operations are skipped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Create, update and delete users in one request",
+                "parameters": [
+                    {
+                        "description": "Operations; data is User for create and Update for update",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Request"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Batch committed, per-operation results",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Batch exceeds the configured limits",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Atomic batch rolled back (per-operation results), or Idempotency-Key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/export": {
+            "get": {
+                "description": "Stream all users as CSV, a JSON array or NDJSON",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Export users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "Output format (csv, json, ndjson)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users in the requested format",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_user.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/import": {
+            "post": {
+                "description": "Bulk load users from CSV (with header row), a JSON array or NDJSON.\nRows are streamed into PostgreSQL with COPY; each row is validated like CreateUser and rejected rows are reported with their number.\nIn upsert mode users with an existing email are updated, otherwise they are reported as conflicts. Film links are not imported.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Import users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Input format (csv, json, ndjson); defaults to the Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "insert",
+                        "description": "Import mode (insert, upsert)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Validate and report without saving",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import report",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_bulk.Result"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters or malformed input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "User id conflicts with another user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "No rows could be imported",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_bulk.Result"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{uuid}": {
+            "get": {
+                "description": "Retrieve a single user by their UUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Get a user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Requested user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.User"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace all user data with the provided values",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Fully update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated user data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User updated successfully"
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a user by their UUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User deleted successfully"
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update specific fields of a user.\nWith Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902)\napplied atomically to the current user, e.g. [{\"op\":\"remove\",\"path\":\"/film_id/0\"}]",
+                "consumes": [
+                    "application/json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v1"
+                ],
+                "summary": "Partially update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "updates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.Update"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User updated successfully"
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists, or JSON Patch test operation failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "JSON Patch document exceeds 1 MiB",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "JSON Patch cannot be applied, result is invalid or links a missing film",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/{uuid}/recommendations": {
+            "get": {
+                "description": "Recommend films the user does not have yet. Films picked by the same users as the user's own films come first, each with the user's films it is similar to. Similarity is recalculated periodically, so new picks show up after the next rebuild. When there are not enough similar films (for example, the user has none yet) the list is filled with the top rated films.\nThe response has the same format in v1 and v2.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Get film recommendations for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 50,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of recommendations",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recommended films, best first",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_recommend_Recommendation"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/films": {
+            "get": {
+                "description": "Retrieve a list of all films. With sort=title films are ordered by title, then rating, then release date.\nWith stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "films v2"
+                ],
+                "summary": "Get all films",
+                "parameters": [
+                    {
+                        "enum": [
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Stream rows as they are read from the database; a failure mid-stream leaves the JSON envelope unterminated",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of films",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_films_FilmV2"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+                            },
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT when served from the response cache, MISS otherwise"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort order",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new film with the provided details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v2"
+                ],
+                "summary": "Create a new film",
+                "parameters": [
+                    {
+                        "description": "Film data to create",
+                        "name": "film",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_films.FilmV2"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully created film",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_films_FilmV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Film with this title already exists, or request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body with an Idempotency-Key exceeds the configured limit",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/films/{uuid}": {
+            "get": {
+                "description": "Retrieve a single film by its UUID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v2"
+                ],
+                "summary": "Get a film by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Film ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Requested film",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_films_FilmV2"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+                            },
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT when served from the response cache, MISS otherwise"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Film not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a film by its UUID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v2"
+                ],
+                "summary": "Delete a film",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Film ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Film deleted successfully"
+                    },
+                    "404": {
+                        "description": "Film not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update specific fields of a film and return the updated film.\nWith Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902) applied atomically to the v2 representation of the film",
+                "consumes": [
+                    "application/json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v2"
+                ],
+                "summary": "Partially update film",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Film ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "updates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_films.UpdateFilm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated film",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_films_FilmV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Film not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Film with this title already exists, or JSON Patch test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "JSON Patch document exceeds 1 MiB",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "JSON Patch cannot be applied or result is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/users": {
+            "get": {
+                "description": "Retrieve a list of all users. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "users v2"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Stream rows as they are read from the database; a failure mid-stream leaves the JSON envelope unterminated",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of users",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_user_UserV2"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new user with the provided details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v2"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data to create",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.UserV2"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully created user",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_user_UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists, or request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body with an Idempotency-Key exceeds the configured limit",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Linked film does not exist, or Idempotency-Key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/users/{uuid}": {
+            "get": {
+                "description": "Retrieve a single user by their UUID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v2"
+                ],
+                "summary": "Get a user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Requested user",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_user_UserV2"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace user data with the provided values and return the updated user. Film links are changed with JSON Patch",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v2"
+                ],
+                "summary": "Fully update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated user data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.UserV2"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated user",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_user_UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a user by their UUID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v2"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User deleted successfully"
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update specific fields of a user and return the updated user.\nWith Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902)\napplied atomically to the v2 representation of the user, e.g. [{\"op\":\"remove\",\"path\":\"/film_ids/0\"}]",
+                "consumes": [
+                    "application/json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users v2"
+                ],
+                "summary": "Partially update a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "updates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_user.UpdateV2"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated user",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_user_UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User with this email already exists, or JSON Patch test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "JSON Patch document exceeds 1 MiB",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "JSON Patch cannot be applied, result is invalid or links a missing film",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/users/{uuid}/films": {
+            "get": {
+                "description": "Retrieve all films associated with specific user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "films v2"
+                ],
+                "summary": "Get films by user ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of user's films, empty if the user has none",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_films_FilmV2"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/users/{uuid}/recommendations": {
+            "get": {
+                "description": "Recommend films the user does not have yet. Films picked by the same users as the user's own films come first, each with the user's films it is similar to. Similarity is recalculated periodically, so new picks show up after the next rebuild. When there are not enough similar films (for example, the user has none yet) the list is filled with the top rated films.\nThe response has the same format in v1 and v2.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Get film recommendations for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID (UUID)",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 50,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of recommendations",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recommended films, best first",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_recommend_Recommendation"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve all webhook subscriptions without secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "List of webhooks",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_webhook_Webhook"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Subscribe a URL to film and user events. Every event is sent as a POST with the Event JSON body and the headers Webhook-Id (event id, stable across retries), Webhook-Event, Webhook-Timestamp and Webhook-Signature: \"v1=\" followed by the hex HMAC-SHA256 of \"\u003cWebhook-Id\u003e.\u003cWebhook-Timestamp\u003e.\u003cbody\u003e\" keyed with the secret. The secret is returned only in this response. A delivery succeeds on any 2xx response; otherwise it is retried with exponential backoff and marked dead after WEBHOOK_MAX_ATTEMPTS attempts. The URL host must resolve to public addresses: loopback, private, link-local and other reserved targets are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "Target URL and event types",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.CreateWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Registered webhook with secret",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_webhook_IssuedWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, URL or event type",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a webhook subscription by ID without its secret",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_webhook_Webhook"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a webhook subscription together with its delivery history",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Webhook deleted"
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change the URL, event types or active flag of a webhook. Omitted fields keep their values. Deactivated webhooks receive no new events; their pending deliveries wait until reactivation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.UpdateWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated webhook",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_webhook_Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, URL or event type",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve the most recent deliveries of a webhook, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "description": "Delivery status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of deliveries",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of deliveries",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Collection-internal_webhook_Delivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status or limit",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{delivery_id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Queue one delivery again with a fresh attempt counter, whatever its current status. The receiver gets the same Webhook-Id as before.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay a webhook delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Queued delivery",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_webhook_Delivery"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook or delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Queue deliveries of a webhook again with fresh attempt counters. Without since only dead deliveries are replayed; with since every delivery of events created at or after since is replayed, including succeeded ones. Events are kept for WEBHOOK_RETENTION.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Replay window",
+                        "name": "replay",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.Replay"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Number of queued deliveries",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Resource-internal_webhook_ReplayResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing webhooks:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal_account.Account": {
+            "description": "Пользователь с отметкой о подтверждении email",
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "testemail@example.com"
+                },
+                "email_verified": {
+                    "description": "Адрес подтвержден переходом по ссылке из письма",
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Иванов Иван Иванович"
+                }
+            }
+        },
+        "internal_account.EmailRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "testemail@example.com"
+                }
+            }
+        },
+        "internal_account.Login": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "testemail@example.com"
+                },
+                "password": {
+                    "description": "@maxLength 72",
+                    "type": "string",
+                    "maxLength": 72
+                }
+            }
+        },
+        "internal_account.ResetPassword": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "description": "@minLength 8\n@maxLength 72",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                },
+                "token": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "internal_account.Signup": {
+            "description": "Пользователь с паролем; на email уходит письмо со ссылкой подтверждения",
+            "type": "object",
+            "required": [
+                "date_of_birth",
+                "email",
+                "gender",
+                "name",
+                "password"
+            ],
+            "properties": {
+                "date_of_birth": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "testemail@example.com"
+                },
+                "gender": {
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "name": {
+                    "description": "@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Иванов Иван Иванович"
+                },
+                "password": {
+                    "description": "Не длиннее 72 байт: bcrypt не учитывает остаток\n@minLength 8\n@maxLength 72",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "internal_account.VerifyEmail": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "internal_apikey.APIKey": {
+            "description": "Ключ сервисного клиента с областями доступа и сроком действия",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Публичная часть ключа, по которой его можно узнать в логах и списке",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant_id": {
+                    "description": "Арендатор, к каталогу которого ограничен ключ; без него ключ платформенный\n@format uuid",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_apikey.CreateAPIKey": {
+            "description": "Название, области доступа и необязательный срок действия",
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "nightly-import"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "films:read",
+                        "films:write"
+                    ]
+                },
+                "tenant_id": {
+                    "description": "Арендатор ключа; платформенные области (api_keys:admin, tenants:admin) ему выдать нельзя\n@format uuid",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_apikey.IssuedAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Публичная часть ключа, по которой его можно узнать в логах и списке",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant_id": {
+                    "description": "Арендатор, к каталогу которого ограничен ключ; без него ключ платформенный\n@format uuid",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_events.Event": {
+            "description": "Событие потока /api/events; data — строка таблицы после изменения, для *.deleted отсутствует",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "id": {
+                    "description": "Последовательный номер события, он же id в SSE и значение Last-Event-ID",
+                    "type": "integer"
+                },
+                "resource_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Тип события: film.created, film.updated, film.deleted, user.created, user.updated, user.deleted",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_films.Film": {
+            "description": "Модель фильма с рейтингом и датой выпуска",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "created_at": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "film_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "@minimum 0\n@maximum 10",
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "release_date": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "updated_at": {
+                    "description": "@format date",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_films.FilmV2": {
+            "description": "Модель фильма в API v2",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "created_at": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "@minimum 0\n@maximum 10",
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "release_date": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "updated_at": {
+                    "description": "@format date",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_films.UpdateFilm": {
+            "description": "Модель фильма с необходимым базисом для обновления",
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "internal_me.AddFilm": {
+            "type": "object",
+            "required": [
+                "film_id"
+            ],
+            "properties": {
+                "film_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_me.Notifications": {
+            "type": "object",
+            "properties": {
+                "new_films": {
+                    "description": "Новые фильмы каталога",
+                    "type": "boolean"
+                },
+                "recommendations": {
+                    "description": "Подборки рекомендаций",
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_me.OwnedFilm": {
+            "description": "Фильм и время его добавления в список",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "@minimum 0\n@maximum 10",
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "release_date": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "updated_at": {
+                    "description": "@format date",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_me.Preferences": {
+            "description": "Язык интерфейса и писем, любимые жанры и подписки на письма",
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "description": "Любимые жанры в том виде, в каком их ввел пользователь",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "драма",
+                        "комедия"
+                    ]
+                },
+                "language": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ],
+                    "example": "ru"
+                },
+                "notifications": {
+                    "$ref": "#/definitions/internal_me.Notifications"
+                },
+                "updated_at": {
+                    "description": "Пусто, пока пользователь не менял настройки",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_me.Profile": {
+            "description": "Данные пользователя, которому выдан токен доступа",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "testemail@example.com"
+                },
+                "email_verified": {
+                    "description": "Адрес подтвержден; смена email снимает подтверждение",
+                    "type": "boolean"
+                },
+                "gender": {
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Иванов Иван Иванович"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_me.UpdateNotifications": {
+            "type": "object",
+            "properties": {
+                "new_films": {
+                    "type": "boolean"
+                },
+                "recommendations": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_me.UpdatePreferences": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "description": "@maxItems 20",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "notifications": {
+                    "$ref": "#/definitions/internal_me.UpdateNotifications"
+                }
+            }
+        },
+        "internal_me.UpdateProfile": {
+            "type": "object",
+            "properties": {
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "gender": {
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                }
+            }
+        },
+        "internal_recommend.FilmRef": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_recommend.Recommendation": {
+            "description": "Фильм, которого еще нет в списке пользователя, с объяснением выбора",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "because_of": {
+                    "description": "Фильмы пользователя, на которые похож рекомендованный, самые похожие первыми",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_recommend.FilmRef"
+                    }
+                },
+                "created_at": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "@minimum 0\n@maximum 10",
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "reason": {
+                    "description": "Объяснение для показа пользователю",
+                    "type": "string",
+                    "example": "Because you liked Matrix"
+                },
+                "release_date": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "score": {
+                    "description": "Чем больше, тем выше фильм в списке; у лучших по рейтингу 0",
+                    "type": "number",
+                    "example": 1.27
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "similar",
+                        "top_rated"
+                    ]
+                },
+                "title": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "updated_at": {
+                    "description": "@format date",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_session.Refresh": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "internal_session.Session": {
+            "description": "Устройство, с которого выполнен вход, и время последней активности",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Этим сеансом выполнен текущий запрос",
+                    "type": "boolean"
+                },
+                "device": {
+                    "description": "Браузер и система, определенные по User-Agent",
+                    "type": "string",
+                    "example": "Firefox on Linux"
+                },
+                "expires_at": {
+                    "description": "Сеанс завершится, если до этого времени не обновить токен",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_session.Token": {
+            "description": "Токен доступа для заголовка Authorization: Bearer и refresh token для его обновления",
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "Срок действия токена доступа в секундах",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "description": "Одноразовый: обновление возвращает новый refresh token, повторное\nиспользование старого завершает сеанс",
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "internal_sso.Provider": {
+            "type": "object",
+            "properties": {
+                "login_url": {
+                    "description": "Адрес, на который клиент отправляет браузер",
+                    "type": "string",
+                    "example": "/api/auth/oidc/corp/login"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "corp"
+                }
+            }
+        },
+        "internal_sso.Token": {
+            "description": "Токены нового сеанса пользователя",
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "created": {
+                    "description": "Пользователь создан при этом входе",
+                    "type": "boolean"
+                },
+                "expires_in": {
+                    "description": "Срок действия токена доступа в секундах",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "description": "Одноразовый: обновление возвращает новый refresh token, повторное\nиспользование старого завершает сеанс",
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                },
+                "user_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_stats.AddedFilm": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "internal_stats.Demographic": {
+            "type": "object",
+            "properties": {
+                "age_group": {
+                    "type": "string",
+                    "enum": [
+                        "under 18",
+                        "18-24",
+                        "25-34",
+                        "35-44",
+                        "45-54",
+                        "55-64",
+                        "65+"
+                    ]
+                },
+                "gender": {
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "users": {
+                    "type": "integer",
+                    "example": 17
+                }
+            }
+        },
+        "internal_stats.Growth": {
+            "type": "object",
+            "properties": {
+                "new_films": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "new_users": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "period": {
+                    "description": "Начало периода\n@format date",
+                    "type": "string"
+                },
+                "total_films": {
+                    "type": "integer",
+                    "example": 48
+                },
+                "total_users": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "internal_stats.RatingBucket": {
+            "type": "object",
+            "properties": {
+                "films": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "from": {
+                    "type": "number",
+                    "example": 7
+                },
+                "to": {
+                    "type": "number",
+                    "example": 8
+                }
+            }
+        },
+        "internal_stats.ReleaseYear": {
+            "type": "object",
+            "properties": {
+                "average_rating": {
+                    "type": "number",
+                    "example": 7.8
+                },
+                "films": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "year": {
+                    "type": "integer",
+                    "example": 1999
+                }
+            }
+        },
+        "internal_tenant.CreateTenant": {
+            "description": "Короткое имя и название",
+            "type": "object",
+            "required": [
+                "name",
+                "slug"
+            ],
+            "properties": {
+                "name": {
+                    "description": "@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Cinema Club"
+                },
+                "slug": {
+                    "description": "@maxLength 63",
+                    "type": "string",
+                    "maxLength": 63,
+                    "example": "cinema-club"
+                }
+            }
+        },
+        "internal_tenant.Tenant": {
+            "description": "Кинотеатр или клуб со своим каталогом",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Cinema Club"
+                },
+                "slug": {
+                    "description": "Короткое имя для поддомена и заголовка X-Tenant-ID",
+                    "type": "string",
+                    "example": "cinema-club"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_tenant.UpdateTenant": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Cinema Club"
+                }
+            }
+        },
+        "internal_user.Update": {
+            "description": "Модель пользователя с данными, необходимыми для обновления",
+            "type": "object",
+            "properties": {
+                "date_of_birth": {
+                    "description": "Информация о дате рождения пользователя\n@Example \"2000.01.01\"\n@Format date",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Электронная почта пользователя\n@Example \"testemail@example.com\"\n@Format email",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "film_id": {
+                    "description": "Уникальный идентификатор фильма, с которым связан пользователь\n@Example \"1111a111-2b2b-3333-444d-55555555eee5\"\n@DFormat uuid",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "gender": {
+                    "description": "Пол пользователя\n@Enum \"М\" \"Ж\"\n@Format string\n@MaxLength 1",
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "name": {
+                    "description": "Полное ФИО пользователя\n@Example \"Иванов Иван Иванович\"\n@MinLength 2\n@MaxLength 100",
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "internal_user.UpdateV2": {
+            "description": "Модель пользователя в API v2 с данными, необходимыми для обновления",
+            "type": "object",
+            "properties": {
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "film_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "gender": {
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "internal_user.User": {
+            "description": "Модель пользователя со всеми его данными",
+            "type": "object",
+            "required": [
+                "date_of_birth",
+                "email",
+                "gender",
+                "name"
+            ],
+            "properties": {
+                "created_at": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "film_id": {
+                    "description": "@format uuid",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "gender": {
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "updated_at": {
+                    "description": "@format date",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_user.UserV2": {
+            "description": "Модель пользователя в API v2",
+            "type": "object",
+            "required": [
+                "date_of_birth",
+                "email",
+                "gender",
+                "name"
+            ],
+            "properties": {
+                "created_at": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "description": "@format date",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "film_ids": {
+                    "description": "@format uuid",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "gender": {
+                    "type": "string",
+                    "enum": [
+                        "М",
+                        "Ж"
+                    ]
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@minLength 1\n@maxLength 255",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "updated_at": {
+                    "description": "@format date",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_webhook.CreateWebhook": {
+            "description": "URL получателя, типы событий и признак активности",
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "active": {
+                    "description": "По умолчанию true",
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "film.created",
+                        "film.updated"
+                    ]
+                },
+                "url": {
+                    "description": "@maxLength 2048",
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://partner.example.com/hooks/movies"
+                }
+            }
+        },
+        "internal_webhook.Delivery": {
+            "description": "Состояние доставки: pending — ждет попытки, succeeded — получатель ответил 2xx, dead — попытки исчерпаны",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "description": "Id события, он же заголовок Webhook-Id; не меняется при повторах",
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "succeeded",
+                        "dead"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_webhook.IssuedWebhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Неактивной подписке новые события не раскладываются и не доставляются",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Типы событий (film.updated) или ресурсы (film); пустой список — все события",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "film"
+                    ]
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_3q2-7wAAAAA"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/movies"
+                }
+            }
+        },
+        "internal_webhook.Replay": {
+            "description": "Без since повторяются доставки в состоянии dead, с since — все доставки событий начиная с этого момента",
+            "type": "object",
+            "properties": {
+                "since": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_webhook.ReplayResult": {
+            "type": "object",
+            "properties": {
+                "replayed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_webhook.UpdateWebhook": {
+            "description": "Переданные поля заменяют текущие значения",
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "description": "@maxLength 2048",
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "internal_webhook.Webhook": {
+            "description": "URL, на который доставляются события фильмов и пользователей",
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Неактивной подписке новые события не раскладываются и не доставляются",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Типы событий (film.updated) или ресурсы (film); пустой список — все события",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "film"
+                    ]
+                },
+                "id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/movies"
+                }
+            }
+        },
+        "rest-api-tutorial_internal_session.Token": {
+            "description": "Токен доступа для заголовка Authorization: Bearer и refresh token для его обновления",
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "Срок действия токена доступа в секундах",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "description": "Одноразовый: обновление возвращает новый refresh token, повторное\nиспользование старого завершает сеанс",
+                    "type": "string"
+                },
+                "session_id": {
+                    "description": "@format uuid",
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_batch.ItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Op"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_batch.Mode": {
+            "type": "string",
+            "enum": [
+                "atomic",
+                "best_effort"
+            ],
+            "x-enum-varnames": [
+                "ModeAtomic",
+                "ModeBestEffort"
+            ]
+        },
+        "rest-api-tutorial_pkg_batch.Op": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "OpCreate",
+                "OpUpdate",
+                "OpDelete"
+            ]
+        },
+        "rest-api-tutorial_pkg_batch.Operation": {
+            "description": "Для create в data передается ресурс целиком, для update — изменяемые поля, для delete — только id",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "op": {
+                    "description": "@Enum create update delete",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Op"
+                        }
+                    ],
+                    "example": "create"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_batch.Request": {
+            "description": "Список операций create/update/delete над ресурсами одного типа",
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "@Enum atomic best_effort",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Mode"
+                        }
+                    ],
+                    "example": "atomic"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Operation"
+                    }
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_batch.Response": {
+            "description": "Статус и ошибка по каждой операции в порядке запроса",
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_batch.Mode"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest-api-tutorial_pkg_batch.ItemResult"
+                    }
                 },
-                "title": {
-                    "type": "string"
+                "succeeded": {
+                    "type": "integer"
                 }
             }
         },
-        "internal_user.Update": {
-            "description": "Модель пользователя с данными, необходимыми для обновления",
+        "rest-api-tutorial_pkg_bulk.Format": {
+            "type": "string",
+            "enum": [
+                "csv",
+                "json",
+                "ndjson"
+            ],
+            "x-enum-varnames": [
+                "FormatCSV",
+                "FormatJSON",
+                "FormatNDJSON"
+            ]
+        },
+        "rest-api-tutorial_pkg_bulk.Result": {
+            "description": "Количество обработанных строк и ошибки по каждой отклоненной строке",
             "type": "object",
             "properties": {
-                "date_of_birth": {
-                    "description": "Информация о дате рождения пользователя\n@Example \"2000.01.01\"\n@Format date",
-                    "type": "string"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "email": {
-                    "description": "Электронная почта пользователя\n@Example \"testemail@example.com\"\n@Format email",
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/rest-api-tutorial_pkg_bulk.RowError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "format": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_bulk.Format"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "upsert": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_bulk.RowError": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string"
                 },
-                "film_id": {
-                    "description": "Уникальный идентификатор фильма, с которым связан пользователь\n@Example \"1111a111-2b2b-3333-444d-55555555eee5\"\n@DFormat uuid",
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_films_FilmV2": {
+            "type": "object",
+            "properties": {
+                "data": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/internal_films.FilmV2"
                     }
                 },
-                "gender": {
-                    "description": "Пол пользователя\n@Enum \"М\" \"Ж\"\n@Format string\n@MaxLength 1",
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_me_OwnedFilm": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_me.OwnedFilm"
+                    }
                 },
-                "name": {
-                    "description": "Полное ФИО пользователя\n@Example \"Иванов Иван Иванович\"\n@MinLength 2\n@MaxLength 100",
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
                 }
             }
         },
-        "internal_user.User": {
-            "description": "Модель пользователя со всеми его данными",
+        "rest-api-tutorial_pkg_envelope.Collection-internal_recommend_Recommendation": {
             "type": "object",
-            "required": [
-                "date_of_birth",
-                "email",
-                "gender",
-                "name"
-            ],
             "properties": {
-                "created_at": {
-                    "description": "@format date",
-                    "type": "string"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_recommend.Recommendation"
+                    }
                 },
-                "date_of_birth": {
-                    "description": "@format date",
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_session_Session": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_session.Session"
+                    }
                 },
-                "email": {
-                    "description": "@minLength 1\n@maxLength 255",
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_sso_Provider": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_sso.Provider"
+                    }
                 },
-                "film_id": {
-                    "description": "@format uuid",
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_stats_AddedFilm": {
+            "type": "object",
+            "properties": {
+                "data": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/internal_stats.AddedFilm"
                     }
                 },
-                "gender": {
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_stats_Demographic": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_stats.Demographic"
+                    }
                 },
-                "id": {
-                    "description": "@format uuid",
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_stats_Growth": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_stats.Growth"
+                    }
                 },
-                "name": {
-                    "description": "@minLength 1\n@maxLength 255",
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_stats_RatingBucket": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_stats.RatingBucket"
+                    }
                 },
-                "updated_at": {
-                    "description": "@format date",
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_stats_ReleaseYear": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_stats.ReleaseYear"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_tenant_Tenant": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_tenant.Tenant"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_user_UserV2": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_user.UserV2"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_webhook_Delivery": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_webhook.Delivery"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Collection-internal_webhook_Webhook": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_webhook.Webhook"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/rest-api-tutorial_pkg_envelope.Meta"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.ErrorResponse": {
+            "description": "Используется для возврата ошибок клиенту",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "HTTP-код ошибки\n@example 400",
+                    "type": "integer"
+                },
+                "details": {
+                    "description": "Детали ошибки (опционально)"
+                },
+                "message": {
+                    "description": "Сообщение об ошибке\n@example \"Invalid request parameters\"",
                     "type": "string"
                 }
             }
+        },
+        "rest-api-tutorial_pkg_envelope.Meta": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Количество элементов в data",
+                    "type": "integer"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_account_Account": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_account.Account"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_films_FilmV2": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_films.FilmV2"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_me_OwnedFilm": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_me.OwnedFilm"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_me_Preferences": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_me.Preferences"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_me_Profile": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_me.Profile"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_session_Token": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_session.Token"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_sso_Token": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_sso.Token"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_tenant_Tenant": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_tenant.Tenant"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_user_UserV2": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_user.UserV2"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_webhook_Delivery": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_webhook.Delivery"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_webhook_IssuedWebhook": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_webhook.IssuedWebhook"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_webhook_ReplayResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_webhook.ReplayResult"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-internal_webhook_Webhook": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_webhook.Webhook"
+                }
+            }
+        },
+        "rest-api-tutorial_pkg_envelope.Resource-rest-api-tutorial_internal_session_Token": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/rest-api-tutorial_internal_session.Token"
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "BearerAuth": {
             "type": "apiKey",
             "name": "Authorization",
-            "in": "query"
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,9 @@
+package docs
+
+import _ "embed"
+
+// SwaggerJSON спецификация Swagger 2.0, сгенерированная swag и встроенная в бинарник,
+// чтобы документация не зависела от рабочего каталога
+//
+//go:embed swagger.json
+var SwaggerJSON []byte
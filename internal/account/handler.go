@@ -0,0 +1,321 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"rest-api-tutorial/internal/session"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"strings"
+	"time"
+)
+
+// Options сроки действия ссылок и шаблоны ссылок в письмах
+type Options struct {
+	VerifyTTL time.Duration
+	ResetTTL  time.Duration
+	// VerifyURL и ResetURL адреса страниц клиента с плейсхолдерами {token} и {tenant}
+	VerifyURL string
+	ResetURL  string
+}
+
+// Validate проверяет сроки действия и наличие {token} в шаблонах ссылок
+func (o Options) Validate() error {
+	if o.VerifyTTL < time.Minute || o.ResetTTL < time.Minute {
+		return fmt.Errorf("account token lifetimes must be at least a minute")
+	}
+	if !strings.Contains(o.VerifyURL, "{token}") || !strings.Contains(o.ResetURL, "{token}") {
+		return fmt.Errorf("account link URLs must contain the {token} placeholder")
+	}
+	return nil
+}
+
+type Handler struct {
+	logger   *logging.Logger
+	storage  *Storage
+	notifier *Notifier
+	sessions *session.Manager
+	opts     Options
+}
+
+func NewHandler(storage *Storage, notifier *Notifier, sessions *session.Manager, opts Options, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:   logger,
+		storage:  storage,
+		notifier: notifier,
+		sessions: sessions,
+		opts:     opts,
+	}
+}
+
+// dummyHash хеш, с которым сравнивается пароль, если пользователя нет или у него
+// нет пароля: ответ занимает столько же времени, и по нему не узнать, зарегистрирован ли email
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// Signup godoc
+// @Summary Sign up
+// @Description Register a user with a password. A confirmation link is emailed to the address in the language of Accept-Language (ru or en); the email stays unverified until the link is used.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Param Accept-Language header string false "Language of the email" example(en)
+// @Param account body Signup true "User data and password"
+// @Success 201 {object} envelope.Resource[account.Account] "Registered account"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 409 {object} envelope.ErrorResponse "User with this email already exists"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /account/signup [post]
+func (h *Handler) Signup(c *gin.Context) {
+	var input Signup
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to generate ID")
+		return
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+	newUser := user.User{
+		ID:          id.String(),
+		Name:        input.Name,
+		Email:       input.Email,
+		DateOfBirth: input.DateOfBirth,
+		Gender:      input.Gender,
+		CreatedAt:   time.Now(),
+	}
+	newUser.UpdatedAt = newUser.CreatedAt
+
+	ctx := c.Request.Context()
+	if err := h.storage.Create(ctx, newUser, string(passwordHash)); err != nil {
+		if errors.Is(err, ErrConflict) {
+			envelope.Error(c, http.StatusConflict, "User with this email already exists")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to create account")
+		return
+	}
+
+	account := &Account{ID: newUser.ID, Name: newUser.Name, Email: newUser.Email}
+	// Пользователь уже создан: письмо можно запросить повторно, поэтому ошибка отправки не отменяет регистрацию
+	if err := h.sendLink(ctx, account, purposeVerifyEmail, c.GetHeader("Accept-Language")); err != nil {
+		h.logger.Errorf("Failed to send verification email to user %s: %v", account.ID, err)
+	}
+	envelope.Data(c, http.StatusCreated, *account)
+}
+
+// ResendVerification godoc
+// @Summary Resend the email confirmation link
+// @Description Email a new confirmation link; earlier links stop working. The response is the same whether or not the address is registered or already verified.
+// @Tags account
+// @Accept json
+// @Param Accept-Language header string false "Language of the email" example(en)
+// @Param request body EmailRequest true "Email address"
+// @Success 202 "Request accepted"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Router /account/verify-email/resend [post]
+func (h *Handler) ResendVerification(c *gin.Context) {
+	var input EmailRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := c.Request.Context()
+	account, err := h.storage.FindByEmail(ctx, input.Email)
+	switch {
+	case err == nil && !account.EmailVerified:
+		if err := h.sendLink(ctx, account, purposeVerifyEmail, c.GetHeader("Accept-Language")); err != nil {
+			h.logger.Errorf("Failed to send verification email to user %s: %v", account.ID, err)
+		}
+	case err != nil && !errors.Is(err, ErrNotFound):
+		h.logger.Errorf("Failed to find account: %v", err)
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyEmail godoc
+// @Summary Confirm the email address
+// @Description Confirm the email address with the token from the confirmation link. The token works once; it stops working when a newer link is sent or the email is changed.
+// @Tags account
+// @Accept json
+// @Param request body VerifyEmail true "Token from the link"
+// @Success 204 "Email confirmed"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body, or invalid or expired token"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /account/verify-email [post]
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var input VerifyEmail
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.storage.VerifyEmail(c.Request.Context(), input.Token); err != nil {
+		h.tokenError(c, err, "Failed to verify email")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a password reset link. The response is the same whether or not the address is registered.
+// @Tags account
+// @Accept json
+// @Param Accept-Language header string false "Language of the email" example(en)
+// @Param request body EmailRequest true "Email address"
+// @Success 202 "Request accepted"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Router /account/forgot-password [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var input EmailRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := c.Request.Context()
+	account, err := h.storage.FindByEmail(ctx, input.Email)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			h.logger.Errorf("Failed to find account: %v", err)
+		}
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	if err := h.sendLink(ctx, account, purposeResetPassword, c.GetHeader("Accept-Language")); err != nil {
+		h.logger.Errorf("Failed to send password reset email to user %s: %v", account.ID, err)
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset the password
+// @Description Set a new password with the token from the reset link. The token works once and also confirms the email address. All sessions of the user are ended.
+// @Tags account
+// @Accept json
+// @Param request body ResetPassword true "Token from the link and the new password"
+// @Success 204 "Password changed"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body, or invalid or expired token"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /account/reset-password [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var input ResetPassword
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+	if _, err := h.storage.ResetPassword(c.Request.Context(), input.Token, string(passwordHash)); err != nil {
+		h.tokenError(c, err, "Failed to reset password")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Login godoc
+// @Summary Sign in with a password
+// @Description Sign in with the email and password set at signup or by a password reset. The email must be confirmed first. A session is started for the device; the access token is refreshed through /auth/refresh.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body Login true "Email and password"
+// @Success 200 {object} envelope.Resource[session.Token] "Signed in"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 401 {object} envelope.ErrorResponse "Invalid email or password"
+// @Failure 403 {object} envelope.ErrorResponse "Email is not confirmed"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /auth/login [post]
+func (h *Handler) Login(c *gin.Context) {
+	var input Login
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := c.Request.Context()
+	account, passwordHash, err := h.storage.FindCredentials(ctx, input.Email)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		h.logger.Errorf("Failed to find account: %v", err)
+		envelope.Error(c, http.StatusInternalServerError, "Failed to sign in")
+		return
+	}
+	if passwordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(input.Password))
+		envelope.Error(c, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(input.Password)); err != nil {
+		envelope.Error(c, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+	// О неподтвержденном адресе сообщается только знающему пароль
+	if !account.EmailVerified {
+		envelope.Error(c, http.StatusForbidden, "Email is not confirmed, follow the link from the confirmation email")
+		return
+	}
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		envelope.Error(c, http.StatusBadRequest, "Tenant is required")
+		return
+	}
+	token, err := h.sessions.Start(ctx, tenantID, account.ID, session.ClientFrom(c))
+	if err != nil {
+		h.logger.Errorf("Failed to start session: %v", err)
+		envelope.Error(c, http.StatusInternalServerError, "Failed to sign in")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	envelope.Data(c, http.StatusOK, *token)
+}
+
+// sendLink выдает токен назначения purpose и отправляет письмо со ссылкой.
+// Письмо называется так же, как назначение токена.
+func (h *Handler) sendLink(ctx context.Context, account *Account, purpose, acceptLanguage string) error {
+	ttl, pattern := h.opts.VerifyTTL, h.opts.VerifyURL
+	if purpose == purposeResetPassword {
+		ttl, pattern = h.opts.ResetTTL, h.opts.ResetURL
+	}
+	token, err := h.storage.IssueToken(ctx, account, purpose, ttl)
+	if err != nil {
+		return err
+	}
+	lang := h.notifier.Language(acceptLanguage)
+	tenantID, _ := tenant.FromContext(ctx)
+	return h.notifier.Send(ctx, lang, purpose, mailData{
+		Name:      account.Name,
+		Email:     account.Email,
+		Link:      link(pattern, token, tenantID),
+		ExpiresIn: expiresIn(lang, ttl),
+	})
+}
+
+func (h *Handler) tokenError(c *gin.Context, err error, message string) {
+	if errors.Is(err, ErrInvalidToken) {
+		envelope.Error(c, http.StatusBadRequest, "Invalid or expired token")
+		return
+	}
+	h.logger.Errorf("%s: %v", message, err)
+	envelope.Error(c, http.StatusInternalServerError, message)
+}
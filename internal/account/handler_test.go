@@ -0,0 +1,103 @@
+package account_test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"rest-api-tutorial/internal/account"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/pgtest"
+	"rest-api-tutorial/internal/session"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m, pgtest.Options{}))
+}
+
+func TestLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pool := pgtest.DB(t)
+	fixtures := pgtest.NewFixtures(t, pool)
+	logger := logging.GetLogger()
+	ctx := pgtest.Context()
+
+	storage := account.NewStorage(pool, logger)
+	tokens, err := auth.NewTokens("", time.Minute, nil)
+	if err != nil {
+		t.Fatalf("NewTokens: %v", err)
+	}
+	sessions := session.NewManager(session.NewStorage(pool, logger), tokens, session.Options{TTL: time.Hour})
+	h := account.NewHandler(storage, nil, sessions, account.Options{}, logger)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	for _, email := range []string{"verified@example.com", "unverified@example.com"} {
+		u := user.User{
+			ID:          uuid.Must(uuid.NewV4()).String(),
+			Name:        "Иванов Иван",
+			Email:       email,
+			DateOfBirth: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			Gender:      "М",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		if err := storage.Create(ctx, u, string(hash)); err != nil {
+			t.Fatalf("Create(%s): %v", email, err)
+		}
+	}
+	if _, err := pool.Exec(ctx, `UPDATE users SET email_verified_at = NOW() WHERE email = 'verified@example.com'`); err != nil {
+		t.Fatalf("verify email: %v", err)
+	}
+	// Пользователь без пароля, как созданный администратором
+	passwordless := fixtures.User()
+
+	router := gin.New()
+	router.POST("/auth/login", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ctx)
+		h.Login(c)
+	})
+
+	tests := []struct {
+		name, email, password string
+		want                  int
+	}{
+		{"valid password", "Verified@example.com", "correct horse", http.StatusOK},
+		{"wrong password", "verified@example.com", "wrong horse", http.StatusUnauthorized},
+		{"unknown email", "missing@example.com", "correct horse", http.StatusUnauthorized},
+		{"empty password", "verified@example.com", "", http.StatusBadRequest},
+		{"user without a password", passwordless.Email, "correct horse", http.StatusUnauthorized},
+		{"unconfirmed email", "unverified@example.com", "correct horse", http.StatusForbidden},
+		// Неподтвержденный адрес не раскрывается без пароля
+		{"unconfirmed email, wrong password", "unverified@example.com", "wrong horse", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(account.Login{Email: tt.email, Password: tt.password})
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var resp envelope.Resource[session.Token]
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.AccessToken == "" || resp.Data.RefreshToken == "" {
+			t.Errorf("%s: body %s, want session tokens", tt.name, w.Body)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package account
+
+import "time"
+
+// Account учетная запись пользователя
+// @description Пользователь с отметкой о подтверждении email
+type Account struct {
+	// @format uuid
+	ID string `json:"id"`
+
+	Name  string `json:"name" example:"Иванов Иван Иванович"`
+	Email string `json:"email" example:"testemail@example.com"`
+
+	// Адрес подтвержден переходом по ссылке из письма
+	EmailVerified bool `json:"email_verified"`
+}
+
+// Signup модель регистрации: данные пользователя и пароль
+// @description Пользователь с паролем; на email уходит письмо со ссылкой подтверждения
+type Signup struct {
+	// @maxLength 255
+	Name string `json:"name" binding:"required,max=255" example:"Иванов Иван Иванович"`
+
+	// @maxLength 255
+	Email string `json:"email" binding:"required,email,max=255" example:"testemail@example.com"`
+
+	// Не длиннее 72 байт: bcrypt не учитывает остаток
+	// @minLength 8
+	// @maxLength 72
+	Password string `json:"password" binding:"required,min=8,max=72"`
+
+	// @format date
+	DateOfBirth time.Time `json:"date_of_birth" binding:"required"`
+	Gender      string    `json:"gender" binding:"required,oneof=М Ж" enums:"М,Ж"`
+}
+
+// EmailRequest адрес для повторного письма подтверждения или восстановления пароля
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email,max=255" example:"testemail@example.com"`
+}
+
+// VerifyEmail токен из ссылки подтверждения email
+type VerifyEmail struct {
+	Token string `json:"token" binding:"required,max=64"`
+}
+
+// ResetPassword токен из ссылки восстановления и новый пароль
+type ResetPassword struct {
+	Token string `json:"token" binding:"required,max=64"`
+
+	// @minLength 8
+	// @maxLength 72
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// Login email и пароль для входа
+type Login struct {
+	Email string `json:"email" binding:"required,email,max=255" example:"testemail@example.com"`
+
+	// @maxLength 72
+	Password string `json:"password" binding:"required,max=72"`
+}
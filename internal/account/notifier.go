@@ -0,0 +1,144 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"golang.org/x/text/language"
+	"net/url"
+	"rest-api-tutorial/pkg/mail"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// languages языки, на которые переведены шаблоны писем
+var languages = []string{"ru", "en"}
+
+// mailData данные шаблона письма
+type mailData struct {
+	Name      string
+	Email     string
+	Link      string
+	ExpiresIn string
+}
+
+// Notifier отправляет письма со ссылками на языке запроса
+type Notifier struct {
+	mailer    mail.Mailer
+	templates map[string]*template.Template
+	matcher   language.Matcher
+	supported []string
+}
+
+// NewNotifier загружает шаблоны писем. defaultLanguage выбирается, когда клиент
+// не указал язык или указал неподдерживаемый.
+func NewNotifier(mailer mail.Mailer, defaultLanguage string) (*Notifier, error) {
+	supported := []string{defaultLanguage}
+	for _, lang := range languages {
+		if lang != defaultLanguage {
+			supported = append(supported, lang)
+		}
+	}
+	if len(supported) != len(languages) {
+		return nil, fmt.Errorf("unsupported mail language %q, expected one of %s", defaultLanguage, strings.Join(languages, ", "))
+	}
+
+	tags := make([]language.Tag, 0, len(supported))
+	templates := make(map[string]*template.Template)
+	for _, lang := range supported {
+		tags = append(tags, language.MustParse(lang))
+		// Шаблон письма называется по назначению токена: templates/<язык>/<назначение>.tmpl
+		for _, name := range []string{purposeVerifyEmail, purposeResetPassword} {
+			tmpl, err := template.ParseFS(templatesFS, "templates/"+lang+"/"+name+".tmpl")
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse mail template %s/%s: %w", lang, name, err)
+			}
+			templates[lang+"/"+name] = tmpl
+		}
+	}
+	return &Notifier{
+		mailer:    mailer,
+		templates: templates,
+		matcher:   language.NewMatcher(tags),
+		supported: supported,
+	}, nil
+}
+
+// Language выбирает язык письма по заголовку Accept-Language
+func (n *Notifier) Language(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return n.supported[0]
+	}
+	_, index, confidence := n.matcher.Match(tags...)
+	if confidence == language.No {
+		return n.supported[0]
+	}
+	return n.supported[index]
+}
+
+// Send отправляет письмо name на языке lang
+func (n *Notifier) Send(ctx context.Context, lang, name string, data mailData) error {
+	tmpl, ok := n.templates[lang+"/"+name]
+	if !ok {
+		return fmt.Errorf("no mail template %s/%s", lang, name)
+	}
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("failed to render mail subject: %w", err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return fmt.Errorf("failed to render mail body: %w", err)
+	}
+	return n.mailer.Send(ctx, mail.Message{
+		To:      data.Email,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimLeft(body.String(), "\n"),
+	})
+}
+
+// link подставляет токен и арендатора в шаблон ссылки вида
+// https://movies.example.com/verify-email?token={token}&tenant={tenant}
+func link(pattern, token, tenantID string) string {
+	return strings.NewReplacer(
+		"{token}", url.QueryEscape(token),
+		"{tenant}", url.QueryEscape(tenantID),
+	).Replace(pattern)
+}
+
+// expiresIn срок действия ссылки словами: "24 hours", "30 минут"
+func expiresIn(lang string, d time.Duration) string {
+	n, unit := int(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unit = int(d/time.Hour), "hour"
+	}
+
+	if lang == "ru" {
+		forms := map[string][3]string{
+			"minute": {"минуту", "минуты", "минут"},
+			"hour":   {"час", "часа", "часов"},
+		}[unit]
+		return strconv.Itoa(n) + " " + russianPlural(n, forms)
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return strconv.Itoa(n) + " " + unit
+}
+
+// russianPlural выбирает форму слова для числа n: 1 час, 2 часа, 5 часов, 21 час
+func russianPlural(n int, forms [3]string) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return forms[0]
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+		return forms[1]
+	}
+	return forms[2]
+}
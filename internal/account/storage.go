@@ -0,0 +1,227 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+var (
+	// ErrNotFound пользователя с таким email нет
+	ErrNotFound = errors.New("account not found")
+	// ErrConflict пользователь с таким email уже существует
+	ErrConflict = errors.New("account already exists")
+	// ErrInvalidToken токен не существует, истек, уже использован или выдан для прежнего email
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+const uniqueViolation = "23505"
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+// Create регистрирует пользователя с паролем. Email считается неподтвержденным.
+func (s *Storage) Create(ctx context.Context, u user.User, passwordHash string) error {
+	q := `
+        INSERT INTO users (id, name, email, date_of_birth, gender, password_hash, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+	_, err := s.client.Exec(ctx, q, u.ID, u.Name, u.Email, u.DateOfBirth, u.Gender, passwordHash, u.CreatedAt, u.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrConflict
+		}
+		s.logger.Errorf("Failed to create account: %v", err)
+		return fmt.Errorf("failed to create account: %w", err)
+	}
+	return nil
+}
+
+// FindByEmail находит пользователя по email без учета регистра
+func (s *Storage) FindByEmail(ctx context.Context, email string) (*Account, error) {
+	q := `
+        SELECT id, name, email, email_verified_at IS NOT NULL
+        FROM users
+        WHERE lower(email) = lower($1)
+        ORDER BY created_at
+        LIMIT 1
+    `
+	var account Account
+	err := s.client.QueryRow(ctx, q, email).Scan(
+		&account.ID,
+		&account.Name,
+		&account.Email,
+		&account.EmailVerified,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	return &account, nil
+}
+
+// FindCredentials находит пользователя по email вместе с хешем пароля. У пользователей,
+// созданных без пароля (администратором или через SSO), хеш пустой.
+func (s *Storage) FindCredentials(ctx context.Context, email string) (*Account, string, error) {
+	q := `
+        SELECT id, name, email, email_verified_at IS NOT NULL, COALESCE(password_hash, '')
+        FROM users
+        WHERE lower(email) = lower($1)
+        ORDER BY created_at
+        LIMIT 1
+    `
+	var account Account
+	var passwordHash string
+	err := s.client.QueryRow(ctx, q, email).Scan(
+		&account.ID,
+		&account.Name,
+		&account.Email,
+		&account.EmailVerified,
+		&passwordHash,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", fmt.Errorf("failed to get account: %w", err)
+	}
+	return &account, passwordHash, nil
+}
+
+// IssueToken создает токен назначения purpose для текущего email пользователя.
+// Прежние токены того же назначения перестают действовать: работает только
+// ссылка из последнего письма.
+func (s *Storage) IssueToken(ctx context.Context, account *Account, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2`, account.ID, purpose); err != nil {
+		return "", fmt.Errorf("failed to revoke previous tokens: %w", err)
+	}
+	q := `
+        INSERT INTO user_tokens (user_id, purpose, email, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	if _, err := tx.Exec(ctx, q, account.ID, purpose, account.Email, hash, time.Now().Add(ttl)); err != nil {
+		s.logger.Errorf("Failed to issue %s token: %v", purpose, err)
+		return "", fmt.Errorf("failed to issue token: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return token, nil
+}
+
+// VerifyEmail погашает токен подтверждения и отмечает email пользователя подтвержденным
+func (s *Storage) VerifyEmail(ctx context.Context, token string) error {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID, email, err := consumeToken(ctx, tx, token, purposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	q := `
+        UPDATE users
+        SET email_verified_at = COALESCE(email_verified_at, NOW())
+        WHERE id = $1 AND email = $2
+    `
+	tag, err := tx.Exec(ctx, q, userID, email)
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidToken
+	}
+	return tx.Commit(ctx)
+}
+
+// ResetPassword погашает токен восстановления и заменяет хеш пароля. Переход
+// по ссылке из письма заодно подтверждает email. Сеансы пользователя завершаются.
+// Возвращает id пользователя.
+func (s *Storage) ResetPassword(ctx context.Context, token, passwordHash string) (string, error) {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID, email, err := consumeToken(ctx, tx, token, purposeResetPassword)
+	if err != nil {
+		return "", err
+	}
+	q := `
+        UPDATE users
+        SET password_hash = $3,
+            email_verified_at = COALESCE(email_verified_at, NOW())
+        WHERE id = $1 AND email = $2
+    `
+	tag, err := tx.Exec(ctx, q, userID, email, passwordHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to reset password: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return "", ErrInvalidToken
+	}
+	// Новый пароль завершает все сеансы: вошедший с украденными данными теряет доступ
+	qSessions := `
+        UPDATE user_sessions
+        SET revoked_at = NOW(), revoked_reason = 'password_reset'
+        WHERE user_id = $1 AND revoked_at IS NULL
+    `
+	if _, err := tx.Exec(ctx, qSessions, userID); err != nil {
+		return "", fmt.Errorf("failed to end sessions: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, nil
+}
+
+// consumeToken отмечает действующий токен использованным и возвращает пользователя
+// и email, для которых он выдан
+func consumeToken(ctx context.Context, tx pgx.Tx, token, purpose string) (userID, email string, err error) {
+	q := `
+        UPDATE user_tokens
+        SET used_at = NOW()
+        WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+        RETURNING user_id, email
+    `
+	err = tx.QueryRow(ctx, q, hashToken(token), purpose).Scan(&userID, &email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", ErrInvalidToken
+		}
+		return "", "", fmt.Errorf("failed to consume token: %w", err)
+	}
+	return userID, email, nil
+}
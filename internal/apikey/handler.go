@@ -0,0 +1,190 @@
+package apikey
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/pkg/logging"
+	"slices"
+	"time"
+)
+
+type Handler struct {
+	logger  *logging.Logger
+	storage *Storage
+}
+
+func NewHandler(storage *Storage, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Issue an API key
+// @Description Create a key for a service client. The full key is returned only in this response; only its hash is stored.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param key body CreateAPIKey true "Key name, scopes and optional expiry"
+// @Success 201 {object} IssuedAPIKey "Issued key with secret"
+// @Failure 400 {object} map[string]string "Invalid request body, unknown scope or tenant, or platform scope for a tenant key"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Missing api_keys:admin scope"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys [post]
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var input CreateAPIKey
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := auth.ValidateScopes(input.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.TenantID != nil {
+		if _, err := uuid.FromString(*input.TenantID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tenant"})
+			return
+		}
+		for _, scope := range auth.PlatformScopes {
+			if slices.Contains(input.Scopes, scope) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "scope " + scope + " cannot be granted to a tenant key"})
+				return
+			}
+		}
+	}
+	if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate ID"})
+		return
+	}
+	prefix, key, hash, err := generateKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	issued := IssuedAPIKey{
+		APIKey: APIKey{
+			ID:        id.String(),
+			Name:      input.Name,
+			Prefix:    prefix,
+			Scopes:    input.Scopes,
+			TenantID:  input.TenantID,
+			ExpiresAt: input.ExpiresAt,
+			CreatedAt: time.Now(),
+		},
+		Key: key,
+	}
+	issued.UpdatedAt = issued.CreatedAt
+
+	if err := h.storage.Create(c.Request.Context(), issued.APIKey, hash); err != nil {
+		if errors.Is(err, ErrUnknownTenant) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tenant"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+	c.JSON(http.StatusCreated, issued)
+}
+
+// GetList godoc
+// @Summary List API keys
+// @Description Retrieve all API keys including revoked ones, without secrets
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} APIKey "List of API keys"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Missing api_keys:admin scope"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys [get]
+func (h *Handler) GetList(c *gin.Context) {
+	keys, err := h.storage.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description Replace the secret of an active key. The old secret stops working immediately; the new one is returned only in this response.
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID (UUID)"
+// @Success 200 {object} IssuedAPIKey "Key with the new secret"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Missing api_keys:admin scope"
+// @Failure 404 {object} map[string]string "API key not found or revoked"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys/{id}/rotate [post]
+func (h *Handler) RotateAPIKey(c *gin.Context) {
+	param := c.Param("id")
+	if _, err := uuid.FromString(param); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	prefix, key, hash, err := generateKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	rotated, err := h.storage.Rotate(c.Request.Context(), param, prefix, hash)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate API key"})
+		return
+	}
+	c.JSON(http.StatusOK, IssuedAPIKey{APIKey: *rotated, Key: key})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Permanently disable a key. The record is kept for auditing.
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID (UUID)"
+// @Success 204 "API key revoked"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Missing api_keys:admin scope"
+// @Failure 404 {object} map[string]string "API key not found or already revoked"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	param := c.Param("id")
+	if _, err := uuid.FromString(param); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	if err := h.storage.Revoke(c.Request.Context(), param); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,46 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	prefix, key, hash, err := generateKey()
+	if err != nil {
+		t.Fatalf("generateKey: %v", err)
+	}
+	gotPrefix, secret, ok := splitKey(key)
+	if !ok || gotPrefix != prefix || !strings.HasPrefix(prefix, "mk_") {
+		t.Errorf("splitKey(%q) = %q, %v; want prefix %q", key, gotPrefix, ok, prefix)
+	}
+	// В базе хранится только хеш секрета
+	if hash != hashSecret(secret) || strings.Contains(hash, secret) {
+		t.Errorf("hash %q does not match the secret", hash)
+	}
+
+	_, other, _, err := generateKey()
+	if err != nil || other == key {
+		t.Errorf("second key %q, %v; want a different key", other, err)
+	}
+}
+
+func TestSplitKey(t *testing.T) {
+	tests := []struct {
+		key            string
+		prefix, secret string
+		ok             bool
+	}{
+		{"mk_0a1b2c.c2VjcmV0", "mk_0a1b2c", "c2VjcmV0", true},
+		{"mk_0a1b2c.", "", "", false},
+		{".c2VjcmV0", "", "", false},
+		{"mk_0a1b2c", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		prefix, secret, ok := splitKey(tt.key)
+		if ok != tt.ok || (ok && (prefix != tt.prefix || secret != tt.secret)) {
+			t.Errorf("splitKey(%q) = %q, %q, %v; want %q, %q, %v", tt.key, prefix, secret, ok, tt.prefix, tt.secret, tt.ok)
+		}
+	}
+}
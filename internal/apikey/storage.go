@@ -0,0 +1,203 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+var (
+	// ErrNotFound ключ не существует или уже отозван
+	ErrNotFound = errors.New("api key not found")
+	// ErrUnknownTenant арендатор ключа не существует
+	ErrUnknownTenant = errors.New("unknown tenant")
+)
+
+const foreignKeyViolation = "23503"
+
+// lastUsedPrecision как часто обновляется last_used_at, чтобы не писать в базу на каждый запрос
+const lastUsedPrecision = time.Minute
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+func (s *Storage) Create(ctx context.Context, key APIKey, hash string) error {
+	q := `
+        INSERT INTO api_keys (id, name, prefix, key_hash, scopes, tenant_id, expires_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+	_, err := s.client.Exec(
+		ctx,
+		q,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		hash,
+		key.Scopes,
+		key.TenantID,
+		key.ExpiresAt,
+		key.CreatedAt,
+		key.UpdatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return ErrUnknownTenant
+		}
+		s.logger.Errorf("Failed to create api key: %v", err)
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) FindAll(ctx context.Context) ([]APIKey, error) {
+	q := `
+        SELECT id, name, prefix, scopes, tenant_id, expires_at, last_used_at, revoked_at, created_at, updated_at
+        FROM api_keys
+        ORDER BY created_at
+    `
+	rows, err := s.client.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		var key APIKey
+		if err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.Scopes,
+			&key.TenantID,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+			&key.CreatedAt,
+			&key.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Rotate заменяет секрет действующего ключа; старый секрет сразу перестает работать
+func (s *Storage) Rotate(ctx context.Context, id, prefix, hash string) (*APIKey, error) {
+	q := `
+        UPDATE api_keys
+        SET prefix = $2, key_hash = $3, updated_at = NOW()
+        WHERE id = $1 AND revoked_at IS NULL
+        RETURNING id, name, prefix, scopes, tenant_id, expires_at, last_used_at, revoked_at, created_at, updated_at
+    `
+	var key APIKey
+	err := s.client.QueryRow(ctx, q, id, prefix, hash).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.TenantID,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		s.logger.Errorf("Failed to rotate api key: %v", err)
+		return nil, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+	return &key, nil
+}
+
+func (s *Storage) Revoke(ctx context.Context, id string) error {
+	q := `
+        UPDATE api_keys
+        SET revoked_at = NOW(), updated_at = NOW()
+        WHERE id = $1 AND revoked_at IS NULL
+    `
+	tag, err := s.client.Exec(ctx, q, id)
+	if err != nil {
+		s.logger.Errorf("Failed to revoke api key: %v", err)
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AuthenticateKey реализует auth.KeyAuthenticator: находит ключ по префиксу,
+// сравнивает хеш секрета и проверяет отзыв и срок действия
+func (s *Storage) AuthenticateKey(ctx context.Context, raw string) (*auth.Principal, error) {
+	prefix, secret, ok := splitKey(raw)
+	if !ok {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	q := `
+        SELECT id, name, key_hash, scopes, COALESCE(tenant_id::text, ''), expires_at, revoked_at
+        FROM api_keys
+        WHERE prefix = $1
+    `
+	var (
+		principal = auth.Principal{Type: auth.PrincipalAPIKey}
+		hash      string
+		expiresAt *time.Time
+		revokedAt *time.Time
+	)
+	err := s.client.QueryRow(ctx, q, prefix).Scan(
+		&principal.ID,
+		&principal.Name,
+		&hash,
+		&principal.Scopes,
+		&principal.TenantID,
+		&expiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, auth.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashSecret(secret))) != 1 {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if revokedAt != nil || (expiresAt != nil && expiresAt.Before(time.Now())) {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	qUsed := `
+        UPDATE api_keys
+        SET last_used_at = NOW()
+        WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - make_interval(secs => $2))
+    `
+	if _, err := s.client.Exec(ctx, qUsed, principal.ID, lastUsedPrecision.Seconds()); err != nil {
+		s.logger.Warnf("Failed to update api key last use: %v", err)
+	}
+
+	return &principal, nil
+}
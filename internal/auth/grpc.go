@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"rest-api-tutorial/pkg/logging"
+)
+
+// MetadataAPIKey ключ метаданных gRPC с API-ключом, аналог заголовка X-API-Key
+const MetadataAPIKey = "x-api-key"
+
+// MetadataAuthorization ключ метаданных gRPC с токеном доступа: "Bearer <token>"
+const MetadataAuthorization = "authorization"
+
+type principalKey struct{}
+
+// ContextWithPrincipal кладет субъект в контекст вызова
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает субъект вызова gRPC или nil
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// UnaryServerInterceptor аутентифицирует вызов gRPC по метаданным x-api-key или authorization и проверяет
+// область доступа метода по scopes (полное имя метода -> область). Метод без записи
+// в scopes запрещен, чтобы новый RPC не оказался открытым по ошибке.
+func UnaryServerInterceptor(keys KeyAuthenticator, opts Options, scopes map[string]string, logger *logging.Logger) grpc.UnaryServerInterceptor {
+	authenticator := NewAuthenticator(keys, opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var key, authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataAPIKey); len(values) > 0 {
+				key = values[0]
+			}
+			if values := md.Get(MetadataAuthorization); len(values) > 0 {
+				authorization = values[0]
+			}
+		}
+
+		principal, bearer, err := authenticator.authenticate(ctx, key, authorization)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidCredentials) {
+				logger.Errorf("Failed to authenticate request: %v", err)
+				return nil, status.Error(codes.Internal, "Failed to authenticate request")
+			}
+			if bearer {
+				return nil, status.Error(codes.Unauthenticated, "Invalid or expired access token")
+			}
+			return nil, status.Error(codes.Unauthenticated, "Invalid, expired or revoked API key")
+		}
+
+		scope, ok := scopes[info.FullMethod]
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "Method %s has no access policy", info.FullMethod)
+		}
+		if !principal.HasScope(scope) {
+			if principal.Type == PrincipalAnonymous {
+				return nil, status.Errorf(codes.Unauthenticated, "Missing required scope %s", scope)
+			}
+			return nil, status.Errorf(codes.PermissionDenied, "Missing required scope %s", scope)
+		}
+
+		return handler(ContextWithPrincipal(ctx, principal), req)
+	}
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"rest-api-tutorial/internal/ratelimit"
+	apierrors "rest-api-tutorial/pkg/errors"
+	"rest-api-tutorial/pkg/logging"
+)
+
+// HeaderAPIKey заголовок с API-ключом сервисного клиента
+const HeaderAPIKey = "X-API-Key"
+
+// principalContextKey ключ gin-контекста с *Principal текущего запроса
+const principalContextKey = "auth_principal"
+
+// ErrInvalidCredentials переданные учетные данные не подходят
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// KeyAuthenticator проверяет значение заголовка X-API-Key
+type KeyAuthenticator interface {
+	AuthenticateKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// SessionChecker проверяет, что сеанс пользователя не отозван и не истек.
+// Отозванный сеанс — ErrInvalidCredentials.
+type SessionChecker interface {
+	CheckSession(ctx context.Context, sessionID string) error
+}
+
+// Options настройки аутентификации
+type Options struct {
+	// AdminKey статический ключ администратора для первоначальной настройки; пустой — выключен
+	AdminKey string
+	// AnonymousScopes области доступа запросов без учетных данных
+	AnonymousScopes []string
+	// Tokens проверяет токены доступа пользователей (Authorization: Bearer); nil — не принимаются
+	Tokens *Tokens
+	// Sessions проверяет сеанс токена доступа на каждом запросе; nil — токен действует до истечения
+	Sessions SessionChecker
+}
+
+// Authenticator определяет субъект по значению API-ключа. Общий для REST и gRPC.
+type Authenticator struct {
+	keys      KeyAuthenticator
+	adminKey  string
+	tokens    *Tokens
+	sessions  SessionChecker
+	anonymous *Principal
+	admin     *Principal
+}
+
+func NewAuthenticator(keys KeyAuthenticator, opts Options) *Authenticator {
+	return &Authenticator{
+		keys:      keys,
+		adminKey:  opts.AdminKey,
+		tokens:    opts.Tokens,
+		sessions:  opts.Sessions,
+		anonymous: &Principal{Type: PrincipalAnonymous, Scopes: opts.AnonymousScopes},
+		admin:     &Principal{Type: PrincipalAdmin, ID: "bootstrap", Name: "Bootstrap admin", Scopes: AllScopes},
+	}
+}
+
+// Authenticate возвращает субъект для ключа: пустой ключ дает анонимного субъекта,
+// неверный — ErrInvalidCredentials
+func (a *Authenticator) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	if key == "" {
+		return a.anonymous, nil
+	}
+	if a.adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(a.adminKey)) == 1 {
+		return a.admin, nil
+	}
+	return a.keys.AuthenticateKey(ctx, key)
+}
+
+// AuthenticateBearer возвращает пользователя по токену доступа; неверный или
+// просроченный токен либо отозванный сеанс — ErrInvalidCredentials
+func (a *Authenticator) AuthenticateBearer(ctx context.Context, token string) (*Principal, error) {
+	if a.tokens == nil {
+		return nil, ErrInvalidCredentials
+	}
+	principal, err := a.tokens.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if a.sessions != nil {
+		if err := a.sessions.CheckSession(ctx, principal.SessionID); err != nil {
+			return nil, err
+		}
+	}
+	return principal, nil
+}
+
+// authenticate выбирает способ по переданным учетным данным: API-ключ важнее токена
+func (a *Authenticator) authenticate(ctx context.Context, key, authorization string) (*Principal, bool, error) {
+	if token := bearerToken(authorization); token != "" && key == "" {
+		principal, err := a.AuthenticateBearer(ctx, token)
+		return principal, true, err
+	}
+	principal, err := a.Authenticate(ctx, key)
+	return principal, false, err
+}
+
+// Middleware определяет субъект запроса по X-API-Key или Authorization: Bearer
+// и кладет его в контекст. Запрос без учетных данных получает анонимного субъекта
+// с AnonymousScopes, неверный ключ или токен — 401. Права на конкретный маршрут
+// проверяет RequireScope.
+func Middleware(keys KeyAuthenticator, opts Options, logger *logging.Logger) gin.HandlerFunc {
+	authenticator := NewAuthenticator(keys, opts)
+
+	return func(c *gin.Context) {
+		principal, bearer, err := authenticator.authenticate(c.Request.Context(), c.GetHeader(HeaderAPIKey), c.GetHeader("Authorization"))
+		if err != nil {
+			if !errors.Is(err, ErrInvalidCredentials) {
+				logger.Errorf("Failed to authenticate request: %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, apierrors.ErrorResponse{
+					Code:    http.StatusInternalServerError,
+					Message: "Failed to authenticate request",
+				})
+				return
+			}
+			if bearer {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, apierrors.ErrorResponse{
+					Code:    http.StatusUnauthorized,
+					Message: "Invalid or expired access token",
+				})
+				return
+			}
+			c.Header("WWW-Authenticate", `ApiKey header="`+HeaderAPIKey+`"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apierrors.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: "Invalid, expired or revoked API key",
+			})
+			return
+		}
+
+		setPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// RequireScope пропускает запрос, только если у субъекта есть область доступа scope
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := PrincipalFrom(c)
+		if principal != nil && principal.HasScope(scope) {
+			c.Next()
+			return
+		}
+
+		status := http.StatusForbidden
+		if principal == nil || principal.Type == PrincipalAnonymous {
+			status = http.StatusUnauthorized
+		}
+		c.AbortWithStatusJSON(status, apierrors.ErrorResponse{
+			Code:    status,
+			Message: "Missing required scope",
+			Details: gin.H{"scope": scope},
+		})
+	}
+}
+
+// RequireUser пропускает только запросы пользователя с токеном доступа: маршруты
+// /me работают с его собственными данными
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal := PrincipalFrom(c); principal != nil && principal.Type == PrincipalUser {
+			c.Next()
+			return
+		}
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, apierrors.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "User access token required",
+		})
+	}
+}
+
+// PrincipalFrom возвращает субъект текущего запроса или nil, если Middleware не вызывался
+func PrincipalFrom(c *gin.Context) *Principal {
+	v, ok := c.Get(principalContextKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := v.(*Principal)
+	return principal
+}
+
+func setPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalContextKey, principal)
+	if principal.Type != PrincipalAnonymous {
+		c.Set(ratelimit.SubjectContextKey, principal.Subject())
+	}
+}
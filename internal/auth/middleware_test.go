@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"rest-api-tutorial/pkg/logging"
+	"testing"
+	"time"
+)
+
+// keyStore ключи сервисных клиентов по значению X-API-Key
+type keyStore map[string]*Principal
+
+func (s keyStore) AuthenticateKey(_ context.Context, key string) (*Principal, error) {
+	if principal, ok := s[key]; ok {
+		return principal, nil
+	}
+	return nil, ErrInvalidCredentials
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens, err := NewTokens("", time.Minute, []string{ScopeFilmsRead})
+	if err != nil {
+		t.Fatalf("NewTokens: %v", err)
+	}
+	userToken, _, err := tokens.Issue("5b1d1b4e-4c2f-4a57-9c1e-0d5f7a3e8b21", "", "0f8e7b2a-3c4d-4e5f-8a9b-1c2d3e4f5a6b")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	keys := keyStore{
+		"mk_reader.secret": {Type: PrincipalAPIKey, ID: "reader", Scopes: []string{ScopeFilmsRead}},
+		"mk_writer.secret": {Type: PrincipalAPIKey, ID: "writer", Scopes: []string{ScopeFilmsRead, ScopeFilmsWrite}},
+	}
+
+	router := gin.New()
+	router.Use(Middleware(keys, Options{
+		AdminKey:        "bootstrap-admin-key",
+		AnonymousScopes: []string{ScopeFilmsRead},
+		Tokens:          tokens,
+	}, logging.GetLogger()))
+	router.GET("/films", RequireScope(ScopeFilmsRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/films", RequireScope(ScopeFilmsWrite), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	tests := []struct {
+		name         string
+		method       string
+		key, bearer  string
+		want         int
+		authenticate string
+	}{
+		{"anonymous read", http.MethodGet, "", "", http.StatusOK, ""},
+		{"anonymous write", http.MethodPost, "", "", http.StatusUnauthorized, ""},
+		{"unknown key", http.MethodGet, "mk_unknown.secret", "", http.StatusUnauthorized, `ApiKey header="X-API-Key"`},
+		{"key without the scope", http.MethodPost, "mk_reader.secret", "", http.StatusForbidden, ""},
+		{"key with the scope", http.MethodPost, "mk_writer.secret", "", http.StatusCreated, ""},
+		{"bootstrap admin key", http.MethodPost, "bootstrap-admin-key", "", http.StatusCreated, ""},
+		{"user token", http.MethodGet, "", userToken, http.StatusOK, ""},
+		{"user token without the scope", http.MethodPost, "", userToken, http.StatusForbidden, ""},
+		{"forged user token", http.MethodGet, "", userToken + "x", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		// API-ключ важнее токена: неверный ключ не спасает действующий токен
+		{"key takes precedence over token", http.MethodGet, "mk_unknown.secret", userToken, http.StatusUnauthorized, `ApiKey header="X-API-Key"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/films", nil)
+		if tt.key != "" {
+			req.Header.Set(HeaderAPIKey, tt.key)
+		}
+		if tt.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.bearer)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); got != tt.authenticate {
+			t.Errorf("%s: WWW-Authenticate %q, want %q", tt.name, got, tt.authenticate)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"films:read, users:read,", 2, false},
+		{"films:read,films:delete", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseScopes(tt.in)
+		if (err != nil) != tt.wantErr || len(got) != tt.want {
+			t.Errorf("ParseScopes(%q) = %v, %v; want %d scopes, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
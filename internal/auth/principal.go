@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Области доступа (scopes), которые проверяются на маршрутах API
+const (
+	ScopeFilmsRead     = "films:read"
+	ScopeFilmsWrite    = "films:write"
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeAPIKeysAdmin  = "api_keys:admin"
+	ScopeWebhooksAdmin = "webhooks:admin"
+	ScopeTenantsAdmin  = "tenants:admin"
+	ScopeSessionsAdmin = "sessions:admin"
+	ScopeStatsRead     = "stats:read"
+)
+
+// PlatformScopes области доступа, которые действуют на все каталоги сразу.
+// Их нельзя выдать ключу, привязанному к арендатору.
+var PlatformScopes = []string{ScopeAPIKeysAdmin, ScopeTenantsAdmin}
+
+// AllScopes все известные области доступа
+var AllScopes = []string{
+	ScopeFilmsRead,
+	ScopeFilmsWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeAPIKeysAdmin,
+	ScopeWebhooksAdmin,
+	ScopeTenantsAdmin,
+	ScopeSessionsAdmin,
+	ScopeStatsRead,
+}
+
+// Типы субъектов запроса
+const (
+	PrincipalAnonymous = "anonymous"
+	PrincipalAPIKey    = "api_key"
+	PrincipalAdmin     = "admin"
+	// PrincipalUser пользователь, вошедший через провайдера OpenID Connect
+	PrincipalUser = "user"
+)
+
+// Principal аутентифицированный субъект запроса
+type Principal struct {
+	Type   string
+	ID     string
+	Name   string
+	Scopes []string
+	// TenantID арендатор, к каталогу которого ограничен субъект. Пустой у платформенных
+	// ключей и администратора: они выбирают арендатора заголовком. Анонимные запросы
+	// выбирают арендатора только на маршрутах регистрации и входа (tenant.Options.AnonymousPaths).
+	TenantID string
+	// SessionID сеанс, к которому выпущен токен доступа пользователя
+	SessionID string
+}
+
+// HasScope проверяет, выдана ли субъекту область доступа
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject строка для идентификации субъекта, например в rate limiting
+func (p *Principal) Subject() string {
+	return p.Type + ":" + p.ID
+}
+
+// ParseScopes разбирает список областей через запятую и проверяет, что все они известны
+func ParseScopes(s string) ([]string, error) {
+	scopes := make([]string, 0)
+	for _, scope := range strings.Split(s, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if err := ValidateScopes([]string{scope}); err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// ValidateScopes проверяет, что все области доступа известны
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		known := false
+		for _, s := range AllScopes {
+			if s == scope {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// tokenIssuer значение iss токенов доступа пользователей
+const tokenIssuer = "movies"
+
+// tokenHeader заголовок JWT; других алгоритмов Tokens не выпускает и не принимает
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Tokens выпускает и проверяет токены доступа пользователей, вошедших через
+// провайдера (JWT с подписью HS256). Токен короткоживущий и не хранится на сервере;
+// отзыв сеанса, к которому он выпущен, проверяет SessionChecker.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+	scopes []string
+}
+
+// NewTokens создает выпуск токенов с секретом secret. Пустой секрет заменяется
+// случайным: токены перестанут действовать после перезапуска и не подойдут
+// другим репликам, поэтому в рабочей конфигурации секрет задается явно.
+// scopes области доступа, которые получает любой вошедший пользователь.
+func NewTokens(secret string, ttl time.Duration, scopes []string) (*Tokens, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("access token ttl must be positive, got %s", ttl)
+	}
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate token secret: %w", err)
+		}
+	} else if len(key) < 32 {
+		return nil, errors.New("token secret must be at least 32 bytes")
+	}
+	return &Tokens{secret: key, ttl: ttl, scopes: scopes}, nil
+}
+
+type tokenClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	TenantID  string `json:"tid,omitempty"`
+	SessionID string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Issue выпускает токен доступа пользователя userID в каталоге арендатора tenantID
+// для сеанса sessionID
+func (t *Tokens) Issue(userID, tenantID, sessionID string) (token string, expiresAt time.Time, err error) {
+	now := time.Now()
+	expiresAt = now.Add(t.ttl)
+	payload, err := json.Marshal(tokenClaims{
+		Issuer:    tokenIssuer,
+		Subject:   userID,
+		TenantID:  tenantID,
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to encode token: %w", err)
+	}
+	signed := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + t.sign(signed), expiresAt, nil
+}
+
+// TTL срок действия выпускаемых токенов
+func (t *Tokens) TTL() time.Duration {
+	return t.ttl
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает субъекта-пользователя.
+// Любая ошибка проверки — ErrInvalidCredentials.
+func (t *Tokens) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, ErrInvalidCredentials
+	}
+	signed := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(t.sign(signed))) {
+		return nil, ErrInvalidCredentials
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if claims.Issuer != tokenIssuer || claims.Subject == "" || claims.SessionID == "" || !time.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{
+		Type:      PrincipalUser,
+		ID:        claims.Subject,
+		Scopes:    t.scopes,
+		TenantID:  claims.TenantID,
+		SessionID: claims.SessionID,
+	}, nil
+}
+
+func (t *Tokens) sign(signed string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// bearerToken извлекает токен из заголовка Authorization: Bearer <token>
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
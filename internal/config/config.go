@@ -5,12 +5,34 @@ import (
 	"os"
 	"rest-api-tutorial/pkg/logging"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	IsDebug    bool
-	Listen     Listen
-	PostgreSQL PostgreSQL
+	IsDebug     bool
+	Listen      Listen
+	HTTP        HTTP
+	PostgreSQL  PostgreSQL
+	Batch       Batch
+	Idempotency Idempotency
+	RateLimit   RateLimit
+	Auth        Auth
+	Tenancy     Tenancy
+	Versioning  Versioning
+	GRPC        GRPC
+	GraphQL     GraphQL
+	Events      Events
+	Webhooks    Webhooks
+	Cache       Cache
+	Redis       Redis
+	Compression Compression
+	Format      Format
+	Mail        Mail
+	Account     Account
+	OIDC        OIDC
+	Recommend   Recommend
+	Stats       Stats
 }
 
 type Listen struct {
@@ -19,6 +41,13 @@ type Listen struct {
 	Port   string
 }
 
+type HTTP struct {
+	ReadTimeout        time.Duration
+	WriteTimeout       time.Duration
+	IdleTimeout        time.Duration
+	WriteTimeoutRoutes string
+}
+
 type PostgreSQL struct {
 	Host     string
 	Port     string
@@ -27,6 +56,149 @@ type PostgreSQL struct {
 	Database string
 }
 
+type Batch struct {
+	MaxOperations int
+	MaxBodyBytes  int64
+}
+
+type Idempotency struct {
+	TTL         time.Duration
+	LockTimeout time.Duration
+	StaleAfter  time.Duration
+}
+
+type RateLimit struct {
+	Enabled bool
+	Store   string
+	Default string
+	Routes  string
+	// IP лимит запросов с одного IP-адреса до аутентификации; пустой выключает его
+	IP string
+}
+
+type Auth struct {
+	AdminKey        string
+	AnonymousScopes string
+	TokenSecret     string
+	TokenTTL        time.Duration
+	SessionTTL      time.Duration
+	// UserScopes области доступа вошедших пользователей. Свои данные они меняют
+	// через /me, поэтому по умолчанию только чтение каталога
+	UserScopes string
+}
+
+type Tenancy struct {
+	BaseDomain string
+	Default    string
+}
+
+type GRPC struct {
+	Enabled bool
+	Port    string
+}
+
+type GraphQL struct {
+	MaxDepth         int
+	MaxComplexity    int
+	PersistedQueries int
+}
+
+type Events struct {
+	Retention time.Duration
+	Heartbeat time.Duration
+}
+
+type Webhooks struct {
+	Enabled      bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+	Retention    time.Duration
+}
+
+type Cache struct {
+	Enabled    bool
+	Backend    string
+	TTL        time.Duration
+	MaxAge     time.Duration
+	MaxEntries int
+}
+
+type Redis struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+type Compression struct {
+	Enabled   bool
+	MinSize   int
+	Encodings string
+}
+
+type Format struct {
+	// MaxBufferBytes предел JSON-ответа, который перекодируется в MessagePack,
+	// CSV или NDJSON целиком; потоковые списки его не используют
+	MaxBufferBytes int64
+}
+
+type Mail struct {
+	// Backend smtp, file или stdout
+	Backend      string
+	From         string
+	Dir          string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTimeout  time.Duration
+}
+
+type Account struct {
+	VerifyTTL       time.Duration
+	ResetTTL        time.Duration
+	VerifyURL       string
+	ResetURL        string
+	DefaultLanguage string
+}
+
+type OIDC struct {
+	Providers  []OIDCProvider
+	ReturnURLs string
+	StateTTL   time.Duration
+}
+
+// OIDCProvider провайдер из OIDC_PROVIDERS, настройки читаются из OIDC_<ИМЯ>_*
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       string
+	TrustEmail   bool
+	JIT          bool
+}
+
+type Recommend struct {
+	Enabled        bool
+	Interval       time.Duration
+	MinCommonUsers int
+	Neighbours     int
+}
+
+type Stats struct {
+	CacheTTL time.Duration
+}
+
+type Versioning struct {
+	DefaultVersion int
+	V1DeprecatedAt string
+	V1Sunset       string
+}
+
 type User struct {
 	Host     string
 	Port     string
@@ -45,7 +217,7 @@ func LoadConfigEnv() *Config {
 		PostgreSQL: PostgreSQL{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
-			Username: getEnv("DB_USER", "postgres"),
+			Username: getEnv("DB_USER", "movies_app"),
 			Password: getEnv("DB_PASSWORD", ""),
 			Database: getEnv("DB_NAME", "db"),
 		},
@@ -54,10 +226,145 @@ func LoadConfigEnv() *Config {
 			BindIP: getEnv("BIND_IP", "0.0.0.0"),
 			Port:   getEnv("APP_PORT", "8080"),
 		},
+		HTTP: HTTP{
+			ReadTimeout:        getEnvAsDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:       getEnvAsDuration("HTTP_WRITE_TIMEOUT", 15*time.Second),
+			IdleTimeout:        getEnvAsDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+			WriteTimeoutRoutes: getEnv("HTTP_WRITE_TIMEOUT_ROUTES", "GET /api/v1/films=5m;GET /api/v1/films/sort=5m;GET /api/v2/films=5m;GET /api/v1/users=5m;GET /api/v2/users=5m;GET /api/v1/films/export=30m;GET /api/v1/users/export=30m"),
+		},
+		Batch: Batch{
+			MaxOperations: getEnvAsInt("BATCH_MAX_OPERATIONS", 1000),
+			MaxBodyBytes:  int64(getEnvAsInt("BATCH_MAX_BODY_BYTES", 10<<20)),
+		},
+		Idempotency: Idempotency{
+			TTL:         getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			LockTimeout: getEnvAsDuration("IDEMPOTENCY_LOCK_TIMEOUT", 10*time.Second),
+			StaleAfter:  getEnvAsDuration("IDEMPOTENCY_STALE_AFTER", time.Minute),
+		},
+		RateLimit: RateLimit{
+			Enabled: getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Store:   getEnv("RATE_LIMIT_STORE", "memory"),
+			Default: getEnv("RATE_LIMIT_DEFAULT", "100/1m"),
+			Routes:  getEnv("RATE_LIMIT_ROUTES", ""),
+			IP:      getEnv("RATE_LIMIT_IP", "300/1m"),
+		},
+		Auth: Auth{
+			AdminKey:        getEnv("AUTH_ADMIN_API_KEY", ""),
+			AnonymousScopes: getEnv("AUTH_ANONYMOUS_SCOPES", "films:read,users:read"),
+			TokenSecret:     getEnv("AUTH_TOKEN_SECRET", ""),
+			TokenTTL:        getEnvAsDuration("AUTH_TOKEN_TTL", 15*time.Minute),
+			SessionTTL:      getEnvAsDuration("AUTH_SESSION_TTL", 30*24*time.Hour),
+			UserScopes:      getEnv("AUTH_USER_SCOPES", "films:read,users:read"),
+		},
+		Tenancy: Tenancy{
+			BaseDomain: getEnv("TENANT_BASE_DOMAIN", ""),
+			Default:    getEnv("TENANT_DEFAULT", "default"),
+		},
+		Versioning: Versioning{
+			DefaultVersion: getEnvAsInt("API_DEFAULT_VERSION", 1),
+			V1DeprecatedAt: getEnv("API_V1_DEPRECATED_AT", "2026-11-01"),
+			V1Sunset:       getEnv("API_V1_SUNSET", "2027-05-01"),
+		},
+		GRPC: GRPC{
+			Enabled: getEnvAsBool("GRPC_ENABLED", true),
+			Port:    getEnv("GRPC_PORT", "9090"),
+		},
+		GraphQL: GraphQL{
+			MaxDepth:         getEnvAsInt("GRAPHQL_MAX_DEPTH", 15),
+			MaxComplexity:    getEnvAsInt("GRAPHQL_MAX_COMPLEXITY", 5000),
+			PersistedQueries: getEnvAsInt("GRAPHQL_PERSISTED_QUERIES", 1000),
+		},
+		Events: Events{
+			Retention: getEnvAsDuration("EVENTS_RETENTION", 24*time.Hour),
+			Heartbeat: getEnvAsDuration("EVENTS_HEARTBEAT", 15*time.Second),
+		},
+		Webhooks: Webhooks{
+			Enabled:      getEnvAsBool("WEBHOOK_DISPATCHER_ENABLED", true),
+			PollInterval: getEnvAsDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+			Timeout:      getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			Backoff:      getEnvAsDuration("WEBHOOK_BACKOFF", 30*time.Second),
+			MaxBackoff:   getEnvAsDuration("WEBHOOK_MAX_BACKOFF", 6*time.Hour),
+			Retention:    getEnvAsDuration("WEBHOOK_RETENTION", 7*24*time.Hour),
+		},
+		Cache: Cache{
+			Enabled:    getEnvAsBool("CACHE_ENABLED", true),
+			Backend:    getEnv("CACHE_BACKEND", "memory"),
+			TTL:        getEnvAsDuration("CACHE_TTL", time.Minute),
+			MaxAge:     getEnvAsDuration("CACHE_MAX_AGE", 10*time.Second),
+			MaxEntries: getEnvAsInt("CACHE_MAX_ENTRIES", 1000),
+		},
+		Redis: Redis{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: getEnv("REDIS_PASSWORD", ""),
+			DB:       getEnvAsInt("REDIS_DB", 0),
+		},
+		Compression: Compression{
+			Enabled:   getEnvAsBool("COMPRESSION_ENABLED", true),
+			MinSize:   getEnvAsInt("COMPRESSION_MIN_SIZE", 1024),
+			Encodings: getEnv("COMPRESSION_ENCODINGS", "br,zstd,gzip"),
+		},
+		Format: Format{
+			MaxBufferBytes: int64(getEnvAsInt("FORMAT_MAX_BUFFER_BYTES", 10<<20)),
+		},
+		Mail: Mail{
+			Backend:      getEnv("MAIL_BACKEND", "stdout"),
+			From:         getEnv("MAIL_FROM", "Movies <no-reply@localhost>"),
+			Dir:          getEnv("MAIL_DIR", "mail"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "1025"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			SMTPTimeout:  getEnvAsDuration("SMTP_TIMEOUT", 10*time.Second),
+		},
+		Account: Account{
+			VerifyTTL:       getEnvAsDuration("ACCOUNT_VERIFY_TTL", 24*time.Hour),
+			ResetTTL:        getEnvAsDuration("ACCOUNT_RESET_TTL", time.Hour),
+			VerifyURL:       getEnv("ACCOUNT_VERIFY_URL", "http://localhost:3000/verify-email?token={token}&tenant={tenant}"),
+			ResetURL:        getEnv("ACCOUNT_RESET_URL", "http://localhost:3000/reset-password?token={token}&tenant={tenant}"),
+			DefaultLanguage: getEnv("ACCOUNT_DEFAULT_LANGUAGE", "ru"),
+		},
+		OIDC: OIDC{
+			Providers:  getOIDCProviders(getEnv("OIDC_PROVIDERS", "")),
+			ReturnURLs: getEnv("OIDC_RETURN_URLS", "http://localhost:3000/"),
+			StateTTL:   getEnvAsDuration("OIDC_STATE_TTL", 10*time.Minute),
+		},
+		Recommend: Recommend{
+			Enabled:        getEnvAsBool("RECOMMEND_BUILDER_ENABLED", true),
+			Interval:       getEnvAsDuration("RECOMMEND_INTERVAL", time.Hour),
+			MinCommonUsers: getEnvAsInt("RECOMMEND_MIN_COMMON_USERS", 2),
+			Neighbours:     getEnvAsInt("RECOMMEND_NEIGHBOURS", 50),
+		},
+		Stats: Stats{
+			CacheTTL: getEnvAsDuration("STATS_CACHE_TTL", 5*time.Minute),
+		},
 		IsDebug: getEnvAsBool("DEBUG", false),
 	}
 }
 
+// getOIDCProviders читает настройки провайдеров из списка имен через запятую
+func getOIDCProviders(names string) []OIDCProvider {
+	providers := make([]OIDCProvider, 0)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       getEnv(prefix+"SCOPES", "openid email profile"),
+			TrustEmail:   getEnvAsBool(prefix+"TRUST_EMAIL", false),
+			JIT:          getEnvAsBool(prefix+"JIT", true),
+		})
+	}
+	return providers
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -72,3 +379,19 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvAsInt(key string, defaultValue int) int {
+	value := getEnv(key, "")
+	if v, err := strconv.Atoi(value); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if v, err := time.ParseDuration(value); err == nil {
+		return v
+	}
+	return defaultValue
+}
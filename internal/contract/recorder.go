@@ -0,0 +1,128 @@
+package contract
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Violation ответ тестового сервера, не соответствующий спецификации
+type Violation struct {
+	Method  string
+	Path    string
+	Status  int
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s -> %d: %s", v.Method, v.Path, v.Status, v.Message)
+}
+
+// Recorder проверяет каждый ответ тестового сервера по спецификации,
+// накапливает нарушения и запоминает, какие описанные ответы были получены
+type Recorder struct {
+	spec *Spec
+
+	mu         sync.Mutex
+	violations []Violation
+	covered    map[string]bool
+}
+
+func NewRecorder(spec *Spec) *Recorder {
+	return &Recorder{
+		spec:    spec,
+		covered: make(map[string]bool),
+	}
+}
+
+// Middleware подключается к роутеру первым, чтобы видеть и ответы других middleware
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &bodyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// Маршрут не найден — это не ответ операции из спецификации
+			return
+		}
+		path, ok := r.spec.trimBasePath(route)
+		if !ok {
+			return
+		}
+		path = SwaggerPath(path)
+		method := c.Request.Method
+		status := writer.Status()
+
+		err := r.spec.ValidateResponse(method, path, status, writer.Header().Get("Content-Type"), writer.body.Bytes())
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.covered[coverageKey(method, path, status)] = true
+		if err != nil {
+			r.violations = append(r.violations, Violation{
+				Method:  method,
+				Path:    path,
+				Status:  status,
+				Message: err.Error(),
+			})
+		}
+	}
+}
+
+// Violations нарушения в порядке получения ответов
+func (r *Recorder) Violations() []Violation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Violation(nil), r.violations...)
+}
+
+// Uncovered описанные в спецификации ответы с успешным статусом, которые ни разу
+// не были получены, в виде "GET /users/{uuid} 200"
+func (r *Recorder) Uncovered() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var uncovered []string
+	for path, ops := range r.spec.Paths {
+		for method, op := range ops {
+			method = strings.ToUpper(method)
+			for code := range op.Responses {
+				var status int
+				if _, err := fmt.Sscan(code, &status); err != nil || status >= http.StatusBadRequest {
+					continue
+				}
+				if !r.covered[coverageKey(method, path, status)] {
+					uncovered = append(uncovered, coverageKey(method, path, status))
+				}
+			}
+		}
+	}
+	sort.Strings(uncovered)
+	return uncovered
+}
+
+func coverageKey(method, path string, status int) string {
+	return fmt.Sprintf("%s %s %d", method, path, status)
+}
+
+// bodyWriter копирует тело ответа для проверки, не задерживая его отправку
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
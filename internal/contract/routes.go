@@ -0,0 +1,84 @@
+package contract
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"sort"
+	"strings"
+)
+
+// Виды расхождений между роутером и спецификацией
+const (
+	// Undocumented маршрут зарегистрирован в роутере, но отсутствует в спецификации
+	Undocumented = "undocumented"
+	// Phantom маршрут описан в спецификации, но не зарегистрирован в роутере
+	Phantom = "phantom"
+)
+
+// Mismatch расхождение между роутером и спецификацией
+type Mismatch struct {
+	Kind   string
+	Method string
+	Path   string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s route %s %s", m.Kind, m.Method, m.Path)
+}
+
+// CheckRoutes сравнивает маршруты роутера под BasePath с путями спецификации.
+// Параметры gin (:uuid, *any) сравниваются с параметрами Swagger ({uuid}) по имени.
+func (s *Spec) CheckRoutes(routes gin.RoutesInfo) []Mismatch {
+	registered := make(map[string]bool)
+	var mismatches []Mismatch
+
+	for _, route := range routes {
+		path, ok := s.trimBasePath(route.Path)
+		if !ok {
+			continue
+		}
+		path = SwaggerPath(path)
+		registered[route.Method+" "+path] = true
+		if _, ok := s.Operation(route.Method, path); !ok {
+			mismatches = append(mismatches, Mismatch{Kind: Undocumented, Method: route.Method, Path: path})
+		}
+	}
+
+	for path, ops := range s.Paths {
+		for method := range ops {
+			method = strings.ToUpper(method)
+			if !registered[method+" "+path] {
+				mismatches = append(mismatches, Mismatch{Kind: Phantom, Method: method, Path: path})
+			}
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].Path != mismatches[j].Path {
+			return mismatches[i].Path < mismatches[j].Path
+		}
+		return mismatches[i].Method < mismatches[j].Method
+	})
+	return mismatches
+}
+
+// SwaggerPath переводит шаблон пути gin в шаблон Swagger: /users/:uuid -> /users/{uuid}
+func SwaggerPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s *Spec) trimBasePath(path string) (string, bool) {
+	if s.BasePath == "" {
+		return path, true
+	}
+	if path != s.BasePath && !strings.HasPrefix(path, s.BasePath+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(path, s.BasePath), true
+}
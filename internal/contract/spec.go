@@ -0,0 +1,137 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// methods HTTP-методы, которые могут быть ключами path item в Swagger 2.0
+var methods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true,
+}
+
+// Spec разобранная спецификация Swagger 2.0 в том объеме, который нужен для проверок
+type Spec struct {
+	BasePath    string
+	Paths       map[string]map[string]*Operation
+	Definitions map[string]*Schema
+}
+
+// Operation описание операции: поддерживаемые типы ответа и ответы по статусам
+type Operation struct {
+	Produces  []string             `json:"produces"`
+	Responses map[string]*Response `json:"responses"`
+}
+
+// Response описание ответа с необязательной схемой тела
+type Response struct {
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema подмножество JSON Schema, которое генерирует swag
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Items                *Schema            `json:"items"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *Schema            `json:"-"`
+	Required             []string           `json:"required"`
+	Enum                 []interface{}      `json:"enum"`
+	AllOf                []*Schema          `json:"allOf"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Nullable             bool               `json:"x-nullable"`
+}
+
+// UnmarshalJSON разбирает additionalProperties, который может быть схемой или
+// булевым значением; true равносильно пустой схеме, то есть любому значению
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	var raw struct {
+		*plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	raw.plain = (*plain)(s)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch string(raw.AdditionalProperties) {
+	case "", "false", "null":
+	case "true":
+		s.AdditionalProperties = &Schema{}
+	default:
+		s.AdditionalProperties = &Schema{}
+		if err := json.Unmarshal(raw.AdditionalProperties, s.AdditionalProperties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSpec читает спецификацию из файла, например docs/swagger.json
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	return ParseSpec(data)
+}
+
+func ParseSpec(data []byte) (*Spec, error) {
+	var raw struct {
+		Swagger     string                                `json:"swagger"`
+		BasePath    string                                `json:"basePath"`
+		Paths       map[string]map[string]json.RawMessage `json:"paths"`
+		Definitions map[string]*Schema                    `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	if raw.Swagger != "2.0" {
+		return nil, fmt.Errorf("unsupported spec version %q, expected swagger 2.0", raw.Swagger)
+	}
+
+	spec := &Spec{
+		BasePath:    strings.TrimSuffix(raw.BasePath, "/"),
+		Paths:       make(map[string]map[string]*Operation, len(raw.Paths)),
+		Definitions: raw.Definitions,
+	}
+	for path, item := range raw.Paths {
+		ops := make(map[string]*Operation)
+		for method, body := range item {
+			if !methods[method] {
+				continue
+			}
+			var op Operation
+			if err := json.Unmarshal(body, &op); err != nil {
+				return nil, fmt.Errorf("failed to parse %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			ops[method] = &op
+		}
+		spec.Paths[path] = ops
+	}
+	return spec, nil
+}
+
+// Operation находит операцию по методу и шаблону пути без basePath, например /users/{uuid}
+func (s *Spec) Operation(method, path string) (*Operation, bool) {
+	op, ok := s.Paths[path][strings.ToLower(method)]
+	return op, ok
+}
+
+// resolve возвращает определение для ссылки вида #/definitions/Name
+func (s *Spec) resolve(ref string) (*Schema, error) {
+	name := strings.TrimPrefix(ref, "#/definitions/")
+	schema, ok := s.Definitions[name]
+	if !ok || name == ref {
+		return nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return schema, nil
+}
@@ -0,0 +1,194 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateResponse проверяет ответ операции method path (шаблон без basePath)
+// по спецификации: статус должен быть описан, а JSON-тело — соответствовать схеме.
+// Тела в других форматах (CSV, NDJSON) не проверяются.
+func (s *Spec) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op, ok := s.Operation(method, path)
+	if !ok {
+		return fmt.Errorf("operation is not documented")
+	}
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if resp, ok = op.Responses["default"]; !ok {
+			return fmt.Errorf("status %d is not documented", status)
+		}
+	}
+
+	if resp.Schema == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("response is not valid JSON: %w", err)
+	}
+
+	// v1 отдает пустой список как null: nil-срез Go, который Swagger 2.0 не описывает
+	var errs []string
+	s.validate(resp.Schema, value, "$", resp.Schema.Type == "array", &errs)
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validate проверяет value по схеме и дописывает ошибки в errs. Swagger 2.0 не умеет
+// описывать null, а Go кодирует nil-указатели и срезы как null, поэтому null
+// допускается для необязательных свойств и схем с x-nullable.
+func (s *Spec) validate(schema *Schema, value interface{}, at string, nullable bool, errs *[]string) {
+	if schema.Ref != "" {
+		resolved, err := s.resolve(schema.Ref)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s: %v", at, err))
+			return
+		}
+		schema = resolved
+	}
+	for _, sub := range schema.AllOf {
+		s.validate(sub, value, at, nullable, errs)
+	}
+
+	if value == nil {
+		if !nullable && !schema.Nullable && (schema.Type != "" || len(schema.AllOf) > 0) {
+			*errs = append(*errs, fmt.Sprintf("%s: must not be null", at))
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "":
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: expected object, got %s", at, kind(value)))
+			return
+		}
+		s.validateObject(schema, object, at, errs)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: expected array, got %s", at, kind(value)))
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				s.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i), false, errs)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: expected string, got %s", at, kind(value)))
+			return
+		}
+		length := utf8.RuneCountInString(str)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			*errs = append(*errs, fmt.Sprintf("%s: shorter than %d", at, *schema.MinLength))
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: longer than %d", at, *schema.MaxLength))
+		}
+	case "number", "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", at, schema.Type, kind(value)))
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				*errs = append(*errs, fmt.Sprintf("%s: expected integer, got %s", at, number))
+				return
+			}
+		}
+		f, _ := number.Float64()
+		if schema.Minimum != nil && f < *schema.Minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is less than %v", at, number, *schema.Minimum))
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is greater than %v", at, number, *schema.Maximum))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: expected boolean, got %s", at, kind(value)))
+			return
+		}
+	default:
+		*errs = append(*errs, fmt.Sprintf("%s: unsupported schema type %q", at, schema.Type))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		*errs = append(*errs, fmt.Sprintf("%s: %v is not one of %v", at, value, schema.Enum))
+	}
+}
+
+// validateObject проверяет обязательные и описанные свойства. Неописанные свойства
+// допускаются, если схема не задает additionalProperties.
+func (s *Spec) validateObject(schema *Schema, object map[string]interface{}, at string, errs *[]string) {
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", at, name))
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if prop, ok := schema.Properties[name]; ok {
+			s.validate(prop, object[name], at+"."+name, !required[name], errs)
+			continue
+		}
+		if schema.AdditionalProperties != nil {
+			s.validate(schema.AdditionalProperties, object[name], at+"."+name, false, errs)
+		}
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, v := range enum {
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func kind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
@@ -0,0 +1,50 @@
+package events
+
+import "sync"
+
+// subscriberBuffer сколько событий может накопиться у медленного подписчика.
+// При переполнении подписка закрывается, и клиент переподключается с Last-Event-ID.
+const subscriberBuffer = 64
+
+// Broker рассылает события всем подписчикам внутри одного процесса
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe возвращает канал событий. Канал закрывается после Unsubscribe
+// или если подписчик не успевает читать события.
+func (b *Broker) Subscribe() chan Event {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *Broker) Unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Publish отправляет событие подписчикам, не блокируясь на медленных
+func (b *Broker) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
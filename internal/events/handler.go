@@ -0,0 +1,219 @@
+package events
+
+import (
+	"fmt"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// replayBatch сколько событий читается из таблицы за один запрос при возобновлении
+	replayBatch = 500
+	// retryMillis через сколько браузерный EventSource переподключается после разрыва
+	retryMillis = 3000
+)
+
+// resourceScopes область доступа на чтение для событий каждого ресурса
+var resourceScopes = map[string]string{
+	ResourceFilm: auth.ScopeFilmsRead,
+	ResourceUser: auth.ScopeUsersRead,
+}
+
+type Handler struct {
+	logger    *logging.Logger
+	storage   *Storage
+	broker    *Broker
+	heartbeat time.Duration
+}
+
+func NewHandler(storage *Storage, broker *Broker, heartbeat time.Duration, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:    logger,
+		storage:   storage,
+		broker:    broker,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream godoc
+// @Summary Stream catalog changes
+// @Description Server-Sent Events stream of film and user create, update and delete events. Each event has an id, the event type as the SSE event name and the Event object as data. To resume after a disconnect send the last received id in the Last-Event-ID header (browsers do this automatically) or the last_event_id query parameter; events are kept for EVENTS_RETENTION. Without either only new events are sent. Only events of the request tenant are sent, and events of resources the caller has no read scope for are skipped. A comment line is sent every EVENTS_HEARTBEAT to keep the connection open
+// @Tags events
+// @Produce text/event-stream
+// @Param types query string false "Comma separated event types (film.created) or resources (film) to receive; all readable events by default"
+// @Param Last-Event-ID header integer false "Resume after this event id"
+// @Param last_event_id query integer false "Resume after this event id, for clients that cannot set headers"
+// @Success 200 {object} events.Event "Event stream"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid types or event id"
+// @Failure 403 {object} envelope.ErrorResponse "Missing read scope for the requested events"
+// @Security ApiKeyAuth
+// @Router /events [get]
+func (h *Handler) Stream(c *gin.Context) {
+	filter, err := parseFilter(c.Query("types"))
+	if err != nil {
+		envelope.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.authorize(c, filter) {
+		envelope.Error(c, http.StatusForbidden, "Insufficient scope")
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			envelope.Error(c, http.StatusBadRequest, "Invalid event id")
+			return
+		}
+	}
+
+	// Подписка до чтения таблицы: события, пришедшие во время возобновления,
+	// не теряются, а повторы отбрасываются по id
+	sub := h.broker.Subscribe()
+	defer h.broker.Unsubscribe(sub)
+
+	ctx := c.Request.Context()
+	tenantID, _ := tenant.FromContext(ctx)
+
+	// Поток живет дольше WriteTimeout сервера
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warnf("Failed to disable write deadline for event stream: %v", err)
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	// Одно поле retry без data не создает события на клиенте
+	fmt.Fprintf(c.Writer, "retry:%d\n\n", retryMillis)
+	c.Writer.Flush()
+
+	replayedID := lastID
+	if lastID > 0 {
+		for {
+			list, err := h.storage.FindSince(ctx, tenantID, replayedID, replayBatch)
+			if err != nil {
+				h.logger.Errorf("Failed to replay catalog events: %v", err)
+				return
+			}
+			for _, event := range list {
+				replayedID = event.ID
+				if filter.match(event) {
+					h.send(c, event)
+				}
+			}
+			if len(list) < replayBatch {
+				break
+			}
+		}
+		c.Writer.Flush()
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub:
+			if !ok {
+				// Клиент не успевал читать; он переподключится с Last-Event-ID
+				return
+			}
+			if event.ID <= replayedID || event.TenantID != tenantID || !filter.match(event) {
+				continue
+			}
+			h.send(c, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func (h *Handler) send(c *gin.Context, event Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(event.ID, 10),
+		Event: event.Type,
+		Data:  event,
+	})
+}
+
+// authorize сужает фильтр до ресурсов, доступных субъекту. Явно запрошенный
+// недоступный ресурс или отсутствие доступа ко всем ресурсам — отказ.
+func (h *Handler) authorize(c *gin.Context, filter *filter) bool {
+	principal := auth.PrincipalFrom(c)
+	allowed := make(map[string]bool, len(resourceScopes))
+	for resource, scope := range resourceScopes {
+		allowed[resource] = principal != nil && principal.HasScope(scope)
+	}
+
+	if filter.all() {
+		for resource, ok := range allowed {
+			if ok {
+				filter.resources[resource] = true
+			}
+		}
+		return !filter.all()
+	}
+
+	for resource := range filter.resources {
+		if !allowed[resource] {
+			return false
+		}
+	}
+	for eventType := range filter.types {
+		if !allowed[Event{Type: eventType}.Resource()] {
+			return false
+		}
+	}
+	return true
+}
+
+// filter отбирает события по ресурсу (film) или точному типу (film.updated)
+type filter struct {
+	resources map[string]bool
+	types     map[string]bool
+}
+
+func parseFilter(s string) (*filter, error) {
+	f := &filter{resources: make(map[string]bool), types: make(map[string]bool)}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case !ValidSelector(item):
+			return nil, fmt.Errorf("unknown event type %q, expected one of: film, user, %s", item, strings.Join(Types, ", "))
+		case item == ResourceFilm || item == ResourceUser:
+			f.resources[item] = true
+		default:
+			f.types[item] = true
+		}
+	}
+	return f, nil
+}
+
+func (f *filter) all() bool {
+	return len(f.resources) == 0 && len(f.types) == 0
+}
+
+func (f *filter) match(event Event) bool {
+	return f.resources[event.Resource()] || f.types[event.Type]
+}
@@ -0,0 +1,158 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+	"time"
+)
+
+// Channel канал LISTEN/NOTIFY, в который триггеры пишут id новых событий
+const Channel = "catalog_events"
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+	pruneInterval     = time.Hour
+	catchUpBatch      = 500
+)
+
+// Listener держит отдельное соединение с LISTEN catalog_events и передает
+// события в Broker. Каждая реплика слушает канал сама, поэтому клиенты видят
+// изменения, сделанные через любую реплику.
+type Listener struct {
+	pool      *pgxpool.Pool
+	storage   *Storage
+	broker    *Broker
+	retention time.Duration
+	logger    *logging.Logger
+
+	// lastID последнее опубликованное событие; после переподключения
+	// пропущенные за время разрыва события дочитываются из таблицы
+	lastID int64
+}
+
+func NewListener(pool *pgxpool.Pool, storage *Storage, broker *Broker, retention time.Duration, logger *logging.Logger) *Listener {
+	return &Listener{
+		pool:      pool,
+		storage:   storage,
+		broker:    broker,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Run слушает канал до отмены ctx, переподключаясь с экспоненциальной задержкой
+func (l *Listener) Run(ctx context.Context) {
+	go l.prune(ctx)
+
+	delay := minReconnectDelay
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = minReconnectDelay
+		}
+		l.logger.Warnf("Catalog events listener stopped, reconnecting in %s: %v", delay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// listen выполняет LISTEN и публикует уведомления, пока соединение живо.
+// connected сообщает, удалось ли подписаться на канал.
+func (l *Listener) listen(ctx context.Context) (connected bool, err error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// После LISTEN соединение нельзя возвращать в пул: закрываем его
+	defer conn.Release()
+	defer conn.Conn().Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return false, fmt.Errorf("failed to listen %s: %w", Channel, err)
+	}
+	l.logger.Infof("Listening for catalog events on channel %s", Channel)
+
+	if err := l.catchUp(ctx); err != nil {
+		return true, err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			l.logger.Warnf("Invalid catalog event notification %q", notification.Payload)
+			continue
+		}
+		event, err := l.storage.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return true, err
+		}
+		l.publish(*event)
+	}
+}
+
+// catchUp публикует события, записанные, пока слушатель был отключен
+func (l *Listener) catchUp(ctx context.Context) error {
+	if l.lastID == 0 {
+		return nil
+	}
+	for {
+		list, err := l.storage.FindSince(ctx, "", l.lastID, catchUpBatch)
+		if err != nil {
+			return err
+		}
+		for _, event := range list {
+			l.publish(event)
+		}
+		if len(list) < catchUpBatch {
+			return nil
+		}
+	}
+}
+
+func (l *Listener) publish(event Event) {
+	l.lastID = max(l.lastID, event.ID)
+	l.broker.Publish(event)
+}
+
+// prune раз в час удаляет события старше срока хранения
+func (l *Listener) prune(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := l.storage.Prune(ctx, l.retention)
+			if err != nil {
+				l.logger.Warnf("Failed to prune catalog events: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				l.logger.Infof("Pruned %d catalog events older than %s", deleted, l.retention)
+			}
+		}
+	}
+}
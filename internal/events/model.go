@@ -0,0 +1,51 @@
+package events
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Ресурсы, изменения которых попадают в поток
+const (
+	ResourceFilm = "film"
+	ResourceUser = "user"
+)
+
+// Types все типы событий в порядке документации
+var Types = []string{
+	"film.created", "film.updated", "film.deleted",
+	"user.created", "user.updated", "user.deleted",
+}
+
+// Event изменение фильма или пользователя
+// @description Событие потока /api/events; data — строка таблицы после изменения, для *.deleted отсутствует
+type Event struct {
+	// Последовательный номер события, он же id в SSE и значение Last-Event-ID
+	ID int64 `json:"id"`
+
+	// Тип события: film.created, film.updated, film.deleted, user.created, user.updated, user.deleted
+	Type string `json:"type"`
+
+	// @format uuid
+	ResourceID string `json:"resource_id"`
+
+	Data json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// TenantID арендатор измененной строки; событие видно только его клиентам
+	TenantID string `json:"-"`
+}
+
+// Resource возвращает ресурс события (film или user)
+func (e Event) Resource() string {
+	resource, _, _ := strings.Cut(e.Type, ".")
+	return resource
+}
+
+// ValidSelector проверяет элемент фильтра событий: ресурс (film) или точный тип (film.updated)
+func ValidSelector(s string) bool {
+	return s == ResourceFilm || s == ResourceUser || slices.Contains(Types, s)
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+// ErrNotFound событие не существует или уже удалено по сроку хранения
+var ErrNotFound = errors.New("event not found")
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+func (s *Storage) FindByID(ctx context.Context, id int64) (*Event, error) {
+	q := `
+        SELECT id, type, resource_id, data, created_at, COALESCE(tenant_id::text, '')
+        FROM catalog_events
+        WHERE id = $1
+    `
+	var event Event
+	err := s.client.QueryRow(ctx, q, id).Scan(
+		&event.ID,
+		&event.Type,
+		&event.ResourceID,
+		&event.Data,
+		&event.CreatedAt,
+		&event.TenantID,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	return &event, nil
+}
+
+// FindSince возвращает не больше limit событий арендатора tenantID с номером больше
+// afterID по порядку. Пустой tenantID означает события всех арендаторов.
+func (s *Storage) FindSince(ctx context.Context, tenantID string, afterID int64, limit int) ([]Event, error) {
+	q := `
+        SELECT id, type, resource_id, data, created_at, COALESCE(tenant_id::text, '')
+        FROM catalog_events
+        WHERE (NULLIF($1::text, '') IS NULL OR tenant_id = NULLIF($1::text, '')::uuid) AND id > $2
+        ORDER BY id
+        LIMIT $3
+    `
+	rows, err := s.client.Query(ctx, q, tenantID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]Event, 0)
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.ResourceID,
+			&event.Data,
+			&event.CreatedAt,
+			&event.TenantID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		list = append(list, event)
+	}
+	return list, rows.Err()
+}
+
+// Prune удаляет события старше retention
+func (s *Storage) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	q := `DELETE FROM catalog_events WHERE created_at < NOW() - make_interval(secs => $1)`
+	tag, err := s.client.Exec(ctx, q, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
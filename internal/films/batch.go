@@ -0,0 +1,67 @@
+package films
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"rest-api-tutorial/pkg/batch"
+	"time"
+)
+
+// BatchItem операция пакета, уже разобранная и проверенная
+type BatchItem struct {
+	Op     batch.Op
+	ID     string
+	Film   Film
+	Update UpdateFilm
+}
+
+// decodeBatchOperation разбирает операцию пакета по тем же правилам,
+// что и одиночные CreateFilm, PartiallyUpdateFilm и DeleteFilm
+func decodeBatchOperation(op batch.Operation) (BatchItem, error) {
+	item := BatchItem{Op: op.Op, ID: op.ID}
+
+	switch op.Op {
+	case batch.OpCreate:
+		if len(op.Data) == 0 {
+			return item, fmt.Errorf("data is required for create")
+		}
+		if err := json.Unmarshal(op.Data, &item.Film); err != nil {
+			return item, fmt.Errorf("invalid film: %v", err)
+		}
+		if err := binding.Validator.ValidateStruct(&item.Film); err != nil {
+			return item, err
+		}
+
+		id, err := uuid.NewV4()
+		if err != nil {
+			return item, fmt.Errorf("failed to generate ID")
+		}
+		item.Film.ID = id.String()
+		item.Film.CreatedAt = time.Now()
+		item.Film.UpdatedAt = item.Film.CreatedAt
+		item.ID = item.Film.ID
+	case batch.OpUpdate:
+		if _, err := uuid.FromString(op.ID); err != nil {
+			return item, fmt.Errorf("invalid id %q", op.ID)
+		}
+		if len(op.Data) == 0 {
+			return item, fmt.Errorf("data is required for update")
+		}
+		if err := json.Unmarshal(op.Data, &item.Update); err != nil {
+			return item, fmt.Errorf("invalid film update: %v", err)
+		}
+		if err := binding.Validator.ValidateStruct(&item.Update); err != nil {
+			return item, err
+		}
+	case batch.OpDelete:
+		if _, err := uuid.FromString(op.ID); err != nil {
+			return item, fmt.Errorf("invalid id %q", op.ID)
+		}
+	default:
+		return item, fmt.Errorf("unknown op %q, expected create, update or delete", op.Op)
+	}
+
+	return item, nil
+}
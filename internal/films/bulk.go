@@ -0,0 +1,79 @@
+package films
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"rest-api-tutorial/pkg/bulk"
+	"strconv"
+)
+
+// importColumns колонки временной таблицы films_import в порядке значений CopySource
+var importColumns = []string{"row_num", "film_id", "title", "description", "rating", "release_date"}
+
+// decodeFilmRow возвращает значения колонок importColumns для COPY
+func decodeFilmRow(row bulk.Row) ([]interface{}, error) {
+	film, err := DecodeImportRow(row)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{film.ID, film.Title, film.Description, film.Rating, film.ReleaseDate}, nil
+}
+
+// DecodeImportRow разбирает строку импорта в Film и проверяет ее теми же правилами, что и CreateFilm
+func DecodeImportRow(row bulk.Row) (Film, error) {
+	var film Film
+	if row.JSON != nil {
+		if err := json.Unmarshal(row.JSON, &film); err != nil {
+			return Film{}, fmt.Errorf("invalid film: %v", err)
+		}
+	} else {
+		var err error
+		if film, err = filmFromFields(row.Fields); err != nil {
+			return Film{}, err
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(&film); err != nil {
+		return Film{}, err
+	}
+
+	if film.ID == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return Film{}, fmt.Errorf("failed to generate ID: %w", err)
+		}
+		film.ID = id.String()
+	} else if _, err := uuid.FromString(film.ID); err != nil {
+		return Film{}, fmt.Errorf("invalid film_id %q", film.ID)
+	}
+
+	return film, nil
+}
+
+func filmFromFields(fields map[string]string) (Film, error) {
+	film := Film{
+		ID:          fields["film_id"],
+		Title:       fields["title"],
+		Description: fields["description"],
+	}
+
+	if v := fields["rating"]; v != "" {
+		rating, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return film, fmt.Errorf("invalid rating %q", v)
+		}
+		film.Rating = rating
+	}
+
+	if v := fields["release_date"]; v != "" {
+		releaseDate, err := bulk.ParseTime(v)
+		if err != nil {
+			return film, err
+		}
+		film.ReleaseDate = releaseDate
+	}
+
+	return film, nil
+}
@@ -1,36 +1,49 @@
 package films
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/gofrs/uuid"
 	"net/http"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
 	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/patch"
+	"rest-api-tutorial/pkg/stream"
+	"strconv"
 	"time"
 )
 
 type Handler struct {
-	logger  *logging.Logger
-	storage *Storage
+	logger      *logging.Logger
+	storage     FilmRepository
+	batchLimits batch.Limits
 }
 
-func NewHandler(storage *Storage, logger *logging.Logger) *Handler {
+func NewHandler(storage FilmRepository, batchLimits batch.Limits, logger *logging.Logger) *Handler {
 	return &Handler{
-		logger:  logger,
-		storage: storage,
+		logger:      logger,
+		storage:     storage,
+		batchLimits: batchLimits,
 	}
 }
 
 // CreateFilm godoc
 // @Summary Create a new film
 // @Description Create a new film with the provided details
-// @Tags films
+// @Tags films v1
 // @Accept json
 // @Produce json
 // @Param film body Film true "Film data to create"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
 // @Success 201 {object} Film "Successfully created film"
 // @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 409 {object} map[string]string "Film with this title already exists, or request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} map[string]string "Request body with an Idempotency-Key exceeds the configured limit"
+// @Failure 422 {object} map[string]string "Idempotency-Key reused with a different request"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /films [post]
+// @Router /v1/films [post]
 func (h *Handler) CreateFilm(c *gin.Context) {
 	var newFilm Film
 	if err := c.ShouldBindJSON(&newFilm); err != nil {
@@ -48,6 +61,10 @@ func (h *Handler) CreateFilm(c *gin.Context) {
 	newFilm.UpdatedAt = newFilm.CreatedAt
 
 	if err := h.storage.Create(c.Request.Context(), newFilm); err != nil {
+		if errors.Is(err, ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Film with this title already exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create film card"})
 		return
 	}
@@ -56,14 +73,20 @@ func (h *Handler) CreateFilm(c *gin.Context) {
 
 // GetList godoc
 // @Summary Get all films
-// @Description Retrieve a list of all films with optional sorting
-// @Tags films
-// @Produce json
-// @Param sort_by query string false "Field to sort by (title, rating, release_date)"
-// @Success 200 {array} Film "List of films"
+// @Description Retrieve a list of all films. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row
+// @Tags films v1
+// @Produce json,application/x-ndjson,text/csv
+// @Param stream query bool false "Stream rows as they are read from the database; a failure mid-stream leaves the JSON array unterminated"
+// @Success 200 {array} Film "List of films, null if there are none"
+// @Header 200 {string} Cache-Control "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+// @Header 200 {string} X-Cache "HIT when served from the response cache, MISS otherwise"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /films [get]
+// @Router /v1/films [get]
 func (h *Handler) GetList(c *gin.Context) {
+	if stream.Requested(c) {
+		h.streamList(c, h.storage.StreamAll)
+		return
+	}
 	films, err := h.storage.FindAll(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch films"})
@@ -74,16 +97,20 @@ func (h *Handler) GetList(c *gin.Context) {
 
 // GetListSort godoc
 // @Summary Get sorted films list
-// @Description Retrieve a list of films sorted by specified criteria
-// @Tags films
-// @Produce json
-// @Param sort_by query string false "Field to sort by (title, rating, release_date)"
-// @Param order query string false "Sort order (asc, desc)" default(asc)
-// @Success 200 {array} Film "Sorted list of films"
-// @Failure 400 {object} map[string]string "Invalid sort parameters"
+// @Description Retrieve a list of films sorted by title, then rating, then release date. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row
+// @Tags films v1
+// @Produce json,application/x-ndjson,text/csv
+// @Param stream query bool false "Stream rows as they are read from the database; a failure mid-stream leaves the JSON array unterminated"
+// @Success 200 {array} Film "Sorted list of films, null if there are none"
+// @Header 200 {string} Cache-Control "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+// @Header 200 {string} X-Cache "HIT when served from the response cache, MISS otherwise"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /films/sorted [get]
+// @Router /v1/films/sort [get]
 func (h *Handler) GetListSort(c *gin.Context) {
+	if stream.Requested(c) {
+		h.streamList(c, h.storage.StreamAllSort)
+		return
+	}
 	films, err := h.storage.FindAllSort(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch films"})
@@ -92,16 +119,30 @@ func (h *Handler) GetListSort(c *gin.Context) {
 	c.JSON(http.StatusOK, films)
 }
 
+// streamList пишет фильмы из streamAll в ответ по мере чтения
+func (h *Handler) streamList(c *gin.Context, streamAll func(context.Context, func(Film) error) error) {
+	w := stream.New(c, stream.Array)
+	err := streamAll(c.Request.Context(), func(film Film) error {
+		return w.Write(film)
+	})
+	if err != nil && c.Request.Context().Err() == nil {
+		h.logger.Errorf("Failed to stream films: %v", err)
+	}
+	if err := w.Finish(err); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch films"})
+	}
+}
+
 // GetUserFilm godoc
 // @Summary Get films by user ID
-// @Description Retrieve all films associated with specific user
-// @Tags films
+// @Description Retrieve all films associated with specific user.
+// @Description If the user has no films, the response is {"message": "No films found for this user"} instead of an array; GET /v2/users/{uuid}/films returns an empty list.
+// @Tags films v1
 // @Produce json
 // @Param uuid path string true "User ID (UUID)"
 // @Success 200 {array} Film "List of user's films"
-// @Success 200 {object} map[string]string "No films found for user"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /films/user/{uuid} [get]
+// @Router /v1/films/{uuid} [get]
 func (h *Handler) GetUserFilm(c *gin.Context) {
 	param := c.Param("uuid")
 	user, err := h.storage.FindOne(c.Request.Context(), param)
@@ -119,19 +160,29 @@ func (h *Handler) GetUserFilm(c *gin.Context) {
 
 // PartiallyUpdateFilm godoc
 // @Summary Partially update film
-// @Description Update specific fields of a film
-// @Tags films
-// @Accept json
+// @Description Update specific fields of a film.
+// @Description With Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902)
+// @Description applied atomically to the current film, e.g. [{"op":"test","path":"/rating","value":5.4},{"op":"replace","path":"/rating","value":6}]
+// @Tags films v1
+// @Accept json,application/json-patch+json
 // @Produce json
 // @Param uuid path string true "Film ID (UUID)"
 // @Param updates body UpdateFilm true "Fields to update"
 // @Success 204 "Film updated successfully"
 // @Failure 400 {object} map[string]string "Invalid request body"
 // @Failure 404 {object} map[string]string "Film not found"
+// @Failure 409 {object} map[string]string "Film with this title already exists, or JSON Patch test operation failed"
+// @Failure 422 {object} map[string]string "JSON Patch cannot be applied or result is invalid"
+// @Failure 413 {object} map[string]string "JSON Patch document exceeds 1 MiB"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /films/{uuid} [patch]
+// @Router /v1/films/{uuid} [patch]
 func (h *Handler) PartiallyUpdateFilm(c *gin.Context) {
 	param := c.Param("uuid")
+	if c.ContentType() == patch.MIMEJSONPatch {
+		h.jsonPatchFilm(c, param)
+		return
+	}
+
 	var input UpdateFilm
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
@@ -139,27 +190,219 @@ func (h *Handler) PartiallyUpdateFilm(c *gin.Context) {
 	}
 
 	if err := h.storage.PartialUpdate(c.Request.Context(), param, input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update film card"})
+		switch {
+		case errors.Is(err, ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+		case errors.Is(err, ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": "Film with this title already exists"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update film card"})
+		}
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+func (h *Handler) jsonPatchFilm(c *gin.Context, id string) {
+	body, err := patch.Read(c.Writer, c.Request)
+	if err != nil {
+		if errors.Is(err, patch.ErrTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ops, err := patch.Decode(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON Patch document"})
+		return
+	}
+
+	err = h.storage.Patch(c.Request.Context(), id, func(f *Film) error {
+		createdAt := f.CreatedAt
+		if err := patch.Apply(ops, f); err != nil {
+			return err
+		}
+		// Идентификатор и служебные даты патчем не меняются
+		f.ID = id
+		f.CreatedAt = createdAt
+		f.UpdatedAt = time.Now()
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+	case errors.Is(err, patch.ErrTestFailed), errors.Is(err, ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, patch.ErrValidation):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		h.logger.Errorf("Failed to apply JSON Patch to film %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update film card"})
+	}
+}
+
 // DeleteFilm godoc
 // @Summary Delete a film
 // @Description Remove a film by its UUID
-// @Tags films
+// @Tags films v1
 // @Produce json
 // @Param uuid path string true "Film ID (UUID)"
 // @Success 204 "Film deleted successfully"
 // @Failure 404 {object} map[string]string "Film not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /films/{uuid} [delete]
+// @Router /v1/films/{uuid} [delete]
 func (h *Handler) DeleteFilm(c *gin.Context) {
 	par := c.Param("uuid")
 	if err := h.storage.Delete(c.Request.Context(), par); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Film not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete film"})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ImportFilms godoc
+// @Summary Import films
+// @Description Bulk load films from CSV (with header row), a JSON array or NDJSON.
+// @Description Rows are streamed into PostgreSQL with COPY; each row is validated like CreateFilm and rejected rows are reported with their number.
+// @Description In upsert mode films with an existing title are updated, otherwise they are reported as conflicts.
+// @Tags films v1
+// @Accept json,text/csv,application/x-ndjson
+// @Produce json
+// @Param format query string false "Input format (csv, json, ndjson); defaults to the Content-Type"
+// @Param mode query string false "Import mode (insert, upsert)" default(insert)
+// @Param dry_run query bool false "Validate and report without saving" default(false)
+// @Success 200 {object} bulk.Result "Import report"
+// @Failure 400 {object} map[string]string "Invalid parameters or malformed input"
+// @Failure 409 {object} map[string]string "Film id conflicts with another film"
+// @Failure 422 {object} bulk.Result "No rows could be imported"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/films/import [post]
+func (h *Handler) ImportFilms(c *gin.Context) {
+	format, err := bulk.DetectFormat(c.Query("format"), c.GetHeader("Content-Type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := c.DefaultQuery("mode", "insert")
+	if mode != "insert" && mode != "upsert" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode, expected insert or upsert"})
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run value"})
+		return
+	}
+
+	reader, err := bulk.NewReader(format, c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := bulk.NewResult(format, mode == "upsert", dryRun)
+	if err := h.storage.Import(c.Request.Context(), reader, result); err != nil {
+		switch {
+		case errors.Is(err, bulk.ErrMalformedInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Errorf("Failed to import films: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import films"})
+		}
+		return
+	}
+
+	if result.Failed > 0 && result.Inserted+result.Updated == 0 {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportFilms godoc
+// @Summary Export films
+// @Description Stream all films as CSV, a JSON array or NDJSON
+// @Tags films v1
+// @Produce json,text/csv,application/x-ndjson
+// @Param format query string false "Output format (csv, json, ndjson)" default(json)
+// @Success 200 {array} Film "Films in the requested format"
+// @Failure 400 {object} map[string]string "Invalid format"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/films/export [get]
+func (h *Handler) ExportFilms(c *gin.Context) {
+	format, err := bulk.ParseFormat(c.DefaultQuery("format", string(bulk.FormatJSON)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", "attachment; filename=films."+string(format))
+	c.Status(http.StatusOK)
+
+	// Заголовки уже отправлены, поэтому ошибку посреди потока можно только залогировать
+	if err := h.storage.Export(c.Request.Context(), format, c.Writer); err != nil {
+		h.logger.Errorf("Failed to export films: %v", err)
+	}
+}
+
+// BatchFilms godoc
+// @Summary Create, update and delete films in one request
+// @Description Apply a list of operations in a single transaction. Each operation is validated like the single-film endpoint and gets its own status and error.
+// @Description In atomic mode (default) any failure rolls back the whole batch and the remaining operations get status 424; in best_effort mode only failed operations are skipped.
+// @Tags films v1
+// @Accept json
+// @Produce json
+// @Param batch body batch.Request true "Operations; data is Film for create and UpdateFilm for update"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Success 200 {object} batch.Response "Batch committed, per-operation results"
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 409 {object} map[string]string "Request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} map[string]string "Batch exceeds the configured limits"
+// @Failure 422 {object} batch.Response "Atomic batch rolled back (per-operation results), or Idempotency-Key reused with a different request"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/films/batch [post]
+func (h *Handler) BatchFilms(c *gin.Context) {
+	req, err := batch.Bind(c, h.batchLimits)
+	if err != nil {
+		if errors.Is(err, batch.ErrTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := batch.NewResponse(req)
+	items := make([]BatchItem, len(req.Operations))
+	for i, op := range req.Operations {
+		item, err := decodeBatchOperation(op)
+		if err != nil {
+			resp.Reject(i, http.StatusBadRequest, err)
+			continue
+		}
+		items[i] = item
+		resp.Results[i].ID = item.ID
+	}
+
+	if err := h.storage.Batch(c.Request.Context(), items, resp); err != nil {
+		h.logger.Errorf("Failed to execute films batch: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute batch"})
+		return
+	}
+	c.JSON(resp.StatusCode(), resp)
+}
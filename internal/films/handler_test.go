@@ -0,0 +1,161 @@
+package films_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/memory"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/patch"
+	"strings"
+	"testing"
+	"time"
+)
+
+const stalker = `{"title": "Сталкер", "description": "Зона", "rating": 8.1, "release_date": "1979-05-25T00:00:00Z"}`
+
+// newFilmRouter маршруты v1 фильмов над in-memory хранилищем
+func newFilmRouter(t *testing.T) (*gin.Engine, *memory.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := memory.NewDB()
+	h := films.NewHandler(memory.NewFilmStorage(db), batch.Limits{MaxOperations: 10, MaxBodyBytes: 1 << 20}, logging.GetLogger())
+
+	r := gin.New()
+	r.POST("/films", h.CreateFilm)
+	r.GET("/films", h.GetList)
+	r.GET("/films/sort", h.GetListSort)
+	r.GET("/films/:uuid", h.GetUserFilm)
+	r.PATCH("/films/:uuid", h.PartiallyUpdateFilm)
+	r.DELETE("/films/:uuid", h.DeleteFilm)
+	return r, db
+}
+
+func serve(r *gin.Engine, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createFilm(t *testing.T, r *gin.Engine, body string) films.Film {
+	t.Helper()
+	w := serve(r, http.MethodPost, "/films", "application/json", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /films: status %d: %s", w.Code, w.Body)
+	}
+	var film films.Film
+	if err := json.Unmarshal(w.Body.Bytes(), &film); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	return film
+}
+
+func TestCreateFilm(t *testing.T) {
+	r, _ := newFilmRouter(t)
+	film := createFilm(t, r, stalker)
+	if film.ID == "" || film.Title != "Сталкер" || film.CreatedAt.IsZero() {
+		t.Errorf("created film = %+v", film)
+	}
+
+	if w := serve(r, http.MethodPost, "/films", "application/json", stalker); w.Code != http.StatusConflict {
+		t.Errorf("duplicate title: status %d, want 409", w.Code)
+	}
+	if w := serve(r, http.MethodPost, "/films", "application/json", `{"title": "Солярис", "rating": 11}`); w.Code != http.StatusBadRequest {
+		t.Errorf("rating out of range: status %d, want 400", w.Code)
+	}
+}
+
+func TestGetListV1Shapes(t *testing.T) {
+	r, _ := newFilmRouter(t)
+
+	// v1 сохраняет прежний формат: пустой список — null
+	if w := serve(r, http.MethodGet, "/films", "", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "null" {
+		t.Errorf("empty list: status %d, body %s, want 200 null", w.Code, w.Body)
+	}
+
+	createFilm(t, r, stalker)
+	createFilm(t, r, `{"title": "Андрей Рублев", "rating": 8.1, "release_date": "1966-12-16T00:00:00Z"}`)
+	w := serve(r, http.MethodGet, "/films/sort", "", "")
+	var list []films.Film
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET /films/sort: status %d, body %s", w.Code, w.Body)
+	}
+	if len(list) != 2 || list[0].Title != "Андрей Рублев" {
+		t.Errorf("sorted list = %+v, want Андрей Рублев first", list)
+	}
+}
+
+func TestGetUserFilm(t *testing.T) {
+	r, _ := newFilmRouter(t)
+
+	w := serve(r, http.MethodGet, "/films/7c9e6679-7425-40de-944b-e07fc1f90ae7", "", "")
+	var message map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &message); err != nil || message["message"] == "" {
+		t.Errorf("user without films: status %d, body %s, want a message object", w.Code, w.Body)
+	}
+}
+
+func TestPartiallyUpdateFilm(t *testing.T) {
+	r, db := newFilmRouter(t)
+	film := createFilm(t, r, stalker)
+	createFilm(t, r, `{"title": "Солярис", "rating": 8, "release_date": "1972-03-20T00:00:00Z"}`)
+
+	if w := serve(r, http.MethodPatch, "/films/"+film.ID, "application/json", `{"rating": 9}`); w.Code != http.StatusNoContent {
+		t.Fatalf("PATCH: status %d: %s", w.Code, w.Body)
+	}
+	got, err := memory.NewFilmStorage(db).FindByID(context.Background(), film.ID)
+	if err != nil || got.Rating != 9 || got.Title != film.Title {
+		t.Errorf("after PATCH film = %+v, %v", got, err)
+	}
+
+	if w := serve(r, http.MethodPatch, "/films/"+film.ID, "application/json", `{"title": "Солярис"}`); w.Code != http.StatusConflict {
+		t.Errorf("PATCH to a taken title: status %d, want 409", w.Code)
+	}
+	if w := serve(r, http.MethodPatch, "/films/7c9e6679-7425-40de-944b-e07fc1f90ae7", "application/json", `{"rating": 9}`); w.Code != http.StatusNotFound {
+		t.Errorf("PATCH missing film: status %d, want 404", w.Code)
+	}
+}
+
+func TestJSONPatchFilm(t *testing.T) {
+	r, db := newFilmRouter(t)
+	film := createFilm(t, r, stalker)
+
+	ops := `[{"op": "test", "path": "/rating", "value": 8.1}, {"op": "replace", "path": "/rating", "value": 6}]`
+	if w := serve(r, http.MethodPatch, "/films/"+film.ID, "application/json-patch+json", ops); w.Code != http.StatusNoContent {
+		t.Fatalf("JSON Patch: status %d: %s", w.Code, w.Body)
+	}
+	got, _ := memory.NewFilmStorage(db).FindByID(context.Background(), film.ID)
+	if got.Rating != 6 || !got.CreatedAt.Equal(film.CreatedAt) || !got.UpdatedAt.After(film.UpdatedAt.Add(-time.Second)) {
+		t.Errorf("after JSON Patch film = %+v", got)
+	}
+
+	// test не проходит: рейтинг уже 6
+	if w := serve(r, http.MethodPatch, "/films/"+film.ID, "application/json-patch+json", ops); w.Code != http.StatusConflict {
+		t.Errorf("failed test operation: status %d, want 409", w.Code)
+	}
+
+	oversized := "[" + strings.Repeat(" ", patch.MaxBodyBytes) + "]"
+	if w := serve(r, http.MethodPatch, "/films/"+film.ID, "application/json-patch+json", oversized); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized JSON Patch: status %d, want 413", w.Code)
+	}
+}
+
+func TestDeleteFilm(t *testing.T) {
+	r, _ := newFilmRouter(t)
+	film := createFilm(t, r, stalker)
+
+	if w := serve(r, http.MethodDelete, "/films/"+film.ID, "", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d: %s", w.Code, w.Body)
+	}
+	if w := serve(r, http.MethodDelete, "/films/"+film.ID, "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("second DELETE: status %d, want 404", w.Code)
+	}
+}
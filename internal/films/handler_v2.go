@@ -0,0 +1,301 @@
+package films
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/patch"
+	"rest-api-tutorial/pkg/stream"
+	"time"
+)
+
+// HandlerV2 обработчики фильмов API v2: ответы в конвертах envelope,
+// ошибки в формате errors.ErrorResponse
+type HandlerV2 struct {
+	logger  *logging.Logger
+	storage FilmRepository
+}
+
+func NewHandlerV2(storage FilmRepository, logger *logging.Logger) *HandlerV2 {
+	return &HandlerV2{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+// CreateFilm godoc
+// @Summary Create a new film
+// @Description Create a new film with the provided details
+// @Tags films v2
+// @Accept json
+// @Produce json
+// @Param film body FilmV2 true "Film data to create"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Success 201 {object} envelope.Resource[films.FilmV2] "Successfully created film"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 409 {object} envelope.ErrorResponse "Film with this title already exists, or request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} envelope.ErrorResponse "Request body with an Idempotency-Key exceeds the configured limit"
+// @Failure 422 {object} envelope.ErrorResponse "Idempotency-Key reused with a different request"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/films [post]
+func (h *HandlerV2) CreateFilm(c *gin.Context) {
+	var input FilmV2
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to generate ID")
+		return
+	}
+	newFilm := input.Film()
+	newFilm.ID = id.String()
+	newFilm.CreatedAt = time.Now()
+	newFilm.UpdatedAt = newFilm.CreatedAt
+
+	if err := h.storage.Create(c.Request.Context(), newFilm); err != nil {
+		if errors.Is(err, ErrConflict) {
+			envelope.Error(c, http.StatusConflict, "Film with this title already exists")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to create film card")
+		return
+	}
+	envelope.Data(c, http.StatusCreated, NewFilmV2(newFilm))
+}
+
+// GetList godoc
+// @Summary Get all films
+// @Description Retrieve a list of all films. With sort=title films are ordered by title, then rating, then release date.
+// @Description With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row
+// @Tags films v2
+// @Produce json,application/x-ndjson,text/csv
+// @Param sort query string false "Sort order" Enums(title)
+// @Param stream query bool false "Stream rows as they are read from the database; a failure mid-stream leaves the JSON envelope unterminated"
+// @Success 200 {object} envelope.Collection[films.FilmV2] "List of films"
+// @Header 200 {string} Cache-Control "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+// @Header 200 {string} X-Cache "HIT when served from the response cache, MISS otherwise"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid sort order"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/films [get]
+func (h *HandlerV2) GetList(c *gin.Context) {
+	var (
+		findAll   func(context.Context) ([]Film, error)
+		streamAll func(context.Context, func(Film) error) error
+	)
+	switch c.Query("sort") {
+	case "":
+		findAll, streamAll = h.storage.FindAll, h.storage.StreamAll
+	case "title":
+		findAll, streamAll = h.storage.FindAllSort, h.storage.StreamAllSort
+	default:
+		envelope.Error(c, http.StatusBadRequest, "Invalid sort order, expected title")
+		return
+	}
+
+	if stream.Requested(c) {
+		w := stream.New(c, stream.Envelope)
+		err := streamAll(c.Request.Context(), func(film Film) error {
+			return w.Write(NewFilmV2(film))
+		})
+		if err != nil && c.Request.Context().Err() == nil {
+			h.logger.Errorf("Failed to stream films: %v", err)
+		}
+		if err := w.Finish(err); err != nil {
+			envelope.Error(c, http.StatusInternalServerError, "Failed to fetch films")
+		}
+		return
+	}
+
+	list, err := findAll(c.Request.Context())
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch films")
+		return
+	}
+	envelope.List(c, http.StatusOK, newFilmsV2(list))
+}
+
+// GetFilm godoc
+// @Summary Get a film by ID
+// @Description Retrieve a single film by its UUID
+// @Tags films v2
+// @Produce json
+// @Param uuid path string true "Film ID (UUID)"
+// @Success 200 {object} envelope.Resource[films.FilmV2] "Requested film"
+// @Header 200 {string} Cache-Control "private, max-age=CACHE_MAX_AGE when the response cache is enabled"
+// @Header 200 {string} X-Cache "HIT when served from the response cache, MISS otherwise"
+// @Failure 404 {object} envelope.ErrorResponse "Film not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/films/{uuid} [get]
+func (h *HandlerV2) GetFilm(c *gin.Context) {
+	film, err := h.storage.FindByID(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			envelope.Error(c, http.StatusNotFound, "Film not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to find film")
+		return
+	}
+	envelope.Data(c, http.StatusOK, NewFilmV2(*film))
+}
+
+// GetUserFilms godoc
+// @Summary Get films by user ID
+// @Description Retrieve all films associated with specific user
+// @Tags films v2
+// @Produce json
+// @Param uuid path string true "User ID (UUID)"
+// @Success 200 {object} envelope.Collection[films.FilmV2] "List of user's films, empty if the user has none"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/users/{uuid}/films [get]
+func (h *HandlerV2) GetUserFilms(c *gin.Context) {
+	list, err := h.storage.FindOne(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to find films")
+		return
+	}
+	envelope.List(c, http.StatusOK, newFilmsV2(list))
+}
+
+// PartiallyUpdateFilm godoc
+// @Summary Partially update film
+// @Description Update specific fields of a film and return the updated film.
+// @Description With Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902) applied atomically to the v2 representation of the film
+// @Tags films v2
+// @Accept json,application/json-patch+json
+// @Produce json
+// @Param uuid path string true "Film ID (UUID)"
+// @Param updates body UpdateFilm true "Fields to update"
+// @Success 200 {object} envelope.Resource[films.FilmV2] "Updated film"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 404 {object} envelope.ErrorResponse "Film not found"
+// @Failure 409 {object} envelope.ErrorResponse "Film with this title already exists, or JSON Patch test operation failed"
+// @Failure 422 {object} envelope.ErrorResponse "JSON Patch cannot be applied or result is invalid"
+// @Failure 413 {object} envelope.ErrorResponse "JSON Patch document exceeds 1 MiB"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/films/{uuid} [patch]
+func (h *HandlerV2) PartiallyUpdateFilm(c *gin.Context) {
+	id := c.Param("uuid")
+	if c.ContentType() == patch.MIMEJSONPatch {
+		h.jsonPatchFilm(c, id)
+		return
+	}
+
+	var input UpdateFilm
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.storage.PartialUpdate(c.Request.Context(), id, input); err != nil {
+		h.updateError(c, err)
+		return
+	}
+	h.respondFilm(c, id)
+}
+
+func (h *HandlerV2) jsonPatchFilm(c *gin.Context, id string) {
+	body, err := patch.Read(c.Writer, c.Request)
+	if err != nil {
+		if errors.Is(err, patch.ErrTooLarge) {
+			envelope.Error(c, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ops, err := patch.Decode(body)
+	if err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid JSON Patch document")
+		return
+	}
+
+	err = h.storage.Patch(c.Request.Context(), id, func(f *Film) error {
+		// Патч применяется к представлению v2, чтобы пути совпадали с тем, что видит клиент
+		view := NewFilmV2(*f)
+		if err := patch.Apply(ops, &view); err != nil {
+			return err
+		}
+		createdAt := f.CreatedAt
+		*f = view.Film()
+		// Идентификатор и служебные даты патчем не меняются
+		f.ID = id
+		f.CreatedAt = createdAt
+		f.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		h.updateError(c, err)
+		return
+	}
+	h.respondFilm(c, id)
+}
+
+// updateError отвечает на ошибку обновления фильма
+func (h *HandlerV2) updateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		envelope.Error(c, http.StatusNotFound, "Film not found")
+	case errors.Is(err, ErrConflict):
+		envelope.Error(c, http.StatusConflict, "Film with this title already exists")
+	case errors.Is(err, patch.ErrTestFailed):
+		envelope.Error(c, http.StatusConflict, err.Error())
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, patch.ErrValidation):
+		envelope.Error(c, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.logger.Errorf("Failed to update film: %v", err)
+		envelope.Error(c, http.StatusInternalServerError, "Failed to update film card")
+	}
+}
+
+// respondFilm отвечает актуальным состоянием фильма после изменения
+func (h *HandlerV2) respondFilm(c *gin.Context, id string) {
+	film, err := h.storage.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			envelope.Error(c, http.StatusNotFound, "Film not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to find film")
+		return
+	}
+	envelope.Data(c, http.StatusOK, NewFilmV2(*film))
+}
+
+// DeleteFilm godoc
+// @Summary Delete a film
+// @Description Remove a film by its UUID
+// @Tags films v2
+// @Produce json
+// @Param uuid path string true "Film ID (UUID)"
+// @Success 204 "Film deleted successfully"
+// @Failure 404 {object} envelope.ErrorResponse "Film not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/films/{uuid} [delete]
+func (h *HandlerV2) DeleteFilm(c *gin.Context) {
+	if err := h.storage.Delete(c.Request.Context(), c.Param("uuid")); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			envelope.Error(c, http.StatusNotFound, "Film not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to delete film")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func newFilmsV2(list []Film) []FilmV2 {
+	out := make([]FilmV2, 0, len(list))
+	for _, f := range list {
+		out = append(out, NewFilmV2(f))
+	}
+	return out
+}
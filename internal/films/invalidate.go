@@ -0,0 +1,54 @@
+package films
+
+import (
+	"context"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
+)
+
+// WithInvalidation оборачивает хранилище так, что после каждого изменения фильмов
+// вызывается invalidate, например сброс кеша ответов. Чтение проходит без изменений.
+// invalidate вызывается и после ошибки: часть пакета или импорта могла примениться.
+func WithInvalidation(repo FilmRepository, invalidate func(ctx context.Context)) FilmRepository {
+	return &invalidatingRepository{FilmRepository: repo, invalidate: invalidate}
+}
+
+type invalidatingRepository struct {
+	FilmRepository
+	invalidate func(ctx context.Context)
+}
+
+func (r *invalidatingRepository) Create(ctx context.Context, film Film) error {
+	defer r.changed(ctx)
+	return r.FilmRepository.Create(ctx, film)
+}
+
+func (r *invalidatingRepository) PartialUpdate(ctx context.Context, id string, input UpdateFilm) error {
+	defer r.changed(ctx)
+	return r.FilmRepository.PartialUpdate(ctx, id, input)
+}
+
+func (r *invalidatingRepository) Patch(ctx context.Context, id string, apply func(*Film) error) error {
+	defer r.changed(ctx)
+	return r.FilmRepository.Patch(ctx, id, apply)
+}
+
+func (r *invalidatingRepository) Delete(ctx context.Context, id string) error {
+	defer r.changed(ctx)
+	return r.FilmRepository.Delete(ctx, id)
+}
+
+func (r *invalidatingRepository) Batch(ctx context.Context, items []BatchItem, resp *batch.Response) error {
+	defer r.changed(ctx)
+	return r.FilmRepository.Batch(ctx, items, resp)
+}
+
+func (r *invalidatingRepository) Import(ctx context.Context, reader *bulk.Reader, result *bulk.Result) error {
+	defer r.changed(ctx)
+	return r.FilmRepository.Import(ctx, reader, result)
+}
+
+// changed сбрасывает кеш, даже если клиент уже отключился: изменение могло зафиксироваться
+func (r *invalidatingRepository) changed(ctx context.Context) {
+	r.invalidate(context.WithoutCancel(ctx))
+}
@@ -12,13 +12,13 @@ type Film struct {
 
 	// @minLength 1
 	// @maxLength 255
-	Title string `json:"title" binding:"required"`
+	Title string `json:"title" binding:"required,max=255"`
 
 	Description string `json:"description"`
 
 	// @minimum 0
 	// @maximum 10
-	Rating float64 `json:"rating"`
+	Rating float64 `json:"rating" binding:"gte=0,lte=10"`
 
 	// @format date
 	ReleaseDate time.Time `json:"release_date"`
@@ -33,9 +33,9 @@ type Film struct {
 // UpdateFilm модель для документации Swagger
 // @description Модель фильма с необходимым базисом для обновления
 type UpdateFilm struct {
-	Title       *string    `json:"title"`
+	Title       *string    `json:"title" binding:"omitempty,max=255"`
 	Description *string    `json:"description"`
-	Rating      *float64   `json:"rating"`
+	Rating      *float64   `json:"rating" binding:"omitempty,gte=0,lte=10"`
 	ReleaseDate *time.Time `json:"release_date"`
 }
 
@@ -50,3 +50,39 @@ type UserFilm struct {
 	// @format uuid
 	FilmID string `json:"film_id"`
 }
+
+// FilmV2 представление фильма в API v2: идентификатор называется id, как у остальных ресурсов
+// @description Модель фильма в API v2
+type FilmV2 struct {
+	// @format uuid
+	ID string `json:"id"`
+
+	// @minLength 1
+	// @maxLength 255
+	Title string `json:"title" binding:"required,max=255"`
+
+	Description string `json:"description"`
+
+	// @minimum 0
+	// @maximum 10
+	Rating float64 `json:"rating" binding:"gte=0,lte=10"`
+
+	// @format date
+	ReleaseDate time.Time `json:"release_date"`
+
+	// @format date
+	CreatedAt time.Time `json:"created_at"`
+
+	// @format date
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewFilmV2 переводит фильм в представление API v2
+func NewFilmV2(f Film) FilmV2 {
+	return FilmV2(f)
+}
+
+// Film переводит представление API v2 обратно в модель хранилища
+func (f FilmV2) Film() Film {
+	return Film(f)
+}
@@ -0,0 +1,33 @@
+package films
+
+import (
+	"context"
+	"io"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
+)
+
+// FilmRepository хранилище фильмов, с которым работает Handler.
+// Реализации обязаны соблюдать одну семантику: ErrNotFound для отсутствующего
+// фильма, ErrConflict при повторе id или названия; при удалении фильма
+// удаляются его связи с пользователями.
+type FilmRepository interface {
+	Create(ctx context.Context, film Film) error
+	FindOne(ctx context.Context, userID string) ([]Film, error)
+	FindByID(ctx context.Context, id string) (*Film, error)
+	FindByUserIDs(ctx context.Context, userIDs []string) (map[string][]Film, error)
+	FindAll(ctx context.Context) ([]Film, error)
+	FindAllSort(ctx context.Context) ([]Film, error)
+	// StreamAll и StreamAllSort передают фильмы в fn по одному в порядке FindAll и
+	// FindAllSort; ошибка fn прерывает чтение и возвращается без изменений
+	StreamAll(ctx context.Context, fn func(Film) error) error
+	StreamAllSort(ctx context.Context, fn func(Film) error) error
+	PartialUpdate(ctx context.Context, id string, input UpdateFilm) error
+	Patch(ctx context.Context, id string, apply func(*Film) error) error
+	Delete(ctx context.Context, id string) error
+	Batch(ctx context.Context, items []BatchItem, resp *batch.Response) error
+	Import(ctx context.Context, reader *bulk.Reader, result *bulk.Result) error
+	Export(ctx context.Context, format bulk.Format, w io.Writer) error
+}
+
+var _ FilmRepository = (*Storage)(nil)
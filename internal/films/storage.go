@@ -4,11 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"io"
+	"net/http"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
+	"rest-api-tutorial/pkg/client/postgres"
 	"rest-api-tutorial/pkg/logging"
+	"sort"
 )
 
+var (
+	// ErrNotFound фильм с указанным идентификатором не существует
+	ErrNotFound = errors.New("film not found")
+	// ErrConflict фильм с таким идентификатором или названием уже существует
+	ErrConflict = errors.New("film already exists")
+)
+
+// uniqueViolation код ошибки PostgreSQL при нарушении уникального ограничения
+const uniqueViolation = "23505"
+
 type Storage struct {
 	client *pgxpool.Pool
 	logger *logging.Logger
@@ -22,11 +39,18 @@ func NewFilmStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
 }
 
 func (s *Storage) Create(ctx context.Context, film Film) error {
+	if err := s.create(ctx, s.client, film); err != nil {
+		return constraintError(fmt.Errorf("failed to insert film: %w", err))
+	}
+	return nil
+}
+
+func (s *Storage) create(ctx context.Context, client postgres.Client, film Film) error {
 	q := `
         INSERT INTO films (film_id, title, description, rating, release_date, created_at, updated_at) 
         VALUES ($1, $2, $3, $4, $5, $6, $7)
     `
-	_, err := s.client.Exec(
+	_, err := client.Exec(
 		ctx,
 		q,
 		film.ID,
@@ -42,10 +66,10 @@ func (s *Storage) Create(ctx context.Context, film Film) error {
 
 func (s *Storage) FindOne(ctx context.Context, id string) ([]Film, error) {
 	q := `
-       		SELECT films.*
-			FROM films
-			LEFT JOIN user_film ON films.film_id = user_film.film_id
-			WHERE user_film.user_id = $1;
+        SELECT films.film_id, films.title, films.description, films.rating, films.release_date, films.created_at, films.updated_at
+        FROM films
+        JOIN user_film ON films.film_id = user_film.film_id
+        WHERE user_film.user_id = $1
     `
 
 	rows, err := s.client.Query(ctx, q, id)
@@ -83,19 +107,58 @@ func (s *Storage) FindOne(ctx context.Context, id string) ([]Film, error) {
 	return userFilms, nil
 }
 
-func (s *Storage) FindAll(ctx context.Context) ([]Film, error) {
-	q := `SELECT film_id, title, description, rating, release_date, created_at, updated_at
-        FROM films`
-	rows, err := s.client.Query(ctx, q)
+func (s *Storage) FindByID(ctx context.Context, id string) (*Film, error) {
+	q := `
+        SELECT film_id, title, description, rating, release_date, created_at, updated_at
+        FROM films
+        WHERE film_id = $1
+    `
+
+	var film Film
+	err := s.client.QueryRow(ctx, q, id).Scan(
+		&film.ID,
+		&film.Title,
+		&film.Description,
+		&film.Rating,
+		&film.ReleaseDate,
+		&film.CreatedAt,
+		&film.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Warnf("Film not found: %s", id)
+			return nil, ErrNotFound
+		}
+		s.logger.Errorf("Failed to get film: %v", err)
+		return nil, fmt.Errorf("failed to get film: %w", err)
+	}
+	return &film, nil
+}
+
+// FindByUserIDs одним запросом находит фильмы каждого из пользователей userIDs.
+// Используется пакетными загрузчиками GraphQL.
+func (s *Storage) FindByUserIDs(ctx context.Context, userIDs []string) (map[string][]Film, error) {
+	q := `
+        SELECT user_film.user_id, films.film_id, films.title, films.description, films.rating, films.release_date, films.created_at, films.updated_at
+        FROM films
+        JOIN user_film ON films.film_id = user_film.film_id
+        WHERE user_film.user_id = ANY($1)
+        ORDER BY films.title, films.film_id
+    `
+	rows, err := s.client.Query(ctx, q, userIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get list of films: %w", err)
+		return nil, fmt.Errorf("failed to get films for users: %w", err)
 	}
 	defer rows.Close()
 
-	var films []Film
+	userFilms := make(map[string][]Film, len(userIDs))
 	for rows.Next() {
-		var film Film
+		var (
+			userID string
+			film   Film
+		)
 		if err := rows.Scan(
+			&userID,
 			&film.ID,
 			&film.Title,
 			&film.Description,
@@ -104,25 +167,105 @@ func (s *Storage) FindAll(ctx context.Context) ([]Film, error) {
 			&film.CreatedAt,
 			&film.UpdatedAt,
 		); err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			return nil, fmt.Errorf("failed to scan film: %w", err)
 		}
-		films = append(films, film)
+		userFilms[userID] = append(userFilms[userID], film)
 	}
-	return films, nil
+	return userFilms, rows.Err()
+}
+
+// Patch загружает фильм с блокировкой строки, передает его в apply
+// и сохраняет результат в той же транзакции.
+// Если apply возвращает ошибку, транзакция откатывается.
+func (s *Storage) Patch(ctx context.Context, id string, apply func(*Film) error) error {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+        SELECT film_id, title, description, rating, release_date, created_at, updated_at
+        FROM films
+        WHERE film_id = $1
+        FOR UPDATE
+    `
+
+	var film Film
+	err = tx.QueryRow(ctx, q, id).Scan(
+		&film.ID,
+		&film.Title,
+		&film.Description,
+		&film.Rating,
+		&film.ReleaseDate,
+		&film.CreatedAt,
+		&film.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get film: %w", err)
+	}
+
+	if err := apply(&film); err != nil {
+		return err
+	}
+
+	qUpdate := `
+        UPDATE films 
+        SET 
+            title = $2,
+            description = $3,
+            rating = $4,
+            release_date = $5,
+            updated_at = $6
+        WHERE film_id = $1
+    `
+	_, err = tx.Exec(ctx, qUpdate, id, film.Title, film.Description, film.Rating, film.ReleaseDate, film.UpdatedAt)
+	if err != nil {
+		s.logger.Errorf("Failed to patch film: %v", err)
+		return constraintError(fmt.Errorf("failed to patch film: %w", err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) FindAll(ctx context.Context) ([]Film, error) {
+	return collect(ctx, s.StreamAll)
 }
 
 func (s *Storage) FindAllSort(ctx context.Context) ([]Film, error) {
+	return collect(ctx, s.StreamAllSort)
+}
+
+// StreamAll передает фильмы в fn по мере чтения строк, не собирая список в памяти.
+// Ошибка fn прерывает чтение и возвращается без изменений.
+func (s *Storage) StreamAll(ctx context.Context, fn func(Film) error) error {
+	q := `SELECT film_id, title, description, rating, release_date, created_at, updated_at
+        FROM films`
+	return s.stream(ctx, q, fn)
+}
+
+// StreamAllSort как StreamAll, но в порядке FindAllSort
+func (s *Storage) StreamAllSort(ctx context.Context, fn func(Film) error) error {
 	q := `  SELECT film_id, title, description, rating, release_date, created_at, updated_at
         	FROM films
         	ORDER BY title, rating, release_date 
 		  `
+	return s.stream(ctx, q, fn)
+}
+
+func (s *Storage) stream(ctx context.Context, q string, fn func(Film) error) error {
 	rows, err := s.client.Query(ctx, q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get list of films: %w", err)
+		return fmt.Errorf("failed to get list of films: %w", err)
 	}
 	defer rows.Close()
 
-	var films []Film
 	for rows.Next() {
 		var film Film
 		if err := rows.Scan(
@@ -134,14 +277,43 @@ func (s *Storage) FindAllSort(ctx context.Context) ([]Film, error) {
 			&film.CreatedAt,
 			&film.UpdatedAt,
 		); err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			return fmt.Errorf("failed to scan film: %w", err)
+		}
+		if err := fn(film); err != nil {
+			return err
 		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get list of films: %w", err)
+	}
+	return nil
+}
+
+// collect собирает все фильмы из stream в список
+func collect(ctx context.Context, stream func(context.Context, func(Film) error) error) ([]Film, error) {
+	var films []Film
+	err := stream(ctx, func(film Film) error {
 		films = append(films, film)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return films, nil
 }
 
 func (s *Storage) PartialUpdate(ctx context.Context, id string, input UpdateFilm) error {
+	tag, err := s.partialUpdate(ctx, s.client, id, input)
+	if err != nil {
+		return constraintError(fmt.Errorf("failed to update film: %w", err))
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Storage) partialUpdate(ctx context.Context, client postgres.Client, id string, input UpdateFilm) (pgconn.CommandTag, error) {
 	q := `
         UPDATE films 
         SET 
@@ -152,17 +324,312 @@ func (s *Storage) PartialUpdate(ctx context.Context, id string, input UpdateFilm
             updated_at = NOW()
         WHERE film_id = $1
     `
-	_, err := s.client.Exec(ctx, q, id, input.Title, input.Description, input.Rating, input.ReleaseDate)
-	return err
+	return client.Exec(ctx, q, id, input.Title, input.Description, input.Rating, input.ReleaseDate)
 }
 
 func (s *Storage) Delete(ctx context.Context, id string) error {
+	tag, err := s.delete(ctx, s.client, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Storage) delete(ctx context.Context, client postgres.Client, id string) (pgconn.CommandTag, error) {
 	q := `DELETE FROM films WHERE film_id = $1`
 
-	_, err := s.client.Exec(ctx, q, id)
+	tag, err := client.Exec(ctx, q, id)
 	if err != nil {
 		s.logger.Errorf("Failed to delete film: %v", err)
-		return fmt.Errorf("failed to delete film: %w", err)
+		return nil, fmt.Errorf("failed to delete film: %w", err)
+	}
+	return tag, nil
+}
+
+// Batch выполняет подготовленные операции пакета в одной транзакции через batch.Run
+func (s *Storage) Batch(ctx context.Context, items []BatchItem, resp *batch.Response) error {
+	return batch.Run(ctx, s.client, resp, func(ctx context.Context, tx pgx.Tx, i int) (string, int, error) {
+		item := items[i]
+		switch item.Op {
+		case batch.OpCreate:
+			if err := s.create(ctx, tx, item.Film); err != nil {
+				status, err := batchError(err, "failed to create film card")
+				return item.Film.ID, status, err
+			}
+			return item.Film.ID, http.StatusCreated, nil
+		case batch.OpUpdate:
+			tag, err := s.partialUpdate(ctx, tx, item.ID, item.Update)
+			if err != nil {
+				status, err := batchError(err, "failed to update film card")
+				return item.ID, status, err
+			}
+			if tag.RowsAffected() == 0 {
+				return item.ID, http.StatusNotFound, ErrNotFound
+			}
+			return item.ID, http.StatusNoContent, nil
+		default:
+			tag, err := s.delete(ctx, tx, item.ID)
+			if err != nil {
+				return item.ID, http.StatusInternalServerError, fmt.Errorf("failed to delete film")
+			}
+			if tag.RowsAffected() == 0 {
+				return item.ID, http.StatusNotFound, ErrNotFound
+			}
+			return item.ID, http.StatusNoContent, nil
+		}
+	})
+}
+
+// batchError переводит ошибку PostgreSQL в статус операции пакета
+func batchError(err error, message string) (int, error) {
+	if errors.Is(constraintError(err), ErrConflict) {
+		return http.StatusConflict, ErrConflict
+	}
+	return http.StatusInternalServerError, errors.New(message)
+}
+
+// constraintError заменяет нарушение уникальности на ErrConflict, остальные ошибки возвращает без изменений
+func constraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrConflict
+	}
+	return err
+}
+
+// Import загружает фильмы из reader через COPY во временную таблицу и переносит их
+// в films одним запросом. В режиме upsert существующие фильмы обновляются по
+// ограничению films_tenant_title_key, иначе конфликтующие строки отклоняются.
+// При DryRun транзакция откатывается, но результат содержит те же счетчики и ошибки.
+func (s *Storage) Import(ctx context.Context, reader *bulk.Reader, result *bulk.Result) error {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qTemp := `
+        CREATE TEMP TABLE films_import (
+            row_num integer NOT NULL,
+            film_id uuid NOT NULL,
+            title character varying(255) NOT NULL,
+            description text,
+            rating numeric(3,1),
+            release_date timestamp without time zone NOT NULL
+        ) ON COMMIT DROP
+    `
+	if _, err := tx.Exec(ctx, qTemp); err != nil {
+		return fmt.Errorf("failed to create import table: %w", err)
+	}
+
+	src := bulk.NewCopySource(reader, result, decodeFilmRow)
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"films_import"}, importColumns, src); err != nil {
+		if src.Err() != nil {
+			return src.Err()
+		}
+		return fmt.Errorf("failed to copy films: %w", err)
+	}
+
+	if result.Upsert {
+		err = s.importUpsert(ctx, tx, result)
+	} else {
+		err = s.importInsert(ctx, tx, result)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrConflict, pgErr.Detail)
+		}
+		return err
+	}
+
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+
+	if result.DryRun {
+		return nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) importInsert(ctx context.Context, tx pgx.Tx, result *bulk.Result) error {
+	q := `
+        INSERT INTO films (film_id, title, description, rating, release_date, created_at, updated_at)
+        SELECT film_id, title, description, rating, release_date, NOW(), NOW()
+        FROM films_import
+        ORDER BY row_num
+        ON CONFLICT DO NOTHING
+        RETURNING film_id::text
+    `
+	rows, err := tx.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to insert films: %w", err)
+	}
+	inserted := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan film id: %w", err)
+		}
+		inserted[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to insert films: %w", err)
+	}
+
+	// Строки, чей film_id не вернулся из INSERT, конфликтовали по id или названию.
+	// Повтор одного film_id в файле засчитывается только первой строке.
+	rows, err = tx.Query(ctx, `SELECT row_num, film_id::text, title FROM films_import ORDER BY row_num`)
+	if err != nil {
+		return fmt.Errorf("failed to read import table: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rowNum    int
+			id, title string
+		)
+		if err := rows.Scan(&rowNum, &id, &title); err != nil {
+			return fmt.Errorf("failed to scan import row: %w", err)
+		}
+		if inserted[id] {
+			result.Inserted++
+			delete(inserted, id)
+			continue
+		}
+		result.AddError(rowNum, fmt.Errorf("film %q or id %s already exists", title, id))
+	}
+	return rows.Err()
+}
+
+func (s *Storage) importUpsert(ctx context.Context, tx pgx.Tx, result *bulk.Result) error {
+	// ON CONFLICT DO UPDATE не может изменить одну строку дважды,
+	// поэтому из повторяющихся названий в файле берется последнее
+	qDup := `
+        SELECT row_num, title
+        FROM films_import i
+        WHERE EXISTS (
+            SELECT 1 FROM films_import j WHERE j.title = i.title AND j.row_num > i.row_num
+        )
+    `
+	rows, err := tx.Query(ctx, qDup)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate titles: %w", err)
+	}
+	for rows.Next() {
+		var (
+			rowNum int
+			title  string
+		)
+		if err := rows.Scan(&rowNum, &title); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan import row: %w", err)
+		}
+		result.AddError(rowNum, fmt.Errorf("film %q is superseded by a later row", title))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to find duplicate titles: %w", err)
+	}
+
+	q := `
+        INSERT INTO films (film_id, title, description, rating, release_date, created_at, updated_at)
+        SELECT DISTINCT ON (title) film_id, title, description, rating, release_date, NOW(), NOW()
+        FROM films_import
+        ORDER BY title, row_num DESC
+        ON CONFLICT ON CONSTRAINT films_tenant_title_key DO UPDATE
+        SET
+            description = EXCLUDED.description,
+            rating = EXCLUDED.rating,
+            release_date = EXCLUDED.release_date,
+            updated_at = NOW()
+        RETURNING (xmax = 0) AS inserted
+    `
+	rows, err = tx.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to upsert films: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return fmt.Errorf("failed to scan upsert result: %w", err)
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to upsert films: %w", err)
 	}
 	return nil
 }
+
+// Export пишет все фильмы в w. CSV отдается напрямую из COPY TO STDOUT,
+// JSON и NDJSON кодируются построчно по мере чтения курсора.
+func (s *Storage) Export(ctx context.Context, format bulk.Format, w io.Writer) error {
+	if format == bulk.FormatCSV {
+		conn, err := s.client.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to acquire connection: %w", err)
+		}
+		defer conn.Release()
+
+		q := `
+            COPY (
+                SELECT film_id, title, description, rating, release_date, created_at, updated_at
+                FROM films
+                ORDER BY title
+            ) TO STDOUT WITH (FORMAT csv, HEADER true)
+        `
+		if _, err := conn.Conn().PgConn().CopyTo(ctx, w, q); err != nil {
+			return fmt.Errorf("failed to export films: %w", err)
+		}
+		return nil
+	}
+
+	q := `SELECT film_id, title, description, rating, release_date, created_at, updated_at
+        FROM films
+        ORDER BY title`
+	rows, err := s.client.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to export films: %w", err)
+	}
+	defer rows.Close()
+
+	out := bulk.NewWriter(format, w)
+	for rows.Next() {
+		var film Film
+		if err := rows.Scan(
+			&film.ID,
+			&film.Title,
+			&film.Description,
+			&film.Rating,
+			&film.ReleaseDate,
+			&film.CreatedAt,
+			&film.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan film: %w", err)
+		}
+		if err := out.Write(film); err != nil {
+			return fmt.Errorf("failed to write film: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export films: %w", err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,132 @@
+package films_test
+
+import (
+	"errors"
+	"os"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/pgtest"
+	"rest-api-tutorial/pkg/logging"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m, pgtest.Options{}))
+}
+
+func newStorage(t *testing.T) (*films.Storage, *pgtest.Fixtures) {
+	t.Helper()
+	pool := pgtest.DB(t)
+	return films.NewFilmStorage(pool, logging.GetLogger()), pgtest.NewFixtures(t, pool)
+}
+
+func TestStorageCreateAndFind(t *testing.T) {
+	storage, _ := newStorage(t)
+	ctx := pgtest.Context()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	film := films.Film{
+		ID:          "5b1d1b4e-4c2f-4a57-9c1e-0d5f7a3e8b21",
+		Title:       "Сталкер",
+		Description: "Зона",
+		Rating:      8.1,
+		ReleaseDate: time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := storage.Create(ctx, film); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := storage.FindByID(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if got.Title != film.Title || got.Rating != film.Rating || !got.ReleaseDate.Equal(film.ReleaseDate) {
+		t.Errorf("FindByID = %+v, want %+v", *got, film)
+	}
+}
+
+func TestStorageCreateConflict(t *testing.T) {
+	storage, fixtures := newStorage(t)
+	existing := fixtures.Film()
+
+	duplicate := existing
+	duplicate.ID = "0f8e7b2a-3c4d-4e5f-8a9b-1c2d3e4f5a6b"
+	if err := storage.Create(pgtest.Context(), duplicate); !errors.Is(err, films.ErrConflict) {
+		t.Fatalf("Create with taken title: got %v, want ErrConflict", err)
+	}
+}
+
+func TestStorageNotFound(t *testing.T) {
+	storage, _ := newStorage(t)
+	ctx := pgtest.Context()
+	missing := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	if _, err := storage.FindByID(ctx, missing); !errors.Is(err, films.ErrNotFound) {
+		t.Errorf("FindByID: got %v, want ErrNotFound", err)
+	}
+	title := "Новое название"
+	if err := storage.PartialUpdate(ctx, missing, films.UpdateFilm{Title: &title}); !errors.Is(err, films.ErrNotFound) {
+		t.Errorf("PartialUpdate: got %v, want ErrNotFound", err)
+	}
+	if err := storage.Delete(ctx, missing); !errors.Is(err, films.ErrNotFound) {
+		t.Errorf("Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoragePartialUpdate(t *testing.T) {
+	storage, fixtures := newStorage(t)
+	ctx := pgtest.Context()
+	film := fixtures.Film()
+
+	rating := 9.5
+	if err := storage.PartialUpdate(ctx, film.ID, films.UpdateFilm{Rating: &rating}); err != nil {
+		t.Fatalf("PartialUpdate: %v", err)
+	}
+	got, err := storage.FindByID(ctx, film.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if got.Rating != rating || got.Title != film.Title {
+		t.Errorf("after PartialUpdate got rating %v and title %q, want %v and %q", got.Rating, got.Title, rating, film.Title)
+	}
+}
+
+func TestStorageFindOne(t *testing.T) {
+	storage, fixtures := newStorage(t)
+	picked, other := fixtures.Film(), fixtures.Film()
+	u := fixtures.User()
+	fixtures.Link(u.ID, picked.ID)
+
+	list, err := storage.FindOne(pgtest.Context(), u.ID)
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != picked.ID {
+		t.Errorf("FindOne = %v, want only %s (not %s)", list, picked.ID, other.ID)
+	}
+}
+
+func TestStorageTenantIsolation(t *testing.T) {
+	storage, fixtures := newStorage(t)
+	foreign := fixtures.Tenant()
+	film := foreign.Film()
+
+	if _, err := storage.FindByID(pgtest.Context(), film.ID); !errors.Is(err, films.ErrNotFound) {
+		t.Errorf("FindByID of another tenant's film: got %v, want ErrNotFound", err)
+	}
+	if err := storage.Delete(pgtest.Context(), film.ID); !errors.Is(err, films.ErrNotFound) {
+		t.Errorf("Delete of another tenant's film: got %v, want ErrNotFound", err)
+	}
+	if _, err := storage.FindByID(foreign.Context(), film.ID); err != nil {
+		t.Errorf("FindByID in the film's tenant: %v", err)
+	}
+
+	// Название уникально в пределах арендатора
+	duplicate := film
+	duplicate.ID = "3d6f4c1e-2b7a-4e8d-9f0a-5c1b2d3e4f60"
+	if err := storage.Create(pgtest.Context(), duplicate); err != nil {
+		t.Errorf("Create with a title taken in another tenant: %v", err)
+	}
+}
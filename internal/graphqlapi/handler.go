@@ -0,0 +1,175 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"net/http"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/logging"
+)
+
+// Коды ошибок автоматически сохраняемых запросов, которые ожидают клиенты Apollo
+const (
+	errPersistedQueryNotFound  = "PersistedQueryNotFound"
+	codePersistedQueryNotFound = "PERSISTED_QUERY_NOT_FOUND"
+)
+
+// Options ограничения GraphQL-запросов
+type Options struct {
+	// MaxDepth максимальная вложенность выборки; 0 — без ограничения
+	MaxDepth int
+	// MaxComplexity максимальная стоимость запроса (поле = 1, список умножает вложенную выборку); 0 — без ограничения
+	MaxComplexity int
+	// PersistedQueries сколько сохраненных запросов держать в памяти; 0 — APQ выключены
+	PersistedQueries int
+}
+
+// Request тело запроса GraphQL over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *struct {
+			Version    int    `json:"version"`
+			Sha256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+type Handler struct {
+	logger    *logging.Logger
+	schema    graphql.Schema
+	users     user.UserRepository
+	films     films.FilmRepository
+	limits    limits
+	persisted *persistedQueries
+}
+
+func NewHandler(users user.UserRepository, filmStorage films.FilmRepository, opts Options, logger *logging.Logger) (*Handler, error) {
+	schema, err := newSchema(users, filmStorage)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		logger:    logger,
+		schema:    schema,
+		users:     users,
+		films:     filmStorage,
+		limits:    limits{maxDepth: opts.MaxDepth, maxComplexity: opts.MaxComplexity},
+		persisted: newPersistedQueries(opts.PersistedQueries),
+	}, nil
+}
+
+// Serve выполняет запрос GraphQL. POST принимает JSON-тело, GET — параметры query,
+// operationName, variables и extensions (удобно для сохраненных запросов и кеширования).
+// Ошибки разбора, валидации и превышения лимитов возвращаются с кодом 400,
+// ошибки резолверов — в поле errors ответа 200.
+func (h *Handler) Serve(c *gin.Context) {
+	req, err := bindRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResult(err.Error(), nil))
+		return
+	}
+
+	query, status, result := h.resolveQuery(req)
+	if result != nil {
+		c.JSON(status, result)
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"})})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		c.JSON(http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+		return
+	}
+	if err := h.limits.check(h.schema, doc, req.OperationName); err != nil {
+		c.JSON(http.StatusBadRequest, errorResult(err.Error(), nil))
+		return
+	}
+	if req.Extensions.PersistedQuery != nil {
+		h.persisted.put(req.Extensions.PersistedQuery.Sha256Hash, query)
+	}
+
+	ctx := auth.ContextWithPrincipal(c.Request.Context(), auth.PrincipalFrom(c))
+	ctx = context.WithValue(ctx, loadersKey{}, newLoaders(h.users, h.films))
+
+	c.JSON(http.StatusOK, graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	}))
+}
+
+// resolveQuery возвращает текст запроса с учетом APQ или готовый ответ об ошибке
+func (h *Handler) resolveQuery(req *Request) (string, int, *graphql.Result) {
+	persisted := req.Extensions.PersistedQuery
+	if persisted == nil {
+		if req.Query == "" {
+			return "", http.StatusBadRequest, errorResult("query is required", nil)
+		}
+		return req.Query, 0, nil
+	}
+
+	if persisted.Version != 1 || persisted.Sha256Hash == "" {
+		return "", http.StatusBadRequest, errorResult("unsupported persisted query version", nil)
+	}
+	if req.Query == "" {
+		query, ok := h.persisted.get(persisted.Sha256Hash)
+		if !ok {
+			// Клиент повторит запрос с полным текстом; статус 200, как ожидают клиенты Apollo
+			return "", http.StatusOK, errorResult(errPersistedQueryNotFound, map[string]interface{}{
+				"code": codePersistedQueryNotFound,
+			})
+		}
+		return query, 0, nil
+	}
+	if queryHash(req.Query) != persisted.Sha256Hash {
+		return "", http.StatusBadRequest, errorResult("provided sha does not match query", nil)
+	}
+	return req.Query, 0, nil
+}
+
+func bindRequest(c *gin.Context) (*Request, error) {
+	var req Request
+	if c.Request.Method != http.MethodGet {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, err
+		}
+		return &req, nil
+	}
+
+	req.Query = c.Query("query")
+	req.OperationName = c.Query("operationName")
+	if raw := c.Query("variables"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+			return nil, err
+		}
+	}
+	if raw := c.Query("extensions"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Extensions); err != nil {
+			return nil, err
+		}
+	}
+	return &req, nil
+}
+
+func errorResult(message string, extensions map[string]interface{}) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{{
+		Message:    message,
+		Extensions: extensions,
+	}}}
+}
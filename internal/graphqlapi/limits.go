@@ -0,0 +1,158 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strings"
+)
+
+// listCost во сколько раз поле-список умножает стоимость вложенной выборки:
+// { users { films { viewers { id } } } } стоит заметно дороже трех полей
+const listCost = 10
+
+// limits ограничения запроса, проверяемые до выполнения
+type limits struct {
+	maxDepth      int
+	maxComplexity int
+}
+
+// costs глубина и сложность операции
+type costs struct {
+	depth      int
+	complexity int
+}
+
+// check считает глубину и сложность выбранной операции документа. Документ уже прошел
+// валидацию, поэтому фрагменты существуют и не образуют циклов.
+func (l limits) check(schema graphql.Schema, doc *ast.Document, operationName string) error {
+	op, fragments, err := operation(doc, operationName)
+	if err != nil {
+		return err
+	}
+
+	var root *graphql.Object
+	switch op.Operation {
+	case ast.OperationTypeQuery:
+		root = schema.QueryType()
+	case ast.OperationTypeMutation:
+		root = schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = schema.SubscriptionType()
+	}
+
+	c := selectionCost(schema, fragments, op.SelectionSet, root, 1)
+	if l.maxDepth > 0 && c.depth > l.maxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", c.depth, l.maxDepth)
+	}
+	if l.maxComplexity > 0 && c.complexity > l.maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", c.complexity, l.maxComplexity)
+	}
+	return nil
+}
+
+// operation находит операцию по имени (или единственную) и фрагменты документа
+func operation(doc *ast.Document, name string) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition, error) {
+	var op *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if name == "" && op != nil {
+				return nil, nil, fmt.Errorf("must provide operation name if query contains multiple operations")
+			}
+			if name == "" || (def.Name != nil && def.Name.Value == name) {
+				op = def
+			}
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+	if op == nil {
+		return nil, nil, fmt.Errorf("unknown operation named %q", name)
+	}
+	return op, fragments, nil
+}
+
+// selectionCost обходит выборку. Поля интроспекции (__schema, __type) учитываются
+// только в глубине: их стоимость ограничена самой схемой.
+func selectionCost(schema graphql.Schema, fragments map[string]*ast.FragmentDefinition, set *ast.SelectionSet, parent graphql.Type, depth int) costs {
+	var total costs
+	if set == nil {
+		return total
+	}
+
+	for _, selection := range set.Selections {
+		var c costs
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			fieldType, list := fieldInfo(parent, name)
+			child := selectionCost(schema, fragments, selection.SelectionSet, fieldType, depth+1)
+			c.depth = depth
+			if child.depth > c.depth {
+				c.depth = child.depth
+			}
+			if !strings.HasPrefix(name, "__") {
+				multiplier := 1
+				if list {
+					multiplier = listCost
+				}
+				c.complexity = 1 + child.complexity*multiplier
+			}
+		case *ast.InlineFragment:
+			c = selectionCost(schema, fragments, selection.SelectionSet, typeCondition(schema, selection.TypeCondition, parent), depth)
+		case *ast.FragmentSpread:
+			if fragment, ok := fragments[selection.Name.Value]; ok {
+				c = selectionCost(schema, fragments, fragment.SelectionSet, typeCondition(schema, fragment.TypeCondition, parent), depth)
+			}
+		}
+
+		if c.depth > total.depth {
+			total.depth = c.depth
+		}
+		total.complexity += c.complexity
+	}
+	return total
+}
+
+// fieldInfo возвращает именованный тип поля и признак списка. Для полей,
+// которых нет в схеме (интроспекция), тип неизвестен.
+func fieldInfo(parent graphql.Type, name string) (graphql.Type, bool) {
+	var fields graphql.FieldDefinitionMap
+	switch parent := parent.(type) {
+	case *graphql.Object:
+		fields = parent.Fields()
+	case *graphql.Interface:
+		fields = parent.Fields()
+	default:
+		return nil, false
+	}
+
+	def, ok := fields[name]
+	if !ok {
+		return nil, false
+	}
+
+	t := def.Type
+	list := false
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+			continue
+		case *graphql.List:
+			list = true
+			t = wrapped.OfType
+			continue
+		}
+		return t, list
+	}
+}
+
+func typeCondition(schema graphql.Schema, named *ast.Named, parent graphql.Type) graphql.Type {
+	if named == nil {
+		return parent
+	}
+	return schema.Type(named.Name.Value)
+}
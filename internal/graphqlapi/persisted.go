@@ -0,0 +1,54 @@
+package graphqlapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// persistedQueries кеш автоматически сохраняемых запросов (Apollo APQ): клиент
+// отправляет sha256 текста запроса, а полный текст — только если сервер его не знает.
+// При переполнении вытесняются самые старые записи.
+type persistedQueries struct {
+	mu      sync.Mutex
+	size    int
+	queries map[string]string
+	order   []string
+}
+
+func newPersistedQueries(size int) *persistedQueries {
+	return &persistedQueries{
+		size:    size,
+		queries: make(map[string]string),
+	}
+}
+
+func (p *persistedQueries) get(hash string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	query, ok := p.queries[hash]
+	return query, ok
+}
+
+func (p *persistedQueries) put(hash, query string) {
+	if p.size <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.queries[hash]; ok {
+		return
+	}
+	for len(p.order) >= p.size {
+		delete(p.queries, p.order[0])
+		p.order = p.order[1:]
+	}
+	p.queries[hash] = query
+	p.order = append(p.order, hash)
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
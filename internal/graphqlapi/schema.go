@@ -0,0 +1,228 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/dataloader"
+)
+
+// loaders пакетные загрузчики связей user_film на время одного запроса
+type loaders struct {
+	userFilms   *dataloader.Loader[string, []films.Film]
+	filmViewers *dataloader.Loader[string, []user.User]
+}
+
+type loadersKey struct{}
+
+func newLoaders(users user.UserRepository, filmStorage films.FilmRepository) *loaders {
+	return &loaders{
+		userFilms:   dataloader.New(filmStorage.FindByUserIDs),
+		filmViewers: dataloader.New(users.FindByFilmIDs),
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
+
+// requireScope проверяет область доступа субъекта запроса так же, как auth.RequireScope
+func requireScope(ctx context.Context, scope string) error {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil || !principal.HasScope(scope) {
+		return fmt.Errorf("missing required scope %s", scope)
+	}
+	return nil
+}
+
+// newSchema описывает типы User и Film со связью user_film в обе стороны:
+// User.films и Film.viewers загружаются пакетно через loaders
+func newSchema(users user.UserRepository, filmStorage films.FilmRepository) (graphql.Schema, error) {
+	filmType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Film",
+		Description: "Фильм",
+		Fields: graphql.Fields{
+			"id":          filmField(graphql.NewNonNull(graphql.ID), func(f films.Film) interface{} { return f.ID }),
+			"title":       filmField(graphql.NewNonNull(graphql.String), func(f films.Film) interface{} { return f.Title }),
+			"description": filmField(graphql.NewNonNull(graphql.String), func(f films.Film) interface{} { return f.Description }),
+			"rating":      filmField(graphql.NewNonNull(graphql.Float), func(f films.Film) interface{} { return f.Rating }),
+			"releaseDate": filmField(graphql.DateTime, func(f films.Film) interface{} { return f.ReleaseDate }),
+			"createdAt":   filmField(graphql.DateTime, func(f films.Film) interface{} { return f.CreatedAt }),
+			"updatedAt":   filmField(graphql.DateTime, func(f films.Film) interface{} { return f.UpdatedAt }),
+		},
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "Пользователь",
+		Fields: graphql.Fields{
+			"id":          userField(graphql.NewNonNull(graphql.ID), func(u user.User) interface{} { return u.ID }),
+			"name":        userField(graphql.NewNonNull(graphql.String), func(u user.User) interface{} { return u.Name }),
+			"email":       userField(graphql.NewNonNull(graphql.String), func(u user.User) interface{} { return u.Email }),
+			"dateOfBirth": userField(graphql.DateTime, func(u user.User) interface{} { return u.DateOfBirth }),
+			"gender":      userField(graphql.NewNonNull(graphql.String), func(u user.User) interface{} { return u.Gender }),
+			"createdAt":   userField(graphql.DateTime, func(u user.User) interface{} { return u.CreatedAt }),
+			"updatedAt":   userField(graphql.DateTime, func(u user.User) interface{} { return u.UpdatedAt }),
+			"films": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(filmType))),
+				Description: "Фильмы, связанные с пользователем",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := requireScope(p.Context, auth.ScopeFilmsRead); err != nil {
+						return nil, err
+					}
+					u := p.Source.(user.User)
+					thunk := loadersFrom(p.Context).userFilms.Load(p.Context, u.ID)
+					return func() (interface{}, error) {
+						list, err := thunk()
+						if err != nil {
+							return nil, err
+						}
+						if list == nil {
+							list = make([]films.Film, 0)
+						}
+						return list, nil
+					}, nil
+				},
+			},
+		},
+	})
+
+	filmType.AddFieldConfig("viewers", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+		Description: "Пользователи, связанные с фильмом",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if err := requireScope(p.Context, auth.ScopeUsersRead); err != nil {
+				return nil, err
+			}
+			f := p.Source.(films.Film)
+			thunk := loadersFrom(p.Context).filmViewers.Load(p.Context, f.ID)
+			return func() (interface{}, error) {
+				list, err := thunk()
+				if err != nil {
+					return nil, err
+				}
+				if list == nil {
+					list = make([]user.User, 0)
+				}
+				return list, nil
+			}, nil
+		},
+	})
+
+	filmSort := graphql.NewEnum(graphql.EnumConfig{
+		Name:        "FilmSort",
+		Description: "Порядок списка фильмов",
+		Values: graphql.EnumValueConfigMap{
+			"TITLE": &graphql.EnumValueConfig{
+				Value:       "title",
+				Description: "По названию, затем по рейтингу и дате выхода",
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := requireScope(p.Context, auth.ScopeUsersRead); err != nil {
+						return nil, err
+					}
+					u, err := users.FindOne(p.Context, p.Args["id"].(string))
+					if errors.Is(err, user.ErrNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, errors.New("failed to find user")
+					}
+					return *u, nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := requireScope(p.Context, auth.ScopeUsersRead); err != nil {
+						return nil, err
+					}
+					list, err := users.FindAll(p.Context)
+					if err != nil {
+						return nil, errors.New("failed to fetch users")
+					}
+					return list, nil
+				},
+			},
+			"film": &graphql.Field{
+				Type: filmType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := requireScope(p.Context, auth.ScopeFilmsRead); err != nil {
+						return nil, err
+					}
+					f, err := filmStorage.FindByID(p.Context, p.Args["id"].(string))
+					if errors.Is(err, films.ErrNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, errors.New("failed to find film")
+					}
+					return *f, nil
+				},
+			},
+			"films": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(filmType))),
+				Args: graphql.FieldConfigArgument{
+					"sort": &graphql.ArgumentConfig{Type: filmSort},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := requireScope(p.Context, auth.ScopeFilmsRead); err != nil {
+						return nil, err
+					}
+					var (
+						list []films.Film
+						err  error
+					)
+					if p.Args["sort"] == "title" {
+						list, err = filmStorage.FindAllSort(p.Context)
+					} else {
+						list, err = filmStorage.FindAll(p.Context)
+					}
+					if err != nil {
+						return nil, errors.New("failed to fetch films")
+					}
+					return list, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func filmField(t graphql.Output, get func(films.Film) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(films.Film)), nil
+		},
+	}
+}
+
+func userField(t graphql.Output, get func(user.User) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(user.User)), nil
+		},
+	}
+}
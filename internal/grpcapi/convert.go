@@ -0,0 +1,143 @@
+package grpcapi
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/user"
+	moviesv1 "rest-api-tutorial/pkg/pb/movies/v1"
+	"time"
+)
+
+// validate проверяет модель теми же правилами binding, что и тело REST-запроса
+func validate(model interface{}) error {
+	if err := binding.Validator.ValidateStruct(model); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid request: %v", err)
+	}
+	return nil
+}
+
+// validateID проверяет, что идентификатор из запроса — UUID. Иначе хранилище
+// получило бы ошибку приведения типа и вызов завершился бы с Internal.
+func validateID(kind, raw string) error {
+	if _, err := uuid.FromString(raw); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid %s id %q", kind, raw)
+	}
+	return nil
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// fromTimestamp переводит отсутствующую дату в нулевое время, как при разборе JSON
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func userToProto(u user.User) *moviesv1.User {
+	filmIDs := make([]string, 0, len(u.FilmUUID))
+	for _, id := range u.FilmUUID {
+		filmIDs = append(filmIDs, id.String())
+	}
+	return &moviesv1.User{
+		Id:          u.ID,
+		Name:        u.Name,
+		Email:       u.Email,
+		DateOfBirth: timestamp(u.DateOfBirth),
+		Gender:      u.Gender,
+		CreatedAt:   timestamp(u.CreatedAt),
+		UpdatedAt:   timestamp(u.UpdatedAt),
+		FilmIds:     filmIDs,
+	}
+}
+
+func userFromProto(in *moviesv1.User) (user.User, error) {
+	if in == nil {
+		return user.User{}, status.Error(codes.InvalidArgument, "user is required")
+	}
+	filmIDs := make([]uuid.UUID, 0, len(in.GetFilmIds()))
+	for _, raw := range in.GetFilmIds() {
+		id, err := uuid.FromString(raw)
+		if err != nil {
+			return user.User{}, status.Errorf(codes.InvalidArgument, "Invalid film id %q", raw)
+		}
+		filmIDs = append(filmIDs, id)
+	}
+	return user.User{
+		ID:          in.GetId(),
+		Name:        in.GetName(),
+		Email:       in.GetEmail(),
+		DateOfBirth: fromTimestamp(in.GetDateOfBirth()),
+		Gender:      in.GetGender(),
+		FilmUUID:    filmIDs,
+	}, nil
+}
+
+func userUpdateFromProto(in *moviesv1.UserUpdate) user.Update {
+	return user.Update{
+		Name:        in.Name,
+		Email:       in.Email,
+		DateOfBirth: optionalTime(in.GetDateOfBirth()),
+		Gender:      in.Gender,
+	}
+}
+
+func filmToProto(f films.Film) *moviesv1.Film {
+	return &moviesv1.Film{
+		Id:          f.ID,
+		Title:       f.Title,
+		Description: f.Description,
+		Rating:      f.Rating,
+		ReleaseDate: timestamp(f.ReleaseDate),
+		CreatedAt:   timestamp(f.CreatedAt),
+		UpdatedAt:   timestamp(f.UpdatedAt),
+	}
+}
+
+func filmsToProto(list []films.Film) []*moviesv1.Film {
+	out := make([]*moviesv1.Film, 0, len(list))
+	for _, f := range list {
+		out = append(out, filmToProto(f))
+	}
+	return out
+}
+
+func filmFromProto(in *moviesv1.Film) (films.Film, error) {
+	if in == nil {
+		return films.Film{}, status.Error(codes.InvalidArgument, "film is required")
+	}
+	return films.Film{
+		ID:          in.GetId(),
+		Title:       in.GetTitle(),
+		Description: in.GetDescription(),
+		Rating:      in.GetRating(),
+		ReleaseDate: fromTimestamp(in.GetReleaseDate()),
+	}, nil
+}
+
+func filmUpdateFromProto(in *moviesv1.FilmUpdate) films.UpdateFilm {
+	return films.UpdateFilm{
+		Title:       in.Title,
+		Description: in.Description,
+		Rating:      in.Rating,
+		ReleaseDate: optionalTime(in.GetReleaseDate()),
+	}
+}
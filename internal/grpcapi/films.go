@@ -0,0 +1,137 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/pkg/logging"
+	moviesv1 "rest-api-tutorial/pkg/pb/movies/v1"
+	"time"
+)
+
+// FilmServer реализация moviesv1.FilmServiceServer поверх films.FilmRepository
+type FilmServer struct {
+	moviesv1.UnimplementedFilmServiceServer
+	logger  *logging.Logger
+	storage films.FilmRepository
+}
+
+func NewFilmServer(storage films.FilmRepository, logger *logging.Logger) *FilmServer {
+	return &FilmServer{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+func (s *FilmServer) CreateFilm(ctx context.Context, req *moviesv1.CreateFilmRequest) (*moviesv1.Film, error) {
+	newFilm, err := filmFromProto(req.GetFilm())
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(&newFilm); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to generate ID")
+	}
+	newFilm.ID = id.String()
+	newFilm.CreatedAt = time.Now()
+	newFilm.UpdatedAt = newFilm.CreatedAt
+
+	if err := s.storage.Create(ctx, newFilm); err != nil {
+		return nil, s.error(err, "Failed to create film card")
+	}
+	return filmToProto(newFilm), nil
+}
+
+func (s *FilmServer) ListFilms(ctx context.Context, req *moviesv1.ListFilmsRequest) (*moviesv1.ListFilmsResponse, error) {
+	var (
+		list []films.Film
+		err  error
+	)
+	switch req.GetSort() {
+	case "":
+		list, err = s.storage.FindAll(ctx)
+	case "title":
+		list, err = s.storage.FindAllSort(ctx)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "Invalid sort order, expected title")
+	}
+	if err != nil {
+		return nil, s.error(err, "Failed to fetch films")
+	}
+	return &moviesv1.ListFilmsResponse{Films: filmsToProto(list)}, nil
+}
+
+func (s *FilmServer) GetFilm(ctx context.Context, req *moviesv1.GetFilmRequest) (*moviesv1.Film, error) {
+	if err := validateID("film", req.GetId()); err != nil {
+		return nil, err
+	}
+	return s.find(ctx, req.GetId())
+}
+
+func (s *FilmServer) ListUserFilms(ctx context.Context, req *moviesv1.ListUserFilmsRequest) (*moviesv1.ListFilmsResponse, error) {
+	if err := validateID("user", req.GetUserId()); err != nil {
+		return nil, err
+	}
+	list, err := s.storage.FindOne(ctx, req.GetUserId())
+	if err != nil {
+		return nil, s.error(err, "Failed to find films")
+	}
+	return &moviesv1.ListFilmsResponse{Films: filmsToProto(list)}, nil
+}
+
+func (s *FilmServer) PartiallyUpdateFilm(ctx context.Context, req *moviesv1.PartiallyUpdateFilmRequest) (*moviesv1.Film, error) {
+	if err := validateID("film", req.GetId()); err != nil {
+		return nil, err
+	}
+	if req.GetFilm() == nil {
+		return nil, status.Error(codes.InvalidArgument, "film is required")
+	}
+	input := filmUpdateFromProto(req.GetFilm())
+	if err := validate(&input); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.PartialUpdate(ctx, req.GetId(), input); err != nil {
+		return nil, s.error(err, "Failed to update film card")
+	}
+	return s.find(ctx, req.GetId())
+}
+
+func (s *FilmServer) DeleteFilm(ctx context.Context, req *moviesv1.DeleteFilmRequest) (*emptypb.Empty, error) {
+	if err := validateID("film", req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Delete(ctx, req.GetId()); err != nil {
+		return nil, s.error(err, "Failed to delete film")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *FilmServer) find(ctx context.Context, id string) (*moviesv1.Film, error) {
+	f, err := s.storage.FindByID(ctx, id)
+	if err != nil {
+		return nil, s.error(err, "Failed to find film")
+	}
+	return filmToProto(*f), nil
+}
+
+// error переводит ошибку хранилища в статус gRPC; fallback уходит клиенту при Internal
+func (s *FilmServer) error(err error, fallback string) error {
+	switch {
+	case errors.Is(err, films.ErrNotFound):
+		return status.Error(codes.NotFound, "Film not found")
+	case errors.Is(err, films.ErrConflict):
+		return status.Error(codes.AlreadyExists, "Film with this title already exists")
+	default:
+		s.logger.Errorf("%s: %v", fallback, err)
+		return status.Error(codes.Internal, fallback)
+	}
+}
@@ -0,0 +1,50 @@
+package grpcapi
+
+import (
+	"google.golang.org/grpc"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/logging"
+	moviesv1 "rest-api-tutorial/pkg/pb/movies/v1"
+)
+
+// Options зависимости gRPC-сервера: те же хранилища и аутентификация, что у REST
+type Options struct {
+	Users user.UserRepository
+	Films films.FilmRepository
+	Keys  auth.KeyAuthenticator
+	Auth  auth.Options
+	// Tenants определяет арендатора вызова; nil — без разделения каталогов
+	Tenants *tenant.Resolver
+}
+
+// scopes области доступа методов, как у соответствующих маршрутов REST
+var scopes = map[string]string{
+	moviesv1.UserService_CreateUser_FullMethodName:          auth.ScopeUsersWrite,
+	moviesv1.UserService_ListUsers_FullMethodName:           auth.ScopeUsersRead,
+	moviesv1.UserService_GetUser_FullMethodName:             auth.ScopeUsersRead,
+	moviesv1.UserService_UpdateUser_FullMethodName:          auth.ScopeUsersWrite,
+	moviesv1.UserService_PartiallyUpdateUser_FullMethodName: auth.ScopeUsersWrite,
+	moviesv1.UserService_DeleteUser_FullMethodName:          auth.ScopeUsersWrite,
+
+	moviesv1.FilmService_CreateFilm_FullMethodName:          auth.ScopeFilmsWrite,
+	moviesv1.FilmService_ListFilms_FullMethodName:           auth.ScopeFilmsRead,
+	moviesv1.FilmService_GetFilm_FullMethodName:             auth.ScopeFilmsRead,
+	moviesv1.FilmService_ListUserFilms_FullMethodName:       auth.ScopeFilmsRead,
+	moviesv1.FilmService_PartiallyUpdateFilm_FullMethodName: auth.ScopeFilmsWrite,
+	moviesv1.FilmService_DeleteFilm_FullMethodName:          auth.ScopeFilmsWrite,
+}
+
+// NewServer собирает gRPC-сервер с сервисами пользователей и фильмов
+func NewServer(opts Options, logger *logging.Logger) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{auth.UnaryServerInterceptor(opts.Keys, opts.Auth, scopes, logger)}
+	if opts.Tenants != nil {
+		interceptors = append(interceptors, tenant.UnaryServerInterceptor(opts.Tenants, logger))
+	}
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	moviesv1.RegisterUserServiceServer(server, NewUserServer(opts.Users, logger))
+	moviesv1.RegisterFilmServiceServer(server, NewFilmServer(opts.Films, logger))
+	return server
+}
@@ -0,0 +1,141 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/logging"
+	moviesv1 "rest-api-tutorial/pkg/pb/movies/v1"
+	"time"
+)
+
+// UserServer реализация moviesv1.UserServiceServer поверх user.UserRepository
+type UserServer struct {
+	moviesv1.UnimplementedUserServiceServer
+	logger  *logging.Logger
+	storage user.UserRepository
+}
+
+func NewUserServer(storage user.UserRepository, logger *logging.Logger) *UserServer {
+	return &UserServer{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *moviesv1.CreateUserRequest) (*moviesv1.User, error) {
+	newUser, err := userFromProto(req.GetUser())
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(&newUser); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to generate ID")
+	}
+	newUser.ID = id.String()
+	newUser.CreatedAt = time.Now()
+	newUser.UpdatedAt = newUser.CreatedAt
+
+	if err := s.storage.Create(ctx, newUser); err != nil {
+		return nil, s.error(err, "Failed to create user")
+	}
+	return userToProto(newUser), nil
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, _ *moviesv1.ListUsersRequest) (*moviesv1.ListUsersResponse, error) {
+	users, err := s.storage.FindAll(ctx)
+	if err != nil {
+		return nil, s.error(err, "Failed to fetch users")
+	}
+	resp := &moviesv1.ListUsersResponse{Users: make([]*moviesv1.User, 0, len(users))}
+	for _, u := range users {
+		resp.Users = append(resp.Users, userToProto(u))
+	}
+	return resp, nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *moviesv1.GetUserRequest) (*moviesv1.User, error) {
+	if err := validateID("user", req.GetId()); err != nil {
+		return nil, err
+	}
+	return s.find(ctx, req.GetId())
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *moviesv1.UpdateUserRequest) (*moviesv1.User, error) {
+	if err := validateID("user", req.GetId()); err != nil {
+		return nil, err
+	}
+	input, err := userFromProto(req.GetUser())
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(&input); err != nil {
+		return nil, err
+	}
+	input.ID = req.GetId()
+	input.UpdatedAt = time.Now()
+
+	if err := s.storage.Update(ctx, req.GetId(), input); err != nil {
+		return nil, s.error(err, "Failed to update user")
+	}
+	return s.find(ctx, req.GetId())
+}
+
+func (s *UserServer) PartiallyUpdateUser(ctx context.Context, req *moviesv1.PartiallyUpdateUserRequest) (*moviesv1.User, error) {
+	if err := validateID("user", req.GetId()); err != nil {
+		return nil, err
+	}
+	if req.GetUser() == nil {
+		return nil, status.Error(codes.InvalidArgument, "user is required")
+	}
+	input := userUpdateFromProto(req.GetUser())
+	if err := validate(&input); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.PartialUpdate(ctx, req.GetId(), input); err != nil {
+		return nil, s.error(err, "Failed to update user")
+	}
+	return s.find(ctx, req.GetId())
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *moviesv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := validateID("user", req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Delete(ctx, req.GetId()); err != nil {
+		return nil, s.error(err, "Failed to delete user")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) find(ctx context.Context, id string) (*moviesv1.User, error) {
+	u, err := s.storage.FindOne(ctx, id)
+	if err != nil {
+		return nil, s.error(err, "Failed to find user")
+	}
+	return userToProto(*u), nil
+}
+
+// error переводит ошибку хранилища в статус gRPC; fallback уходит клиенту при Internal
+func (s *UserServer) error(err error, fallback string) error {
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		return status.Error(codes.NotFound, "User not found")
+	case errors.Is(err, user.ErrConflict):
+		return status.Error(codes.AlreadyExists, "User with this email already exists")
+	case errors.Is(err, user.ErrFilmNotFound):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		s.logger.Errorf("%s: %v", fallback, err)
+		return status.Error(codes.Internal, fallback)
+	}
+}
@@ -0,0 +1,253 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+const (
+	// HeaderKey заголовок, в котором клиент передает ключ идемпотентности
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed выставляется в ответах, взятых из сохраненного результата
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength  = 255
+	pollInterval  = 100 * time.Millisecond
+	pruneInterval = 10 * time.Minute
+)
+
+// replayHeaders заголовки ответа, которые сохраняются и возвращаются при повторе
+var replayHeaders = []string{"Content-Type", "Location"}
+
+// Options настройки хранения ключей идемпотентности
+type Options struct {
+	// TTL сколько хранится ответ на запрос с ключом
+	TTL time.Duration
+	// LockTimeout сколько параллельный повтор ждет завершения первого запроса
+	LockTimeout time.Duration
+	// StaleAfter через сколько незавершенный запрос считается брошенным. Пока
+	// обработчик работает, блокировка продлевается каждые StaleAfter/3, поэтому
+	// долгий запрос не забирается повтором.
+	StaleAfter time.Duration
+	// MaxBodyBytes сколько байт тела читается для отпечатка запроса; 0 — без ограничения.
+	// Совпадает с лимитом обработчиков, чтобы заголовок не позволял его обойти.
+	MaxBodyBytes int64
+}
+
+// Middleware делает POST-обработчик идемпотентным по заголовку Idempotency-Key.
+// Первый запрос с ключом выполняется и его ответ сохраняется на TTL; повтор с тем же
+// телом получает сохраненный ответ, а с другим телом — 422. Параллельный повтор
+// ждет завершения первого запроса не дольше LockTimeout и затем получает 409.
+// Ответы 5xx не сохраняются, чтобы клиент мог повторить запрос.
+// Ключ действует в пределах арендатора, субъекта и маршрута, поэтому middleware
+// подключается после аутентификации.
+func Middleware(storage *Storage, opts Options, logger *logging.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		if opts.MaxBodyBytes > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, opts.MaxBodyBytes)
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error": fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit),
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		// Результат сохраняется даже если клиент отключился, не дождавшись ответа
+		saveCtx := context.WithoutCancel(ctx)
+		scope := c.Request.Method + " " + c.FullPath()
+		// Клиенты разных каталогов могут выбрать одинаковые ключи
+		if tenantID, ok := tenant.FromContext(ctx); ok {
+			scope = tenantID + " " + scope
+		}
+		// Ключ принадлежит субъекту: другой клиент того же каталога не получит чужой ответ
+		if principal := auth.PrincipalFrom(c); principal != nil {
+			scope = principal.Type + ":" + principal.ID + " " + scope
+		}
+		fingerprint := fingerprintOf(c.Request, body)
+		deadline := time.Now().Add(opts.LockTimeout)
+
+		for {
+			rec, acquired, err := storage.Acquire(ctx, scope, key, fingerprint, opts.TTL)
+			if err != nil {
+				logger.Errorf("Failed to acquire idempotency key: %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+				return
+			}
+			if acquired {
+				break
+			}
+
+			if rec.Fingerprint != fingerprint {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "Idempotency-Key was already used with a different request",
+				})
+				return
+			}
+
+			if rec.StatusCode != 0 {
+				replay(c, rec)
+				return
+			}
+
+			// Первый запрос еще выполняется: ждем его результата или забираем
+			// ключ, если обработчик так и не завершился
+			if took, err := storage.TakeOver(ctx, scope, key, opts.StaleAfter); err != nil {
+				logger.Errorf("Failed to take over idempotency key: %v", err)
+			} else if took {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": "A request with this Idempotency-Key is still in progress",
+				})
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				c.Abort()
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// Обработчик запаниковал или ответ не сохранен — освобождаем ключ
+			if err := storage.Release(saveCtx, scope, key); err != nil {
+				logger.Errorf("Failed to release idempotency key: %v", err)
+			}
+		}()
+
+		stop := keepLocked(saveCtx, storage, scope, key, opts.StaleAfter, logger)
+		defer stop()
+
+		c.Next()
+		stop()
+
+		status := rec.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		header := make(http.Header)
+		for _, name := range replayHeaders {
+			if v := rec.Header().Get(name); v != "" {
+				header.Set(name, v)
+			}
+		}
+
+		if err := storage.Complete(saveCtx, scope, key, status, header, rec.body.Bytes()); err != nil {
+			logger.Errorf("Failed to save idempotent response: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// keepLocked продлевает блокировку ключа, пока не вызвана stop. Повторный вызов stop
+// ничего не делает.
+func keepLocked(ctx context.Context, storage *Storage, scope, key string, staleAfter time.Duration, logger *logging.Logger) (stop func()) {
+	if staleAfter <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(staleAfter / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := storage.Touch(ctx, scope, key); err != nil && ctx.Err() == nil {
+					logger.Errorf("Failed to extend idempotency key lock: %v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func replay(c *gin.Context, rec *Record) {
+	for name, values := range rec.Header {
+		for _, v := range values {
+			c.Writer.Header().Add(name, v)
+		}
+	}
+	c.Header(HeaderReplayed, "true")
+	c.Status(rec.StatusCode)
+	if len(rec.Body) > 0 {
+		c.Writer.Write(rec.Body)
+	}
+	c.Abort()
+}
+
+// fingerprintOf хеш метода, пути с параметрами и тела запроса
+func fingerprintOf(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder копирует тело ответа, продолжая писать его клиенту
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,28 @@
+package idempotency
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"rest-api-tutorial/pkg/logging"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// До хранилища запрос не доходит: тело отклоняется раньше
+	router.POST("/films", Middleware(nil, Options{MaxBodyBytes: 16}, logging.GetLogger()), func(c *gin.Context) {
+		t.Error("handler must not run for an oversized body")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/films", strings.NewReader(strings.Repeat("x", 17)))
+	req.Header.Set(HeaderKey, "key-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want 413: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,166 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"net/http"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+// Record сохраненный запрос с ключом идемпотентности.
+// StatusCode равен нулю, пока первый запрос еще выполняется.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+// Acquire пытается занять ключ для нового запроса. Если ключ уже занят,
+// возвращается существующая запись и acquired = false.
+// Просроченные записи удаляются перед попыткой.
+func (s *Storage) Acquire(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	qExpired := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND expires_at < NOW()`
+	if _, err := s.client.Exec(ctx, qExpired, scope, key); err != nil {
+		return nil, false, fmt.Errorf("failed to delete expired idempotency key: %w", err)
+	}
+
+	qInsert := `
+        INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at)
+        VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+        ON CONFLICT (scope, key) DO NOTHING
+    `
+	tag, err := s.client.Exec(ctx, qInsert, scope, key, fingerprint, ttl.Seconds())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	q := `
+        SELECT scope, key, fingerprint, COALESCE(status_code, 0), response_header, response_body
+        FROM idempotency_keys
+        WHERE scope = $1 AND key = $2
+    `
+	var rec Record
+	err = s.client.QueryRow(ctx, q, scope, key).Scan(
+		&rec.Scope,
+		&rec.Key,
+		&rec.Fingerprint,
+		&rec.StatusCode,
+		&rec.Header,
+		&rec.Body,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Запись успели удалить между INSERT и SELECT — пробуем еще раз
+			return s.Acquire(ctx, scope, key, fingerprint, ttl)
+		}
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &rec, false, nil
+}
+
+// TakeOver забирает ключ у запроса, который не завершился за staleAfter
+// (например, реплика упала посреди обработки)
+func (s *Storage) TakeOver(ctx context.Context, scope, key string, staleAfter time.Duration) (bool, error) {
+	q := `
+        UPDATE idempotency_keys
+        SET locked_at = NOW()
+        WHERE scope = $1 AND key = $2
+          AND status_code IS NULL
+          AND locked_at < NOW() - make_interval(secs => $3)
+    `
+	tag, err := s.client.Exec(ctx, q, scope, key, staleAfter.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to take over idempotency key: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Touch продлевает блокировку ключа, пока первый запрос еще выполняется
+func (s *Storage) Touch(ctx context.Context, scope, key string) error {
+	q := `
+        UPDATE idempotency_keys
+        SET locked_at = NOW()
+        WHERE scope = $1 AND key = $2 AND status_code IS NULL
+    `
+	if _, err := s.client.Exec(ctx, q, scope, key); err != nil {
+		return fmt.Errorf("failed to extend idempotency key lock: %w", err)
+	}
+	return nil
+}
+
+// Complete сохраняет ответ, который будет возвращаться на повторы запроса
+func (s *Storage) Complete(ctx context.Context, scope, key string, status int, header http.Header, body []byte) error {
+	q := `
+        UPDATE idempotency_keys
+        SET status_code = $3, response_header = $4, response_body = $5
+        WHERE scope = $1 AND key = $2
+    `
+	if _, err := s.client.Exec(ctx, q, scope, key, status, header, body); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release освобождает ключ без сохранения ответа, чтобы клиент мог повторить запрос
+func (s *Storage) Release(ctx context.Context, scope, key string) error {
+	q := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+	if _, err := s.client.Exec(ctx, q, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Prune удаляет просроченные ключи. Acquire удаляет только повторно пришедший
+// ключ, остальные просроченные записи чистятся здесь.
+func (s *Storage) Prune(ctx context.Context) (int64, error) {
+	q := `DELETE FROM idempotency_keys WHERE expires_at < NOW()`
+	tag, err := s.client.Exec(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Run периодически удаляет просроченные ключи до отмены ctx
+func (s *Storage) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.Prune(ctx)
+			if err != nil {
+				s.logger.Warnf("Failed to prune idempotency keys: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				s.logger.Infof("Pruned %d expired idempotency keys", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package me
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"strings"
+)
+
+// Options настройки обработчика
+type Options struct {
+	// DefaultLanguage язык пользователя, который его не выбирал
+	DefaultLanguage string
+}
+
+// Handler обслуживает /me: пользователь определяется токеном доступа, а не путем запроса
+type Handler struct {
+	logger  *logging.Logger
+	storage *Storage
+	opts    Options
+}
+
+func NewHandler(storage *Storage, opts Options, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:  logger,
+		storage: storage,
+		opts:    opts,
+	}
+}
+
+// GetProfile godoc
+// @Summary Get my profile
+// @Description Get the profile of the signed-in user
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} envelope.Resource[me.Profile] "Profile"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 404 {object} envelope.ErrorResponse "User was deleted"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me [get]
+func (h *Handler) GetProfile(c *gin.Context) {
+	profile, err := h.storage.FindProfile(c.Request.Context(), auth.PrincipalFrom(c).ID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			envelope.Error(c, http.StatusNotFound, "User not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch profile")
+		return
+	}
+	envelope.Data(c, http.StatusOK, *profile)
+}
+
+// UpdateProfile godoc
+// @Summary Update my profile
+// @Description Change name, email, date of birth or gender of the signed-in user. Fields that are not sent stay unchanged. A new email has to be verified again.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateProfile true "Profile fields to change"
+// @Success 200 {object} envelope.Resource[me.Profile] "Updated profile"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 404 {object} envelope.ErrorResponse "User was deleted"
+// @Failure 409 {object} envelope.ErrorResponse "Email already taken"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me [patch]
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var input UpdateProfile
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID := auth.PrincipalFrom(c).ID
+	if err := h.storage.UpdateProfile(ctx, userID, input); err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			envelope.Error(c, http.StatusNotFound, "User not found")
+		case errors.Is(err, ErrConflict):
+			envelope.Error(c, http.StatusConflict, "Email already taken")
+		default:
+			envelope.Error(c, http.StatusInternalServerError, "Failed to update profile")
+		}
+		return
+	}
+	h.GetProfile(c)
+}
+
+// GetFilms godoc
+// @Summary List my films
+// @Description List the films in the signed-in user's list, most recently added first
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} envelope.Collection[me.OwnedFilm] "Films"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me/films [get]
+func (h *Handler) GetFilms(c *gin.Context) {
+	owned, err := h.storage.FindFilms(c.Request.Context(), auth.PrincipalFrom(c).ID)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch films")
+		return
+	}
+	envelope.List(c, http.StatusOK, owned)
+}
+
+// AddFilm godoc
+// @Summary Add a film to my list
+// @Description Add a catalog film to the signed-in user's list. Adding a film that is already in the list changes nothing and returns it with 200.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AddFilm true "Film to add"
+// @Success 201 {object} envelope.Resource[me.OwnedFilm] "Film added"
+// @Success 200 {object} envelope.Resource[me.OwnedFilm] "Film already in the list"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 404 {object} envelope.ErrorResponse "User was deleted"
+// @Failure 422 {object} envelope.ErrorResponse "Film does not exist"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me/films [post]
+func (h *Handler) AddFilm(c *gin.Context) {
+	var input AddFilm
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	film, added, err := h.storage.AddFilm(c.Request.Context(), auth.PrincipalFrom(c).ID, input.FilmID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrFilmNotFound):
+			envelope.Error(c, http.StatusUnprocessableEntity, "Film does not exist")
+		case errors.Is(err, ErrNotFound):
+			envelope.Error(c, http.StatusNotFound, "User not found")
+		default:
+			h.logger.Errorf("Failed to add film to user list: %v", err)
+			envelope.Error(c, http.StatusInternalServerError, "Failed to add film")
+		}
+		return
+	}
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	envelope.Data(c, status, *film)
+}
+
+// RemoveFilm godoc
+// @Summary Remove a film from my list
+// @Description Remove a film from the signed-in user's list. The film stays in the catalog.
+// @Tags me
+// @Security BearerAuth
+// @Param id path string true "Film ID (UUID)"
+// @Success 204 "Film removed"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 404 {object} envelope.ErrorResponse "Film is not in the list"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me/films/{id} [delete]
+func (h *Handler) RemoveFilm(c *gin.Context) {
+	filmID := c.Param("id")
+	if _, err := uuid.FromString(filmID); err != nil {
+		envelope.Error(c, http.StatusNotFound, "Film is not in the list")
+		return
+	}
+	if err := h.storage.RemoveFilm(c.Request.Context(), auth.PrincipalFrom(c).ID, filmID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			envelope.Error(c, http.StatusNotFound, "Film is not in the list")
+			return
+		}
+		h.logger.Errorf("Failed to remove film from user list: %v", err)
+		envelope.Error(c, http.StatusInternalServerError, "Failed to remove film")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetPreferences godoc
+// @Summary Get my preferences
+// @Description Get language, favourite genres and email subscriptions of the signed-in user. A user who never changed them gets the defaults.
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} envelope.Resource[me.Preferences] "Preferences"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me/preferences [get]
+func (h *Handler) GetPreferences(c *gin.Context) {
+	prefs, err := h.storage.FindPreferences(c.Request.Context(), auth.PrincipalFrom(c).ID)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch preferences")
+		return
+	}
+	envelope.Data(c, http.StatusOK, h.withDefaults(*prefs))
+}
+
+// UpdatePreferences godoc
+// @Summary Update my preferences
+// @Description Change language, favourite genres or email subscriptions of the signed-in user. Fields that are not sent stay unchanged; genres replaces the whole list, an empty list clears it.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdatePreferences true "Preferences to change"
+// @Success 200 {object} envelope.Resource[me.Preferences] "Updated preferences"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 404 {object} envelope.ErrorResponse "User was deleted"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me/preferences [patch]
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	var input UpdatePreferences
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.Genres != nil {
+		input.Genres = normalizeGenres(input.Genres)
+	}
+
+	prefs, err := h.storage.UpdatePreferences(c.Request.Context(), auth.PrincipalFrom(c).ID, input)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			envelope.Error(c, http.StatusNotFound, "User not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to update preferences")
+		return
+	}
+	envelope.Data(c, http.StatusOK, h.withDefaults(*prefs))
+}
+
+// withDefaults подставляет язык по умолчанию, если пользователь его не выбрал
+func (h *Handler) withDefaults(prefs Preferences) Preferences {
+	if prefs.Language == "" {
+		prefs.Language = h.opts.DefaultLanguage
+	}
+	return prefs
+}
+
+// normalizeGenres убирает пробелы по краям, пустые значения и повторы без учета регистра;
+// порядок, в котором пользователь перечислил жанры, сохраняется
+func normalizeGenres(genres []string) []string {
+	seen := make(map[string]bool, len(genres))
+	out := make([]string, 0, len(genres))
+	for _, genre := range genres {
+		genre = strings.TrimSpace(genre)
+		key := strings.ToLower(genre)
+		if genre == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, genre)
+	}
+	return out
+}
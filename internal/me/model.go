@@ -0,0 +1,86 @@
+package me
+
+import (
+	"rest-api-tutorial/internal/films"
+	"time"
+)
+
+// Profile профиль вошедшего пользователя
+// @description Данные пользователя, которому выдан токен доступа
+type Profile struct {
+	// @format uuid
+	ID string `json:"id"`
+
+	Name  string `json:"name" example:"Иванов Иван Иванович"`
+	Email string `json:"email" example:"testemail@example.com"`
+
+	// Адрес подтвержден; смена email снимает подтверждение
+	EmailVerified bool `json:"email_verified"`
+
+	// @format date
+	DateOfBirth time.Time `json:"date_of_birth"`
+	Gender      string    `json:"gender" enums:"М,Ж"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UpdateProfile частичное обновление профиля; отсутствующие поля не меняются
+type UpdateProfile struct {
+	Name        *string    `json:"name" binding:"omitempty,min=1,max=255"`
+	Email       *string    `json:"email" binding:"omitempty,email,max=255"`
+	DateOfBirth *time.Time `json:"date_of_birth"`
+	Gender      *string    `json:"gender" binding:"omitempty,oneof=М Ж" enums:"М,Ж"`
+}
+
+// OwnedFilm фильм из списка пользователя
+// @description Фильм и время его добавления в список
+type OwnedFilm struct {
+	films.FilmV2
+	AddedAt time.Time `json:"added_at"`
+}
+
+// AddFilm запрос добавления фильма в список
+type AddFilm struct {
+	// @format uuid
+	FilmID string `json:"film_id" binding:"required,uuid"`
+}
+
+// Notifications на какие письма подписан пользователь. Письма подтверждения
+// email и восстановления пароля отправляются всегда.
+type Notifications struct {
+	// Новые фильмы каталога
+	NewFilms bool `json:"new_films"`
+	// Подборки рекомендаций
+	Recommendations bool `json:"recommendations"`
+}
+
+// Preferences настройки пользователя
+// @description Язык интерфейса и писем, любимые жанры и подписки на письма
+type Preferences struct {
+	Language string `json:"language" enums:"ru,en" example:"ru"`
+
+	// Любимые жанры в том виде, в каком их ввел пользователь
+	Genres []string `json:"genres" example:"драма,комедия"`
+
+	Notifications Notifications `json:"notifications"`
+
+	// Пусто, пока пользователь не менял настройки
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateNotifications частичное обновление подписок
+type UpdateNotifications struct {
+	NewFilms        *bool `json:"new_films"`
+	Recommendations *bool `json:"recommendations"`
+}
+
+// UpdatePreferences частичное обновление настроек; пустой список genres очищает жанры
+type UpdatePreferences struct {
+	Language *string `json:"language" binding:"omitempty,oneof=ru en" enums:"ru,en"`
+
+	// @maxItems 20
+	Genres []string `json:"genres" binding:"omitempty,max=20,dive,min=1,max=64"`
+
+	Notifications *UpdateNotifications `json:"notifications"`
+}
@@ -0,0 +1,293 @@
+package me
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+var (
+	// ErrNotFound пользователь удален или фильма нет в его списке
+	ErrNotFound = errors.New("not found")
+	// ErrConflict email занят другим пользователем
+	ErrConflict = errors.New("email already taken")
+	// ErrFilmNotFound фильм не существует
+	ErrFilmNotFound = errors.New("film does not exist")
+)
+
+// Коды ошибок PostgreSQL при нарушении ограничений
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+func (s *Storage) FindProfile(ctx context.Context, userID string) (*Profile, error) {
+	q := `
+        SELECT id, name, email, email_verified_at IS NOT NULL, date_of_birth, gender, created_at, updated_at
+        FROM users
+        WHERE id = $1
+    `
+	var profile Profile
+	err := s.client.QueryRow(ctx, q, userID).Scan(
+		&profile.ID,
+		&profile.Name,
+		&profile.Email,
+		&profile.EmailVerified,
+		&profile.DateOfBirth,
+		&profile.Gender,
+		&profile.CreatedAt,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		s.logger.Errorf("Failed to get profile: %v", err)
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	return &profile, nil
+}
+
+func (s *Storage) UpdateProfile(ctx context.Context, userID string, input UpdateProfile) error {
+	q := `
+        UPDATE users
+        SET
+            name = COALESCE($2, name),
+            email = COALESCE($3, email),
+            date_of_birth = COALESCE($4, date_of_birth),
+            gender = COALESCE($5, gender),
+            updated_at = NOW()
+        WHERE id = $1
+    `
+	tag, err := s.client.Exec(ctx, q, userID, input.Name, input.Email, input.DateOfBirth, input.Gender)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrConflict
+		}
+		s.logger.Errorf("Failed to update profile: %v", err)
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindFilms возвращает список фильмов пользователя, последние добавленные первыми
+func (s *Storage) FindFilms(ctx context.Context, userID string) ([]OwnedFilm, error) {
+	q := `
+        SELECT films.film_id, films.title, films.description, films.rating, films.release_date,
+               films.created_at, films.updated_at, user_film.added_at
+        FROM user_film
+        JOIN films ON films.film_id = user_film.film_id
+        WHERE user_film.user_id = $1
+        ORDER BY user_film.added_at DESC, films.title
+    `
+	rows, err := s.client.Query(ctx, q, userID)
+	if err != nil {
+		s.logger.Errorf("Failed to get films for user: %v", err)
+		return nil, fmt.Errorf("failed to get films for user: %w", err)
+	}
+	defer rows.Close()
+
+	owned := make([]OwnedFilm, 0)
+	for rows.Next() {
+		var film OwnedFilm
+		if err := rows.Scan(
+			&film.ID,
+			&film.Title,
+			&film.Description,
+			&film.Rating,
+			&film.ReleaseDate,
+			&film.CreatedAt,
+			&film.UpdatedAt,
+			&film.AddedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan film: %w", err)
+		}
+		owned = append(owned, film)
+	}
+	return owned, rows.Err()
+}
+
+// AddFilm добавляет фильм в список пользователя. Повторное добавление не ошибка:
+// возвращается уже добавленный фильм и added = false.
+func (s *Storage) AddFilm(ctx context.Context, userID, filmID string) (film *OwnedFilm, added bool, err error) {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+        INSERT INTO user_film (user_id, film_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+	tag, err := tx.Exec(ctx, q, userID, filmID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			if pgErr.ConstraintName == "user_film_user_id_fkey" {
+				return nil, false, ErrNotFound
+			}
+			return nil, false, ErrFilmNotFound
+		}
+		return nil, false, fmt.Errorf("failed to insert user-film relation: %w", err)
+	}
+	added = tag.RowsAffected() > 0
+	if added {
+		if err := touchUser(ctx, tx, userID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	qFilm := `
+        SELECT films.film_id, films.title, films.description, films.rating, films.release_date,
+               films.created_at, films.updated_at, user_film.added_at
+        FROM user_film
+        JOIN films ON films.film_id = user_film.film_id
+        WHERE user_film.user_id = $1 AND user_film.film_id = $2
+    `
+	film = &OwnedFilm{}
+	err = tx.QueryRow(ctx, qFilm, userID, filmID).Scan(
+		&film.ID,
+		&film.Title,
+		&film.Description,
+		&film.Rating,
+		&film.ReleaseDate,
+		&film.CreatedAt,
+		&film.UpdatedAt,
+		&film.AddedAt,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get film: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return film, added, nil
+}
+
+// RemoveFilm убирает фильм из списка пользователя
+func (s *Storage) RemoveFilm(ctx context.Context, userID, filmID string) error {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM user_film WHERE user_id = $1 AND film_id = $2`, userID, filmID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user-film relation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if err := touchUser(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// touchUser обновляет updated_at пользователя: список фильмов входит в его
+// представление, и подписчики событий должны узнать об изменении
+func touchUser(ctx context.Context, tx pgx.Tx, userID string) error {
+	if _, err := tx.Exec(ctx, `UPDATE users SET updated_at = NOW() WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// FindPreferences возвращает настройки пользователя; без сохраненных настроек
+// возвращаются значения по умолчанию с пустым языком
+func (s *Storage) FindPreferences(ctx context.Context, userID string) (*Preferences, error) {
+	q := `
+        SELECT COALESCE(language, ''), genres, notify_new_films, notify_recommendations, updated_at
+        FROM user_preferences
+        WHERE user_id = $1
+    `
+	var (
+		prefs     Preferences
+		updatedAt time.Time
+	)
+	err := s.client.QueryRow(ctx, q, userID).Scan(
+		&prefs.Language,
+		&prefs.Genres,
+		&prefs.Notifications.NewFilms,
+		&prefs.Notifications.Recommendations,
+		&updatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &Preferences{Genres: make([]string, 0)}, nil
+		}
+		s.logger.Errorf("Failed to get preferences: %v", err)
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	prefs.UpdatedAt = &updatedAt
+	return &prefs, nil
+}
+
+// UpdatePreferences меняет переданные настройки и возвращает результат.
+// Настройки создаются при первом изменении.
+func (s *Storage) UpdatePreferences(ctx context.Context, userID string, input UpdatePreferences) (*Preferences, error) {
+	var newFilms, recommendations *bool
+	if input.Notifications != nil {
+		newFilms, recommendations = input.Notifications.NewFilms, input.Notifications.Recommendations
+	}
+	q := `
+        INSERT INTO user_preferences (user_id, language, genres, notify_new_films, notify_recommendations)
+        VALUES ($1, $2, COALESCE($3::text[], '{}'), COALESCE($4, false), COALESCE($5, false))
+        ON CONFLICT (tenant_id, user_id) DO UPDATE
+        SET
+            language = COALESCE($2, user_preferences.language),
+            genres = COALESCE($3, user_preferences.genres),
+            notify_new_films = COALESCE($4, user_preferences.notify_new_films),
+            notify_recommendations = COALESCE($5, user_preferences.notify_recommendations),
+            updated_at = NOW()
+        RETURNING COALESCE(language, ''), genres, notify_new_films, notify_recommendations, updated_at
+    `
+	var (
+		prefs     Preferences
+		updatedAt time.Time
+	)
+	err := s.client.QueryRow(ctx, q, userID, input.Language, input.Genres, newFilms, recommendations).Scan(
+		&prefs.Language,
+		&prefs.Genres,
+		&prefs.Notifications.NewFilms,
+		&prefs.Notifications.Recommendations,
+		&updatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return nil, ErrNotFound
+		}
+		s.logger.Errorf("Failed to update preferences: %v", err)
+		return nil, fmt.Errorf("failed to update preferences: %w", err)
+	}
+	prefs.UpdatedAt = &updatedAt
+	return &prefs, nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"errors"
+	"net/http"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/user"
+	"sync"
+	"time"
+)
+
+// Форматы дат, в которых PostgreSQL отдает timestamp и timestamptz в COPY ... CSV
+const (
+	timestampLayout   = "2006-01-02 15:04:05.999999"
+	timestamptzLayout = "2006-01-02 15:04:05.999999-07"
+)
+
+// DB общее состояние in-memory хранилищ. Пользователи, фильмы и связи между
+// ними защищены одной блокировкой, поэтому каскадное удаление и проверка
+// ссылок на фильмы атомарны так же, как в PostgreSQL.
+type DB struct {
+	mu    sync.RWMutex
+	users map[string]user.User
+	films map[string]films.Film
+	// links связи пользователей с фильмами: user id -> множество film id
+	links map[string]map[string]struct{}
+}
+
+func NewDB() *DB {
+	return &DB{
+		users: make(map[string]user.User),
+		films: make(map[string]films.Film),
+		links: make(map[string]map[string]struct{}),
+	}
+}
+
+// snapshot копия состояния для отката пакета или импорта
+type snapshot struct {
+	users map[string]user.User
+	films map[string]films.Film
+	links map[string]map[string]struct{}
+}
+
+// snapshot вызывается под блокировкой на запись
+func (db *DB) snapshot() snapshot {
+	s := snapshot{
+		users: make(map[string]user.User, len(db.users)),
+		films: make(map[string]films.Film, len(db.films)),
+		links: make(map[string]map[string]struct{}, len(db.links)),
+	}
+	for id, u := range db.users {
+		s.users[id] = u
+	}
+	for id, f := range db.films {
+		s.films[id] = f
+	}
+	for userID, filmIDs := range db.links {
+		set := make(map[string]struct{}, len(filmIDs))
+		for filmID := range filmIDs {
+			set[filmID] = struct{}{}
+		}
+		s.links[userID] = set
+	}
+	return s
+}
+
+// restore вызывается под блокировкой на запись
+func (db *DB) restore(s snapshot) {
+	db.users = s.users
+	db.films = s.films
+	db.links = s.links
+}
+
+// unlinkFilm удаляет связи всех пользователей с фильмом (ON DELETE CASCADE)
+func (db *DB) unlinkFilm(filmID string) {
+	for userID, filmIDs := range db.links {
+		delete(filmIDs, filmID)
+		if len(filmIDs) == 0 {
+			delete(db.links, userID)
+		}
+	}
+}
+
+// importRow проверенная строка импорта с номером строки во входном файле
+type importRow struct {
+	num   int
+	value interface{}
+}
+
+// batchStatus переводит ошибку хранилища в статус операции пакета так же,
+// как batchError в PostgreSQL-хранилищах
+func batchStatus(err error) int {
+	switch {
+	case errors.Is(err, user.ErrNotFound), errors.Is(err, films.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, user.ErrConflict), errors.Is(err, films.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, user.ErrFilmNotFound):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.Format(timestampLayout)
+}
+
+func formatTimestamptz(t time.Time) string {
+	return t.Format(timestamptzLayout)
+}
+
+// each передает элементы list в fn, пока fn не вернет ошибку. Вызывается без
+// блокировки, чтобы медленный потребитель не задерживал запись.
+func each[T any](list []T, fn func(T) error) error {
+	for _, item := range list {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
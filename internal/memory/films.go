@@ -0,0 +1,400 @@
+package memory
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FilmStorage реализация films.FilmRepository поверх DB
+type FilmStorage struct {
+	db *DB
+}
+
+var _ films.FilmRepository = (*FilmStorage)(nil)
+
+func NewFilmStorage(db *DB) *FilmStorage {
+	return &FilmStorage{db: db}
+}
+
+func (s *FilmStorage) Create(ctx context.Context, film films.Film) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.create(film)
+}
+
+// create вызывается под блокировкой на запись
+func (s *FilmStorage) create(film films.Film) error {
+	if _, ok := s.db.films[film.ID]; ok {
+		return films.ErrConflict
+	}
+	if s.titleTaken(film.Title, "") {
+		return films.ErrConflict
+	}
+	s.db.films[film.ID] = film
+	return nil
+}
+
+// FindOne возвращает фильмы, связанные с пользователем userID
+func (s *FilmStorage) FindOne(ctx context.Context, userID string) ([]films.Film, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var userFilms []films.Film
+	for filmID := range s.db.links[userID] {
+		userFilms = append(userFilms, s.db.films[filmID])
+	}
+	sort.Slice(userFilms, func(i, j int) bool {
+		return userFilms[i].Title < userFilms[j].Title
+	})
+	return userFilms, nil
+}
+
+func (s *FilmStorage) FindByID(ctx context.Context, id string) (*films.Film, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	film, ok := s.db.films[id]
+	if !ok {
+		return nil, films.ErrNotFound
+	}
+	return &film, nil
+}
+
+// FindByUserIDs возвращает фильмы каждого пользователя, отсортированные по названию
+func (s *FilmStorage) FindByUserIDs(ctx context.Context, userIDs []string) (map[string][]films.Film, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	userFilms := make(map[string][]films.Film, len(userIDs))
+	for _, userID := range userIDs {
+		linked := s.db.links[userID]
+		if len(linked) == 0 {
+			continue
+		}
+		list := make([]films.Film, 0, len(linked))
+		for filmID := range linked {
+			list = append(list, s.db.films[filmID])
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Title != list[j].Title {
+				return list[i].Title < list[j].Title
+			}
+			return list[i].ID < list[j].ID
+		})
+		userFilms[userID] = list
+	}
+	return userFilms, nil
+}
+
+func (s *FilmStorage) FindAll(ctx context.Context) ([]films.Film, error) {
+	list := s.list()
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+// FindAllSort сортирует как ORDER BY title, rating, release_date
+func (s *FilmStorage) FindAllSort(ctx context.Context) ([]films.Film, error) {
+	list := s.list()
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		if a.Rating != b.Rating {
+			return a.Rating < b.Rating
+		}
+		return a.ReleaseDate.Before(b.ReleaseDate)
+	})
+	return list, nil
+}
+
+// StreamAll передает в fn снимок списка FindAll
+func (s *FilmStorage) StreamAll(ctx context.Context, fn func(films.Film) error) error {
+	list, _ := s.FindAll(ctx)
+	return each(list, fn)
+}
+
+// StreamAllSort передает в fn снимок списка FindAllSort
+func (s *FilmStorage) StreamAllSort(ctx context.Context, fn func(films.Film) error) error {
+	list, _ := s.FindAllSort(ctx)
+	return each(list, fn)
+}
+
+func (s *FilmStorage) PartialUpdate(ctx context.Context, id string, input films.UpdateFilm) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.partialUpdate(id, input)
+}
+
+// partialUpdate вызывается под блокировкой на запись
+func (s *FilmStorage) partialUpdate(id string, input films.UpdateFilm) error {
+	film, ok := s.db.films[id]
+	if !ok {
+		return films.ErrNotFound
+	}
+	if input.Title != nil && s.titleTaken(*input.Title, id) {
+		return films.ErrConflict
+	}
+
+	if input.Title != nil {
+		film.Title = *input.Title
+	}
+	if input.Description != nil {
+		film.Description = *input.Description
+	}
+	if input.Rating != nil {
+		film.Rating = *input.Rating
+	}
+	if input.ReleaseDate != nil {
+		film.ReleaseDate = *input.ReleaseDate
+	}
+	film.UpdatedAt = time.Now()
+	s.db.films[id] = film
+	return nil
+}
+
+// Patch передает в apply копию фильма и сохраняет результат, только если
+// apply и проверка уникальности названия прошли успешно
+func (s *FilmStorage) Patch(ctx context.Context, id string, apply func(*films.Film) error) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	current, ok := s.db.films[id]
+	if !ok {
+		return films.ErrNotFound
+	}
+	film := current
+
+	if err := apply(&film); err != nil {
+		return err
+	}
+	if s.titleTaken(film.Title, id) {
+		return films.ErrConflict
+	}
+
+	current.Title = film.Title
+	current.Description = film.Description
+	current.Rating = film.Rating
+	current.ReleaseDate = film.ReleaseDate
+	current.UpdatedAt = film.UpdatedAt
+	s.db.films[id] = current
+	return nil
+}
+
+func (s *FilmStorage) Delete(ctx context.Context, id string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.delete(id)
+}
+
+// delete вызывается под блокировкой на запись
+func (s *FilmStorage) delete(id string) error {
+	if _, ok := s.db.films[id]; !ok {
+		return films.ErrNotFound
+	}
+	delete(s.db.films, id)
+	s.db.unlinkFilm(id)
+	return nil
+}
+
+// Batch выполняет операции пакета через batch.Apply; в режиме atomic при ошибке
+// состояние возвращается к снимку, сделанному до пакета
+func (s *FilmStorage) Batch(ctx context.Context, items []films.BatchItem, resp *batch.Response) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	before := s.db.snapshot()
+	batch.Apply(resp, func(i int) (string, int, error) {
+		item := items[i]
+		switch item.Op {
+		case batch.OpCreate:
+			if err := s.create(item.Film); err != nil {
+				return item.Film.ID, batchStatus(err), err
+			}
+			return item.Film.ID, http.StatusCreated, nil
+		case batch.OpUpdate:
+			if err := s.partialUpdate(item.ID, item.Update); err != nil {
+				return item.ID, batchStatus(err), err
+			}
+			return item.ID, http.StatusNoContent, nil
+		default:
+			if err := s.delete(item.ID); err != nil {
+				return item.ID, batchStatus(err), err
+			}
+			return item.ID, http.StatusNoContent, nil
+		}
+	}, func() {
+		s.db.restore(before)
+	})
+	return nil
+}
+
+// Import повторяет семантику PostgreSQL-импорта: в режиме insert конфликтующие
+// по id или названию строки отклоняются, в режиме upsert фильм с тем же названием
+// обновляется, а из повторов названия в файле берется последний. Файл применяется
+// целиком или не применяется совсем; при DryRun состояние не меняется.
+func (s *FilmStorage) Import(ctx context.Context, reader *bulk.Reader, result *bulk.Result) error {
+	var rows []importRow
+	src := bulk.NewCopySource(reader, result, func(row bulk.Row) ([]interface{}, error) {
+		film, err := films.DecodeImportRow(row)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{film}, nil
+	})
+	for src.Next() {
+		values, _ := src.Values()
+		rows = append(rows, importRow{num: values[0].(int), value: values[1]})
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	before := s.db.snapshot()
+	var err error
+	if result.Upsert {
+		err = s.importUpsert(rows, result)
+	} else {
+		s.importInsert(rows, result)
+	}
+	if err != nil || result.DryRun {
+		s.db.restore(before)
+	}
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+	return nil
+}
+
+func (s *FilmStorage) importInsert(rows []importRow, result *bulk.Result) {
+	now := time.Now()
+	for _, row := range rows {
+		film := row.value.(films.Film)
+		if _, ok := s.db.films[film.ID]; ok || s.titleTaken(film.Title, "") {
+			result.AddError(row.num, fmt.Errorf("film %q or id %s already exists", film.Title, film.ID))
+			continue
+		}
+		film.CreatedAt, film.UpdatedAt = now, now
+		s.db.films[film.ID] = film
+		result.Inserted++
+	}
+}
+
+func (s *FilmStorage) importUpsert(rows []importRow, result *bulk.Result) error {
+	last := make(map[string]int, len(rows))
+	for i, row := range rows {
+		last[row.value.(films.Film).Title] = i
+	}
+
+	now := time.Now()
+	for i, row := range rows {
+		film := row.value.(films.Film)
+		if last[film.Title] != i {
+			result.AddError(row.num, fmt.Errorf("film %q is superseded by a later row", film.Title))
+			continue
+		}
+
+		if existing, ok := s.findByTitle(film.Title); ok {
+			existing.Description = film.Description
+			existing.Rating = film.Rating
+			existing.ReleaseDate = film.ReleaseDate
+			existing.UpdatedAt = now
+			s.db.films[existing.ID] = existing
+			result.Updated++
+			continue
+		}
+		if _, ok := s.db.films[film.ID]; ok {
+			return fmt.Errorf("%w: Key (film_id)=(%s) already exists.", films.ErrConflict, film.ID)
+		}
+		film.CreatedAt, film.UpdatedAt = now, now
+		s.db.films[film.ID] = film
+		result.Inserted++
+	}
+	return nil
+}
+
+// Export пишет фильмы в порядке названия в том же виде, что и PostgreSQL-хранилище
+func (s *FilmStorage) Export(ctx context.Context, format bulk.Format, w io.Writer) error {
+	list := s.list()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Title < list[j].Title
+	})
+
+	if format == bulk.FormatCSV {
+		out := csv.NewWriter(w)
+		if err := out.Write([]string{"film_id", "title", "description", "rating", "release_date", "created_at", "updated_at"}); err != nil {
+			return fmt.Errorf("failed to export films: %w", err)
+		}
+		for _, film := range list {
+			record := []string{
+				film.ID,
+				film.Title,
+				film.Description,
+				strconv.FormatFloat(film.Rating, 'f', 1, 64),
+				formatTimestamp(film.ReleaseDate),
+				formatTimestamptz(film.CreatedAt),
+				formatTimestamptz(film.UpdatedAt),
+			}
+			if err := out.Write(record); err != nil {
+				return fmt.Errorf("failed to export films: %w", err)
+			}
+		}
+		out.Flush()
+		return out.Error()
+	}
+
+	out := bulk.NewWriter(format, w)
+	for _, film := range list {
+		if err := out.Write(film); err != nil {
+			return fmt.Errorf("failed to write film: %w", err)
+		}
+	}
+	return out.Close()
+}
+
+func (s *FilmStorage) list() []films.Film {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var list []films.Film
+	for _, film := range s.db.films {
+		list = append(list, film)
+	}
+	return list
+}
+
+// titleTaken проверяет, занято ли название другим фильмом, кроме exceptID
+func (s *FilmStorage) titleTaken(title, exceptID string) bool {
+	existing, ok := s.findByTitle(title)
+	return ok && existing.ID != exceptID
+}
+
+func (s *FilmStorage) findByTitle(title string) (films.Film, bool) {
+	for _, film := range s.db.films {
+		if film.Title == title {
+			return film, true
+		}
+	}
+	return films.Film{}, false
+}
@@ -0,0 +1,205 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"github.com/gofrs/uuid"
+	"os"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/memory"
+	"rest-api-tutorial/internal/pgtest"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/logging"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m, pgtest.Options{}))
+}
+
+// store пара хранилищ над общими данными и контекст запросов к ним
+type store struct {
+	ctx   context.Context
+	films films.FilmRepository
+	users user.UserRepository
+}
+
+// stores in-memory реализация и PostgreSQL проверяются одними и теми же тестами.
+// Без бинарников PostgreSQL вариант postgres пропускается.
+var stores = map[string]func(t *testing.T) store{
+	"memory": func(t *testing.T) store {
+		db := memory.NewDB()
+		return store{ctx: context.Background(), films: memory.NewFilmStorage(db), users: memory.NewUserStorage(db)}
+	},
+	"postgres": func(t *testing.T) store {
+		pool := pgtest.DB(t)
+		logger := logging.GetLogger()
+		return store{ctx: pgtest.Context(), films: films.NewFilmStorage(pool, logger), users: user.NewUserStorage(pool, logger)}
+	},
+}
+
+func each(t *testing.T, test func(t *testing.T, s store)) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			test(t, open(t))
+		})
+	}
+}
+
+func newID(t *testing.T) string {
+	t.Helper()
+	id, err := uuid.NewV4()
+	if err != nil {
+		t.Fatalf("failed to generate ID: %v", err)
+	}
+	return id.String()
+}
+
+func newFilm(t *testing.T, s store, title string) films.Film {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	film := films.Film{
+		ID:          newID(t),
+		Title:       title,
+		Rating:      7,
+		ReleaseDate: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.films.Create(s.ctx, film); err != nil {
+		t.Fatalf("create film %q: %v", title, err)
+	}
+	return film
+}
+
+func newUser(t *testing.T, email string, filmIDs ...string) user.User {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	u := user.User{
+		ID:          newID(t),
+		Name:        "Иванов Иван",
+		Email:       email,
+		DateOfBirth: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		Gender:      "М",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, id := range filmIDs {
+		u.FilmUUID = append(u.FilmUUID, uuid.FromStringOrNil(id))
+	}
+	return u
+}
+
+func TestFilmTitleConflict(t *testing.T) {
+	each(t, func(t *testing.T, s store) {
+		newFilm(t, s, "Сталкер")
+		other := newFilm(t, s, "Солярис")
+
+		duplicate := other
+		duplicate.ID = newID(t)
+		duplicate.Title = "Сталкер"
+		if err := s.films.Create(s.ctx, duplicate); !errors.Is(err, films.ErrConflict) {
+			t.Errorf("Create with a taken title: got %v, want ErrConflict", err)
+		}
+		title := "Сталкер"
+		if err := s.films.PartialUpdate(s.ctx, other.ID, films.UpdateFilm{Title: &title}); !errors.Is(err, films.ErrConflict) {
+			t.Errorf("PartialUpdate to a taken title: got %v, want ErrConflict", err)
+		}
+	})
+}
+
+func TestUserEmailConflict(t *testing.T) {
+	each(t, func(t *testing.T, s store) {
+		if err := s.users.Create(s.ctx, newUser(t, "ivanov@example.com")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := s.users.Create(s.ctx, newUser(t, "ivanov@example.com")); !errors.Is(err, user.ErrConflict) {
+			t.Errorf("Create with a taken email: got %v, want ErrConflict", err)
+		}
+
+		other := newUser(t, "petrov@example.com")
+		if err := s.users.Create(s.ctx, other); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		email := "ivanov@example.com"
+		if err := s.users.PartialUpdate(s.ctx, other.ID, user.Update{Email: &email}); !errors.Is(err, user.ErrConflict) {
+			t.Errorf("PartialUpdate to a taken email: got %v, want ErrConflict", err)
+		}
+	})
+}
+
+func TestUnknownFilmReference(t *testing.T) {
+	each(t, func(t *testing.T, s store) {
+		u := newUser(t, "ivanov@example.com", newID(t))
+		if err := s.users.Create(s.ctx, u); !errors.Is(err, user.ErrFilmNotFound) {
+			t.Errorf("Create with an unknown film: got %v, want ErrFilmNotFound", err)
+		}
+	})
+}
+
+func TestDeleteCascadesToUserFilms(t *testing.T) {
+	each(t, func(t *testing.T, s store) {
+		kept, deleted := newFilm(t, s, "Сталкер"), newFilm(t, s, "Солярис")
+		u := newUser(t, "ivanov@example.com", kept.ID, deleted.ID)
+		if err := s.users.Create(s.ctx, u); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		if err := s.films.Delete(s.ctx, deleted.ID); err != nil {
+			t.Fatalf("Delete film: %v", err)
+		}
+		got, err := s.users.FindOne(s.ctx, u.ID)
+		if err != nil {
+			t.Fatalf("FindOne: %v", err)
+		}
+		if len(got.FilmUUID) != 1 || got.FilmUUID[0].String() != kept.ID {
+			t.Errorf("films of the user after deleting a film = %v, want only %s", got.FilmUUID, kept.ID)
+		}
+
+		if err := s.users.Delete(s.ctx, u.ID); err != nil {
+			t.Fatalf("Delete user: %v", err)
+		}
+		linked, err := s.films.FindOne(s.ctx, u.ID)
+		if err != nil {
+			t.Fatalf("FindOne films: %v", err)
+		}
+		if len(linked) != 0 {
+			t.Errorf("films of a deleted user = %v, want none", linked)
+		}
+		if _, err := s.films.FindByID(s.ctx, kept.ID); err != nil {
+			t.Errorf("deleting a user must keep the film: %v", err)
+		}
+	})
+}
+
+func TestNotFound(t *testing.T) {
+	each(t, func(t *testing.T, s store) {
+		missing := newID(t)
+
+		if _, err := s.films.FindByID(s.ctx, missing); !errors.Is(err, films.ErrNotFound) {
+			t.Errorf("films.FindByID: got %v, want ErrNotFound", err)
+		}
+		rating := 5.0
+		if err := s.films.PartialUpdate(s.ctx, missing, films.UpdateFilm{Rating: &rating}); !errors.Is(err, films.ErrNotFound) {
+			t.Errorf("films.PartialUpdate: got %v, want ErrNotFound", err)
+		}
+		if err := s.films.Delete(s.ctx, missing); !errors.Is(err, films.ErrNotFound) {
+			t.Errorf("films.Delete: got %v, want ErrNotFound", err)
+		}
+
+		if _, err := s.users.FindOne(s.ctx, missing); !errors.Is(err, user.ErrNotFound) {
+			t.Errorf("users.FindOne: got %v, want ErrNotFound", err)
+		}
+		if err := s.users.Update(s.ctx, missing, newUser(t, "ivanov@example.com")); !errors.Is(err, user.ErrNotFound) {
+			t.Errorf("users.Update: got %v, want ErrNotFound", err)
+		}
+		name := "Петров Петр"
+		if err := s.users.PartialUpdate(s.ctx, missing, user.Update{Name: &name}); !errors.Is(err, user.ErrNotFound) {
+			t.Errorf("users.PartialUpdate: got %v, want ErrNotFound", err)
+		}
+		if err := s.users.Delete(s.ctx, missing); !errors.Is(err, user.ErrNotFound) {
+			t.Errorf("users.Delete: got %v, want ErrNotFound", err)
+		}
+	})
+}
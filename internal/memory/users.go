@@ -0,0 +1,433 @@
+package memory
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"io"
+	"net/http"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
+	"sort"
+	"time"
+)
+
+// UserStorage реализация user.UserRepository поверх DB
+type UserStorage struct {
+	db *DB
+}
+
+var _ user.UserRepository = (*UserStorage)(nil)
+
+func NewUserStorage(db *DB) *UserStorage {
+	return &UserStorage{db: db}
+}
+
+func (s *UserStorage) Create(ctx context.Context, u user.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.create(u)
+}
+
+// create вызывается под блокировкой на запись
+func (s *UserStorage) create(u user.User) error {
+	if _, ok := s.db.users[u.ID]; ok {
+		return user.ErrConflict
+	}
+	if s.emailTaken(u.Email, "") {
+		return user.ErrConflict
+	}
+
+	filmIDs := make(map[string]struct{}, len(u.FilmUUID))
+	for _, filmID := range u.FilmUUID {
+		id := filmID.String()
+		if _, ok := s.db.films[id]; !ok {
+			return user.ErrFilmNotFound
+		}
+		if _, ok := filmIDs[id]; ok {
+			return user.ErrConflict
+		}
+		filmIDs[id] = struct{}{}
+	}
+
+	u.FilmUUID = nil
+	s.db.users[u.ID] = u
+	if len(filmIDs) > 0 {
+		s.db.links[u.ID] = filmIDs
+	}
+	return nil
+}
+
+func (s *UserStorage) FindOne(ctx context.Context, id string) (*user.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	u, ok := s.db.users[id]
+	if !ok {
+		return nil, user.ErrNotFound
+	}
+	u.FilmUUID = s.filmIDs(id)
+	return &u, nil
+}
+
+func (s *UserStorage) FindAll(ctx context.Context) ([]user.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var users []user.User
+	for _, u := range s.db.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+	return users, nil
+}
+
+// StreamAll передает в fn снимок списка FindAll
+func (s *UserStorage) StreamAll(ctx context.Context, fn func(user.User) error) error {
+	list, _ := s.FindAll(ctx)
+	return each(list, fn)
+}
+
+// FindByFilmIDs возвращает пользователей каждого фильма, отсортированных по имени
+func (s *UserStorage) FindByFilmIDs(ctx context.Context, filmIDs []string) (map[string][]user.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	wanted := make(map[string]struct{}, len(filmIDs))
+	for _, id := range filmIDs {
+		wanted[id] = struct{}{}
+	}
+
+	viewers := make(map[string][]user.User, len(filmIDs))
+	for userID, linked := range s.db.links {
+		for filmID := range linked {
+			if _, ok := wanted[filmID]; ok {
+				viewers[filmID] = append(viewers[filmID], s.db.users[userID])
+			}
+		}
+	}
+	for _, list := range viewers {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Name != list[j].Name {
+				return list[i].Name < list[j].Name
+			}
+			return list[i].ID < list[j].ID
+		})
+	}
+	return viewers, nil
+}
+
+func (s *UserStorage) Update(ctx context.Context, id string, input user.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	if s.emailTaken(input.Email, id) {
+		return user.ErrConflict
+	}
+
+	u.Name = input.Name
+	u.Email = input.Email
+	u.DateOfBirth = input.DateOfBirth
+	u.Gender = input.Gender
+	u.UpdatedAt = time.Now()
+	s.db.users[id] = u
+	return nil
+}
+
+func (s *UserStorage) PartialUpdate(ctx context.Context, id string, input user.Update) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.partialUpdate(id, input)
+}
+
+// partialUpdate вызывается под блокировкой на запись. Как и в PostgreSQL,
+// список фильмов частичным обновлением не меняется.
+func (s *UserStorage) partialUpdate(id string, input user.Update) error {
+	u, ok := s.db.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	if input.Email != nil && s.emailTaken(*input.Email, id) {
+		return user.ErrConflict
+	}
+
+	if input.Name != nil {
+		u.Name = *input.Name
+	}
+	if input.Email != nil {
+		u.Email = *input.Email
+	}
+	if input.DateOfBirth != nil {
+		u.DateOfBirth = *input.DateOfBirth
+	}
+	if input.Gender != nil {
+		u.Gender = *input.Gender
+	}
+	u.UpdatedAt = time.Now()
+	s.db.users[id] = u
+	return nil
+}
+
+// Patch передает в apply копию пользователя со списком фильмов и сохраняет
+// результат, только если apply и проверки ограничений прошли успешно
+func (s *UserStorage) Patch(ctx context.Context, id string, apply func(*user.User) error) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	current, ok := s.db.users[id]
+	if !ok {
+		return user.ErrNotFound
+	}
+	u := current
+	u.FilmUUID = s.filmIDs(id)
+
+	if err := apply(&u); err != nil {
+		return err
+	}
+	if s.emailTaken(u.Email, id) {
+		return user.ErrConflict
+	}
+
+	filmIDs := make(map[string]struct{}, len(u.FilmUUID))
+	for _, filmID := range u.FilmUUID {
+		if _, ok := s.db.films[filmID.String()]; !ok {
+			return user.ErrFilmNotFound
+		}
+		filmIDs[filmID.String()] = struct{}{}
+	}
+
+	current.Name = u.Name
+	current.Email = u.Email
+	current.DateOfBirth = u.DateOfBirth
+	current.Gender = u.Gender
+	current.UpdatedAt = u.UpdatedAt
+	s.db.users[id] = current
+	if len(filmIDs) > 0 {
+		s.db.links[id] = filmIDs
+	} else {
+		delete(s.db.links, id)
+	}
+	return nil
+}
+
+func (s *UserStorage) Delete(ctx context.Context, id string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return s.delete(id)
+}
+
+// delete вызывается под блокировкой на запись
+func (s *UserStorage) delete(id string) error {
+	if _, ok := s.db.users[id]; !ok {
+		return user.ErrNotFound
+	}
+	delete(s.db.users, id)
+	delete(s.db.links, id)
+	return nil
+}
+
+// Batch выполняет операции пакета через batch.Apply; в режиме atomic при ошибке
+// состояние возвращается к снимку, сделанному до пакета
+func (s *UserStorage) Batch(ctx context.Context, items []user.BatchItem, resp *batch.Response) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	before := s.db.snapshot()
+	batch.Apply(resp, func(i int) (string, int, error) {
+		item := items[i]
+		switch item.Op {
+		case batch.OpCreate:
+			if err := s.create(item.User); err != nil {
+				return item.User.ID, batchStatus(err), err
+			}
+			return item.User.ID, http.StatusCreated, nil
+		case batch.OpUpdate:
+			if err := s.partialUpdate(item.ID, item.Update); err != nil {
+				return item.ID, batchStatus(err), err
+			}
+			return item.ID, http.StatusNoContent, nil
+		default:
+			if err := s.delete(item.ID); err != nil {
+				return item.ID, batchStatus(err), err
+			}
+			return item.ID, http.StatusNoContent, nil
+		}
+	}, func() {
+		s.db.restore(before)
+	})
+	return nil
+}
+
+// Import повторяет семантику PostgreSQL-импорта: в режиме insert конфликтующие
+// по id или email строки отклоняются, в режиме upsert пользователь с тем же email
+// обновляется, а из повторов email в файле берется последний. Файл применяется
+// целиком или не применяется совсем; при DryRun состояние не меняется.
+func (s *UserStorage) Import(ctx context.Context, reader *bulk.Reader, result *bulk.Result) error {
+	var rows []importRow
+	src := bulk.NewCopySource(reader, result, func(row bulk.Row) ([]interface{}, error) {
+		u, err := user.DecodeImportRow(row)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{u}, nil
+	})
+	for src.Next() {
+		values, _ := src.Values()
+		rows = append(rows, importRow{num: values[0].(int), value: values[1]})
+	}
+	if err := src.Err(); err != nil {
+		return err
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	before := s.db.snapshot()
+	var err error
+	if result.Upsert {
+		err = s.importUpsert(rows, result)
+	} else {
+		s.importInsert(rows, result)
+	}
+	if err != nil || result.DryRun {
+		s.db.restore(before)
+	}
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+	return nil
+}
+
+func (s *UserStorage) importInsert(rows []importRow, result *bulk.Result) {
+	now := time.Now()
+	for _, row := range rows {
+		u := row.value.(user.User)
+		if _, ok := s.db.users[u.ID]; ok || s.emailTaken(u.Email, "") {
+			result.AddError(row.num, fmt.Errorf("user %q or id %s already exists", u.Email, u.ID))
+			continue
+		}
+		u.CreatedAt, u.UpdatedAt = now, now
+		s.db.users[u.ID] = u
+		result.Inserted++
+	}
+}
+
+func (s *UserStorage) importUpsert(rows []importRow, result *bulk.Result) error {
+	last := make(map[string]int, len(rows))
+	for i, row := range rows {
+		last[row.value.(user.User).Email] = i
+	}
+
+	now := time.Now()
+	for i, row := range rows {
+		u := row.value.(user.User)
+		if last[u.Email] != i {
+			result.AddError(row.num, fmt.Errorf("user %q is superseded by a later row", u.Email))
+			continue
+		}
+
+		if existing, ok := s.findByEmail(u.Email); ok {
+			existing.Name = u.Name
+			existing.DateOfBirth = u.DateOfBirth
+			existing.Gender = u.Gender
+			existing.UpdatedAt = now
+			s.db.users[existing.ID] = existing
+			result.Updated++
+			continue
+		}
+		if _, ok := s.db.users[u.ID]; ok {
+			return fmt.Errorf("%w: Key (id)=(%s) already exists.", user.ErrConflict, u.ID)
+		}
+		u.CreatedAt, u.UpdatedAt = now, now
+		s.db.users[u.ID] = u
+		result.Inserted++
+	}
+	return nil
+}
+
+// Export пишет пользователей в порядке email в том же виде, что и PostgreSQL-хранилище
+func (s *UserStorage) Export(ctx context.Context, format bulk.Format, w io.Writer) error {
+	s.db.mu.RLock()
+	users := make([]user.User, 0, len(s.db.users))
+	for _, u := range s.db.users {
+		users = append(users, u)
+	}
+	s.db.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+
+	if format == bulk.FormatCSV {
+		out := csv.NewWriter(w)
+		if err := out.Write([]string{"id", "name", "email", "date_of_birth", "gender", "created_at", "updated_at"}); err != nil {
+			return fmt.Errorf("failed to export users: %w", err)
+		}
+		for _, u := range users {
+			record := []string{
+				u.ID,
+				u.Name,
+				u.Email,
+				formatTimestamp(u.DateOfBirth),
+				u.Gender,
+				formatTimestamptz(u.CreatedAt),
+				formatTimestamptz(u.UpdatedAt),
+			}
+			if err := out.Write(record); err != nil {
+				return fmt.Errorf("failed to export users: %w", err)
+			}
+		}
+		out.Flush()
+		return out.Error()
+	}
+
+	out := bulk.NewWriter(format, w)
+	for _, u := range users {
+		if err := out.Write(u); err != nil {
+			return fmt.Errorf("failed to write user: %w", err)
+		}
+	}
+	return out.Close()
+}
+
+// emailTaken проверяет, занят ли email другим пользователем, кроме exceptID
+func (s *UserStorage) emailTaken(email, exceptID string) bool {
+	existing, ok := s.findByEmail(email)
+	return ok && existing.ID != exceptID
+}
+
+func (s *UserStorage) findByEmail(email string) (user.User, bool) {
+	for _, u := range s.db.users {
+		if u.Email == email {
+			return u, true
+		}
+	}
+	return user.User{}, false
+}
+
+// filmIDs возвращает отсортированный список фильмов пользователя
+func (s *UserStorage) filmIDs(userID string) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(s.db.links[userID]))
+	for filmID := range s.db.links[userID] {
+		ids = append(ids, uuid.FromStringOrNil(filmID))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	return ids
+}
@@ -0,0 +1,378 @@
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"rest-api-tutorial/pkg/oidc"
+	"strings"
+	"sync"
+	"time"
+)
+
+// codeTTL срок действия кода авторизации
+const codeTTL = time.Minute
+
+// User пользователь, которым мок-провайдер «входит» без формы логина
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// Birthdate YYYY-MM-DD; пустая строка — утверждение не передается
+	Birthdate string
+	// Gender female или male; пустая строка — утверждение не передается
+	Gender string
+}
+
+// Options настройки мок-провайдера
+type Options struct {
+	// URL адрес, по которому доступен провайдер, он же issuer. Start заполняет его сам.
+	URL          string
+	ClientID     string
+	ClientSecret string
+	// RedirectURLs разрешенные адреса callback; пустой список разрешает любой
+	RedirectURLs []string
+	// Algorithm алгоритм подписи ID token: RS256 (по умолчанию) или ES256
+	Algorithm string
+	User      User
+}
+
+// Issuer провайдер OpenID Connect для тестов и локальной разработки. Страница
+// входа сразу возвращает код для пользователя Options.User (или заданного SetUser),
+// остальные конечные точки работают по протоколу: discovery, token с проверкой PKCE
+// и JWKS. Провайдер работает в процессе теста на loopback-адресе, сеть не нужна.
+type Issuer struct {
+	opts   Options
+	signer crypto.Signer
+	kid    string
+	server *httptest.Server
+
+	mu       sync.Mutex
+	user     User
+	denyWith string
+	codes    map[string]authCode
+}
+
+type authCode struct {
+	user        User
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	expiresAt   time.Time
+}
+
+// New создает провайдер для запуска на собственном listener; Options.URL обязателен
+func New(opts Options) (*Issuer, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = "RS256"
+	}
+	var (
+		signer crypto.Signer
+		err    error
+	)
+	switch opts.Algorithm {
+	case "RS256":
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, expected RS256 or ES256", opts.Algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	kid, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{
+		opts:   opts,
+		signer: signer,
+		kid:    kid[:8],
+		user:   opts.User,
+		codes:  make(map[string]authCode),
+	}, nil
+}
+
+// Start запускает провайдер на httptest.Server с адресом 127.0.0.1
+func Start(opts Options) (*Issuer, error) {
+	issuer, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	issuer.server = httptest.NewServer(issuer)
+	issuer.opts.URL = issuer.server.URL
+	return issuer, nil
+}
+
+// Close останавливает сервер, запущенный Start
+func (i *Issuer) Close() {
+	if i.server != nil {
+		i.server.Close()
+	}
+}
+
+// URL адрес провайдера для oidc.Config.Issuer
+func (i *Issuer) URL() string {
+	return i.opts.URL
+}
+
+// SetUser меняет пользователя, который войдет при следующем запросе авторизации
+func (i *Issuer) SetUser(user User) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.user = user
+}
+
+// Deny заставляет следующие запросы авторизации вернуть ошибку code (например
+// access_denied); пустая строка возвращает обычное поведение
+func (i *Issuer) Deny(code string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.denyWith = code
+}
+
+func (i *Issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                i.opts.URL,
+			"authorization_endpoint":                i.opts.URL + "/authorize",
+			"token_endpoint":                        i.opts.URL + "/token",
+			"jwks_uri":                              i.opts.URL + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{i.opts.Algorithm},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	case "/authorize":
+		i.authorize(w, r)
+	case "/token":
+		i.token(w, r)
+	case "/jwks":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []interface{}{i.jwk()}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("client_id") != i.opts.ClientID || !i.allowedRedirect(redirectURI) {
+		// Без проверенного redirect_uri ошибку можно показать только на странице провайдера
+		http.Error(w, "unknown client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	params := target.Query()
+	params.Set("state", q.Get("state"))
+	i.mu.Lock()
+	user, deny := i.user, i.denyWith
+	i.mu.Unlock()
+
+	switch {
+	case deny != "":
+		params.Set("error", deny)
+	case q.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		params.Set("error", "invalid_request")
+		params.Set("error_description", "PKCE with S256 is required")
+	case !strings.Contains(" "+q.Get("scope")+" ", " openid "):
+		params.Set("error", "invalid_scope")
+	default:
+		code, err := oidc.RandomString()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		i.mu.Lock()
+		i.codes[code] = authCode{
+			user:        user,
+			clientID:    q.Get("client_id"),
+			redirectURI: redirectURI,
+			challenge:   q.Get("code_challenge"),
+			nonce:       q.Get("nonce"),
+			expiresAt:   time.Now().Add(codeTTL),
+		}
+		i.mu.Unlock()
+		params.Set("code", code)
+	}
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request", "malformed form")
+		return
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != i.opts.ClientID || secret != i.opts.ClientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+		return
+	}
+
+	// Код одноразовый: удаляется при первом предъявлении, даже неудачном
+	i.mu.Lock()
+	code, ok := i.codes[r.PostForm.Get("code")]
+	delete(i.codes, r.PostForm.Get("code"))
+	i.mu.Unlock()
+	switch {
+	case !ok || time.Now().After(code.expiresAt) || code.clientID != clientID:
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "unknown or expired code")
+		return
+	case r.PostForm.Get("redirect_uri") != code.redirectURI:
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "redirect_uri mismatch")
+		return
+	case oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != code.challenge:
+		tokenError(w, http.StatusBadRequest, "invalid_grant", "code_verifier mismatch")
+		return
+	}
+
+	idToken, err := i.IDToken(code.user, clientID, code.nonce)
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	accessToken, _ := oidc.RandomString()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// IDToken подписывает ID token для пользователя. Тесты могут вызывать его напрямую,
+// чтобы проверить отказ при неверных audience или nonce.
+func (i *Issuer) IDToken(user User, audience, nonce string) (string, error) {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":            i.opts.URL,
+		"sub":            user.Subject,
+		"aud":            audience,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if user.Birthdate != "" {
+		claims["birthdate"] = user.Birthdate
+	}
+	if user.Gender != "" {
+		claims["gender"] = user.Gender
+	}
+	header, err := json.Marshal(map[string]string{"alg": i.opts.Algorithm, "kid": i.kid, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch key := i.signer.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		if r, s, err = ecdsa.Sign(rand.Reader, key, hash[:]); err == nil {
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign id token: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (i *Issuer) jwk() map[string]string {
+	enc := base64.RawURLEncoding.EncodeToString
+	switch key := i.signer.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA", "kid": i.kid, "use": "sig", "alg": "RS256",
+			"n": enc(key.N.Bytes()),
+			"e": enc(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		return map[string]string{
+			"kty": "EC", "kid": i.kid, "use": "sig", "alg": "ES256", "crv": "P-256",
+			"x": enc(x),
+			"y": enc(y),
+		}
+	}
+	return nil
+}
+
+func (i *Issuer) allowedRedirect(redirectURI string) bool {
+	if redirectURI == "" {
+		return false
+	}
+	if len(i.opts.RedirectURLs) == 0 {
+		return true
+	}
+	for _, allowed := range i.opts.RedirectURLs {
+		if allowed == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,114 @@
+package pgtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"rest-api-tutorial/internal/tenant"
+	"sync"
+	"testing"
+	"time"
+)
+
+// DefaultTenant арендатор по умолчанию из 007_tenants.sql
+const DefaultTenant = "00000000-0000-0000-0000-000000000001"
+
+var (
+	// shared сервер пакета тестов, запущенный в Main
+	shared *Server
+	// sharedErr причина, по которой shared не запущен; тесты с DB пропускаются
+	sharedErr = errors.New("pgtest.Main was not called from TestMain")
+
+	// createMu сериализует CREATE DATABASE ... TEMPLATE: PostgreSQL не дает
+	// копировать шаблон, пока к нему подключен другой процесс копирования
+	createMu sync.Mutex
+	dbSeq    int
+)
+
+// Main запускает общий сервер на время тестов пакета и останавливает его после.
+// Вызывается из TestMain:
+//
+//	func TestMain(m *testing.M) { os.Exit(pgtest.Main(m, pgtest.Options{})) }
+//
+// Если бинарники PostgreSQL не найдены, тесты все равно запускаются,
+// а DB пропускает тесты, которым нужна база.
+func Main(m *testing.M, opts Options) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	server, err := Start(ctx, opts)
+	cancel()
+
+	switch {
+	case err == nil:
+		shared, sharedErr = server, nil
+		defer server.Stop()
+	case errors.Is(err, ErrNoPostgres):
+		sharedErr = err
+	default:
+		fmt.Fprintf(os.Stderr, "pgtest: %v\n", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// Context контекст запросов арендатора по умолчанию. Пул из DB подключается ролью
+// приложения, поэтому запрос без арендатора в контексте не видит строк под RLS.
+func Context() context.Context {
+	return tenant.ContextWithTenant(context.Background(), DefaultTenant)
+}
+
+// DB возвращает пул подключений к новой изолированной базе на общем сервере.
+// Пул подключается ролью приложения и задает app.tenant_id так же, как приложение
+// (tenant.BeforeAcquire). Если сервер не запущен, тест пропускается.
+func DB(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	if shared == nil {
+		t.Skipf("postgres is not available: %v", sharedErr)
+	}
+	return shared.NewDatabase(t)
+}
+
+// NewDatabase создает базу из шаблона с примененными миграциями и удаляет ее
+// по завершении теста. Тесты с собственными базами можно запускать параллельно.
+// Пул подключается ролью приложения с арендатором из контекста запроса.
+func (s *Server) NewDatabase(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	ctx := context.Background()
+
+	admin, err := pgxpool.Connect(ctx, s.ConnString("postgres"))
+	if err != nil {
+		t.Fatalf("pgtest: failed to connect to server: %v", err)
+	}
+
+	createMu.Lock()
+	dbSeq++
+	name := fmt.Sprintf("pgtest_%d_%d", os.Getpid(), dbSeq)
+	_, err = admin.Exec(ctx, fmt.Sprintf(`CREATE DATABASE %q TEMPLATE %q`, name, templateName))
+	createMu.Unlock()
+	if err != nil {
+		admin.Close()
+		t.Fatalf("pgtest: failed to create database: %v", err)
+	}
+
+	config, err := pgxpool.ParseConfig(s.connString(appRole, name))
+	if err != nil {
+		admin.Close()
+		t.Fatalf("pgtest: invalid connection string: %v", err)
+	}
+	config.BeforeAcquire = tenant.BeforeAcquire
+	pool, err := pgxpool.ConnectConfig(ctx, config)
+	if err != nil {
+		admin.Close()
+		t.Fatalf("pgtest: failed to connect to %s: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		pool.Close()
+		if _, err := admin.Exec(ctx, fmt.Sprintf(`DROP DATABASE IF EXISTS %q`, name)); err != nil {
+			t.Errorf("pgtest: failed to drop %s: %v", name, err)
+		}
+		admin.Close()
+	})
+	return pool
+}
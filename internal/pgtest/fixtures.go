@@ -0,0 +1,131 @@
+package pgtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/internal/user"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Fixtures создает записи напрямую через SQL, минуя проверяемые хранилища.
+// Значения по умолчанию проходят все ограничения схемы, а email и title уникальны.
+// Записи принадлежат арендатору Fixtures: политики RLS не дают вставить строку
+// другого арендатора, поэтому tenant_id и app.tenant_id совпадают.
+type Fixtures struct {
+	t      testing.TB
+	pool   *pgxpool.Pool
+	tenant string
+	seq    *int64
+}
+
+func NewFixtures(t testing.TB, pool *pgxpool.Pool) *Fixtures {
+	return &Fixtures{t: t, pool: pool, tenant: DefaultTenant, seq: new(int64)}
+}
+
+// Tenant создает арендатора и возвращает Fixtures, которые создают записи в его каталоге
+func (f *Fixtures) Tenant() *Fixtures {
+	f.t.Helper()
+	id := f.newID()
+	q := `INSERT INTO tenants (id, slug, name) VALUES ($1, $2, $3)`
+	slug := fmt.Sprintf("tenant-%d", atomic.AddInt64(f.seq, 1))
+	if _, err := f.pool.Exec(context.Background(), q, id, slug, slug); err != nil {
+		f.t.Fatalf("pgtest: failed to insert tenant fixture: %v", err)
+	}
+	return &Fixtures{t: f.t, pool: f.pool, tenant: id, seq: f.seq}
+}
+
+// TenantID арендатор, которому принадлежат записи Fixtures
+func (f *Fixtures) TenantID() string {
+	return f.tenant
+}
+
+// Context контекст запросов в каталоге арендатора Fixtures
+func (f *Fixtures) Context() context.Context {
+	return tenant.ContextWithTenant(context.Background(), f.tenant)
+}
+
+// User создает пользователя; opts меняют значения по умолчанию перед вставкой
+func (f *Fixtures) User(opts ...func(*user.User)) user.User {
+	f.t.Helper()
+	n := atomic.AddInt64(f.seq, 1)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	u := user.User{
+		ID:          f.newID(),
+		Name:        fmt.Sprintf("Пользователь %d", n),
+		Email:       fmt.Sprintf("user%d@example.com", n),
+		DateOfBirth: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		Gender:      "М",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(&u)
+	}
+
+	q := `
+        INSERT INTO users (id, name, email, date_of_birth, gender, created_at, updated_at, tenant_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+	_, err := f.pool.Exec(f.Context(), q, u.ID, u.Name, u.Email, u.DateOfBirth, u.Gender, u.CreatedAt, u.UpdatedAt, f.tenant)
+	if err != nil {
+		f.t.Fatalf("pgtest: failed to insert user fixture: %v", err)
+	}
+	for _, filmID := range u.FilmUUID {
+		f.Link(u.ID, filmID.String())
+	}
+	return u
+}
+
+// Film создает фильм; opts меняют значения по умолчанию перед вставкой
+func (f *Fixtures) Film(opts ...func(*films.Film)) films.Film {
+	f.t.Helper()
+	n := atomic.AddInt64(f.seq, 1)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	film := films.Film{
+		ID:          f.newID(),
+		Title:       fmt.Sprintf("Фильм %d", n),
+		Description: fmt.Sprintf("Описание фильма %d", n),
+		Rating:      5,
+		ReleaseDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(&film)
+	}
+
+	q := `
+        INSERT INTO films (film_id, title, description, rating, release_date, created_at, updated_at, tenant_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+	_, err := f.pool.Exec(f.Context(), q, film.ID, film.Title, film.Description, film.Rating, film.ReleaseDate, film.CreatedAt, film.UpdatedAt, f.tenant)
+	if err != nil {
+		f.t.Fatalf("pgtest: failed to insert film fixture: %v", err)
+	}
+	return film
+}
+
+// Link связывает пользователя с фильмом
+func (f *Fixtures) Link(userID, filmID string) {
+	f.t.Helper()
+	q := `INSERT INTO user_film (user_id, film_id, tenant_id) VALUES ($1, $2, $3)`
+	if _, err := f.pool.Exec(f.Context(), q, userID, filmID, f.tenant); err != nil {
+		f.t.Fatalf("pgtest: failed to insert user-film fixture: %v", err)
+	}
+}
+
+func (f *Fixtures) newID() string {
+	id, err := uuid.NewV4()
+	if err != nil {
+		f.t.Fatalf("pgtest: failed to generate ID: %v", err)
+	}
+	return id.String()
+}
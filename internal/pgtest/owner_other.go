@@ -0,0 +1,12 @@
+//go:build !unix
+
+package pgtest
+
+import (
+	"syscall"
+)
+
+// unprivileged на системах без unix-пользователей ничего не меняет
+func unprivileged(string) (*syscall.SysProcAttr, error) {
+	return nil, nil
+}
@@ -0,0 +1,52 @@
+//go:build unix
+
+package pgtest
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// unprivileged возвращает атрибуты запуска сервера от имени пользователя postgres
+// или nobody, если тесты идут под root (например, в контейнере CI), и передает
+// ему каталог dir. Под обычным пользователем атрибуты не нужны.
+func unprivileged(dir string) (*syscall.SysProcAttr, error) {
+	if os.Geteuid() != 0 {
+		return nil, nil
+	}
+
+	var (
+		account *user.User
+		err     error
+	)
+	for _, name := range []string{"postgres", "nobody"} {
+		if account, err = user.Lookup(name); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("running as root and neither postgres nor nobody user exists: %w", err)
+	}
+	uid, errUID := strconv.ParseUint(account.Uid, 10, 32)
+	gid, errGID := strconv.ParseUint(account.Gid, 10, 32)
+	if errUID != nil || errGID != nil {
+		return nil, fmt.Errorf("invalid uid or gid of user %s", account.Username)
+	}
+
+	err = filepath.Walk(dir, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, int(uid), int(gid))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to give %s to user %s: %w", dir, account.Username, err)
+	}
+	return &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)},
+	}, nil
+}
@@ -0,0 +1,306 @@
+package pgtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// EnvBinDir переменная окружения с каталогом бинарников PostgreSQL (initdb, pg_ctl, psql).
+// Если не задана, бинарники ищутся в PATH и в стандартных каталогах пакетов.
+const EnvBinDir = "PG_BIN"
+
+// ErrNoPostgres бинарники PostgreSQL не найдены
+var ErrNoPostgres = errors.New("postgres binaries not found, set " + EnvBinDir + " or add initdb to PATH")
+
+const (
+	superuser = "postgres"
+	// appRole роль приложения из 013_app_role.sql: без SUPERUSER и BYPASSRLS,
+	// поэтому тесты видят данные так же, как приложение, — через политики RLS
+	appRole = "movies_app"
+	// port используется только в имени unix-сокета; TCP выключен
+	port         = 5432
+	templateName = "pgtest_template"
+)
+
+// legacyRoles роли, которым 001_init.sql (дамп pg_dump) назначает владение таблицами
+var legacyRoles = []string{"st1txh"}
+
+// Options настройки временного сервера
+type Options struct {
+	// BinDir каталог с initdb, pg_ctl и psql; по умолчанию — EnvBinDir или PATH
+	BinDir string
+	// MigrationsDir каталог с миграциями *.sql; по умолчанию — migrations в корне модуля
+	MigrationsDir string
+	// KeepSeedData оставляет тестовые данные из миграций; по умолчанию очищаются все
+	// таблицы схемы public, кроме tenants, и тесты наполняют их через Fixtures
+	KeepSeedData bool
+}
+
+// Server временный кластер PostgreSQL в каталоге во временной директории.
+// Сервер слушает только unix-сокет, поэтому не нужны ни Docker, ни сеть.
+// Миграции применяются один раз к шаблонной базе, а каждый тест получает
+// собственную базу, скопированную из шаблона (см. NewDatabase).
+type Server struct {
+	binDir  string
+	dir     string
+	dataDir string
+	sockDir string
+	// serverAttr атрибуты процессов initdb и pg_ctl: под root они запускаются
+	// от непривилегированного пользователя, иначе PostgreSQL отказывается работать
+	serverAttr *syscall.SysProcAttr
+}
+
+// Start инициализирует кластер, запускает его и готовит шаблонную базу с миграциями
+func Start(ctx context.Context, opts Options) (*Server, error) {
+	binDir, err := findBinDir(opts.BinDir)
+	if err != nil {
+		return nil, err
+	}
+	migrationsDir := opts.MigrationsDir
+	if migrationsDir == "" {
+		if migrationsDir, err = findMigrationsDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "pgtest-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	s := &Server{
+		binDir:  binDir,
+		dir:     dir,
+		dataDir: filepath.Join(dir, "data"),
+		sockDir: dir,
+	}
+	if s.serverAttr, err = unprivileged(dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	if err := s.init(ctx); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := s.start(ctx); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := s.prepareTemplate(ctx, migrationsDir, opts.KeepSeedData); err != nil {
+		s.Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Stop останавливает сервер и удаляет его каталог
+func (s *Server) Stop() error {
+	stopErr := s.run(context.Background(), "pg_ctl", "-D", s.dataDir, "-m", "immediate", "-w", "stop")
+	if err := os.RemoveAll(s.dir); err != nil && stopErr == nil {
+		return fmt.Errorf("failed to remove %s: %w", s.dir, err)
+	}
+	return stopErr
+}
+
+// ConnString строка подключения суперпользователя к базе database через unix-сокет
+func (s *Server) ConnString(database string) string {
+	return s.connString(superuser, database)
+}
+
+func (s *Server) connString(role, database string) string {
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=disable", s.sockDir, port, role, database)
+}
+
+func (s *Server) init(ctx context.Context) error {
+	err := s.run(ctx, "initdb",
+		"-D", s.dataDir,
+		"-U", superuser,
+		"--auth=trust",
+		"--encoding=UTF8",
+		"--locale=C",
+		"--no-sync",
+	)
+	if err != nil {
+		return fmt.Errorf("initdb failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) start(ctx context.Context) error {
+	// Настройки ускоряют тесты за счет надежности, которая временному кластеру не нужна
+	options := strings.Join([]string{
+		"-c listen_addresses=''",
+		"-k " + s.sockDir,
+		fmt.Sprintf("-p %d", port),
+		"-c fsync=off",
+		"-c synchronous_commit=off",
+		"-c full_page_writes=off",
+	}, " ")
+
+	err := s.run(ctx, "pg_ctl",
+		"-D", s.dataDir,
+		"-l", filepath.Join(s.dir, "postgres.log"),
+		"-o", options,
+		"-w", "-t", "60",
+		"start",
+	)
+	if err != nil {
+		log, _ := os.ReadFile(filepath.Join(s.dir, "postgres.log"))
+		return fmt.Errorf("pg_ctl start failed: %w\n%s", err, log)
+	}
+	return nil
+}
+
+// prepareTemplate создает шаблонную базу и применяет к ней миграции через psql:
+// 001_init.sql содержит COPY ... FROM stdin, который понимает только psql
+func (s *Server) prepareTemplate(ctx context.Context, migrationsDir string, keepSeedData bool) error {
+	for _, role := range legacyRoles {
+		if err := s.psql(ctx, "postgres", "-c", fmt.Sprintf(`CREATE ROLE %q`, role)); err != nil {
+			return fmt.Errorf("failed to create role %s: %w", role, err)
+		}
+	}
+	if err := s.psql(ctx, "postgres", "-c", fmt.Sprintf(`CREATE DATABASE %q`, templateName)); err != nil {
+		return fmt.Errorf("failed to create template database: %w", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migrations found in %s", migrationsDir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if err := s.psql(ctx, templateName, "-f", file); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	// Роль приложения создается без входа; trust-аутентификации пароль не нужен
+	if err := s.psql(ctx, templateName, "-c", fmt.Sprintf(`ALTER ROLE %q WITH LOGIN`, appRole)); err != nil {
+		return fmt.Errorf("failed to enable login for %s: %w", appRole, err)
+	}
+
+	if !keepSeedData {
+		if err := s.psql(ctx, templateName, "-c", clearSeedData); err != nil {
+			return fmt.Errorf("failed to clear seed data: %w", err)
+		}
+	}
+	return nil
+}
+
+// clearSeedData очищает все таблицы схемы public, в том числе добавленные будущими
+// миграциями. tenants остается: на арендатора по умолчанию ссылаются значения
+// tenant_id по умолчанию.
+const clearSeedData = `
+    DO $$
+    DECLARE
+        tables text;
+    BEGIN
+        SELECT string_agg(format('%I.%I', schemaname, tablename), ', ')
+        INTO tables
+        FROM pg_tables
+        WHERE schemaname = 'public' AND tablename <> 'tenants';
+
+        IF tables IS NOT NULL THEN
+            EXECUTE 'TRUNCATE ' || tables || ' RESTART IDENTITY CASCADE';
+        END IF;
+    END $$
+`
+
+func (s *Server) psql(ctx context.Context, database string, args ...string) error {
+	args = append([]string{
+		"-h", s.sockDir,
+		"-p", fmt.Sprint(port),
+		"-U", superuser,
+		"-d", database,
+		"-X", "-q",
+		"-v", "ON_ERROR_STOP=1",
+	}, args...)
+	return s.run(ctx, "psql", args...)
+}
+
+func (s *Server) run(ctx context.Context, name string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, filepath.Join(s.binDir, name), args...)
+	if name != "psql" {
+		cmd.SysProcAttr = s.serverAttr
+	}
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+// findBinDir ищет каталог, где лежат initdb, pg_ctl и psql
+func findBinDir(binDir string) (string, error) {
+	candidates := []string{binDir, os.Getenv(EnvBinDir)}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		candidates = append(candidates, filepath.Dir(path))
+	}
+	// Debian/Ubuntu кладут серверные бинарники вне PATH, Homebrew — в свой префикс
+	for _, pattern := range []string{
+		"/usr/lib/postgresql/*/bin",
+		"/usr/pgsql-*/bin",
+		"/opt/homebrew/opt/postgresql*/bin",
+		"/usr/local/opt/postgresql*/bin",
+	} {
+		matches, _ := filepath.Glob(pattern)
+		sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+		candidates = append(candidates, matches...)
+	}
+
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		if hasBinaries(dir) {
+			return dir, nil
+		}
+	}
+	return "", ErrNoPostgres
+}
+
+func hasBinaries(dir string) bool {
+	for _, name := range []string{"initdb", "pg_ctl", "psql"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// findMigrationsDir поднимается от рабочего каталога до go.mod и возвращает migrations рядом с ним
+func findMigrationsDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return filepath.Join(dir, "migrations"), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod not found, set Options.MigrationsDir")
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit параметры корзины токенов: Requests запросов за Period.
+// Корзина вмещает Requests токенов и пополняется равномерно.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Rate скорость пополнения корзины в токенах в секунду
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Policy значение заголовка RateLimit-Policy, например "100;w=60"
+func (l Limit) Policy() string {
+	return fmt.Sprintf("%d;w=%d", l.Requests, int(l.Period.Seconds()))
+}
+
+// ParseLimit разбирает лимит в формате "<запросов>/<период>", например "100/1m"
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<period>", s)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", s)
+	}
+
+	period, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("invalid period in rate limit %q", s)
+	}
+
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// ParseRoutes разбирает переопределения лимитов для маршрутов в формате
+// "GET /api/v1/films=20/1s;POST /api/v1/films/import=5/1m". Маршрут указывается так же,
+// как он зарегистрирован в gin, включая параметры (/api/v1/films/:uuid).
+func ParseRoutes(s string) (map[string]Limit, error) {
+	routes := make(map[string]Limit)
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid route rate limit %q, expected <METHOD> <path>=<limit>", entry)
+		}
+
+		fields := strings.Fields(entry[:i])
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid route %q, expected <METHOD> <path>", entry[:i])
+		}
+
+		limit, err := ParseLimit(entry[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.ToUpper(fields[0])+" "+fields[1]] = limit
+	}
+	return routes, nil
+}
+
+// Result итог попытки забрать токен
+type Result struct {
+	Allowed bool
+	// Remaining сколько токенов осталось в корзине
+	Remaining int
+	// RetryAfter через сколько появится следующий токен (для отказов)
+	RetryAfter time.Duration
+	// Reset через сколько корзина заполнится полностью
+	Reset time.Duration
+}
+
+// Store хранилище корзин токенов
+type Store interface {
+	// Take забирает один токен из корзины key с параметрами limit
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result рассчитывает Result по числу токенов, оставшихся после попытки
+func result(allowed bool, tokens float64, limit Limit) Result {
+	rate := limit.Rate()
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
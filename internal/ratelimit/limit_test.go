@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{"100/1m", Limit{Requests: 100, Period: time.Minute}, false},
+		{" 5 / 1s ", Limit{Requests: 5, Period: time.Second}, false},
+		{"100", Limit{}, true},
+		{"0/1m", Limit{}, true},
+		{"-1/1m", Limit{}, true},
+		{"many/1m", Limit{}, true},
+		{"100/0s", Limit{}, true},
+		{"100/minute", Limit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, %v; want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]Limit
+		wantErr bool
+	}{
+		{"", map[string]Limit{}, false},
+		{
+			"get /api/v1/films=20/1s; POST /api/v1/films/import=5/1m;",
+			map[string]Limit{
+				"GET /api/v1/films":         {Requests: 20, Period: time.Second},
+				"POST /api/v1/films/import": {Requests: 5, Period: time.Minute},
+			},
+			false,
+		},
+		// Параметры маршрута записываются так же, как в gin
+		{"GET /api/v1/films/:uuid=1/1s", map[string]Limit{"GET /api/v1/films/:uuid": {Requests: 1, Period: time.Second}}, false},
+		{"GET /api/v1/films", nil, true},
+		{"/api/v1/films=20/1s", nil, true},
+		{"GET /api/v1/films=20", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRoutes(tt.in)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("ParseRoutes(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	// Шаги выполняются по порядку над одной корзиной; пополнение — 1 токен в секунду
+	steps := []struct {
+		name       string
+		at         time.Duration
+		key        string
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{"first request", 0, "a", true, 1, 0},
+		{"second request", 0, "a", true, 0, 0},
+		{"bucket is empty", 0, "a", false, 0, time.Second},
+		{"other key has its own bucket", 0, "b", true, 1, 0},
+		{"half a token refilled", 500 * time.Millisecond, "a", false, 0, 500 * time.Millisecond},
+		{"one token refilled", time.Second, "a", true, 0, 0},
+		{"refill is capped at the limit", time.Hour, "a", true, 1, 0},
+	}
+	for _, step := range steps {
+		now = start.Add(step.at)
+		res, err := store.Take(context.Background(), step.key, limit)
+		if err != nil {
+			t.Fatalf("%s: Take: %v", step.name, err)
+		}
+		if res.Allowed != step.allowed || res.Remaining != step.remaining || res.RetryAfter != step.retryAfter {
+			t.Errorf("%s: Take = %+v; want allowed %v, remaining %d, retry after %s", step.name, res, step.allowed, step.remaining, step.retryAfter)
+		}
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Second}
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.Take(context.Background(), "idle", limit)
+	now = now.Add(sweepInterval)
+	store.Take(context.Background(), "active", limit)
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("a fully refilled bucket was not swept")
+	}
+	if _, ok := store.buckets["active"]; !ok {
+		t.Error("the bucket in use was swept")
+	}
+}
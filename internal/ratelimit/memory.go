@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval как часто из памяти удаляются полностью восстановившиеся корзины
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryStore хранит корзины в памяти процесса. Лимиты считаются отдельно
+// на каждой реплике.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = refill(b.tokens, now.Sub(b.updated), limit)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return result(false, b.tokens, limit), nil
+	}
+	b.tokens--
+	return result(true, b.tokens, limit), nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if refill(b.tokens, now.Sub(b.updated), b.limit) >= float64(b.limit.Requests) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	tokens += elapsed.Seconds() * limit.Rate()
+	if max := float64(limit.Requests); tokens > max {
+		return max
+	}
+	return tokens
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"rest-api-tutorial/pkg/errors"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+	"time"
+)
+
+// SubjectContextKey ключ gin-контекста, в который аутентификация кладет
+// идентификатор клиента (например "user:<uuid>"); он приоритетнее заголовков и IP
+const SubjectContextKey = "rate_limit_subject"
+
+// Options лимит по умолчанию и переопределения для маршрутов ("GET /api/v1/films")
+type Options struct {
+	Default Limit
+	Routes  map[string]Limit
+}
+
+// ClientKey определяет, чей это запрос: аутентифицированный субъект,
+// API-ключ из X-API-Key (хранится только хеш) или IP-адрес
+func ClientKey(c *gin.Context) string {
+	if subject := c.GetString(SubjectContextKey); subject != "" {
+		return subject
+	}
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Middleware ограничивает частоту запросов по алгоритму token bucket.
+// Лимит по умолчанию общий для всех маршрутов клиента, у маршрутов с
+// переопределением своя корзина. Ответ содержит заголовки RateLimit-*,
+// при превышении — 429 с Retry-After. Если хранилище недоступно, запрос пропускается.
+func Middleware(store Store, opts Options, logger *logging.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+
+		limit, scope := opts.Default, "global"
+		if override, ok := opts.Routes[route]; ok {
+			limit, scope = override, route
+		}
+
+		if take(c, store, scope+"|"+ClientKey(c), limit, logger) {
+			c.Next()
+		}
+	}
+}
+
+// IPMiddleware ограничивает частоту запросов с одного IP-адреса независимо от
+// аутентификации. Подключается до нее, чтобы перебор ключей (ответы 401) тоже
+// упирался в лимит: Middleware считает такие запросы по каждому новому ключу отдельно.
+func IPMiddleware(store Store, limit Limit, logger *logging.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if take(c, store, "preauth|ip:"+c.ClientIP(), limit, logger) {
+			c.Next()
+		}
+	}
+}
+
+// take забирает токен из корзины key и выставляет заголовки RateLimit-*.
+// При превышении лимита прерывает запрос с 429 и возвращает false.
+func take(c *gin.Context, store Store, key string, limit Limit, logger *logging.Logger) bool {
+	res, err := store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		logger.Errorf("Rate limiter failed, request allowed: %v", err)
+		return true
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+	c.Header("RateLimit-Policy", limit.Policy())
+
+	if !res.Allowed {
+		retryAfter := seconds(res.RetryAfter)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, errors.ErrorResponse{
+			Code:    http.StatusTooManyRequests,
+			Message: "Too many requests",
+			Details: gin.H{"retry_after": retryAfter},
+		})
+		return false
+	}
+	return true
+}
+
+// seconds округляет длительность вверх до целых секунд
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
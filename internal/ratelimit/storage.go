@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+	"sync"
+	"time"
+)
+
+// Storage хранит корзины в PostgreSQL, чтобы лимиты были общими для всех реплик.
+// Пополнение и списание токена выполняются одним атомарным UPSERT.
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client:    pool,
+		logger:    logger,
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *Storage) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	// В SET b.* содержит еще старые значения, поэтому пополненная корзина
+	// (LEAST(...)) во всех выражениях одна и та же
+	q := `
+        INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+        VALUES ($1, $2::double precision - 1, true, NOW())
+        ON CONFLICT (key) DO UPDATE
+        SET
+            tokens = LEAST($2::double precision, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::double precision * $3::double precision)
+                - (LEAST($2::double precision, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::double precision * $3::double precision) >= 1)::int,
+            allowed = LEAST($2::double precision, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::double precision * $3::double precision) >= 1,
+            updated_at = NOW()
+        RETURNING b.tokens, b.allowed
+    `
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := s.client.QueryRow(ctx, q, key, float64(limit.Requests), limit.Rate()).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	s.sweep(ctx)
+	return result(allowed, tokens, limit), nil
+}
+
+// sweep раз в sweepInterval удаляет корзины, которые давно не использовались
+func (s *Storage) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	q := `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - INTERVAL '1 hour'`
+	if _, err := s.client.Exec(ctx, q); err != nil {
+		s.logger.Warnf("Failed to delete stale rate limit buckets: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package recommend
+
+import (
+	"context"
+	"fmt"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+// BuilderOptions настройки пересборки таблицы похожести
+type BuilderOptions struct {
+	// Interval как часто пересчитывается похожесть; новые выборы пользователей
+	// влияют на рекомендации только после пересборки
+	Interval time.Duration
+	// MinCommonUsers сколько пользователей должны выбрать оба фильма, чтобы они
+	// считались похожими; отсекает случайные совпадения
+	MinCommonUsers int
+	// Neighbours сколько самых похожих фильмов хранится для каждого фильма
+	Neighbours int
+}
+
+// Validate проверяет интервал и пороги
+func (o BuilderOptions) Validate() error {
+	if o.Interval <= 0 {
+		return fmt.Errorf("recommendation interval must be positive, got %s", o.Interval)
+	}
+	if o.MinCommonUsers < 1 || o.Neighbours < 1 {
+		return fmt.Errorf("recommendation min common users and neighbours must be at least 1")
+	}
+	return nil
+}
+
+// Builder периодически пересобирает film_similarity
+type Builder struct {
+	storage *Storage
+	opts    BuilderOptions
+	logger  *logging.Logger
+}
+
+func NewBuilder(storage *Storage, opts BuilderOptions, logger *logging.Logger) *Builder {
+	return &Builder{
+		storage: storage,
+		opts:    opts,
+		logger:  logger,
+	}
+}
+
+// Run пересобирает таблицу при запуске и затем каждые Interval до отмены ctx.
+// Таблица общая для всех арендаторов, поэтому сборка идет в системном контексте.
+func (b *Builder) Run(ctx context.Context) {
+	ctx = tenant.SystemContext(ctx)
+	ticker := time.NewTicker(b.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		b.rebuild(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Builder) rebuild(ctx context.Context) {
+	started := time.Now()
+	pairs, ok, err := b.storage.Rebuild(ctx, b.opts.MinCommonUsers, b.opts.Neighbours)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		b.logger.Errorf("Failed to rebuild film similarity: %v", err)
+	case ok:
+		b.logger.Infof("Rebuilt film similarity: %d pairs in %s", pairs, time.Since(started).Round(time.Millisecond))
+	}
+}
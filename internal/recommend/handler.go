@@ -0,0 +1,109 @@
+package recommend
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 50
+)
+
+type Handler struct {
+	logger  *logging.Logger
+	storage *Storage
+}
+
+func NewHandler(storage *Storage, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+// GetRecommendations godoc
+// @Summary Get film recommendations for a user
+// @Description Recommend films the user does not have yet. Films picked by the same users as the user's own films come first, each with the user's films it is similar to. Similarity is recalculated periodically, so new picks show up after the next rebuild. When there are not enough similar films (for example, the user has none yet) the list is filled with the top rated films.
+// @Description The response has the same format in v1 and v2.
+// @Tags recommendations
+// @Produce json
+// @Param uuid path string true "User ID (UUID)"
+// @Param limit query int false "Maximum number of recommendations" default(10) maximum(50)
+// @Success 200 {object} envelope.Collection[recommend.Recommendation] "Recommended films, best first"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid limit"
+// @Failure 404 {object} envelope.ErrorResponse "User not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v1/users/{uuid}/recommendations [get]
+// @Router /v2/users/{uuid}/recommendations [get]
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	userID := c.Param("uuid")
+	if _, err := uuid.FromString(userID); err != nil {
+		envelope.Error(c, http.StatusNotFound, "User not found")
+		return
+	}
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxLimit {
+			envelope.Error(c, http.StatusBadRequest, "Invalid limit, expected 1 to 50")
+			return
+		}
+		limit = n
+	}
+
+	ctx := c.Request.Context()
+	if err := h.storage.UserExists(ctx, userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			envelope.Error(c, http.StatusNotFound, "User not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch recommendations")
+		return
+	}
+
+	tenantID, _ := tenant.FromContext(ctx)
+	list, err := h.storage.Similar(ctx, tenantID, userID, limit)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch recommendations")
+		return
+	}
+	if len(list) < limit {
+		exclude := make([]string, len(list))
+		for i, r := range list {
+			exclude[i] = r.ID
+		}
+		top, err := h.storage.TopRated(ctx, userID, exclude, limit-len(list))
+		if err != nil {
+			envelope.Error(c, http.StatusInternalServerError, "Failed to fetch recommendations")
+			return
+		}
+		list = append(list, top...)
+	}
+	for i := range list {
+		list[i].Reason = reason(list[i])
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// reason объяснение рекомендации: "Because you liked A, B and C"
+func reason(r Recommendation) string {
+	if r.Source == SourceTopRated || len(r.BecauseOf) == 0 {
+		return "Top rated in the catalog"
+	}
+	titles := make([]string, len(r.BecauseOf))
+	for i, f := range r.BecauseOf {
+		titles[i] = f.Title
+	}
+	if len(titles) == 1 {
+		return "Because you liked " + titles[0]
+	}
+	return "Because you liked " + strings.Join(titles[:len(titles)-1], ", ") + " and " + titles[len(titles)-1]
+}
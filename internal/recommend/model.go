@@ -0,0 +1,38 @@
+package recommend
+
+import (
+	"rest-api-tutorial/internal/films"
+)
+
+// Источники рекомендации
+const (
+	// SourceSimilar фильм похож на фильмы пользователя
+	SourceSimilar = "similar"
+	// SourceTopRated фильм из лучших по рейтингу, когда похожих не хватило (например,
+	// у нового пользователя еще нет фильмов)
+	SourceTopRated = "top_rated"
+)
+
+// FilmRef фильм, на основании которого сделана рекомендация
+type FilmRef struct {
+	// @format uuid
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Recommendation рекомендованный фильм
+// @description Фильм, которого еще нет в списке пользователя, с объяснением выбора
+type Recommendation struct {
+	films.FilmV2
+
+	// Чем больше, тем выше фильм в списке; у лучших по рейтингу 0
+	Score float64 `json:"score" example:"1.27"`
+
+	Source string `json:"source" enums:"similar,top_rated"`
+
+	// Объяснение для показа пользователю
+	Reason string `json:"reason" example:"Because you liked Matrix"`
+
+	// Фильмы пользователя, на которые похож рекомендованный, самые похожие первыми
+	BecauseOf []FilmRef `json:"because_of"`
+}
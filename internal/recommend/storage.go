@@ -0,0 +1,207 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+)
+
+// ErrUserNotFound пользователя нет в каталоге арендатора
+var ErrUserNotFound = errors.New("user not found")
+
+// rebuildLock ключ advisory lock пересборки: реплики не считают похожесть одновременно
+const rebuildLock int64 = 0x66696c6d73696d
+
+// maxBecauseOf сколько фильмов пользователя объясняют одну рекомендацию
+const maxBecauseOf = 3
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+// Rebuild пересчитывает film_similarity для всех арендаторов в одной транзакции,
+// поэтому запросы рекомендаций видят либо старую, либо новую таблицу целиком.
+// Для каждого фильма сохраняются neighbours самых похожих, у которых не меньше
+// minCommonUsers общих пользователей. ok равен false, если пересборку уже ведет
+// другая реплика.
+func (s *Storage) Rebuild(ctx context.Context, minCommonUsers, neighbours int) (pairs int64, ok bool, err error) {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, rebuildLock).Scan(&ok); err != nil {
+		return 0, false, fmt.Errorf("failed to lock film similarity: %w", err)
+	}
+	if !ok {
+		return 0, false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM film_similarity`); err != nil {
+		return 0, false, fmt.Errorf("failed to clear film similarity: %w", err)
+	}
+
+	q := `
+        WITH counts AS (
+            SELECT tenant_id, film_id, count(*) AS users
+            FROM user_film
+            GROUP BY tenant_id, film_id
+        ), pairs AS (
+            SELECT a.tenant_id, a.film_id, b.film_id AS similar_film_id, count(*) AS common_users
+            FROM user_film a
+            JOIN user_film b ON b.tenant_id = a.tenant_id AND b.user_id = a.user_id AND b.film_id <> a.film_id
+            GROUP BY a.tenant_id, a.film_id, b.film_id
+            HAVING count(*) >= $1
+        ), scored AS (
+            SELECT pairs.tenant_id, pairs.film_id, pairs.similar_film_id, pairs.common_users,
+                   pairs.common_users / sqrt((ca.users * cb.users)::float8) AS score
+            FROM pairs
+            JOIN counts ca ON ca.tenant_id = pairs.tenant_id AND ca.film_id = pairs.film_id
+            JOIN counts cb ON cb.tenant_id = pairs.tenant_id AND cb.film_id = pairs.similar_film_id
+        ), ranked AS (
+            SELECT scored.*,
+                   row_number() OVER (PARTITION BY tenant_id, film_id ORDER BY score DESC, common_users DESC, similar_film_id) AS rank
+            FROM scored
+        )
+        INSERT INTO film_similarity (tenant_id, film_id, similar_film_id, score, common_users)
+        SELECT tenant_id, film_id, similar_film_id, score, common_users
+        FROM ranked
+        WHERE rank <= $2
+    `
+	tag, err := tx.Exec(ctx, q, minCommonUsers, neighbours)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to build film similarity: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return tag.RowsAffected(), true, nil
+}
+
+// Similar рекомендует фильмы, похожие на фильмы пользователя. Оценка фильма -
+// сумма его похожести на фильмы пользователя, умноженная на 0.5-1 по рейтингу,
+// чтобы при равной похожести выше шли фильмы с лучшим рейтингом.
+func (s *Storage) Similar(ctx context.Context, tenantID, userID string, limit int) ([]Recommendation, error) {
+	q := `
+        WITH owned AS (
+            SELECT film_id FROM user_film WHERE user_id = $2
+        ), candidates AS (
+            SELECT s.similar_film_id AS film_id, s.film_id AS source_id, s.score
+            FROM film_similarity s
+            JOIN owned ON owned.film_id = s.film_id
+            WHERE s.tenant_id = $1
+              AND s.similar_film_id NOT IN (SELECT film_id FROM owned)
+        )
+        SELECT films.film_id, films.title, films.description, films.rating, films.release_date,
+               films.created_at, films.updated_at,
+               sum(candidates.score) * (0.5 + COALESCE(films.rating, 0)::float8 / 20) AS score,
+               (array_agg(source.film_id::text ORDER BY candidates.score DESC, source.title))[1:$4],
+               (array_agg(source.title::text ORDER BY candidates.score DESC, source.title))[1:$4]
+        FROM candidates
+        JOIN films ON films.film_id = candidates.film_id
+        JOIN films source ON source.film_id = candidates.source_id
+        GROUP BY films.film_id
+        ORDER BY score DESC, films.rating DESC NULLS LAST, films.title
+        LIMIT $3
+    `
+	rows, err := s.client.Query(ctx, q, tenantID, userID, limit, maxBecauseOf)
+	if err != nil {
+		s.logger.Errorf("Failed to get recommendations: %v", err)
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]Recommendation, 0, limit)
+	for rows.Next() {
+		var (
+			r            Recommendation
+			sourceIDs    []string
+			sourceTitles []string
+		)
+		if err := rows.Scan(
+			&r.ID,
+			&r.Title,
+			&r.Description,
+			&r.Rating,
+			&r.ReleaseDate,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+			&r.Score,
+			&sourceIDs,
+			&sourceTitles,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan recommendation: %w", err)
+		}
+		r.Source = SourceSimilar
+		r.BecauseOf = make([]FilmRef, len(sourceIDs))
+		for i := range sourceIDs {
+			r.BecauseOf[i] = FilmRef{ID: sourceIDs[i], Title: sourceTitles[i]}
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// TopRated лучшие по рейтингу фильмы, которых нет у пользователя и в exclude
+func (s *Storage) TopRated(ctx context.Context, userID string, exclude []string, limit int) ([]Recommendation, error) {
+	q := `
+        SELECT film_id, title, description, rating, release_date, created_at, updated_at
+        FROM films
+        WHERE film_id NOT IN (SELECT film_id FROM user_film WHERE user_id = $1)
+          AND NOT (film_id::text = ANY($2))
+        ORDER BY rating DESC NULLS LAST, title
+        LIMIT $3
+    `
+	if exclude == nil {
+		// NULL вместо пустого массива исключил бы все фильмы
+		exclude = make([]string, 0)
+	}
+	rows, err := s.client.Query(ctx, q, userID, exclude, limit)
+	if err != nil {
+		s.logger.Errorf("Failed to get top rated films: %v", err)
+		return nil, fmt.Errorf("failed to get top rated films: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]Recommendation, 0, limit)
+	for rows.Next() {
+		r := Recommendation{Source: SourceTopRated, BecauseOf: make([]FilmRef, 0)}
+		if err := rows.Scan(
+			&r.ID,
+			&r.Title,
+			&r.Description,
+			&r.Rating,
+			&r.ReleaseDate,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan film: %w", err)
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// UserExists проверяет, что пользователь есть в каталоге арендатора запроса
+func (s *Storage) UserExists(ctx context.Context, userID string) error {
+	var exists bool
+	if err := s.client.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		s.logger.Errorf("Failed to check user: %v", err)
+		return fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"rest-api-tutorial/internal/account"
+	"rest-api-tutorial/internal/apikey"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/events"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/graphqlapi"
+	"rest-api-tutorial/internal/me"
+	"rest-api-tutorial/internal/recommend"
+	"rest-api-tutorial/internal/session"
+	"rest-api-tutorial/internal/sso"
+	"rest-api-tutorial/internal/stats"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/internal/webhook"
+)
+
+// Handlers обработчики ресурсов API
+type Handlers struct {
+	Users     *user.Handler
+	Films     *films.Handler
+	UsersV2   *user.HandlerV2
+	FilmsV2   *films.HandlerV2
+	APIKeys   *apikey.Handler
+	Events    *events.Handler
+	Webhooks  *webhook.Handler
+	Tenants   *tenant.Handler
+	Account   *account.Handler
+	SSO       *sso.Handler
+	Sessions  *session.Handler
+	Me        *me.Handler
+	Recommend *recommend.Handler
+	Stats     *stats.Handler
+}
+
+// Middleware общие middleware группы /api. Пустое поле означает, что middleware выключен.
+type Middleware struct {
+	Authenticate gin.HandlerFunc
+	// Tenant определяет арендатора запроса; идет после Authenticate
+	Tenant gin.HandlerFunc
+	// WriteTimeout продлевает срок записи ответа для долгих маршрутов
+	WriteTimeout gin.HandlerFunc
+	// RateLimitIP ограничивает частоту по IP-адресу; идет до Authenticate
+	RateLimitIP gin.HandlerFunc
+	RateLimit   gin.HandlerFunc
+	Idempotent  gin.HandlerFunc
+	// DeprecateV1 добавляет заголовки Deprecation и Sunset к ответам v1
+	DeprecateV1 gin.HandlerFunc
+	// CacheFilms кеширует чтение каталога фильмов
+	CacheFilms gin.HandlerFunc
+	// CacheStats кеширует статистику; она не сбрасывается при изменениях и устаревает по TTL
+	CacheStats gin.HandlerFunc
+}
+
+// Register подключает все маршруты API к группе api. Маршруты собраны здесь,
+// а не в main, чтобы тесты контракта могли построить тот же роутер.
+//
+// Ресурсы версионируются: /api/v1 сохраняет прежние форматы ответов, /api/v2
+// отдает ресурсы в конвертах. Пути без версии переписывает apiversion.Negotiate.
+// Массовые операции (import, export, batch) пока есть только в v1.
+func Register(api *gin.RouterGroup, h Handlers, mw Middleware) {
+	// Аутентификация идет до ограничения частоты, чтобы лимиты считались по ключу.
+	// Лимит по IP стоит перед ней: запросы с неверным ключом получают 401 до
+	// RateLimit и иначе не ограничивались бы вовсе.
+	api.Use(chain(mw.WriteTimeout, mw.RateLimitIP, mw.Authenticate, mw.Tenant, mw.RateLimit)...)
+
+	registerV1(api.Group("/v1", chain(mw.DeprecateV1)...), h, mw)
+	registerV2(api.Group("/v2"), h, mw)
+
+	// Поток событий общий для версий; области доступа проверяет обработчик
+	api.GET("/events", h.Events.Stream)
+
+	// Регистрация и восстановление доступа открыты без ключа
+	accounts := api.Group("/account")
+	accounts.POST("/signup", h.Account.Signup)
+	accounts.POST("/verify-email", h.Account.VerifyEmail)
+	accounts.POST("/verify-email/resend", h.Account.ResendVerification)
+	accounts.POST("/forgot-password", h.Account.ForgotPassword)
+	accounts.POST("/reset-password", h.Account.ResetPassword)
+
+	// Вход по паролю и через провайдеров OpenID Connect; callback вызывает браузер после провайдера
+	signin := api.Group("/auth")
+	signin.POST("/login", h.Account.Login)
+	signin.GET("/providers", h.SSO.GetProviders)
+	signin.GET("/oidc/:provider/login", h.SSO.Login)
+	signin.GET("/oidc/:provider/callback", h.SSO.Callback)
+	signin.POST("/refresh", h.Sessions.Refresh)
+
+	// Данные вошедшего пользователя: только с токеном доступа
+	mine := api.Group("/me", auth.RequireUser())
+	mine.GET("", h.Me.GetProfile)
+	mine.PATCH("", h.Me.UpdateProfile)
+	mine.GET("/films", h.Me.GetFilms)
+	mine.POST("/films", h.Me.AddFilm)
+	mine.DELETE("/films/:id", h.Me.RemoveFilm)
+	mine.GET("/preferences", h.Me.GetPreferences)
+	mine.PATCH("/preferences", h.Me.UpdatePreferences)
+	mine.GET("/sessions", h.Sessions.GetMine)
+	mine.DELETE("/sessions/:id", h.Sessions.RevokeMine)
+
+	admin := api.Group("/admin", auth.RequireScope(auth.ScopeAPIKeysAdmin))
+	admin.POST("/api-keys", h.APIKeys.CreateAPIKey)
+	admin.GET("/api-keys", h.APIKeys.GetList)
+	admin.POST("/api-keys/:id/rotate", h.APIKeys.RotateAPIKey)
+	admin.DELETE("/api-keys/:id", h.APIKeys.RevokeAPIKey)
+
+	sessions := api.Group("/admin", auth.RequireScope(auth.ScopeSessionsAdmin))
+	sessions.GET("/users/:id/sessions", h.Sessions.GetUserSessions)
+	sessions.DELETE("/users/:id/sessions", h.Sessions.RevokeUserSessions)
+	sessions.DELETE("/sessions/:id", h.Sessions.RevokeSession)
+
+	// Статистика одинакова для версий: ответы - списки, CSV через Accept: text/csv
+	statistics := api.Group("/stats", chain(auth.RequireScope(auth.ScopeStatsRead), mw.CacheStats)...)
+	statistics.GET("/films/most-added", h.Stats.GetMostAdded)
+	statistics.GET("/films/ratings", h.Stats.GetRatings)
+	statistics.GET("/films/release-years", h.Stats.GetReleaseYears)
+	statistics.GET("/users/demographics", h.Stats.GetDemographics)
+	statistics.GET("/growth", h.Stats.GetGrowth)
+
+	tenants := api.Group("/admin/tenants", auth.RequireScope(auth.ScopeTenantsAdmin))
+	tenants.POST("", h.Tenants.CreateTenant)
+	tenants.GET("", h.Tenants.GetList)
+	tenants.GET("/:id", h.Tenants.GetTenant)
+	tenants.PATCH("/:id", h.Tenants.UpdateTenant)
+	tenants.DELETE("/:id", h.Tenants.DeleteTenant)
+
+	webhooks := api.Group("/webhooks", auth.RequireScope(auth.ScopeWebhooksAdmin))
+	webhooks.POST("", h.Webhooks.CreateWebhook)
+	webhooks.GET("", h.Webhooks.GetList)
+	webhooks.GET("/:id", h.Webhooks.GetWebhook)
+	webhooks.PATCH("/:id", h.Webhooks.UpdateWebhook)
+	webhooks.DELETE("/:id", h.Webhooks.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", h.Webhooks.GetDeliveries)
+	webhooks.POST("/:id/deliveries/:delivery_id/replay", h.Webhooks.ReplayDelivery)
+	webhooks.POST("/:id/replay", h.Webhooks.Replay)
+}
+
+func registerV1(api *gin.RouterGroup, h Handlers, mw Middleware) {
+	usersRead := auth.RequireScope(auth.ScopeUsersRead)
+	usersWrite := auth.RequireScope(auth.ScopeUsersWrite)
+	api.GET("/users", usersRead, h.Users.GetList)
+	api.POST("/users/import", usersWrite, h.Users.ImportUsers)
+	api.GET("/users/export", usersRead, h.Users.ExportUsers)
+	api.POST("/users/batch", chain(usersWrite, mw.Idempotent, h.Users.BatchUsers)...)
+	api.GET("/users/:uuid", usersRead, h.Users.GetUser)
+	api.POST("/users", chain(usersWrite, mw.Idempotent, h.Users.CreateUser)...)
+	api.PUT("/users/:uuid", usersWrite, h.Users.UpdateUser)
+	api.PATCH("/users/:uuid", usersWrite, h.Users.PartiallyUpdateUser)
+	api.DELETE("/users/:uuid", usersWrite, h.Users.DeleteUser)
+
+	filmsRead := auth.RequireScope(auth.ScopeFilmsRead)
+	filmsWrite := auth.RequireScope(auth.ScopeFilmsWrite)
+	// У рекомендаций нет прежнего формата, v1 отдает их так же, как v2
+	api.GET("/users/:uuid/recommendations", usersRead, filmsRead, h.Recommend.GetRecommendations)
+	api.POST("/films", chain(filmsWrite, mw.Idempotent, h.Films.CreateFilm)...)
+	api.GET("/films", chain(filmsRead, mw.CacheFilms, h.Films.GetList)...)
+	api.GET("/films/sort", chain(filmsRead, mw.CacheFilms, h.Films.GetListSort)...)
+	api.POST("/films/import", filmsWrite, h.Films.ImportFilms)
+	api.GET("/films/export", filmsRead, h.Films.ExportFilms)
+	api.POST("/films/batch", chain(filmsWrite, mw.Idempotent, h.Films.BatchFilms)...)
+	api.GET("/films/:uuid", filmsRead, h.Films.GetUserFilm)
+	api.PATCH("/films/:uuid", filmsWrite, h.Films.PartiallyUpdateFilm)
+	api.DELETE("/films/:uuid", filmsWrite, h.Films.DeleteFilm)
+}
+
+func registerV2(api *gin.RouterGroup, h Handlers, mw Middleware) {
+	usersRead := auth.RequireScope(auth.ScopeUsersRead)
+	usersWrite := auth.RequireScope(auth.ScopeUsersWrite)
+	filmsRead := auth.RequireScope(auth.ScopeFilmsRead)
+	filmsWrite := auth.RequireScope(auth.ScopeFilmsWrite)
+
+	api.GET("/users", usersRead, h.UsersV2.GetList)
+	api.POST("/users", chain(usersWrite, mw.Idempotent, h.UsersV2.CreateUser)...)
+	api.GET("/users/:uuid", usersRead, h.UsersV2.GetUser)
+	api.PUT("/users/:uuid", usersWrite, h.UsersV2.UpdateUser)
+	api.PATCH("/users/:uuid", usersWrite, h.UsersV2.PartiallyUpdateUser)
+	api.DELETE("/users/:uuid", usersWrite, h.UsersV2.DeleteUser)
+	api.GET("/users/:uuid/films", usersRead, filmsRead, h.FilmsV2.GetUserFilms)
+	api.GET("/users/:uuid/recommendations", usersRead, filmsRead, h.Recommend.GetRecommendations)
+
+	api.GET("/films", chain(filmsRead, mw.CacheFilms, h.FilmsV2.GetList)...)
+	api.POST("/films", chain(filmsWrite, mw.Idempotent, h.FilmsV2.CreateFilm)...)
+	api.GET("/films/:uuid", chain(filmsRead, mw.CacheFilms, h.FilmsV2.GetFilm)...)
+	api.PATCH("/films/:uuid", filmsWrite, h.FilmsV2.PartiallyUpdateFilm)
+	api.DELETE("/films/:uuid", filmsWrite, h.FilmsV2.DeleteFilm)
+}
+
+// RegisterGraphQL подключает POST и GET /graphql вне версионируемой группы /api
+// с той же аутентификацией, выбором арендатора и ограничением частоты. Области доступа проверяют резолверы.
+func RegisterGraphQL(router gin.IRoutes, h *graphqlapi.Handler, mw Middleware) {
+	handlers := chain(mw.RateLimitIP, mw.Authenticate, mw.Tenant, mw.RateLimit, h.Serve)
+	router.POST("/graphql", handlers...)
+	router.GET("/graphql", handlers...)
+}
+
+// chain убирает из цепочки выключенные (nil) middleware
+func chain(handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+	out := make([]gin.HandlerFunc, 0, len(handlers))
+	for _, h := range handlers {
+		if h != nil {
+			out = append(out, h)
+		}
+	}
+	return out
+}
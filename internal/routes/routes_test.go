@@ -0,0 +1,74 @@
+package routes_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/config"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/memory"
+	"rest-api-tutorial/internal/routes"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/logging"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUserTokenCannotEditCatalog токен вошедшего пользователя с областями по
+// умолчанию не дает менять чужих пользователей и фильмы
+func TestUserTokenCannotEditCatalog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logging.GetLogger()
+
+	scopes, err := auth.ParseScopes(config.LoadConfigEnv().Auth.UserScopes)
+	if err != nil {
+		t.Fatalf("ParseScopes: %v", err)
+	}
+	tokens, err := auth.NewTokens("", time.Minute, scopes)
+	if err != nil {
+		t.Fatalf("NewTokens: %v", err)
+	}
+	token, _, err := tokens.Issue("5b1d1b4e-4c2f-4a57-9c1e-0d5f7a3e8b21", "", "0f8e7b2a-3c4d-4e5f-8a9b-1c2d3e4f5a6b")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	db := memory.NewDB()
+	filmStorage, userStorage := memory.NewFilmStorage(db), memory.NewUserStorage(db)
+	limits := batch.Limits{MaxOperations: 10, MaxBodyBytes: 1 << 20}
+	router := gin.New()
+	routes.Register(router.Group("/api"), routes.Handlers{
+		Films:   films.NewHandler(filmStorage, limits, logger),
+		FilmsV2: films.NewHandlerV2(filmStorage, logger),
+		Users:   user.NewHandler(userStorage, limits, logger),
+		UsersV2: user.NewHandlerV2(userStorage, logger),
+	}, routes.Middleware{
+		Authenticate: auth.Middleware(nil, auth.Options{Tokens: tokens}, logger),
+	})
+
+	victim := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/api/v1/users", "", http.StatusOK},
+		{http.MethodPatch, "/api/v1/users/" + victim, `{"email": "attacker@example.com"}`, http.StatusForbidden},
+		{http.MethodPut, "/api/v2/users/" + victim, `{}`, http.StatusForbidden},
+		{http.MethodDelete, "/api/v1/users/" + victim, "", http.StatusForbidden},
+		{http.MethodPost, "/api/v2/films", `{}`, http.StatusForbidden},
+		{http.MethodDelete, "/api/v1/films/" + victim, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s with a user token: status %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+		}
+	}
+}
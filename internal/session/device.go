@@ -0,0 +1,60 @@
+package session
+
+import "strings"
+
+// Порядок важен: User-Agent Edge и Opera содержат Chrome, а Chrome содержит Safari
+var browsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"YaBrowser/", "Yandex Browser"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+var systems = []struct{ token, name string }{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// deviceName краткое описание устройства по User-Agent для списка сеансов,
+// например "Firefox on Linux". Клиенты без браузера (curl, мобильные SDK)
+// называются по первому продукту в User-Agent.
+func deviceName(userAgent string) string {
+	var browser, system string
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	product, _, _ := strings.Cut(strings.TrimSpace(userAgent), " ")
+	product, _, _ = strings.Cut(product, "/")
+	if product == "" {
+		return "Unknown device"
+	}
+	if len(product) > 64 {
+		product = product[:64]
+	}
+	return product
+}
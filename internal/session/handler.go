@@ -0,0 +1,193 @@
+package session
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+)
+
+type Handler struct {
+	logger  *logging.Logger
+	manager *Manager
+	storage *Storage
+}
+
+func NewHandler(manager *Manager, storage *Storage, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:  logger,
+		manager: manager,
+		storage: storage,
+	}
+}
+
+// Refresh godoc
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once: presenting a used one again ends the whole session, because it means the token was copied.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body Refresh true "Refresh token"
+// @Success 200 {object} envelope.Resource[session.Token] "New tokens"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 401 {object} envelope.ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /auth/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var input Refresh
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	token, err := h.manager.Refresh(c.Request.Context(), input.RefreshToken, ClientFrom(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTokenReused):
+			envelope.Error(c, http.StatusUnauthorized, "Refresh token was already used, the session has been ended")
+		case errors.Is(err, ErrInvalidToken):
+			envelope.Error(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		default:
+			h.logger.Errorf("Failed to refresh session: %v", err)
+			envelope.Error(c, http.StatusInternalServerError, "Failed to refresh session")
+		}
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	envelope.Data(c, http.StatusOK, *token)
+}
+
+// GetMine godoc
+// @Summary List my sessions
+// @Description List the active sessions of the signed-in user with device, IP address and last activity. The session of this request is marked current.
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} envelope.Collection[session.Session] "Active sessions"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me/sessions [get]
+func (h *Handler) GetMine(c *gin.Context) {
+	principal := auth.PrincipalFrom(c)
+	sessions, err := h.storage.FindByUser(c.Request.Context(), principal.TenantID, principal.ID)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == principal.SessionID
+	}
+	envelope.List(c, http.StatusOK, sessions)
+}
+
+// RevokeMine godoc
+// @Summary End one of my sessions
+// @Description Sign out a device. Its access token stops working immediately and its refresh token can no longer be used. Ending the current session signs out this client.
+// @Tags me
+// @Security BearerAuth
+// @Param id path string true "Session ID (UUID)"
+// @Success 204 "Session ended"
+// @Failure 401 {object} envelope.ErrorResponse "User access token required"
+// @Failure 404 {object} envelope.ErrorResponse "Session not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /me/sessions/{id} [delete]
+func (h *Handler) RevokeMine(c *gin.Context) {
+	principal := auth.PrincipalFrom(c)
+	h.revoke(c, principal.TenantID, principal.ID, reasonLogout)
+}
+
+// GetUserSessions godoc
+// @Summary List a user's sessions
+// @Description List the active sessions of a user in the tenant catalog
+// @Tags sessions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID (UUID)"
+// @Success 200 {object} envelope.Collection[session.Session] "Active sessions"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing sessions:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "User not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/sessions [get]
+func (h *Handler) GetUserSessions(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := uuid.FromString(userID); err != nil {
+		envelope.Error(c, http.StatusNotFound, "User not found")
+		return
+	}
+	tenantID, _ := tenant.FromContext(c.Request.Context())
+	sessions, err := h.storage.FindByUser(c.Request.Context(), tenantID, userID)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+	envelope.List(c, http.StatusOK, sessions)
+}
+
+// RevokeUserSessions godoc
+// @Summary Sign a user out everywhere
+// @Description Forced logout: end all active sessions of a user in the tenant catalog. Access tokens stop working immediately.
+// @Tags sessions
+// @Security ApiKeyAuth
+// @Param id path string true "User ID (UUID)"
+// @Success 204 "Sessions ended"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing sessions:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "User not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/sessions [delete]
+func (h *Handler) RevokeUserSessions(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := uuid.FromString(userID); err != nil {
+		envelope.Error(c, http.StatusNotFound, "User not found")
+		return
+	}
+	ctx := c.Request.Context()
+	tenantID, _ := tenant.FromContext(ctx)
+	revoked, err := h.storage.RevokeUser(ctx, tenantID, userID, reasonAdmin)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to end sessions")
+		return
+	}
+	h.logger.Infof("Ended %d sessions of user %s by %s", revoked, userID, auth.PrincipalFrom(c).Subject())
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeSession godoc
+// @Summary End a session
+// @Description Forced logout of one device of any user in the tenant catalog
+// @Tags sessions
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID (UUID)"
+// @Success 204 "Session ended"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing sessions:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Session not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/sessions/{id} [delete]
+func (h *Handler) RevokeSession(c *gin.Context) {
+	tenantID, _ := tenant.FromContext(c.Request.Context())
+	h.revoke(c, tenantID, "", reasonAdmin)
+}
+
+// revoke завершает сеанс из пути запроса; userID ограничивает поиск его сеансами
+func (h *Handler) revoke(c *gin.Context, tenantID, userID, reason string) {
+	sessionID := c.Param("id")
+	if _, err := uuid.FromString(sessionID); err != nil {
+		envelope.Error(c, http.StatusNotFound, "Session not found")
+		return
+	}
+	if err := h.storage.Revoke(c.Request.Context(), tenantID, sessionID, userID, reason); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			envelope.Error(c, http.StatusNotFound, "Session not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to end session")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"rest-api-tutorial/internal/auth"
+	"time"
+)
+
+// Options настройки сеансов
+type Options struct {
+	// TTL сколько сеанс живет без обновления токенов; каждое обновление продлевает его
+	TTL time.Duration
+}
+
+// Validate проверяет срок жизни сеанса
+func (o Options) Validate() error {
+	if o.TTL < time.Minute {
+		return fmt.Errorf("session ttl must be at least a minute, got %s", o.TTL)
+	}
+	return nil
+}
+
+// Manager начинает сеансы и обновляет их токены. Вход через любой способ
+// аутентификации пользователя заканчивается вызовом Start.
+type Manager struct {
+	storage *Storage
+	tokens  *auth.Tokens
+	opts    Options
+}
+
+func NewManager(storage *Storage, tokens *auth.Tokens, opts Options) *Manager {
+	return &Manager{
+		storage: storage,
+		tokens:  tokens,
+		opts:    opts,
+	}
+}
+
+// ClientFrom адрес и User-Agent клиента запроса
+func ClientFrom(c *gin.Context) Client {
+	return Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// Start начинает сеанс пользователя userID в каталоге арендатора tenantID
+func (m *Manager) Start(ctx context.Context, tenantID, userID string, client Client) (*Token, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	id := identity{TenantID: tenantID, UserID: userID}
+	id.SessionID, err = m.storage.Create(ctx, id, client, refreshHash, time.Now().Add(m.opts.TTL))
+	if err != nil {
+		return nil, err
+	}
+	return m.issue(id, refreshToken)
+}
+
+// Refresh меняет refresh token на новую пару токенов. Возвращает ErrInvalidToken
+// или ErrTokenReused, если токен не подходит.
+func (m *Manager) Refresh(ctx context.Context, refreshToken string, client Client) (*Token, error) {
+	next, nextHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	id, err := m.storage.Rotate(ctx, hashToken(refreshToken), nextHash, client, time.Now().Add(m.opts.TTL))
+	if err != nil {
+		return nil, err
+	}
+	return m.issue(*id, next)
+}
+
+func (m *Manager) issue(id identity, refreshToken string) (*Token, error) {
+	accessToken, expiresAt, err := m.tokens.Issue(id.UserID, id.TenantID, id.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(expiresAt).Round(time.Second).Seconds()),
+		RefreshToken: refreshToken,
+		SessionID:    id.SessionID,
+	}, nil
+}
+
+// newRefreshToken создает случайный refresh token и его хеш для базы
+func newRefreshToken() (token string, hash []byte, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken хеш refresh token, по которому он ищется в refresh_tokens
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
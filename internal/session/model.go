@@ -0,0 +1,70 @@
+package session
+
+import "time"
+
+// Причины отзыва сеанса, колонка user_sessions.revoked_reason. Сеансы,
+// отозванные сменой пароля, помечает account.
+const (
+	reasonLogout = "logout"
+	reasonAdmin  = "admin"
+	reasonReuse  = "reuse"
+)
+
+// Session сеанс пользователя
+// @description Устройство, с которого выполнен вход, и время последней активности
+type Session struct {
+	// @format uuid
+	ID string `json:"id"`
+
+	// @format uuid
+	UserID string `json:"user_id"`
+
+	// Браузер и система, определенные по User-Agent
+	Device    string `json:"device" example:"Firefox on Linux"`
+	UserAgent string `json:"user_agent,omitempty"`
+	IP        string `json:"ip,omitempty" example:"203.0.113.7"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+
+	// Сеанс завершится, если до этого времени не обновить токен
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Этим сеансом выполнен текущий запрос
+	Current bool `json:"current"`
+}
+
+// Token токены сеанса
+// @description Токен доступа для заголовка Authorization: Bearer и refresh token для его обновления
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+
+	// Срок действия токена доступа в секундах
+	ExpiresIn int `json:"expires_in" example:"900"`
+
+	// Одноразовый: обновление возвращает новый refresh token, повторное
+	// использование старого завершает сеанс
+	RefreshToken string `json:"refresh_token"`
+
+	// @format uuid
+	SessionID string `json:"session_id"`
+}
+
+// Refresh запрос обновления токенов
+type Refresh struct {
+	RefreshToken string `json:"refresh_token" binding:"required,max=64"`
+}
+
+// Client откуда выполнен вход или обновление токенов
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// identity пользователь и арендатор сеанса
+type identity struct {
+	SessionID string
+	TenantID  string
+	UserID    string
+}
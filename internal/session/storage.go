@@ -0,0 +1,249 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+var (
+	// ErrNotFound сеанс не существует, уже завершен или принадлежит другому пользователю
+	ErrNotFound = errors.New("session not found")
+	// ErrInvalidToken refresh token неизвестен, истек или его сеанс завершен
+	ErrInvalidToken = errors.New("invalid or expired refresh token")
+	// ErrTokenReused предъявлен уже использованный refresh token; сеанс отозван
+	ErrTokenReused = errors.New("refresh token reuse detected")
+)
+
+// lastSeenPrecision как часто обновляется last_seen_at, чтобы не писать в базу на каждый запрос
+const lastSeenPrecision = time.Minute
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+// Create начинает сеанс с первым refresh token. Заодно удаляются завершенные
+// сеансы пользователя, чтобы таблица не росла.
+func (s *Storage) Create(ctx context.Context, id identity, client Client, refreshHash []byte, expiresAt time.Time) (string, error) {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qCleanup := `
+        DELETE FROM user_sessions
+        WHERE tenant_id = $1 AND user_id = $2 AND (expires_at < NOW() OR revoked_at IS NOT NULL)
+    `
+	if _, err := tx.Exec(ctx, qCleanup, id.TenantID, id.UserID); err != nil {
+		return "", fmt.Errorf("failed to delete finished sessions: %w", err)
+	}
+
+	q := `
+        INSERT INTO user_sessions (tenant_id, user_id, device, user_agent, ip, expires_at)
+        VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, '')::inet, $6)
+        RETURNING id
+    `
+	var sessionID string
+	err = tx.QueryRow(ctx, q, id.TenantID, id.UserID, deviceName(client.UserAgent), client.UserAgent, client.IP, expiresAt).Scan(&sessionID)
+	if err != nil {
+		s.logger.Errorf("Failed to create session: %v", err)
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	qToken := `INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)`
+	if _, err := tx.Exec(ctx, qToken, refreshHash, sessionID); err != nil {
+		return "", fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return sessionID, nil
+}
+
+// Rotate меняет refresh token сеанса на новый и продлевает сеанс до expiresAt.
+// Уже использованный токен означает, что его скопировали: сеанс вместе со всеми
+// токенами семейства отзывается и возвращается ErrTokenReused.
+func (s *Storage) Rotate(ctx context.Context, oldHash, newHash []byte, client Client, expiresAt time.Time) (*identity, error) {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокировка обеих строк: параллельное обновление тем же токеном дождется
+	// этой транзакции и увидит токен использованным
+	q := `
+        SELECT s.id, s.tenant_id, s.user_id, rt.used_at, s.revoked_at, s.expires_at
+        FROM refresh_tokens rt
+        JOIN user_sessions s ON s.id = rt.session_id
+        WHERE rt.token_hash = $1
+        FOR UPDATE
+    `
+	var (
+		id        identity
+		usedAt    *time.Time
+		revokedAt *time.Time
+		sessionTo time.Time
+	)
+	err = tx.QueryRow(ctx, q, oldHash).Scan(&id.SessionID, &id.TenantID, &id.UserID, &usedAt, &revokedAt, &sessionTo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if revokedAt != nil || sessionTo.Before(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	if usedAt != nil {
+		if err := revoke(ctx, tx, id.SessionID, reasonReuse); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		s.logger.Warnf("Refresh token reuse in session %s of user %s, session revoked", id.SessionID, id.UserID)
+		return nil, ErrTokenReused
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1`, oldHash); err != nil {
+		return nil, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)`, newHash, id.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	qSession := `
+        UPDATE user_sessions
+        SET last_seen_at = NOW(),
+            expires_at = $2,
+            ip = COALESCE(NULLIF($3, '')::inet, ip),
+            user_agent = COALESCE(NULLIF($4, ''), user_agent)
+        WHERE id = $1
+    `
+	if _, err := tx.Exec(ctx, qSession, id.SessionID, expiresAt, client.IP, client.UserAgent); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &id, nil
+}
+
+// CheckSession реализует auth.SessionChecker: сеанс действует, если он не отозван
+// и не истек. Заодно отмечается активность сеанса.
+func (s *Storage) CheckSession(ctx context.Context, sessionID string) error {
+	if _, err := uuid.FromString(sessionID); err != nil {
+		return auth.ErrInvalidCredentials
+	}
+	q := `
+        SELECT last_seen_at
+        FROM user_sessions
+        WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+    `
+	var lastSeenAt time.Time
+	if err := s.client.QueryRow(ctx, q, sessionID).Scan(&lastSeenAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.ErrInvalidCredentials
+		}
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if time.Since(lastSeenAt) >= lastSeenPrecision {
+		if _, err := s.client.Exec(ctx, `UPDATE user_sessions SET last_seen_at = NOW() WHERE id = $1`, sessionID); err != nil {
+			s.logger.Warnf("Failed to update session last activity: %v", err)
+		}
+	}
+	return nil
+}
+
+// FindByUser возвращает действующие сеансы пользователя, последние активные первыми
+func (s *Storage) FindByUser(ctx context.Context, tenantID, userID string) ([]Session, error) {
+	q := `
+        SELECT id, user_id, device, COALESCE(user_agent, ''), COALESCE(host(ip), ''), created_at, last_seen_at, expires_at
+        FROM user_sessions
+        WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+        ORDER BY last_seen_at DESC
+    `
+	rows, err := s.client.Query(ctx, q, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.Device,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke завершает сеанс в каталоге арендатора. Непустой userID ограничивает
+// поиск сеансами этого пользователя.
+func (s *Storage) Revoke(ctx context.Context, tenantID, sessionID, userID, reason string) error {
+	q := `
+        UPDATE user_sessions
+        SET revoked_at = NOW(), revoked_reason = $4
+        WHERE id = $1 AND tenant_id = $2 AND ($3 = '' OR user_id::text = $3)
+            AND revoked_at IS NULL AND expires_at > NOW()
+    `
+	tag, err := s.client.Exec(ctx, q, sessionID, tenantID, userID, reason)
+	if err != nil {
+		s.logger.Errorf("Failed to revoke session: %v", err)
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeUser завершает все сеансы пользователя и возвращает их число
+func (s *Storage) RevokeUser(ctx context.Context, tenantID, userID, reason string) (int64, error) {
+	q := `
+        UPDATE user_sessions
+        SET revoked_at = NOW(), revoked_reason = $3
+        WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+    `
+	tag, err := s.client.Exec(ctx, q, tenantID, userID, reason)
+	if err != nil {
+		s.logger.Errorf("Failed to revoke user sessions: %v", err)
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func revoke(ctx context.Context, tx pgx.Tx, sessionID, reason string) error {
+	q := `UPDATE user_sessions SET revoked_at = NOW(), revoked_reason = $2 WHERE id = $1 AND revoked_at IS NULL`
+	if _, err := tx.Exec(ctx, q, sessionID, reason); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/pgtest"
+	"rest-api-tutorial/pkg/logging"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m, pgtest.Options{}))
+}
+
+func TestStorageRotateReuse(t *testing.T) {
+	pool := pgtest.DB(t)
+	storage := NewStorage(pool, logging.GetLogger())
+	ctx := pgtest.Context()
+	u := pgtest.NewFixtures(t, pool).User()
+	client := Client{IP: "203.0.113.7", UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	sessionID, err := storage.Create(ctx, identity{TenantID: pgtest.DefaultTenant, UserID: u.ID}, client, hashToken("first"), expiresAt)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Шаги выполняются по порядку над одним сеансом
+	steps := []struct {
+		name     string
+		old, new string
+		err      error
+	}{
+		{"unknown token", "missing", "unused", ErrInvalidToken},
+		{"first rotation", "first", "second", nil},
+		{"second rotation", "second", "third", nil},
+		// Старый токен предъявлен повторно: его скопировали, сеанс отзывается
+		{"reused token", "first", "stolen", ErrTokenReused},
+		{"current token after reuse", "third", "fourth", ErrInvalidToken},
+		{"reused token again", "first", "stolen-again", ErrInvalidToken},
+	}
+	for _, step := range steps {
+		id, err := storage.Rotate(ctx, hashToken(step.old), hashToken(step.new), client, expiresAt)
+		if !errors.Is(err, step.err) {
+			t.Fatalf("%s: Rotate error %v, want %v", step.name, err, step.err)
+		}
+		if err == nil && (id.SessionID != sessionID || id.UserID != u.ID || id.TenantID != pgtest.DefaultTenant) {
+			t.Errorf("%s: Rotate = %+v, want session %s of user %s", step.name, id, sessionID, u.ID)
+		}
+	}
+
+	if err := storage.CheckSession(ctx, sessionID); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("CheckSession after reuse: %v, want ErrInvalidCredentials", err)
+	}
+	var reason string
+	if err := pool.QueryRow(ctx, `SELECT revoked_reason FROM user_sessions WHERE id = $1`, sessionID).Scan(&reason); err != nil || reason != reasonReuse {
+		t.Errorf("revoked_reason = %q, %v; want %q", reason, err, reasonReuse)
+	}
+}
+
+func TestStorageRotateExpired(t *testing.T) {
+	pool := pgtest.DB(t)
+	storage := NewStorage(pool, logging.GetLogger())
+	ctx := pgtest.Context()
+	u := pgtest.NewFixtures(t, pool).User()
+
+	_, err := storage.Create(ctx, identity{TenantID: pgtest.DefaultTenant, UserID: u.ID}, Client{}, hashToken("first"), time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := storage.Rotate(ctx, hashToken("first"), hashToken("second"), Client{}, time.Now().Add(time.Hour)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Rotate of an expired session: %v, want ErrInvalidToken", err)
+	}
+}
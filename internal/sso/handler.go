@@ -0,0 +1,329 @@
+package sso
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	"path"
+	"rest-api-tutorial/internal/session"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/oidc"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// providerTimeout ограничение одного запроса к провайдеру
+const providerTimeout = 10 * time.Second
+
+// stateCookie хеш state входа в браузере, который начал вход. Callback принимает
+// только state из этой cookie, поэтому чужую ссылку callback нельзя завершить
+// в браузере жертвы (login CSRF).
+const stateCookie = "oidc_state"
+
+// Options настройки потока входа
+type Options struct {
+	// StateTTL сколько ждать возврата пользователя от провайдера
+	StateTTL time.Duration
+	// ReturnURLs адреса клиентов, на которые можно вернуть браузер после входа:
+	// return_to должен совпадать со схемой и хостом одного из них, а путь — с его путем
+	// или продолжать его после "/"
+	ReturnURLs []string
+}
+
+type provider struct {
+	cfg    ProviderConfig
+	client *oidc.Provider
+}
+
+type Handler struct {
+	logger    *logging.Logger
+	storage   *Storage
+	sessions  *session.Manager
+	providers map[string]*provider
+	returnTo  []*url.URL
+	opts      Options
+}
+
+// NewHandler проверяет настройки провайдеров и адресов возврата. Сами провайдеры
+// не опрашиваются до первого входа.
+func NewHandler(configs []ProviderConfig, storage *Storage, sessions *session.Manager, opts Options, logger *logging.Logger) (*Handler, error) {
+	if opts.StateTTL <= 0 {
+		return nil, fmt.Errorf("login state ttl must be positive, got %s", opts.StateTTL)
+	}
+	h := &Handler{
+		logger:    logger,
+		storage:   storage,
+		sessions:  sessions,
+		providers: make(map[string]*provider, len(configs)),
+		opts:      opts,
+	}
+	client := &http.Client{Timeout: providerTimeout}
+	for _, cfg := range configs {
+		if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, fmt.Errorf("oidc provider %q needs an issuer, client id and redirect url", cfg.Name)
+		}
+		if _, ok := h.providers[cfg.Name]; ok {
+			return nil, fmt.Errorf("duplicate oidc provider %q", cfg.Name)
+		}
+		h.providers[cfg.Name] = &provider{cfg: cfg, client: oidc.NewProvider(cfg.Config, client)}
+	}
+	for _, raw := range opts.ReturnURLs {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid return url %q, expected an absolute http(s) url", raw)
+		}
+		h.returnTo = append(h.returnTo, u)
+	}
+	return h, nil
+}
+
+// GetProviders godoc
+// @Summary List identity providers
+// @Description List the configured OpenID Connect providers users can sign in with
+// @Tags auth
+// @Produce json
+// @Success 200 {object} envelope.Collection[sso.Provider] "Providers"
+// @Router /auth/providers [get]
+func (h *Handler) GetProviders(c *gin.Context) {
+	list := make([]Provider, 0, len(h.providers))
+	for name := range h.providers {
+		list = append(list, Provider{Name: name, LoginURL: "/api/auth/oidc/" + name + "/login"})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	envelope.List(c, http.StatusOK, list)
+}
+
+// Login godoc
+// @Summary Start signing in with an identity provider
+// @Description Redirect the browser to the provider's login page (authorization code flow with PKCE). The tenant of this request is remembered for the callback. An HttpOnly oidc_state cookie binds the login to this browser: the callback is accepted only in the browser that started the login.
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Param return_to query string false "Client page to return to after login; must match OIDC_RETURN_URLS. Tokens are passed in the URL fragment."
+// @Success 302 "Redirect to the provider"
+// @Failure 400 {object} envelope.ErrorResponse "return_to is not allowed"
+// @Failure 404 {object} envelope.ErrorResponse "Unknown provider"
+// @Failure 502 {object} envelope.ErrorResponse "Identity provider unavailable"
+// @Router /auth/oidc/{provider}/login [get]
+func (h *Handler) Login(c *gin.Context) {
+	p, ok := h.providers[c.Param("provider")]
+	if !ok {
+		envelope.Error(c, http.StatusNotFound, "Unknown identity provider")
+		return
+	}
+	returnTo := c.Query("return_to")
+	if returnTo != "" && !h.allowedReturn(returnTo) {
+		envelope.Error(c, http.StatusBadRequest, "return_to is not an allowed client URL")
+		return
+	}
+
+	state, errState := oidc.RandomString()
+	nonce, errNonce := oidc.RandomString()
+	verifier, errVerifier := oidc.RandomString()
+	if err := errors.Join(errState, errNonce, errVerifier); err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	ctx := c.Request.Context()
+	target, err := p.client.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		h.logger.Errorf("Failed to discover identity provider %s: %v", p.cfg.Name, err)
+		envelope.Error(c, http.StatusBadGateway, "Identity provider unavailable")
+		return
+	}
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		envelope.Error(c, http.StatusBadRequest, "Tenant is required")
+		return
+	}
+	err = h.storage.SaveState(ctx, state, loginState{
+		TenantID:  tenantID,
+		Provider:  p.cfg.Name,
+		Verifier:  verifier,
+		Nonce:     nonce,
+		ReturnTo:  returnTo,
+		ExpiresAt: time.Now().Add(h.opts.StateTTL),
+	})
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	setStateCookie(c, hex.EncodeToString(hashState(state)), int(h.opts.StateTTL.Seconds()))
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, target)
+}
+
+// Callback godoc
+// @Summary Finish signing in with an identity provider
+// @Description The provider redirects the browser here. The identity is linked to the user with the same verified email or, if allowed, a new user is created from the name, email, birthdate and gender claims. A session is started for the device. Without return_to the tokens are returned as JSON; with return_to the browser is redirected there with access_token and refresh_token (or error) in the URL fragment.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string false "Authorization code"
+// @Param state query string true "Login state"
+// @Param error query string false "Error returned by the provider"
+// @Success 200 {object} envelope.Resource[sso.Token] "Signed in"
+// @Success 302 "Redirect to return_to"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid or expired login state, or the oidc_state cookie does not match"
+// @Failure 401 {object} envelope.ErrorResponse "Login denied by the provider or invalid ID token"
+// @Failure 403 {object} envelope.ErrorResponse "No account for this identity"
+// @Failure 409 {object} envelope.ErrorResponse "Email belongs to another account"
+// @Failure 422 {object} envelope.ErrorResponse "Provider did not return the data required for a new user"
+// @Failure 502 {object} envelope.ErrorResponse "Identity provider unavailable"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *Handler) Callback(c *gin.Context) {
+	ctx := c.Request.Context()
+	state := c.Query("state")
+	cookie, _ := c.Cookie(stateCookie)
+	setStateCookie(c, "", -1)
+	if expected, err := hex.DecodeString(cookie); err != nil || subtle.ConstantTimeCompare(expected, hashState(state)) != 1 {
+		envelope.Error(c, http.StatusBadRequest, "Invalid or expired login state, start the login again")
+		return
+	}
+	login, err := h.storage.ConsumeState(ctx, state)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidState) {
+			h.logger.Errorf("Failed to finish login: %v", err)
+		}
+		envelope.Error(c, http.StatusBadRequest, "Invalid or expired login state, start the login again")
+		return
+	}
+	p, ok := h.providers[c.Param("provider")]
+	if !ok || p.cfg.Name != login.Provider {
+		envelope.Error(c, http.StatusBadRequest, "Invalid or expired login state, start the login again")
+		return
+	}
+	if providerErr := c.Query("error"); providerErr != "" {
+		h.fail(c, login, http.StatusUnauthorized, providerErr, "Login was denied by the identity provider")
+		return
+	}
+
+	idToken, err := p.client.Exchange(ctx, c.Query("code"), login.Verifier)
+	if err != nil {
+		h.logger.Errorf("Failed to exchange code with identity provider %s: %v", p.cfg.Name, err)
+		if errors.Is(err, oidc.ErrProviderUnavailable) {
+			h.fail(c, login, http.StatusBadGateway, "temporarily_unavailable", "Identity provider unavailable")
+			return
+		}
+		h.fail(c, login, http.StatusUnauthorized, "access_denied", "Identity provider rejected the authorization code")
+		return
+	}
+	claims, err := p.client.VerifyIDToken(ctx, idToken, login.Nonce)
+	if err != nil {
+		h.logger.Errorf("Rejected ID token from identity provider %s: %v", p.cfg.Name, err)
+		if errors.Is(err, oidc.ErrProviderUnavailable) {
+			h.fail(c, login, http.StatusBadGateway, "temporarily_unavailable", "Identity provider unavailable")
+			return
+		}
+		h.fail(c, login, http.StatusUnauthorized, "invalid_token", "Invalid ID token")
+		return
+	}
+
+	// Callback общий для всех каталогов: вход продолжается у арендатора, начавшего его
+	ctx = tenant.ContextWithTenant(ctx, login.TenantID)
+	userID, created, err := h.storage.Link(ctx, p.cfg.Name, claims, claims.EmailVerified || p.cfg.TrustEmail, p.cfg.JIT)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoAccount):
+			h.fail(c, login, http.StatusForbidden, "no_account", "No account for this identity")
+		case errors.Is(err, ErrEmailTaken):
+			h.fail(c, login, http.StatusConflict, "email_taken", "Email belongs to another account, sign in with it and verify the email first")
+		case errors.Is(err, ErrIncompleteProfile):
+			h.fail(c, login, http.StatusUnprocessableEntity, "incomplete_profile", "Identity provider did not return name, email, birthdate and gender required for a new user")
+		default:
+			h.logger.Errorf("Failed to link identity: %v", err)
+			h.fail(c, login, http.StatusInternalServerError, "server_error", "Failed to sign in")
+		}
+		return
+	}
+
+	// Вход начинает сеанс: клиент обновляет токен доступа через /auth/refresh
+	started, err := h.sessions.Start(ctx, login.TenantID, userID, session.ClientFrom(c))
+	if err != nil {
+		h.logger.Errorf("Failed to start session: %v", err)
+		h.fail(c, login, http.StatusInternalServerError, "server_error", "Failed to sign in")
+		return
+	}
+	token := Token{Token: *started, UserID: userID, Created: created}
+
+	c.Header("Cache-Control", "no-store")
+	if login.ReturnTo != "" {
+		c.Redirect(http.StatusFound, login.ReturnTo+"#"+url.Values{
+			"access_token":  {token.AccessToken},
+			"token_type":    {token.TokenType},
+			"expires_in":    {strconv.Itoa(token.ExpiresIn)},
+			"refresh_token": {token.RefreshToken},
+		}.Encode())
+		return
+	}
+	envelope.Data(c, http.StatusOK, token)
+}
+
+// fail сообщает об ошибке входа: браузер с return_to возвращается к клиенту
+// с error во фрагменте, иначе ответ JSON
+func (h *Handler) fail(c *gin.Context, login *loginState, status int, code, message string) {
+	if login.ReturnTo != "" {
+		c.Redirect(http.StatusFound, login.ReturnTo+"#"+url.Values{
+			"error":             {code},
+			"error_description": {message},
+		}.Encode())
+		return
+	}
+	envelope.Error(c, status, message)
+}
+
+// setStateCookie ставит или (maxAge < 0) удаляет cookie состояния входа. Путь
+// общий для login и callback провайдера: /api/auth/oidc/<provider>.
+// SameSite=Lax: cookie приходит при возврате браузера от провайдера.
+func setStateCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     stateCookie,
+		Value:    value,
+		Path:     path.Dir(c.Request.URL.Path),
+		MaxAge:   maxAge,
+		Secure:   c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// allowedReturn проверяет return_to по списку адресов клиентов. Путь должен
+// совпадать с путем клиента или продолжать его после "/": /app не разрешает /application.
+func (h *Handler) allowedReturn(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	// Браузер схлопывает . и .., и /app/../admin ушел бы за пределы клиента
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	for _, allowed := range h.returnTo {
+		if u.Scheme == allowed.Scheme && u.Host == allowed.Host && withinPath(u.Path, allowed.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinPath сообщает, что p совпадает с base или лежит под ним по границе сегмента
+func withinPath(p, base string) bool {
+	if p == base || base == "" || base == "/" {
+		return true
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return strings.HasPrefix(p, base)
+}
@@ -0,0 +1,280 @@
+package sso_test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/oidctest"
+	"rest-api-tutorial/internal/pgtest"
+	"rest-api-tutorial/internal/session"
+	"rest-api-tutorial/internal/sso"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/oidc"
+	"strings"
+	"testing"
+	"time"
+)
+
+const callbackURL = "http://api.example.com/api/auth/oidc/mock/callback"
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m, pgtest.Options{}))
+}
+
+// fixture роутер входа через мок-провайдер над изолированной базой
+type fixture struct {
+	t      *testing.T
+	pool   *pgxpool.Pool
+	issuer *oidctest.Issuer
+	router *gin.Engine
+}
+
+func newFixture(t *testing.T, jit bool) *fixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	pool := pgtest.DB(t)
+	logger := logging.GetLogger()
+
+	issuer, err := oidctest.Start(oidctest.Options{
+		ClientID:     "movies",
+		ClientSecret: "secret",
+		RedirectURLs: []string{callbackURL},
+	})
+	if err != nil {
+		t.Fatalf("start issuer: %v", err)
+	}
+	t.Cleanup(issuer.Close)
+
+	tokens, err := auth.NewTokens("", time.Minute, nil)
+	if err != nil {
+		t.Fatalf("NewTokens: %v", err)
+	}
+	sessions := session.NewManager(session.NewStorage(pool, logger), tokens, session.Options{TTL: time.Hour})
+	h, err := sso.NewHandler([]sso.ProviderConfig{{
+		Name: "mock",
+		Config: oidc.Config{
+			Issuer:       issuer.URL(),
+			ClientID:     "movies",
+			ClientSecret: "secret",
+			RedirectURL:  callbackURL,
+			Scopes:       []string{"email", "profile"},
+		},
+		JIT: jit,
+	}}, sso.NewStorage(pool, logger), sessions, sso.Options{StateTTL: time.Minute}, logger)
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+
+	router := gin.New()
+	// Арендатор по умолчанию, как после tenant.Middleware
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(pgtest.Context())
+		c.Next()
+	})
+	router.GET("/api/auth/oidc/:provider/login", h.Login)
+	router.GET("/api/auth/oidc/:provider/callback", h.Callback)
+	return &fixture{t: t, pool: pool, issuer: issuer, router: router}
+}
+
+// login начинает вход и возвращает cookie состояния и адрес страницы провайдера
+func (f *fixture) login() (*http.Cookie, string) {
+	f.t.Helper()
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/mock/login", nil))
+	if w.Code != http.StatusFound {
+		f.t.Fatalf("login: status %d: %s", w.Code, w.Body)
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "oidc_state" {
+			return cookie, w.Header().Get("Location")
+		}
+	}
+	f.t.Fatal("login did not set the oidc_state cookie")
+	return nil, ""
+}
+
+// authorize проходит страницу провайдера и возвращает адрес callback с кодом
+func (f *fixture) authorize(target string) *url.URL {
+	f.t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(target)
+	if err != nil {
+		f.t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		f.t.Fatalf("authorize: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	return callback
+}
+
+// callback возвращает браузер с cookie в приложение
+func (f *fixture) callback(callback *url.URL, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, callback.Path+"?"+callback.RawQuery, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+// signIn проходит весь вход пользователем u
+func (f *fixture) signIn(u oidctest.User) *httptest.ResponseRecorder {
+	f.t.Helper()
+	f.issuer.SetUser(u)
+	cookie, target := f.login()
+	return f.callback(f.authorize(target), cookie)
+}
+
+func token(t *testing.T, w *httptest.ResponseRecorder) sso.Token {
+	t.Helper()
+	var resp envelope.Resource[sso.Token]
+	if w.Code != http.StatusOK {
+		t.Fatalf("callback: status %d: %s", w.Code, w.Body)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.AccessToken == "" {
+		t.Fatalf("callback: body %s, want session tokens", w.Body)
+	}
+	return resp.Data
+}
+
+var newcomer = oidctest.User{
+	Subject:       "subject-1",
+	Email:         "newcomer@example.com",
+	EmailVerified: true,
+	Name:          "Смирнова Ольга",
+	Birthdate:     "1985-01-01",
+	Gender:        "female",
+}
+
+func TestCallbackPKCE(t *testing.T) {
+	f := newFixture(t, true)
+
+	cookie, target := f.login()
+	authorize, err := url.Parse(target)
+	if err != nil {
+		t.Fatalf("invalid authorization url %q: %v", target, err)
+	}
+	q := authorize.Query()
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" || q.Get("nonce") == "" {
+		t.Errorf("authorization url %s, want an S256 code challenge and a nonce", target)
+	}
+	f.issuer.SetUser(newcomer)
+	got := token(t, f.callback(f.authorize(target), cookie))
+	if got.UserID == "" || got.RefreshToken == "" {
+		t.Errorf("token = %+v", got)
+	}
+
+	// Провайдер принимает код только с verifier, из которого получен challenge
+	cookie, target = f.login()
+	callback := f.authorize(target)
+	if _, err := f.pool.Exec(pgtest.Context(), `UPDATE oidc_login_states SET code_verifier = 'forged-verifier'`); err != nil {
+		t.Fatalf("replace verifier: %v", err)
+	}
+	if w := f.callback(callback, cookie); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong code verifier: status %d, want 401: %s", w.Code, w.Body)
+	}
+}
+
+func TestCallbackRejectsForeignLogin(t *testing.T) {
+	f := newFixture(t, true)
+	f.issuer.SetUser(newcomer)
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		cookie, target := f.login()
+		callback := f.authorize(target)
+		if _, err := f.pool.Exec(pgtest.Context(), `UPDATE oidc_login_states SET nonce = 'another-nonce'`); err != nil {
+			t.Fatalf("replace nonce: %v", err)
+		}
+		if w := f.callback(callback, cookie); w.Code != http.StatusUnauthorized {
+			t.Errorf("status %d, want 401: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("state cookie of another login", func(t *testing.T) {
+		_, victimTarget := f.login()
+		attackerCookie, _ := f.login()
+		if w := f.callback(f.authorize(victimTarget), attackerCookie); w.Code != http.StatusBadRequest {
+			t.Errorf("status %d, want 400: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("no state cookie", func(t *testing.T) {
+		_, target := f.login()
+		if w := f.callback(f.authorize(target), nil); w.Code != http.StatusBadRequest {
+			t.Errorf("status %d, want 400: %s", w.Code, w.Body)
+		}
+	})
+}
+
+func TestCallbackLinksVerifiedEmail(t *testing.T) {
+	f := newFixture(t, false)
+	existing := pgtest.NewFixtures(t, f.pool).User(func(u *user.User) { u.Email = "ivanov@example.com" })
+
+	// Неподтвержденный провайдером адрес не связывается, а без JIT входа нет
+	unverified := oidctest.User{Subject: "subject-1", Email: strings.ToUpper(existing.Email)}
+	if w := f.signIn(unverified); w.Code != http.StatusForbidden {
+		t.Errorf("unverified email: status %d, want 403: %s", w.Code, w.Body)
+	}
+
+	verified := unverified
+	verified.EmailVerified = true
+	got := token(t, f.signIn(verified))
+	if got.UserID != existing.ID || got.Created {
+		t.Errorf("verified email: user %s created %v, want existing user %s", got.UserID, got.Created, existing.ID)
+	}
+	var emailVerified bool
+	err := f.pool.QueryRow(pgtest.Context(), `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`, existing.ID).Scan(&emailVerified)
+	if err != nil || !emailVerified {
+		t.Errorf("email of the linked user is not marked verified: %v", err)
+	}
+
+	// Связанная учетная запись находится по subject, даже если email у провайдера сменился
+	verified.Email = "ivanov.new@example.com"
+	if got := token(t, f.signIn(verified)); got.UserID != existing.ID {
+		t.Errorf("linked identity: user %s, want %s", got.UserID, existing.ID)
+	}
+}
+
+func TestCallbackJIT(t *testing.T) {
+	t.Run("creates a user", func(t *testing.T) {
+		f := newFixture(t, true)
+		got := token(t, f.signIn(newcomer))
+		if !got.Created {
+			t.Errorf("token = %+v, want a created user", got)
+		}
+		var name, gender string
+		err := f.pool.QueryRow(pgtest.Context(), `SELECT name, gender FROM users WHERE id = $1`, got.UserID).Scan(&name, &gender)
+		if err != nil || name != newcomer.Name || gender != "Ж" {
+			t.Errorf("created user: name %q, gender %q, %v", name, gender, err)
+		}
+
+		incomplete := newcomer
+		incomplete.Subject, incomplete.Email, incomplete.Birthdate = "subject-2", "incomplete@example.com", ""
+		if w := f.signIn(incomplete); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("profile without birthdate: status %d, want 422: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		f := newFixture(t, false)
+		if w := f.signIn(newcomer); w.Code != http.StatusForbidden {
+			t.Errorf("status %d, want 403: %s", w.Code, w.Body)
+		}
+		var count int
+		if err := f.pool.QueryRow(pgtest.Context(), `SELECT count(*) FROM users WHERE email = $1`, newcomer.Email).Scan(&count); err != nil || count != 0 {
+			t.Errorf("users with the provider email: %d, %v; want none", count, err)
+		}
+	})
+}
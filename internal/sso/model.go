@@ -0,0 +1,49 @@
+package sso
+
+import (
+	"rest-api-tutorial/internal/session"
+	"rest-api-tutorial/pkg/oidc"
+	"time"
+)
+
+// ProviderConfig провайдер входа: регистрация клиента и правила связывания
+type ProviderConfig struct {
+	// Name имя провайдера в адресах /auth/oidc/{provider}/...
+	Name string
+	oidc.Config
+	// TrustEmail считать email провайдера подтвержденным, даже если он не передает
+	// email_verified (например корпоративный каталог)
+	TrustEmail bool
+	// JIT создавать пользователя при первом входе, если email не найден
+	JIT bool
+}
+
+// Provider провайдер входа в списке для клиента
+type Provider struct {
+	Name string `json:"name" example:"corp"`
+
+	// Адрес, на который клиент отправляет браузер
+	LoginURL string `json:"login_url" example:"/api/auth/oidc/corp/login"`
+}
+
+// Token результат входа
+// @description Токены нового сеанса пользователя
+type Token struct {
+	session.Token
+
+	// @format uuid
+	UserID string `json:"user_id"`
+
+	// Пользователь создан при этом входе
+	Created bool `json:"created"`
+}
+
+// loginState незавершенный вход
+type loginState struct {
+	TenantID  string
+	Provider  string
+	Verifier  string
+	Nonce     string
+	ReturnTo  string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,203 @@
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/oidc"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidState state неизвестен, истек или уже использован
+	ErrInvalidState = errors.New("invalid or expired login state")
+	// ErrNoAccount пользователь не найден, а создание при входе выключено
+	ErrNoAccount = errors.New("no account for this identity")
+	// ErrEmailTaken email провайдера занят пользователем, а провайдер его не подтвердил
+	ErrEmailTaken = errors.New("email belongs to another account")
+	// ErrIncompleteProfile провайдер не передал данные, обязательные для нового пользователя
+	ErrIncompleteProfile = errors.New("identity provider did not return name, email, birthdate and gender")
+)
+
+const uniqueViolation = "23505"
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+// SaveState сохраняет незавершенный вход под хешем state и удаляет истекшие
+func (s *Storage) SaveState(ctx context.Context, state string, login loginState) error {
+	if _, err := s.client.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		s.logger.Warnf("Failed to delete expired login states: %v", err)
+	}
+	q := `
+        INSERT INTO oidc_login_states (state_hash, tenant_id, provider, code_verifier, nonce, return_to, expires_at)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+    `
+	_, err := s.client.Exec(ctx, q, hashState(state), login.TenantID, login.Provider, login.Verifier, login.Nonce, login.ReturnTo, login.ExpiresAt)
+	if err != nil {
+		s.logger.Errorf("Failed to save login state: %v", err)
+		return fmt.Errorf("failed to save login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeState возвращает и удаляет незавершенный вход: state действует один раз
+func (s *Storage) ConsumeState(ctx context.Context, state string) (*loginState, error) {
+	q := `
+        DELETE FROM oidc_login_states
+        WHERE state_hash = $1
+        RETURNING tenant_id, provider, code_verifier, nonce, COALESCE(return_to, ''), expires_at
+    `
+	var login loginState
+	err := s.client.QueryRow(ctx, q, hashState(state)).Scan(
+		&login.TenantID,
+		&login.Provider,
+		&login.Verifier,
+		&login.Nonce,
+		&login.ReturnTo,
+		&login.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidState
+		}
+		return nil, fmt.Errorf("failed to get login state: %w", err)
+	}
+	if time.Now().After(login.ExpiresAt) {
+		return nil, ErrInvalidState
+	}
+	return &login, nil
+}
+
+// Link находит пользователя для внешней учетной записи. Порядок: уже связанная
+// учетная запись, пользователь с тем же email (только если email подтвержден),
+// новый пользователь (если jit). Возвращает id пользователя и признак создания.
+func (s *Storage) Link(ctx context.Context, provider string, claims *oidc.Claims, emailTrusted, jit bool) (string, bool, error) {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	q := `
+        UPDATE user_identities
+        SET last_login_at = NOW(), email = NULLIF($3, '')
+        WHERE provider = $1 AND subject = $2
+        RETURNING user_id
+    `
+	err = tx.QueryRow(ctx, q, provider, claims.Subject, claims.Email).Scan(&userID)
+	switch {
+	case err == nil:
+		return userID, false, tx.Commit(ctx)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return "", false, fmt.Errorf("failed to find identity: %w", err)
+	}
+
+	created := false
+	if emailTrusted && claims.Email != "" {
+		q := `SELECT id FROM users WHERE lower(email) = lower($1) ORDER BY created_at LIMIT 1`
+		err = tx.QueryRow(ctx, q, claims.Email).Scan(&userID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return "", false, fmt.Errorf("failed to find user by email: %w", err)
+		}
+	}
+	if userID == "" {
+		if !jit {
+			return "", false, ErrNoAccount
+		}
+		if userID, err = s.createUser(ctx, tx, claims, emailTrusted); err != nil {
+			return "", false, err
+		}
+		created = true
+	} else {
+		// Провайдер подтвердил владение адресом
+		q := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1`
+		if _, err := tx.Exec(ctx, q, userID); err != nil {
+			return "", false, fmt.Errorf("failed to verify email: %w", err)
+		}
+	}
+
+	q = `
+        INSERT INTO user_identities (user_id, provider, subject, email)
+        VALUES ($1, $2, $3, NULLIF($4, ''))
+    `
+	if _, err := tx.Exec(ctx, q, userID, provider, claims.Subject, claims.Email); err != nil {
+		return "", false, fmt.Errorf("failed to link identity: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, created, nil
+}
+
+// createUser создает пользователя из утверждений провайдера. Пароля у него нет:
+// он входит через провайдера или задает пароль через восстановление.
+func (s *Storage) createUser(ctx context.Context, tx pgx.Tx, claims *oidc.Claims, emailTrusted bool) (string, error) {
+	name, dateOfBirth, gender, err := profile(claims)
+	if err != nil {
+		return "", err
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	q := `
+        INSERT INTO users (id, name, email, date_of_birth, gender, email_verified_at)
+        VALUES ($1, $2, $3, $4, $5, CASE WHEN $6 THEN NOW() END)
+    `
+	if _, err := tx.Exec(ctx, q, id.String(), name, claims.Email, dateOfBirth, gender, emailTrusted); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return "", ErrEmailTaken
+		}
+		return "", fmt.Errorf("failed to create user: %w", err)
+	}
+	return id.String(), nil
+}
+
+// profile переводит стандартные утверждения name, birthdate и gender в поля пользователя
+func profile(claims *oidc.Claims) (name string, dateOfBirth time.Time, gender string, err error) {
+	name = strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if len([]rune(name)) > 255 {
+		name = string([]rune(name)[:255])
+	}
+
+	switch strings.ToLower(claims.Gender) {
+	case "female", "ж":
+		gender = "Ж"
+	case "male", "м":
+		gender = "М"
+	}
+
+	// Год 0000 означает, что пользователь скрыл год рождения
+	dateOfBirth, parseErr := time.Parse(time.DateOnly, claims.Birthdate)
+	if name == "" || claims.Email == "" || gender == "" || parseErr != nil || dateOfBirth.Year() == 0 || dateOfBirth.After(time.Now()) {
+		return "", time.Time{}, "", ErrIncompleteProfile
+	}
+	return name, dateOfBirth, gender, nil
+}
+
+func hashState(state string) []byte {
+	sum := sha256.Sum256([]byte(state))
+	return sum[:]
+}
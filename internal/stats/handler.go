@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+)
+
+const (
+	defaultMostAddedLimit = 10
+	maxMostAddedLimit     = 100
+)
+
+// Handler отдает статистику каталога. Все ответы - списки, поэтому с
+// Accept: text/csv они приходят в CSV.
+type Handler struct {
+	logger  *logging.Logger
+	storage *Storage
+}
+
+func NewHandler(storage *Storage, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+// GetMostAdded godoc
+// @Summary Most added films
+// @Description Films added to the most user lists. Results are cached for STATS_CACHE_TTL.
+// @Tags stats
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Param limit query int false "Maximum number of films" default(10) maximum(100)
+// @Success 200 {object} envelope.Collection[stats.AddedFilm] "Films, most added first"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid limit"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing stats:read scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /stats/films/most-added [get]
+func (h *Handler) GetMostAdded(c *gin.Context) {
+	limit := defaultMostAddedLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxMostAddedLimit {
+			envelope.Error(c, http.StatusBadRequest, "Invalid limit, expected 1 to 100")
+			return
+		}
+		limit = n
+	}
+	list, err := h.storage.MostAdded(c.Request.Context(), limit)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch statistics")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// GetRatings godoc
+// @Summary Rating distribution
+// @Description Number of films per rating interval of one point: [0, 1), [1, 2) ... [9, 10]. Films without a rating are counted in a last row with empty from and to. Results are cached for STATS_CACHE_TTL.
+// @Tags stats
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Success 200 {object} envelope.Collection[stats.RatingBucket] "Rating intervals from low to high"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing stats:read scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /stats/films/ratings [get]
+func (h *Handler) GetRatings(c *gin.Context) {
+	list, err := h.storage.Ratings(c.Request.Context())
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch statistics")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// GetReleaseYears godoc
+// @Summary Films per release year
+// @Description Number of films and their average rating for every release year that has films. Results are cached for STATS_CACHE_TTL.
+// @Tags stats
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Success 200 {object} envelope.Collection[stats.ReleaseYear] "Release years, oldest first"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing stats:read scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /stats/films/release-years [get]
+func (h *Handler) GetReleaseYears(c *gin.Context) {
+	list, err := h.storage.ReleaseYears(c.Request.Context())
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch statistics")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// GetDemographics godoc
+// @Summary User demographics
+// @Description Number of users by gender and age group; the age is calculated from date_of_birth as of today. Empty groups are omitted. Results are cached for STATS_CACHE_TTL.
+// @Tags stats
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Success 200 {object} envelope.Collection[stats.Demographic] "Groups by gender, youngest first"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing stats:read scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /stats/users/demographics [get]
+func (h *Handler) GetDemographics(c *gin.Context) {
+	list, err := h.storage.Demographics(c.Request.Context())
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch statistics")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// GetGrowth godoc
+// @Summary Catalog growth
+// @Description New users and films per period by created_at, with running totals. Periods without new users or films are omitted. Results are cached for STATS_CACHE_TTL.
+// @Tags stats
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Param interval query string false "Period length" Enums(day, week, month, year) default(month)
+// @Success 200 {object} envelope.Collection[stats.Growth] "Periods, oldest first"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid interval"
+// @Failure 401 {object} envelope.ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} envelope.ErrorResponse "Missing stats:read scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /stats/growth [get]
+func (h *Handler) GetGrowth(c *gin.Context) {
+	interval := c.DefaultQuery("interval", IntervalMonth)
+	switch interval {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+	default:
+		envelope.Error(c, http.StatusBadRequest, "Invalid interval, expected day, week, month or year")
+		return
+	}
+	list, err := h.storage.Growth(c.Request.Context(), interval)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch statistics")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
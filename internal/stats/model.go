@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"time"
+)
+
+// Интервалы группировки роста
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// AddedFilm фильм и число пользователей, добавивших его в свой список
+type AddedFilm struct {
+	// @format uuid
+	ID     string   `json:"id"`
+	Title  string   `json:"title"`
+	Rating *float64 `json:"rating"`
+	Users  int      `json:"users" example:"42"`
+}
+
+// RatingBucket число фильмов с рейтингом в полуинтервале [from, to); последний
+// интервал включает 10. У фильмов без рейтинга from и to пусты.
+type RatingBucket struct {
+	From  *float64 `json:"from" example:"7"`
+	To    *float64 `json:"to" example:"8"`
+	Films int      `json:"films" example:"12"`
+}
+
+// ReleaseYear фильмы одного года выпуска
+type ReleaseYear struct {
+	Year          int      `json:"year" example:"1999"`
+	Films         int      `json:"films" example:"3"`
+	AverageRating *float64 `json:"average_rating" example:"7.8"`
+}
+
+// Demographic пользователи одного пола и возрастной группы
+type Demographic struct {
+	Gender   string `json:"gender" enums:"М,Ж"`
+	AgeGroup string `json:"age_group" enums:"under 18,18-24,25-34,35-44,45-54,55-64,65+"`
+	Users    int    `json:"users" example:"17"`
+}
+
+// Growth новые пользователи и фильмы за период и их число к концу периода
+type Growth struct {
+	// Начало периода
+	// @format date
+	Period     time.Time `json:"period"`
+	NewUsers   int       `json:"new_users" example:"5"`
+	NewFilms   int       `json:"new_films" example:"2"`
+	TotalUsers int       `json:"total_users" example:"120"`
+	TotalFilms int       `json:"total_films" example:"48"`
+}
@@ -0,0 +1,179 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+)
+
+// Storage считает статистику каталога арендатора запроса: политики RLS
+// оставляют в запросах только его фильмы и пользователей
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+// MostAdded фильмы, которые чаще всего добавляют в свой список
+func (s *Storage) MostAdded(ctx context.Context, limit int) ([]AddedFilm, error) {
+	q := `
+        SELECT films.film_id, films.title, films.rating, count(*) AS users
+        FROM films
+        JOIN user_film ON user_film.film_id = films.film_id
+        GROUP BY films.film_id
+        ORDER BY users DESC, films.title
+        LIMIT $1
+    `
+	return collect(ctx, s, "most added films", q, []interface{}{limit}, func(rows pgx.Rows) (AddedFilm, error) {
+		var f AddedFilm
+		err := rows.Scan(&f.ID, &f.Title, &f.Rating, &f.Users)
+		return f, err
+	})
+}
+
+// Ratings распределение фильмов по рейтингу с шагом 1. Пустые интервалы
+// тоже возвращаются, строка фильмов без рейтинга - только если они есть.
+func (s *Storage) Ratings(ctx context.Context) ([]RatingBucket, error) {
+	q := `
+        WITH counts AS (
+            SELECT LEAST(floor(rating)::int, 9) AS bucket, count(*) AS films
+            FROM films
+            WHERE rating IS NOT NULL
+            GROUP BY 1
+        )
+        SELECT buckets.bucket, COALESCE(counts.films, 0)
+        FROM generate_series(0, 9) AS buckets(bucket)
+        LEFT JOIN counts ON counts.bucket = buckets.bucket
+        UNION ALL
+        SELECT NULL, count(*)
+        FROM films
+        WHERE rating IS NULL
+        HAVING count(*) > 0
+        ORDER BY 1 NULLS LAST
+    `
+	return collect(ctx, s, "rating distribution", q, nil, func(rows pgx.Rows) (RatingBucket, error) {
+		var (
+			b      RatingBucket
+			bucket *int
+		)
+		if err := rows.Scan(&bucket, &b.Films); err != nil {
+			return b, err
+		}
+		if bucket != nil {
+			from, to := float64(*bucket), float64(*bucket+1)
+			b.From, b.To = &from, &to
+		}
+		return b, nil
+	})
+}
+
+// ReleaseYears число фильмов и средний рейтинг по годам выпуска
+func (s *Storage) ReleaseYears(ctx context.Context) ([]ReleaseYear, error) {
+	q := `
+        SELECT extract(year FROM release_date)::int AS year, count(*), round(avg(rating), 1)::float8
+        FROM films
+        GROUP BY 1
+        ORDER BY 1
+    `
+	return collect(ctx, s, "films per release year", q, nil, func(rows pgx.Rows) (ReleaseYear, error) {
+		var y ReleaseYear
+		err := rows.Scan(&y.Year, &y.Films, &y.AverageRating)
+		return y, err
+	})
+}
+
+// Demographics пользователи по полу и возрастным группам; возраст считается
+// по date_of_birth на сегодня, пустые группы не возвращаются
+func (s *Storage) Demographics(ctx context.Context) ([]Demographic, error) {
+	q := `
+        SELECT gender,
+               CASE
+                   WHEN age < 18 THEN 'under 18'
+                   WHEN age < 25 THEN '18-24'
+                   WHEN age < 35 THEN '25-34'
+                   WHEN age < 45 THEN '35-44'
+                   WHEN age < 55 THEN '45-54'
+                   WHEN age < 65 THEN '55-64'
+                   ELSE '65+'
+               END AS age_group,
+               count(*)
+        FROM (
+            SELECT gender, extract(year FROM age(date_of_birth))::int AS age
+            FROM users
+        ) u
+        GROUP BY 1, 2
+        ORDER BY 1, min(age)
+    `
+	return collect(ctx, s, "user demographics", q, nil, func(rows pgx.Rows) (Demographic, error) {
+		var d Demographic
+		err := rows.Scan(&d.Gender, &d.AgeGroup, &d.Users)
+		return d, err
+	})
+}
+
+// Growth новые пользователи и фильмы по периодам interval (day, week, month, year)
+// с нарастающим итогом. Периоды без новых записей пропускаются.
+func (s *Storage) Growth(ctx context.Context, interval string) ([]Growth, error) {
+	q := `
+        WITH new_users AS (
+            SELECT date_trunc($1, created_at) AS period, count(*) AS n
+            FROM users
+            GROUP BY 1
+        ), new_films AS (
+            SELECT date_trunc($1, created_at) AS period, count(*) AS n
+            FROM films
+            WHERE created_at IS NOT NULL
+            GROUP BY 1
+        ), periods AS (
+            SELECT period FROM new_users
+            UNION
+            SELECT period FROM new_films
+        )
+        SELECT periods.period,
+               COALESCE(new_users.n, 0),
+               COALESCE(new_films.n, 0),
+               (sum(COALESCE(new_users.n, 0)) OVER (ORDER BY periods.period))::bigint,
+               (sum(COALESCE(new_films.n, 0)) OVER (ORDER BY periods.period))::bigint
+        FROM periods
+        LEFT JOIN new_users ON new_users.period = periods.period
+        LEFT JOIN new_films ON new_films.period = periods.period
+        ORDER BY periods.period
+    `
+	return collect(ctx, s, "growth", q, []interface{}{interval}, func(rows pgx.Rows) (Growth, error) {
+		var g Growth
+		err := rows.Scan(&g.Period, &g.NewUsers, &g.NewFilms, &g.TotalUsers, &g.TotalFilms)
+		return g, err
+	})
+}
+
+// collect выполняет запрос статистики и собирает строки в список
+func collect[T any](ctx context.Context, s *Storage, name, q string, args []interface{}, scan func(pgx.Rows) (T, error)) ([]T, error) {
+	rows, err := s.client.Query(ctx, q, args...)
+	if err != nil {
+		s.logger.Errorf("Failed to get %s: %v", name, err)
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	list := make([]T, 0)
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", name, err)
+		}
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Errorf("Failed to get %s: %v", name, err)
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+	return list, nil
+}
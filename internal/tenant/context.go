@@ -0,0 +1,44 @@
+package tenant
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4"
+)
+
+// system значение app.tenant_id системного контекста: политики показывают строки
+// всех арендаторов, но создать строку без арендатора нельзя
+const system = "*"
+
+type tenantKey struct{}
+
+// ContextWithTenant кладет id арендатора в контекст. Запросы к базе с этим
+// контекстом видят только строки арендатора (см. BeforeAcquire).
+func ContextWithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// SystemContext контекст фоновых задач, которые обслуживают всех арендаторов,
+// например доставки webhook
+func SystemContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, system)
+}
+
+// FromContext возвращает id арендатора из контекста. Для системного контекста
+// и контекста без арендатора ok равен false.
+func FromContext(ctx context.Context) (id string, ok bool) {
+	id, _ = ctx.Value(tenantKey{}).(string)
+	if id == "" || id == system {
+		return "", false
+	}
+	return id, true
+}
+
+// BeforeAcquire задает параметр сеанса app.tenant_id по контексту, с которым соединение
+// берется из пула; подключается как pgxpool.Config.BeforeAcquire. Значение
+// перезаписывается при каждой выдаче, поэтому арендатор прошлого запроса не переходит
+// к следующему, а запрос без арендатора не видит строк под политиками.
+func BeforeAcquire(ctx context.Context, conn *pgx.Conn) bool {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	_, err := conn.Exec(ctx, `SELECT set_config('app.tenant_id', $1, false)`, id)
+	return err == nil
+}
@@ -0,0 +1,200 @@
+package tenant
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+type Handler struct {
+	logger  *logging.Logger
+	storage *Storage
+}
+
+func NewHandler(storage *Storage, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+// CreateTenant godoc
+// @Summary Create a tenant
+// @Description Create an independent catalog. Its films and users are reached with the X-Tenant-ID header (id or slug), the <slug>.TENANT_BASE_DOMAIN subdomain or an API key issued for the tenant.
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param tenant body CreateTenant true "Slug and name"
+// @Success 201 {object} envelope.Resource[tenant.Tenant] "Created tenant"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body or slug"
+// @Failure 403 {object} envelope.ErrorResponse "Missing tenants:admin scope"
+// @Failure 409 {object} envelope.ErrorResponse "Tenant with this slug already exists"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/tenants [post]
+func (h *Handler) CreateTenant(c *gin.Context) {
+	var input CreateTenant
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := validateSlug(input.Slug); err != nil {
+		envelope.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to generate ID")
+		return
+	}
+	tenant := Tenant{
+		ID:        id.String(),
+		Slug:      input.Slug,
+		Name:      input.Name,
+		CreatedAt: time.Now(),
+	}
+	tenant.UpdatedAt = tenant.CreatedAt
+
+	if err := h.storage.Create(c.Request.Context(), tenant); err != nil {
+		if errors.Is(err, ErrConflict) {
+			envelope.Error(c, http.StatusConflict, "Tenant with this slug already exists")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to create tenant")
+		return
+	}
+	envelope.Data(c, http.StatusCreated, tenant)
+}
+
+// GetList godoc
+// @Summary List tenants
+// @Description Retrieve all tenants
+// @Tags tenants
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} envelope.Collection[tenant.Tenant] "List of tenants"
+// @Failure 403 {object} envelope.ErrorResponse "Missing tenants:admin scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/tenants [get]
+func (h *Handler) GetList(c *gin.Context) {
+	list, err := h.storage.FindAll(c.Request.Context())
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch tenants")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// GetTenant godoc
+// @Summary Get a tenant
+// @Description Retrieve a tenant by ID
+// @Tags tenants
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID (UUID)"
+// @Success 200 {object} envelope.Resource[tenant.Tenant] "Tenant"
+// @Failure 403 {object} envelope.ErrorResponse "Missing tenants:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Tenant not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/tenants/{id} [get]
+func (h *Handler) GetTenant(c *gin.Context) {
+	id, ok := tenantID(c)
+	if !ok {
+		return
+	}
+	tenant, err := h.storage.FindByID(c.Request.Context(), id)
+	if err != nil {
+		h.storageError(c, err, "Failed to fetch tenant")
+		return
+	}
+	envelope.Data(c, http.StatusOK, tenant)
+}
+
+// UpdateTenant godoc
+// @Summary Rename a tenant
+// @Description Change the display name of a tenant. The slug cannot be changed.
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID (UUID)"
+// @Param tenant body UpdateTenant true "New name"
+// @Success 200 {object} envelope.Resource[tenant.Tenant] "Updated tenant"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 403 {object} envelope.ErrorResponse "Missing tenants:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Tenant not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/tenants/{id} [patch]
+func (h *Handler) UpdateTenant(c *gin.Context) {
+	id, ok := tenantID(c)
+	if !ok {
+		return
+	}
+	var input UpdateTenant
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenant, err := h.storage.Update(c.Request.Context(), id, input)
+	if err != nil {
+		h.storageError(c, err, "Failed to update tenant")
+		return
+	}
+	envelope.Data(c, http.StatusOK, tenant)
+}
+
+// DeleteTenant godoc
+// @Summary Delete a tenant
+// @Description Delete an empty tenant together with its API keys. A tenant that still has films, users or webhooks cannot be deleted; neither can the default tenant.
+// @Tags tenants
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID (UUID)"
+// @Success 204 "Tenant deleted"
+// @Failure 403 {object} envelope.ErrorResponse "Missing tenants:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Tenant not found"
+// @Failure 409 {object} envelope.ErrorResponse "Tenant still has data or is the default tenant"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /admin/tenants/{id} [delete]
+func (h *Handler) DeleteTenant(c *gin.Context) {
+	id, ok := tenantID(c)
+	if !ok {
+		return
+	}
+	if id == DefaultID {
+		envelope.Error(c, http.StatusConflict, "The default tenant cannot be deleted")
+		return
+	}
+	if err := h.storage.Delete(c.Request.Context(), id); err != nil {
+		h.storageError(c, err, "Failed to delete tenant")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func tenantID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.FromString(id); err != nil {
+		envelope.Error(c, http.StatusNotFound, "Tenant not found")
+		return "", false
+	}
+	return id, true
+}
+
+func (h *Handler) storageError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		envelope.Error(c, http.StatusNotFound, "Tenant not found")
+		return
+	case errors.Is(err, ErrInUse):
+		envelope.Error(c, http.StatusConflict, "Tenant still has films, users or webhooks")
+		return
+	}
+	h.logger.Errorf("%s: %v", message, err)
+	envelope.Error(c, http.StatusInternalServerError, message)
+}
@@ -0,0 +1,248 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"net/http"
+	"rest-api-tutorial/internal/auth"
+	apierrors "rest-api-tutorial/pkg/errors"
+	"rest-api-tutorial/pkg/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderTenant заголовок с id или slug арендатора
+const HeaderTenant = "X-Tenant-ID"
+
+// MetadataTenant ключ метаданных gRPC, аналог заголовка X-Tenant-ID
+const MetadataTenant = "x-tenant-id"
+
+// cacheTTL сколько найденный арендатор хранится в памяти резолвера
+const cacheTTL = 30 * time.Second
+
+var (
+	// ErrUnknownTenant указанный арендатор не существует
+	ErrUnknownTenant = errors.New("unknown tenant")
+	// ErrForeignTenant ключ привязан к другому арендатору
+	ErrForeignTenant = errors.New("api key belongs to another tenant")
+	// ErrTenantRequired арендатор не указан, а арендатора по умолчанию нет
+	ErrTenantRequired = errors.New("tenant is required")
+	// ErrAnonymousTenant анонимный запрос вне AnonymousPaths выбрал арендатора,
+	// отличного от арендатора по умолчанию
+	ErrAnonymousTenant = errors.New("anonymous requests are limited to the default tenant")
+)
+
+// Options как определяется арендатор запроса
+type Options struct {
+	// BaseDomain домен, поддомен которого называет арендатора:
+	// cinema-club.movies.example.com -> cinema-club. Пустой — поддомены не используются.
+	BaseDomain string
+	// Default slug арендатора для запросов, в которых арендатор не указан.
+	// Пустой — такие запросы отклоняются.
+	Default string
+	// AnonymousPaths префиксы маршрутов, на которых анонимный запрос сам выбирает
+	// арендатора заголовком или поддоменом: регистрация, вход, восстановление пароля.
+	// На остальных маршрутах анонимные запросы работают с арендатором по умолчанию.
+	AnonymousPaths []string
+}
+
+// Resolver определяет арендатора запроса. Порядок источников: арендатор API-ключа,
+// заголовок X-Tenant-ID (id или slug), поддомен BaseDomain, арендатор по умолчанию.
+// Ключ, привязанный к арендатору, не может обратиться к другому, а анонимные
+// запросы выбирают арендатора только на маршрутах AnonymousPaths.
+type Resolver struct {
+	storage *Storage
+	opts    Options
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+type cachedTenant struct {
+	id        string
+	expiresAt time.Time
+}
+
+func NewResolver(storage *Storage, opts Options) *Resolver {
+	return &Resolver{
+		storage: storage,
+		opts:    opts,
+		cache:   make(map[string]cachedTenant),
+	}
+}
+
+// Resolve возвращает id арендатора для субъекта principal и явно указанного
+// арендатора requested (id, slug или пустая строка)
+func (r *Resolver) Resolve(ctx context.Context, principal *auth.Principal, requested string) (string, error) {
+	return r.resolve(ctx, principal, requested, false)
+}
+
+// ResolvePath то же, что Resolve, для запроса к маршруту path: на маршрутах
+// AnonymousPaths анонимный запрос может выбрать любого арендатора
+func (r *Resolver) ResolvePath(ctx context.Context, principal *auth.Principal, requested, path string) (string, error) {
+	return r.resolve(ctx, principal, requested, r.anonymousPath(path))
+}
+
+func (r *Resolver) resolve(ctx context.Context, principal *auth.Principal, requested string, anonymousSelects bool) (string, error) {
+	var id string
+	if requested != "" {
+		var err error
+		if id, err = r.lookup(ctx, requested); err != nil {
+			return "", err
+		}
+	}
+
+	if principal != nil && principal.TenantID != "" {
+		if id != "" && id != principal.TenantID {
+			return "", ErrForeignTenant
+		}
+		return principal.TenantID, nil
+	}
+	anonymous := principal == nil || principal.Type == auth.PrincipalAnonymous
+	if id != "" && (!anonymous || anonymousSelects) {
+		return id, nil
+	}
+	if r.opts.Default == "" {
+		if anonymous && id != "" {
+			return "", ErrAnonymousTenant
+		}
+		return "", ErrTenantRequired
+	}
+	defaultID, err := r.lookup(ctx, r.opts.Default)
+	if err != nil {
+		return "", err
+	}
+	if id != "" && id != defaultID {
+		return "", ErrAnonymousTenant
+	}
+	return defaultID, nil
+}
+
+// anonymousPath сообщает, что path совпадает с одним из AnonymousPaths или вложен в него
+func (r *Resolver) anonymousPath(path string) bool {
+	for _, prefix := range r.opts.AnonymousPaths {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Subdomain возвращает арендатора из имени хоста или пустую строку
+func (r *Resolver) Subdomain(host string) string {
+	if r.opts.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(r.opts.BaseDomain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// lookup находит id арендатора по id или slug
+func (r *Resolver) lookup(ctx context.Context, ref string) (string, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[ref]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.id, nil
+	}
+
+	var (
+		tenant *Tenant
+		err    error
+	)
+	if _, parseErr := uuid.FromString(ref); parseErr == nil {
+		tenant, err = r.storage.FindByID(ctx, ref)
+	} else {
+		tenant, err = r.storage.FindBySlug(ctx, strings.ToLower(ref))
+	}
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return "", ErrUnknownTenant
+		}
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.cache[ref] = cachedTenant{id: tenant.ID, expiresAt: time.Now().Add(cacheTTL)}
+	r.mu.Unlock()
+	return tenant.ID, nil
+}
+
+// Middleware определяет арендатора запроса и кладет его в контекст запроса.
+// Вызывается после auth.Middleware, чтобы учесть арендатора API-ключа.
+func Middleware(resolver *Resolver, logger *logging.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := c.GetHeader(HeaderTenant)
+		if requested == "" {
+			requested = resolver.Subdomain(c.Request.Host)
+		}
+
+		id, err := resolver.ResolvePath(c.Request.Context(), auth.PrincipalFrom(c), requested, c.FullPath())
+		if err != nil {
+			code, message := http.StatusInternalServerError, "Failed to resolve tenant"
+			switch {
+			case errors.Is(err, ErrUnknownTenant):
+				code, message = http.StatusBadRequest, "Unknown tenant"
+			case errors.Is(err, ErrTenantRequired):
+				code, message = http.StatusBadRequest, "Tenant is required, set the "+HeaderTenant+" header"
+			case errors.Is(err, ErrForeignTenant):
+				code, message = http.StatusForbidden, "API key belongs to another tenant"
+			case errors.Is(err, ErrAnonymousTenant):
+				code, message = http.StatusUnauthorized, "An API key is required to select a tenant"
+			default:
+				logger.Errorf("Failed to resolve tenant: %v", err)
+			}
+			c.AbortWithStatusJSON(code, apierrors.ErrorResponse{Code: code, Message: message})
+			return
+		}
+
+		c.Request = c.Request.WithContext(ContextWithTenant(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// UnaryServerInterceptor определяет арендатора вызова gRPC по метаданным x-tenant-id.
+// Ставится после auth.UnaryServerInterceptor.
+func UnaryServerInterceptor(resolver *Resolver, logger *logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var requested string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataTenant); len(values) > 0 {
+				requested = values[0]
+			} else if values := md.Get(":authority"); len(values) > 0 {
+				requested = resolver.Subdomain(values[0])
+			}
+		}
+
+		id, err := resolver.Resolve(ctx, auth.PrincipalFromContext(ctx), requested)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrUnknownTenant):
+				return nil, status.Error(codes.InvalidArgument, "Unknown tenant")
+			case errors.Is(err, ErrTenantRequired):
+				return nil, status.Error(codes.InvalidArgument, "Tenant is required, set the "+MetadataTenant+" metadata")
+			case errors.Is(err, ErrForeignTenant):
+				return nil, status.Error(codes.PermissionDenied, "API key belongs to another tenant")
+			case errors.Is(err, ErrAnonymousTenant):
+				return nil, status.Error(codes.Unauthenticated, "An API key is required to select a tenant")
+			}
+			logger.Errorf("Failed to resolve tenant: %v", err)
+			return nil, status.Error(codes.Internal, "Failed to resolve tenant")
+		}
+		return handler(ContextWithTenant(ctx, id), req)
+	}
+}
@@ -0,0 +1,49 @@
+package tenant_test
+
+import (
+	"errors"
+	"os"
+	"rest-api-tutorial/internal/auth"
+	"rest-api-tutorial/internal/pgtest"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/logging"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m, pgtest.Options{}))
+}
+
+func TestResolveAnonymous(t *testing.T) {
+	pool := pgtest.DB(t)
+	foreign := pgtest.NewFixtures(t, pool).Tenant().TenantID()
+	resolver := tenant.NewResolver(tenant.NewStorage(pool, logging.GetLogger()), tenant.Options{
+		Default:        "default",
+		AnonymousPaths: []string{"/api/account", "/api/auth"},
+	})
+	ctx := pgtest.Context()
+	anonymous := &auth.Principal{Type: auth.PrincipalAnonymous}
+
+	tests := []struct {
+		name      string
+		requested string
+		path      string
+		want      string
+		err       error
+	}{
+		{"default tenant", "", "/api/v1/films", pgtest.DefaultTenant, nil},
+		{"catalog of another tenant", foreign, "/api/v1/films", "", tenant.ErrAnonymousTenant},
+		{"signup in another tenant", foreign, "/api/account/signup", foreign, nil},
+		{"login in another tenant", foreign, "/api/auth/oidc/:provider/login", foreign, nil},
+		{"prefix without a segment boundary", foreign, "/api/accounts", "", tenant.ErrAnonymousTenant},
+		{"unknown tenant", "missing", "/api/account/signup", "", tenant.ErrUnknownTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.ResolvePath(ctx, anonymous, tt.requested, tt.path)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("ResolvePath(%q, %q) = %q, %v; want %q, %v", tt.requested, tt.path, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package tenant
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultID арендатор, созданный миграцией 007_tenants.sql. Ему принадлежат данные,
+// появившиеся до разделения каталогов.
+const DefaultID = "00000000-0000-0000-0000-000000000001"
+
+// Tenant арендатор: независимый каталог фильмов и пользователей
+// @description Кинотеатр или клуб со своим каталогом
+type Tenant struct {
+	// @format uuid
+	ID string `json:"id"`
+
+	// Короткое имя для поддомена и заголовка X-Tenant-ID
+	Slug string `json:"slug" example:"cinema-club"`
+
+	Name string `json:"name" example:"Cinema Club"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateTenant модель запроса на создание арендатора
+// @description Короткое имя и название
+type CreateTenant struct {
+	// @maxLength 63
+	Slug string `json:"slug" binding:"required,max=63" example:"cinema-club"`
+
+	// @maxLength 255
+	Name string `json:"name" binding:"required,max=255" example:"Cinema Club"`
+}
+
+// UpdateTenant модель запроса на переименование арендатора. Slug не меняется:
+// на него ссылаются поддомены и клиенты.
+type UpdateTenant struct {
+	// @maxLength 255
+	Name string `json:"name" binding:"required,max=255" example:"Cinema Club"`
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// validateSlug проверяет, что slug подходит как метка DNS
+func validateSlug(slug string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("invalid slug %q, expected lowercase letters, digits and hyphens", slug)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+)
+
+var (
+	// ErrNotFound арендатор не существует
+	ErrNotFound = errors.New("tenant not found")
+	// ErrConflict арендатор с таким slug уже есть
+	ErrConflict = errors.New("tenant already exists")
+	// ErrInUse у арендатора остались фильмы, пользователи или подписки
+	ErrInUse = errors.New("tenant still has data")
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+const tenantColumns = `id, slug, name, created_at, updated_at`
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+func (s *Storage) Create(ctx context.Context, tenant Tenant) error {
+	q := `
+        INSERT INTO tenants (id, slug, name, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	_, err := s.client.Exec(ctx, q, tenant.ID, tenant.Slug, tenant.Name, tenant.CreatedAt, tenant.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrConflict
+		}
+		s.logger.Errorf("Failed to create tenant: %v", err)
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) FindAll(ctx context.Context) ([]Tenant, error) {
+	q := `SELECT ` + tenantColumns + ` FROM tenants ORDER BY created_at, slug`
+	rows, err := s.client.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenants: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]Tenant, 0)
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *tenant)
+	}
+	return list, rows.Err()
+}
+
+func (s *Storage) FindByID(ctx context.Context, id string) (*Tenant, error) {
+	q := `SELECT ` + tenantColumns + ` FROM tenants WHERE id = $1`
+	return scanTenant(s.client.QueryRow(ctx, q, id))
+}
+
+func (s *Storage) FindBySlug(ctx context.Context, slug string) (*Tenant, error) {
+	q := `SELECT ` + tenantColumns + ` FROM tenants WHERE slug = $1`
+	return scanTenant(s.client.QueryRow(ctx, q, slug))
+}
+
+func (s *Storage) Update(ctx context.Context, id string, input UpdateTenant) (*Tenant, error) {
+	q := `
+        UPDATE tenants
+        SET name = $2, updated_at = NOW()
+        WHERE id = $1
+        RETURNING ` + tenantColumns
+	return scanTenant(s.client.QueryRow(ctx, q, id, input.Name))
+}
+
+// Delete удаляет арендатора вместе с его API-ключами. Арендатора с данными
+// удалить нельзя: каталог сначала очищается его собственными запросами.
+func (s *Storage) Delete(ctx context.Context, id string) error {
+	tag, err := s.client.Exec(ctx, `DELETE FROM tenants WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return ErrInUse
+		}
+		s.logger.Errorf("Failed to delete tenant: %v", err)
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// BypassesRLS сообщает, что текущая роль подключения не соблюдает политики
+// row-level security (суперпользователь или BYPASSRLS) и изоляция не работает
+func (s *Storage) BypassesRLS(ctx context.Context) (bool, error) {
+	q := `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`
+	var bypass bool
+	if err := s.client.QueryRow(ctx, q).Scan(&bypass); err != nil {
+		return false, fmt.Errorf("failed to check database role: %w", err)
+	}
+	return bypass, nil
+}
+
+func scanTenant(row pgx.Row) (*Tenant, error) {
+	var tenant Tenant
+	err := row.Scan(&tenant.ID, &tenant.Slug, &tenant.Name, &tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan tenant: %w", err)
+	}
+	return &tenant, nil
+}
@@ -0,0 +1,67 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"rest-api-tutorial/pkg/batch"
+	"time"
+)
+
+// BatchItem операция пакета, уже разобранная и проверенная
+type BatchItem struct {
+	Op     batch.Op
+	ID     string
+	User   User
+	Update Update
+}
+
+// decodeBatchOperation разбирает операцию пакета по тем же правилам,
+// что и одиночные CreateUser, PartiallyUpdateUser и DeleteUser
+func decodeBatchOperation(op batch.Operation) (BatchItem, error) {
+	item := BatchItem{Op: op.Op, ID: op.ID}
+
+	switch op.Op {
+	case batch.OpCreate:
+		if len(op.Data) == 0 {
+			return item, fmt.Errorf("data is required for create")
+		}
+		if err := json.Unmarshal(op.Data, &item.User); err != nil {
+			return item, fmt.Errorf("invalid user: %v", err)
+		}
+		if err := binding.Validator.ValidateStruct(&item.User); err != nil {
+			return item, err
+		}
+
+		id, err := uuid.NewV4()
+		if err != nil {
+			return item, fmt.Errorf("failed to generate ID")
+		}
+		item.User.ID = id.String()
+		item.User.CreatedAt = time.Now()
+		item.User.UpdatedAt = item.User.CreatedAt
+		item.ID = item.User.ID
+	case batch.OpUpdate:
+		if _, err := uuid.FromString(op.ID); err != nil {
+			return item, fmt.Errorf("invalid id %q", op.ID)
+		}
+		if len(op.Data) == 0 {
+			return item, fmt.Errorf("data is required for update")
+		}
+		if err := json.Unmarshal(op.Data, &item.Update); err != nil {
+			return item, fmt.Errorf("invalid user update: %v", err)
+		}
+		if err := binding.Validator.ValidateStruct(&item.Update); err != nil {
+			return item, err
+		}
+	case batch.OpDelete:
+		if _, err := uuid.FromString(op.ID); err != nil {
+			return item, fmt.Errorf("invalid id %q", op.ID)
+		}
+	default:
+		return item, fmt.Errorf("unknown op %q, expected create, update or delete", op.Op)
+	}
+
+	return item, nil
+}
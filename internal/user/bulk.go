@@ -0,0 +1,77 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gofrs/uuid"
+	"rest-api-tutorial/pkg/bulk"
+	"time"
+)
+
+// importColumns колонки временной таблицы users_import в порядке значений CopySource
+var importColumns = []string{"row_num", "id", "name", "email", "date_of_birth", "gender"}
+
+// decodeUserRow возвращает значения колонок importColumns для COPY
+func decodeUserRow(row bulk.Row) ([]interface{}, error) {
+	user, err := DecodeImportRow(row)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{user.ID, user.Name, user.Email, user.DateOfBirth, user.Gender}, nil
+}
+
+// DecodeImportRow разбирает строку импорта в User и проверяет ее теми же правилами, что и CreateUser.
+// Связи с фильмами (film_id) при импорте не переносятся.
+func DecodeImportRow(row bulk.Row) (User, error) {
+	var user User
+	if row.JSON != nil {
+		if err := json.Unmarshal(row.JSON, &user); err != nil {
+			return User{}, fmt.Errorf("invalid user: %v", err)
+		}
+	} else {
+		var err error
+		if user, err = userFromFields(row.Fields); err != nil {
+			return User{}, err
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(&user); err != nil {
+		return User{}, err
+	}
+	if user.DateOfBirth.After(time.Now()) {
+		return User{}, fmt.Errorf("date_of_birth is in the future")
+	}
+
+	if user.ID == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return User{}, fmt.Errorf("failed to generate ID: %w", err)
+		}
+		user.ID = id.String()
+	} else if _, err := uuid.FromString(user.ID); err != nil {
+		return User{}, fmt.Errorf("invalid id %q", user.ID)
+	}
+
+	user.FilmUUID = nil
+	return user, nil
+}
+
+func userFromFields(fields map[string]string) (User, error) {
+	user := User{
+		ID:     fields["id"],
+		Name:   fields["name"],
+		Email:  fields["email"],
+		Gender: fields["gender"],
+	}
+
+	if v := fields["date_of_birth"]; v != "" {
+		dateOfBirth, err := bulk.ParseTime(v)
+		if err != nil {
+			return user, err
+		}
+		user.DateOfBirth = dateOfBirth
+	}
+
+	return user, nil
+}
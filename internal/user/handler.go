@@ -1,36 +1,48 @@
 package user
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/gofrs/uuid"
 	"net/http"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
 	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/patch"
+	"rest-api-tutorial/pkg/stream"
+	"strconv"
 	"time"
 )
 
 type Handler struct {
-	logger  *logging.Logger
-	storage *Storage
+	logger      *logging.Logger
+	storage     UserRepository
+	batchLimits batch.Limits
 }
 
-func NewHandler(storage *Storage, logger *logging.Logger) *Handler {
+func NewHandler(storage UserRepository, batchLimits batch.Limits, logger *logging.Logger) *Handler {
 	return &Handler{
-		logger:  logger,
-		storage: storage,
+		logger:      logger,
+		storage:     storage,
+		batchLimits: batchLimits,
 	}
 }
 
 // CreateUser godoc
 // @Summary Create a new user
 // @Description Create a new user with the provided details
-// @Tags users
+// @Tags users v1
 // @Accept json
 // @Produce json
 // @Param user body User true "User data to create"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
 // @Success 201 {object} User "Successfully created user"
 // @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 409 {object} map[string]string "User with this email already exists, or request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} map[string]string "Request body with an Idempotency-Key exceeds the configured limit"
+// @Failure 422 {object} map[string]string "Linked film does not exist, or Idempotency-Key reused with a different request"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users [post]
+// @Router /v1/users [post]
 func (h *Handler) CreateUser(c *gin.Context) {
 	var newUser User
 	if err := c.ShouldBindJSON(&newUser); err != nil {
@@ -48,7 +60,14 @@ func (h *Handler) CreateUser(c *gin.Context) {
 	newUser.UpdatedAt = newUser.CreatedAt
 
 	if err := h.storage.Create(c.Request.Context(), newUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		switch {
+		case errors.Is(err, ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
+		case errors.Is(err, ErrFilmNotFound):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		}
 		return
 	}
 	c.JSON(http.StatusCreated, newUser)
@@ -56,14 +75,28 @@ func (h *Handler) CreateUser(c *gin.Context) {
 
 // GetList godoc
 // @Summary Get all users
-// @Description Retrieve a list of all users
-// @Tags users
+// @Description Retrieve a list of all users. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row
+// @Tags users v1
 // @Accept json
-// @Produce json
-// @Success 200 {array} User "List of users"
+// @Produce json,application/x-ndjson,text/csv
+// @Param stream query bool false "Stream rows as they are read from the database; a failure mid-stream leaves the JSON array unterminated"
+// @Success 200 {array} User "List of users, null if there are none"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users [get]
+// @Router /v1/users [get]
 func (h *Handler) GetList(c *gin.Context) {
+	if stream.Requested(c) {
+		w := stream.New(c, stream.Array)
+		err := h.storage.StreamAll(c.Request.Context(), func(user User) error {
+			return w.Write(user)
+		})
+		if err != nil && c.Request.Context().Err() == nil {
+			h.logger.Errorf("Failed to stream users: %v", err)
+		}
+		if err := w.Finish(err); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		}
+		return
+	}
 	users, err := h.storage.FindAll(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
@@ -75,18 +108,22 @@ func (h *Handler) GetList(c *gin.Context) {
 // GetUser godoc
 // @Summary Get a user by ID
 // @Description Retrieve a single user by their UUID
-// @Tags users
+// @Tags users v1
 // @Accept json
 // @Produce json
 // @Param uuid path string true "User ID (UUID)"
 // @Success 200 {object} User "Requested user"
 // @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users/{uuid} [get]
+// @Router /v1/users/{uuid} [get]
 func (h *Handler) GetUser(c *gin.Context) {
 	param := c.Param("uuid")
 	user, err := h.storage.FindOne(c.Request.Context(), param)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
 		return
 	}
@@ -96,7 +133,7 @@ func (h *Handler) GetUser(c *gin.Context) {
 // UpdateUser godoc
 // @Summary Fully update a user
 // @Description Replace all user data with the provided values
-// @Tags users
+// @Tags users v1
 // @Accept json
 // @Produce json
 // @Param uuid path string true "User ID (UUID)"
@@ -104,8 +141,9 @@ func (h *Handler) GetUser(c *gin.Context) {
 // @Success 204 "User updated successfully"
 // @Failure 400 {object} map[string]string "Invalid request body"
 // @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "User with this email already exists"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users/{uuid} [put]
+// @Router /v1/users/{uuid} [put]
 func (h *Handler) UpdateUser(c *gin.Context) {
 	param := c.Param("uuid")
 	var (
@@ -120,7 +158,7 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 	input.UpdatedAt = time.Now()
 
 	if err := h.storage.Update(c.Request.Context(), param, input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		h.updateError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -128,19 +166,29 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 
 // PartiallyUpdateUser godoc
 // @Summary Partially update a user
-// @Description Update specific fields of a user
-// @Tags users
-// @Accept json
+// @Description Update specific fields of a user.
+// @Description With Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902)
+// @Description applied atomically to the current user, e.g. [{"op":"remove","path":"/film_id/0"}]
+// @Tags users v1
+// @Accept json,application/json-patch+json
 // @Produce json
 // @Param uuid path string true "User ID (UUID)"
 // @Param updates body Update true "Fields to update"
 // @Success 204 "User updated successfully"
 // @Failure 400 {object} map[string]string "Invalid request body"
 // @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "User with this email already exists, or JSON Patch test operation failed"
+// @Failure 422 {object} map[string]string "JSON Patch cannot be applied, result is invalid or links a missing film"
+// @Failure 413 {object} map[string]string "JSON Patch document exceeds 1 MiB"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users/{uuid} [patch]
+// @Router /v1/users/{uuid} [patch]
 func (h *Handler) PartiallyUpdateUser(c *gin.Context) {
 	param := c.Param("uuid")
+	if c.ContentType() == patch.MIMEJSONPatch {
+		h.jsonPatchUser(c, param)
+		return
+	}
+
 	var input Update
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
@@ -148,28 +196,225 @@ func (h *Handler) PartiallyUpdateUser(c *gin.Context) {
 	}
 
 	if err := h.storage.PartialUpdate(c.Request.Context(), param, input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		h.updateError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+// updateError отвечает на ошибку обновления пользователя
+func (h *Handler) updateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	}
+}
+
+func (h *Handler) jsonPatchUser(c *gin.Context, id string) {
+	body, err := patch.Read(c.Writer, c.Request)
+	if err != nil {
+		if errors.Is(err, patch.ErrTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ops, err := patch.Decode(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON Patch document"})
+		return
+	}
+
+	err = h.storage.Patch(c.Request.Context(), id, func(u *User) error {
+		createdAt := u.CreatedAt
+		if err := patch.Apply(ops, u); err != nil {
+			return err
+		}
+		// Идентификатор и служебные даты патчем не меняются
+		u.ID = id
+		u.CreatedAt = createdAt
+		u.UpdatedAt = time.Now()
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, patch.ErrTestFailed), errors.Is(err, ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, patch.ErrValidation), errors.Is(err, ErrFilmNotFound):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		h.logger.Errorf("Failed to apply JSON Patch to user %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	}
+}
+
 // DeleteUser godoc
 // @Summary Delete a user
 // @Description Remove a user by their UUID
-// @Tags users
+// @Tags users v1
 // @Accept json
 // @Produce json
 // @Param uuid path string true "User ID (UUID)"
 // @Success 204 "User deleted successfully"
 // @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users/{uuid} [delete]
+// @Router /v1/users/{uuid} [delete]
 func (h *Handler) DeleteUser(c *gin.Context) {
 	par := c.Param("uuid")
 	if err := h.storage.Delete(c.Request.Context(), par); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ImportUsers godoc
+// @Summary Import users
+// @Description Bulk load users from CSV (with header row), a JSON array or NDJSON.
+// @Description Rows are streamed into PostgreSQL with COPY; each row is validated like CreateUser and rejected rows are reported with their number.
+// @Description In upsert mode users with an existing email are updated, otherwise they are reported as conflicts. Film links are not imported.
+// @Tags users v1
+// @Accept json,text/csv,application/x-ndjson
+// @Produce json
+// @Param format query string false "Input format (csv, json, ndjson); defaults to the Content-Type"
+// @Param mode query string false "Import mode (insert, upsert)" default(insert)
+// @Param dry_run query bool false "Validate and report without saving" default(false)
+// @Success 200 {object} bulk.Result "Import report"
+// @Failure 400 {object} map[string]string "Invalid parameters or malformed input"
+// @Failure 409 {object} map[string]string "User id conflicts with another user"
+// @Failure 422 {object} bulk.Result "No rows could be imported"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/users/import [post]
+func (h *Handler) ImportUsers(c *gin.Context) {
+	format, err := bulk.DetectFormat(c.Query("format"), c.GetHeader("Content-Type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := c.DefaultQuery("mode", "insert")
+	if mode != "insert" && mode != "upsert" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode, expected insert or upsert"})
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run value"})
+		return
+	}
+
+	reader, err := bulk.NewReader(format, c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := bulk.NewResult(format, mode == "upsert", dryRun)
+	if err := h.storage.Import(c.Request.Context(), reader, result); err != nil {
+		switch {
+		case errors.Is(err, bulk.ErrMalformedInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Errorf("Failed to import users: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import users"})
+		}
+		return
+	}
+
+	if result.Failed > 0 && result.Inserted+result.Updated == 0 {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportUsers godoc
+// @Summary Export users
+// @Description Stream all users as CSV, a JSON array or NDJSON
+// @Tags users v1
+// @Produce json,text/csv,application/x-ndjson
+// @Param format query string false "Output format (csv, json, ndjson)" default(json)
+// @Success 200 {array} User "Users in the requested format"
+// @Failure 400 {object} map[string]string "Invalid format"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/users/export [get]
+func (h *Handler) ExportUsers(c *gin.Context) {
+	format, err := bulk.ParseFormat(c.DefaultQuery("format", string(bulk.FormatJSON)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", "attachment; filename=users."+string(format))
+	c.Status(http.StatusOK)
+
+	// Заголовки уже отправлены, поэтому ошибку посреди потока можно только залогировать
+	if err := h.storage.Export(c.Request.Context(), format, c.Writer); err != nil {
+		h.logger.Errorf("Failed to export users: %v", err)
+	}
+}
+
+// BatchUsers godoc
+// @Summary Create, update and delete users in one request
+// @Description Apply a list of operations in a single transaction. Each operation is validated like the single-user endpoint and gets its own status and error.
+// @Description In atomic mode (default) any failure rolls back the whole batch and the remaining operations get status 424; in best_effort mode only failed operations are skipped.
+// @Tags users v1
+// @Accept json
+// @Produce json
+// @Param batch body batch.Request true "Operations; data is User for create and Update for update"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Success 200 {object} batch.Response "Batch committed, per-operation results"
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 409 {object} map[string]string "Request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} map[string]string "Batch exceeds the configured limits"
+// @Failure 422 {object} batch.Response "Atomic batch rolled back (per-operation results), or Idempotency-Key reused with a different request"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/users/batch [post]
+func (h *Handler) BatchUsers(c *gin.Context) {
+	req, err := batch.Bind(c, h.batchLimits)
+	if err != nil {
+		if errors.Is(err, batch.ErrTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := batch.NewResponse(req)
+	items := make([]BatchItem, len(req.Operations))
+	for i, op := range req.Operations {
+		item, err := decodeBatchOperation(op)
+		if err != nil {
+			resp.Reject(i, http.StatusBadRequest, err)
+			continue
+		}
+		items[i] = item
+		resp.Results[i].ID = item.ID
+	}
+
+	if err := h.storage.Batch(c.Request.Context(), items, resp); err != nil {
+		h.logger.Errorf("Failed to execute users batch: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute batch"})
+		return
+	}
+	c.JSON(resp.StatusCode(), resp)
+}
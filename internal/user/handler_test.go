@@ -0,0 +1,223 @@
+package user_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"rest-api-tutorial/internal/films"
+	"rest-api-tutorial/internal/memory"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/patch"
+	"strings"
+	"testing"
+	"time"
+)
+
+// missingID UUID, которого нет в хранилище
+const missingID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+// fixture роутер v1 и v2 пользователей над in-memory хранилищем с одним фильмом
+type fixture struct {
+	router *gin.Engine
+	users  *memory.UserStorage
+	filmID string
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := memory.NewDB()
+	storage := memory.NewUserStorage(db)
+	logger := logging.GetLogger()
+
+	film := films.Film{
+		ID:          "5b1d1b4e-4c2f-4a57-9c1e-0d5f7a3e8b21",
+		Title:       "Сталкер",
+		Rating:      8.1,
+		ReleaseDate: time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := memory.NewFilmStorage(db).Create(context.Background(), film); err != nil {
+		t.Fatalf("create film: %v", err)
+	}
+
+	v1 := user.NewHandler(storage, batch.Limits{MaxOperations: 10, MaxBodyBytes: 1 << 20}, logger)
+	v2 := user.NewHandlerV2(storage, logger)
+	r := gin.New()
+	r.POST("/v1/users", v1.CreateUser)
+	r.GET("/v1/users", v1.GetList)
+	r.GET("/v1/users/:uuid", v1.GetUser)
+	r.PUT("/v1/users/:uuid", v1.UpdateUser)
+	r.PATCH("/v1/users/:uuid", v1.PartiallyUpdateUser)
+	r.DELETE("/v1/users/:uuid", v1.DeleteUser)
+	r.POST("/v2/users", v2.CreateUser)
+	r.GET("/v2/users", v2.GetList)
+	r.GET("/v2/users/:uuid", v2.GetUser)
+	r.PATCH("/v2/users/:uuid", v2.PartiallyUpdateUser)
+	return &fixture{router: r, users: storage, filmID: film.ID}
+}
+
+func (f *fixture) serve(method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func (f *fixture) create(t *testing.T, email string, filmIDs ...string) user.User {
+	t.Helper()
+	body := `{"name": "Иванов Иван", "email": "` + email + `", "date_of_birth": "2000-01-01T00:00:00Z", "gender": "М", "film_id": [` + quote(filmIDs) + `]}`
+	w := f.serve(http.MethodPost, "/v1/users", "application/json", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /v1/users: status %d: %s", w.Code, w.Body)
+	}
+	var u user.User
+	if err := json.Unmarshal(w.Body.Bytes(), &u); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	return u
+}
+
+func quote(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	return `"` + strings.Join(ids, `", "`) + `"`
+}
+
+func TestCreateUser(t *testing.T) {
+	f := newFixture(t)
+	u := f.create(t, "ivanov@example.com", f.filmID)
+	if u.ID == "" || len(u.FilmUUID) != 1 || u.FilmUUID[0].String() != f.filmID {
+		t.Errorf("created user = %+v", u)
+	}
+
+	valid := `{"name": "Иванов Иван", "email": "ivanov@example.com", "date_of_birth": "2000-01-01T00:00:00Z", "gender": "М"}`
+	if w := f.serve(http.MethodPost, "/v1/users", "application/json", valid); w.Code != http.StatusConflict {
+		t.Errorf("duplicate email: status %d, want 409", w.Code)
+	}
+	if w := f.serve(http.MethodPost, "/v1/users", "application/json", strings.Replace(valid, `"М"`, `"X"`, 1)); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid gender: status %d, want 400", w.Code)
+	}
+	unknown := strings.Replace(valid, "ivanov@", "petrov@", 1)
+	unknown = strings.Replace(unknown, `"gender": "М"`, `"gender": "М", "film_id": ["`+missingID+`"]`, 1)
+	if w := f.serve(http.MethodPost, "/v1/users", "application/json", unknown); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown film: status %d, want 422", w.Code)
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	f := newFixture(t)
+
+	// v1 сохраняет прежний формат: пустой список — null
+	if w := f.serve(http.MethodGet, "/v1/users", "", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "null" {
+		t.Errorf("empty list: status %d, body %s, want 200 null", w.Code, w.Body)
+	}
+
+	u := f.create(t, "ivanov@example.com")
+	if w := f.serve(http.MethodGet, "/v1/users/"+u.ID, "", ""); w.Code != http.StatusOK {
+		t.Errorf("GET existing user: status %d, want 200", w.Code)
+	}
+	if w := f.serve(http.MethodGet, "/v1/users/"+missingID, "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET missing user: status %d, want 404", w.Code)
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	f := newFixture(t)
+	u := f.create(t, "ivanov@example.com", f.filmID)
+	f.create(t, "petrov@example.com")
+
+	full := `{"name": "Иванов Иван Иванович", "email": "ivanov@example.com", "date_of_birth": "2000-01-01T00:00:00Z", "gender": "М"}`
+	if w := f.serve(http.MethodPut, "/v1/users/"+u.ID, "application/json", full); w.Code != http.StatusNoContent {
+		t.Fatalf("PUT: status %d: %s", w.Code, w.Body)
+	}
+	got, err := f.users.FindOne(context.Background(), u.ID)
+	// Как и в PostgreSQL, PUT не меняет список фильмов
+	if err != nil || got.Name != "Иванов Иван Иванович" || len(got.FilmUUID) != 1 {
+		t.Errorf("after PUT user = %+v, %v", got, err)
+	}
+
+	if w := f.serve(http.MethodPatch, "/v1/users/"+u.ID, "application/json", `{"email": "petrov@example.com"}`); w.Code != http.StatusConflict {
+		t.Errorf("PATCH to a taken email: status %d, want 409", w.Code)
+	}
+	if w := f.serve(http.MethodPatch, "/v1/users/"+missingID, "application/json", `{"name": "Петров Петр"}`); w.Code != http.StatusNotFound {
+		t.Errorf("PATCH missing user: status %d, want 404", w.Code)
+	}
+}
+
+func TestJSONPatchUser(t *testing.T) {
+	f := newFixture(t)
+	u := f.create(t, "ivanov@example.com", f.filmID)
+
+	ops := `[{"op": "remove", "path": "/film_id/0"}, {"op": "replace", "path": "/name", "value": "Иванов И."}]`
+	if w := f.serve(http.MethodPatch, "/v1/users/"+u.ID, "application/json-patch+json", ops); w.Code != http.StatusNoContent {
+		t.Fatalf("JSON Patch: status %d: %s", w.Code, w.Body)
+	}
+	got, _ := f.users.FindOne(context.Background(), u.ID)
+	if got.Name != "Иванов И." || len(got.FilmUUID) != 0 {
+		t.Errorf("after JSON Patch user = %+v", got)
+	}
+
+	link := `[{"op": "add", "path": "/film_id/-", "value": "` + missingID + `"}]`
+	if w := f.serve(http.MethodPatch, "/v1/users/"+u.ID, "application/json-patch+json", link); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("linking a missing film: status %d, want 422", w.Code)
+	}
+
+	oversized := "[" + strings.Repeat(" ", patch.MaxBodyBytes) + "]"
+	for _, path := range []string{"/v1/users/", "/v2/users/"} {
+		if w := f.serve(http.MethodPatch, path+u.ID, "application/json-patch+json", oversized); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("oversized JSON Patch on %s: status %d, want 413", path, w.Code)
+		}
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	f := newFixture(t)
+	u := f.create(t, "ivanov@example.com")
+
+	if w := f.serve(http.MethodDelete, "/v1/users/"+u.ID, "", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d: %s", w.Code, w.Body)
+	}
+	if w := f.serve(http.MethodDelete, "/v1/users/"+u.ID, "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("second DELETE: status %d, want 404", w.Code)
+	}
+}
+
+func TestV2Envelopes(t *testing.T) {
+	f := newFixture(t)
+
+	w := f.serve(http.MethodGet, "/v2/users", "", "")
+	var list envelope.Collection[user.UserV2]
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK || list.Data == nil || list.Meta.Count != 0 {
+		t.Errorf("empty v2 list: status %d, body %s, want data [] and count 0", w.Code, w.Body)
+	}
+
+	body := `{"name": "Смирнова Ольга", "email": "smirnova@example.com", "date_of_birth": "1985-01-01T00:00:00Z", "gender": "Ж", "film_ids": ["` + f.filmID + `"]}`
+	w = f.serve(http.MethodPost, "/v2/users", "application/json", body)
+	var created envelope.Resource[user.UserV2]
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated || created.Data.ID == "" {
+		t.Fatalf("POST /v2/users: status %d, body %s", w.Code, w.Body)
+	}
+
+	w = f.serve(http.MethodPatch, "/v2/users/"+created.Data.ID, "application/json", `{"name": "Смирнова О."}`)
+	var updated envelope.Resource[user.UserV2]
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil || w.Code != http.StatusOK || updated.Data.Name != "Смирнова О." {
+		t.Errorf("PATCH /v2/users: status %d, body %s", w.Code, w.Body)
+	}
+
+	w = f.serve(http.MethodGet, "/v2/users/"+missingID, "", "")
+	var failure envelope.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &failure); err != nil || w.Code != http.StatusNotFound || failure.Code != http.StatusNotFound {
+		t.Errorf("GET missing user: status %d, body %s, want an error envelope with 404", w.Code, w.Body)
+	}
+}
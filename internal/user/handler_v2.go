@@ -0,0 +1,269 @@
+package user
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"rest-api-tutorial/pkg/patch"
+	"rest-api-tutorial/pkg/stream"
+	"time"
+)
+
+// HandlerV2 обработчики пользователей API v2: ответы в конвертах envelope,
+// ошибки в формате errors.ErrorResponse
+type HandlerV2 struct {
+	logger  *logging.Logger
+	storage UserRepository
+}
+
+func NewHandlerV2(storage UserRepository, logger *logging.Logger) *HandlerV2 {
+	return &HandlerV2{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+// CreateUser godoc
+// @Summary Create a new user
+// @Description Create a new user with the provided details
+// @Tags users v2
+// @Accept json
+// @Produce json
+// @Param user body UserV2 true "User data to create"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Success 201 {object} envelope.Resource[user.UserV2] "Successfully created user"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 409 {object} envelope.ErrorResponse "User with this email already exists, or request with the same Idempotency-Key is in progress"
+// @Failure 413 {object} envelope.ErrorResponse "Request body with an Idempotency-Key exceeds the configured limit"
+// @Failure 422 {object} envelope.ErrorResponse "Linked film does not exist, or Idempotency-Key reused with a different request"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/users [post]
+func (h *HandlerV2) CreateUser(c *gin.Context) {
+	var input UserV2
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to generate ID")
+		return
+	}
+	newUser := input.User()
+	newUser.ID = id.String()
+	newUser.CreatedAt = time.Now()
+	newUser.UpdatedAt = newUser.CreatedAt
+
+	if err := h.storage.Create(c.Request.Context(), newUser); err != nil {
+		h.writeError(c, err, "Failed to create user")
+		return
+	}
+	envelope.Data(c, http.StatusCreated, NewUserV2(newUser))
+}
+
+// GetList godoc
+// @Summary Get all users
+// @Description Retrieve a list of all users. With stream=true, Accept: application/x-ndjson or Accept: text/csv the list is written row by row
+// @Tags users v2
+// @Produce json,application/x-ndjson,text/csv
+// @Param stream query bool false "Stream rows as they are read from the database; a failure mid-stream leaves the JSON envelope unterminated"
+// @Success 200 {object} envelope.Collection[user.UserV2] "List of users"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/users [get]
+func (h *HandlerV2) GetList(c *gin.Context) {
+	if stream.Requested(c) {
+		w := stream.New(c, stream.Envelope)
+		err := h.storage.StreamAll(c.Request.Context(), func(u User) error {
+			return w.Write(NewUserV2(u))
+		})
+		if err != nil && c.Request.Context().Err() == nil {
+			h.logger.Errorf("Failed to stream users: %v", err)
+		}
+		if err := w.Finish(err); err != nil {
+			envelope.Error(c, http.StatusInternalServerError, "Failed to fetch users")
+		}
+		return
+	}
+	users, err := h.storage.FindAll(c.Request.Context())
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch users")
+		return
+	}
+	out := make([]UserV2, 0, len(users))
+	for _, u := range users {
+		out = append(out, NewUserV2(u))
+	}
+	envelope.List(c, http.StatusOK, out)
+}
+
+// GetUser godoc
+// @Summary Get a user by ID
+// @Description Retrieve a single user by their UUID
+// @Tags users v2
+// @Produce json
+// @Param uuid path string true "User ID (UUID)"
+// @Success 200 {object} envelope.Resource[user.UserV2] "Requested user"
+// @Failure 404 {object} envelope.ErrorResponse "User not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/users/{uuid} [get]
+func (h *HandlerV2) GetUser(c *gin.Context) {
+	h.respondUser(c, c.Param("uuid"))
+}
+
+// UpdateUser godoc
+// @Summary Fully update a user
+// @Description Replace user data with the provided values and return the updated user. Film links are changed with JSON Patch
+// @Tags users v2
+// @Accept json
+// @Produce json
+// @Param uuid path string true "User ID (UUID)"
+// @Param user body UserV2 true "Updated user data"
+// @Success 200 {object} envelope.Resource[user.UserV2] "Updated user"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 404 {object} envelope.ErrorResponse "User not found"
+// @Failure 409 {object} envelope.ErrorResponse "User with this email already exists"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/users/{uuid} [put]
+func (h *HandlerV2) UpdateUser(c *gin.Context) {
+	id := c.Param("uuid")
+	var input UserV2
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	updated := input.User()
+	updated.ID = id
+	updated.UpdatedAt = time.Now()
+
+	if err := h.storage.Update(c.Request.Context(), id, updated); err != nil {
+		h.writeError(c, err, "Failed to update user")
+		return
+	}
+	h.respondUser(c, id)
+}
+
+// PartiallyUpdateUser godoc
+// @Summary Partially update a user
+// @Description Update specific fields of a user and return the updated user.
+// @Description With Content-Type application/json-patch+json the body is a JSON Patch (RFC 6902)
+// @Description applied atomically to the v2 representation of the user, e.g. [{"op":"remove","path":"/film_ids/0"}]
+// @Tags users v2
+// @Accept json,application/json-patch+json
+// @Produce json
+// @Param uuid path string true "User ID (UUID)"
+// @Param updates body UpdateV2 true "Fields to update"
+// @Success 200 {object} envelope.Resource[user.UserV2] "Updated user"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 404 {object} envelope.ErrorResponse "User not found"
+// @Failure 409 {object} envelope.ErrorResponse "User with this email already exists, or JSON Patch test operation failed"
+// @Failure 422 {object} envelope.ErrorResponse "JSON Patch cannot be applied, result is invalid or links a missing film"
+// @Failure 413 {object} envelope.ErrorResponse "JSON Patch document exceeds 1 MiB"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/users/{uuid} [patch]
+func (h *HandlerV2) PartiallyUpdateUser(c *gin.Context) {
+	id := c.Param("uuid")
+	if c.ContentType() == patch.MIMEJSONPatch {
+		h.jsonPatchUser(c, id)
+		return
+	}
+
+	var input UpdateV2
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.storage.PartialUpdate(c.Request.Context(), id, input.Update()); err != nil {
+		h.writeError(c, err, "Failed to update user")
+		return
+	}
+	h.respondUser(c, id)
+}
+
+func (h *HandlerV2) jsonPatchUser(c *gin.Context, id string) {
+	body, err := patch.Read(c.Writer, c.Request)
+	if err != nil {
+		if errors.Is(err, patch.ErrTooLarge) {
+			envelope.Error(c, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ops, err := patch.Decode(body)
+	if err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid JSON Patch document")
+		return
+	}
+
+	err = h.storage.Patch(c.Request.Context(), id, func(u *User) error {
+		// Патч применяется к представлению v2, чтобы пути совпадали с тем, что видит клиент
+		view := NewUserV2(*u)
+		if err := patch.Apply(ops, &view); err != nil {
+			return err
+		}
+		createdAt := u.CreatedAt
+		*u = view.User()
+		// Идентификатор и служебные даты патчем не меняются
+		u.ID = id
+		u.CreatedAt = createdAt
+		u.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		h.writeError(c, err, "Failed to update user")
+		return
+	}
+	h.respondUser(c, id)
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Remove a user by their UUID
+// @Tags users v2
+// @Produce json
+// @Param uuid path string true "User ID (UUID)"
+// @Success 204 "User deleted successfully"
+// @Failure 404 {object} envelope.ErrorResponse "User not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /v2/users/{uuid} [delete]
+func (h *HandlerV2) DeleteUser(c *gin.Context) {
+	if err := h.storage.Delete(c.Request.Context(), c.Param("uuid")); err != nil {
+		h.writeError(c, err, "Failed to delete user")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondUser отвечает актуальным состоянием пользователя
+func (h *HandlerV2) respondUser(c *gin.Context, id string) {
+	user, err := h.storage.FindOne(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, err, "Failed to find user")
+		return
+	}
+	envelope.Data(c, http.StatusOK, NewUserV2(*user))
+}
+
+// writeError переводит ошибку хранилища или патча в ответ; fallback уходит клиенту при 500
+func (h *HandlerV2) writeError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		envelope.Error(c, http.StatusNotFound, "User not found")
+	case errors.Is(err, ErrConflict):
+		envelope.Error(c, http.StatusConflict, "User with this email already exists")
+	case errors.Is(err, patch.ErrTestFailed):
+		envelope.Error(c, http.StatusConflict, err.Error())
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, patch.ErrValidation), errors.Is(err, ErrFilmNotFound):
+		envelope.Error(c, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.logger.Errorf("%s: %v", fallback, err)
+		envelope.Error(c, http.StatusInternalServerError, fallback)
+	}
+}
@@ -14,15 +14,15 @@ type User struct {
 
 	// @minLength 1
 	// @maxLength 255
-	Name string `json:"name" binding:"required"`
+	Name string `json:"name" binding:"required,max=255"`
 
 	// @minLength 1
 	// @maxLength 255
-	Email string `json:"email" binding:"required,email"`
+	Email string `json:"email" binding:"required,email,max=255"`
 
 	// @format date
 	DateOfBirth time.Time `json:"date_of_birth" binding:"required"`
-	Gender      string    `json:"gender" binding:"required"`
+	Gender      string    `json:"gender" binding:"required,oneof=М Ж"`
 
 	// @format date
 	CreatedAt time.Time `json:"created_at"`
@@ -41,12 +41,12 @@ type Update struct {
 	// @Example "Иванов Иван Иванович"
 	// @MinLength 2
 	// @MaxLength 100
-	Name *string `json:"name"`
+	Name *string `json:"name" binding:"omitempty,max=255"`
 
 	// Электронная почта пользователя
 	// @Example "testemail@example.com"
 	// @Format email
-	Email *string `json:"email"`
+	Email *string `json:"email" binding:"omitempty,email,max=255"`
 
 	// Информация о дате рождения пользователя
 	// @Example "2000.01.01"
@@ -57,10 +57,92 @@ type Update struct {
 	// @Enum "М" "Ж"
 	// @Format string
 	// @MaxLength 1
-	Gender *string `json:"gender"`
+	Gender *string `json:"gender" binding:"omitempty,oneof=М Ж"`
 
 	// Уникальный идентификатор фильма, с которым связан пользователь
 	// @Example "1111a111-2b2b-3333-444d-55555555eee5"
 	// @DFormat uuid
 	FilmUUID []uuid.UUID `json:"film_id"`
 }
+
+// UserV2 представление пользователя в API v2: связанные фильмы называются film_ids
+// @description Модель пользователя в API v2
+type UserV2 struct {
+
+	// @format uuid
+	ID string `json:"id"`
+
+	// @minLength 1
+	// @maxLength 255
+	Name string `json:"name" binding:"required,max=255"`
+
+	// @minLength 1
+	// @maxLength 255
+	Email string `json:"email" binding:"required,email,max=255"`
+
+	// @format date
+	DateOfBirth time.Time `json:"date_of_birth" binding:"required"`
+	Gender      string    `json:"gender" binding:"required,oneof=М Ж"`
+
+	// @format date
+	CreatedAt time.Time `json:"created_at"`
+
+	// @format date
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// @format uuid
+	FilmIDs []uuid.UUID `json:"film_ids"`
+}
+
+// UpdateV2 частичное обновление пользователя в API v2
+// @description Модель пользователя в API v2 с данными, необходимыми для обновления
+type UpdateV2 struct {
+	Name        *string     `json:"name" binding:"omitempty,max=255"`
+	Email       *string     `json:"email" binding:"omitempty,email,max=255"`
+	DateOfBirth *time.Time  `json:"date_of_birth"`
+	Gender      *string     `json:"gender" binding:"omitempty,oneof=М Ж"`
+	FilmIDs     []uuid.UUID `json:"film_ids"`
+}
+
+// NewUserV2 переводит пользователя в представление API v2. Пустой список фильмов
+// отдается массивом, а не null.
+func NewUserV2(u User) UserV2 {
+	filmIDs := u.FilmUUID
+	if filmIDs == nil {
+		filmIDs = make([]uuid.UUID, 0)
+	}
+	return UserV2{
+		ID:          u.ID,
+		Name:        u.Name,
+		Email:       u.Email,
+		DateOfBirth: u.DateOfBirth,
+		Gender:      u.Gender,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		FilmIDs:     filmIDs,
+	}
+}
+
+// User переводит представление API v2 обратно в модель хранилища
+func (u UserV2) User() User {
+	return User{
+		ID:          u.ID,
+		Name:        u.Name,
+		Email:       u.Email,
+		DateOfBirth: u.DateOfBirth,
+		Gender:      u.Gender,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		FilmUUID:    u.FilmIDs,
+	}
+}
+
+// Update переводит частичное обновление API v2 в модель хранилища
+func (u UpdateV2) Update() Update {
+	return Update{
+		Name:        u.Name,
+		Email:       u.Email,
+		DateOfBirth: u.DateOfBirth,
+		Gender:      u.Gender,
+	}
+}
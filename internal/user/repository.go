@@ -0,0 +1,31 @@
+package user
+
+import (
+	"context"
+	"io"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
+)
+
+// UserRepository хранилище пользователей, с которым работает Handler.
+// Реализации обязаны соблюдать одну семантику: ErrNotFound для отсутствующего
+// пользователя, ErrConflict при повторе id или email, ErrFilmNotFound при ссылке
+// на несуществующий фильм; при удалении пользователя удаляются его связи с фильмами.
+type UserRepository interface {
+	Create(ctx context.Context, user User) error
+	FindOne(ctx context.Context, id string) (*User, error)
+	FindAll(ctx context.Context) ([]User, error)
+	// StreamAll передает пользователей в fn по одному в порядке FindAll;
+	// ошибка fn прерывает чтение и возвращается без изменений
+	StreamAll(ctx context.Context, fn func(User) error) error
+	FindByFilmIDs(ctx context.Context, filmIDs []string) (map[string][]User, error)
+	Update(ctx context.Context, id string, input User) error
+	PartialUpdate(ctx context.Context, id string, input Update) error
+	Patch(ctx context.Context, id string, apply func(*User) error) error
+	Delete(ctx context.Context, id string) error
+	Batch(ctx context.Context, items []BatchItem, resp *batch.Response) error
+	Import(ctx context.Context, reader *bulk.Reader, result *bulk.Result) error
+	Export(ctx context.Context, format bulk.Format, w io.Writer) error
+}
+
+var _ UserRepository = (*Storage)(nil)
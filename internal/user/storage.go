@@ -4,9 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"io"
+	"net/http"
+	"rest-api-tutorial/pkg/batch"
+	"rest-api-tutorial/pkg/bulk"
+	"rest-api-tutorial/pkg/client/postgres"
 	"rest-api-tutorial/pkg/logging"
+	"sort"
+)
+
+var (
+	// ErrNotFound пользователь с указанным идентификатором не существует
+	ErrNotFound = errors.New("user not found")
+	// ErrConflict пользователь с таким идентификатором или email уже существует
+	ErrConflict = errors.New("user already exists")
+	// ErrFilmNotFound пользователь ссылается на несуществующий фильм
+	ErrFilmNotFound = errors.New("film does not exist")
+)
+
+// Коды ошибок PostgreSQL при нарушении ограничений
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
 )
 
 type Storage struct {
@@ -22,11 +45,24 @@ func NewUserStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
 }
 
 func (s *Storage) Create(ctx context.Context, user User) error {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.create(ctx, tx, user); err != nil {
+		return constraintError(err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Storage) create(ctx context.Context, client postgres.Client, user User) error {
 	q := `
         INSERT INTO users (id, name, email, date_of_birth, gender, created_at, updated_at) 
         VALUES ($1, $2, $3, $4, $5, $6, $7)
     `
-	_, err := s.client.Exec(
+	_, err := client.Exec(
 		ctx,
 		q,
 		user.ID,
@@ -37,6 +73,9 @@ func (s *Storage) Create(ctx context.Context, user User) error {
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
+	if err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
 
 	if len(user.FilmUUID) > 0 {
 		qFilm := `
@@ -44,14 +83,14 @@ func (s *Storage) Create(ctx context.Context, user User) error {
             VALUES ($1, $2)
         `
 		for _, filmID := range user.FilmUUID {
-			_, err = s.client.Exec(ctx, qFilm, user.ID, filmID)
+			_, err = client.Exec(ctx, qFilm, user.ID, filmID)
 			if err != nil {
 				return fmt.Errorf("failed to insert user-film relation: %w", err)
 			}
 		}
 	}
 
-	return err
+	return nil
 }
 
 func (s *Storage) FindOne(ctx context.Context, id string) (*User, error) {
@@ -75,16 +114,160 @@ func (s *Storage) FindOne(ctx context.Context, id string) (*User, error) {
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			s.logger.Warnf("User not found: %s", id)
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrNotFound
 		}
 		s.logger.Errorf("Failed to get user: %v", err)
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	user.FilmUUID, err = s.findFilmIDs(ctx, s.client, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
+// Patch загружает пользователя с блокировкой строки, передает его в apply
+// и сохраняет результат вместе со списком фильмов в одной транзакции.
+// Если apply возвращает ошибку, транзакция откатывается.
+func (s *Storage) Patch(ctx context.Context, id string, apply func(*User) error) error {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+        SELECT id, name, email, date_of_birth, gender, created_at, updated_at 
+        FROM users 
+        WHERE id = $1
+        FOR UPDATE
+    `
+
+	var user User
+	err = tx.QueryRow(ctx, q, id).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.DateOfBirth,
+		&user.Gender,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	user.FilmUUID, err = s.findFilmIDs(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	linked := append([]uuid.UUID(nil), user.FilmUUID...)
+
+	if err := apply(&user); err != nil {
+		return err
+	}
+
+	qUpdate := `
+        UPDATE users 
+        SET 
+            name = $2,
+            email = $3,
+            date_of_birth = $4,
+            gender = $5,
+            updated_at = $6
+        WHERE id = $1
+    `
+	_, err = tx.Exec(ctx, qUpdate, id, user.Name, user.Email, user.DateOfBirth, user.Gender, user.UpdatedAt)
+	if err != nil {
+		s.logger.Errorf("Failed to patch user: %v", err)
+		return constraintError(fmt.Errorf("failed to patch user: %w", err))
+	}
+
+	// Меняются только добавленные и удаленные связи, чтобы у остальных
+	// сохранилось время добавления added_at
+	added, removed := diffFilmIDs(linked, user.FilmUUID)
+	if len(removed) > 0 {
+		qDelete := `DELETE FROM user_film WHERE user_id = $1 AND film_id = ANY($2)`
+		if _, err := tx.Exec(ctx, qDelete, id, removed); err != nil {
+			return fmt.Errorf("failed to delete user-film relations: %w", err)
+		}
+	}
+
+	qFilm := `
+        INSERT INTO user_film (user_id, film_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+	for _, filmID := range added {
+		if _, err := tx.Exec(ctx, qFilm, id, filmID); err != nil {
+			return constraintError(fmt.Errorf("failed to insert user-film relation: %w", err))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// diffFilmIDs возвращает фильмы, которые есть только в after (added), и фильмы,
+// которые есть только в before (removed), в виде строк для запросов
+func diffFilmIDs(before, after []uuid.UUID) (added, removed []string) {
+	was := make(map[uuid.UUID]bool, len(before))
+	for _, id := range before {
+		was[id] = true
+	}
+	is := make(map[uuid.UUID]bool, len(after))
+	for _, id := range after {
+		if !is[id] && !was[id] {
+			added = append(added, id.String())
+		}
+		is[id] = true
+	}
+	for _, id := range before {
+		if !is[id] {
+			removed = append(removed, id.String())
+		}
+	}
+	return added, removed
+}
+
+func (s *Storage) findFilmIDs(ctx context.Context, client postgres.Client, userID string) ([]uuid.UUID, error) {
+	q := `SELECT film_id FROM user_film WHERE user_id = $1`
+	rows, err := client.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user films: %w", err)
+	}
+	defer rows.Close()
+
+	filmIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var filmID uuid.UUID
+		if err := rows.Scan(&filmID); err != nil {
+			return nil, fmt.Errorf("failed to scan film id: %w", err)
+		}
+		filmIDs = append(filmIDs, filmID)
+	}
+	return filmIDs, rows.Err()
+}
+
 func (s *Storage) PartialUpdate(ctx context.Context, id string, input Update) error {
+	tag, err := s.partialUpdate(ctx, s.client, id, input)
+	if err != nil {
+		return constraintError(fmt.Errorf("failed to update user: %w", err))
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Storage) partialUpdate(ctx context.Context, client postgres.Client, id string, input Update) (pgconn.CommandTag, error) {
 	q := `
         UPDATE users 
         SET 
@@ -95,8 +278,7 @@ func (s *Storage) PartialUpdate(ctx context.Context, id string, input Update) er
             updated_at = NOW()
         WHERE id = $1
     `
-	_, err := s.client.Exec(ctx, q, id, input.Name, input.Email, input.DateOfBirth, input.Gender)
-	return err
+	return client.Exec(ctx, q, id, input.Name, input.Email, input.DateOfBirth, input.Gender)
 }
 
 func (s *Storage) Update(ctx context.Context, id string, input User) error {
@@ -111,35 +293,122 @@ func (s *Storage) Update(ctx context.Context, id string, input User) error {
         WHERE id = $1
     `
 
-	_, err := s.client.Exec(ctx, q, id, input.Name, input.Email, input.DateOfBirth, input.Gender)
+	tag, err := s.client.Exec(ctx, q, id, input.Name, input.Email, input.DateOfBirth, input.Gender)
 
 	if err != nil {
 		s.logger.Errorf("Failed to update user: %v", err)
-		return fmt.Errorf("failed to update user: %w", err)
+		return constraintError(fmt.Errorf("failed to update user: %w", err))
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
 	}
 	return nil
 }
 
 func (s *Storage) Delete(ctx context.Context, id string) error {
+	tag, err := s.delete(ctx, s.client, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Storage) delete(ctx context.Context, client postgres.Client, id string) (pgconn.CommandTag, error) {
 	q := `DELETE FROM users WHERE id = $1`
 
-	_, err := s.client.Exec(ctx, q, id)
+	tag, err := client.Exec(ctx, q, id)
 	if err != nil {
 		s.logger.Errorf("Failed to delete user: %v", err)
-		return fmt.Errorf("failed to delete user: %w", err)
+		return nil, fmt.Errorf("failed to delete user: %w", err)
 	}
-	return nil
+	return tag, nil
+}
+
+// Batch выполняет подготовленные операции пакета в одной транзакции через batch.Run
+func (s *Storage) Batch(ctx context.Context, items []BatchItem, resp *batch.Response) error {
+	return batch.Run(ctx, s.client, resp, func(ctx context.Context, tx pgx.Tx, i int) (string, int, error) {
+		item := items[i]
+		switch item.Op {
+		case batch.OpCreate:
+			if err := s.create(ctx, tx, item.User); err != nil {
+				status, err := batchError(err, "failed to create user")
+				return item.User.ID, status, err
+			}
+			return item.User.ID, http.StatusCreated, nil
+		case batch.OpUpdate:
+			tag, err := s.partialUpdate(ctx, tx, item.ID, item.Update)
+			if err != nil {
+				status, err := batchError(err, "failed to update user")
+				return item.ID, status, err
+			}
+			if tag.RowsAffected() == 0 {
+				return item.ID, http.StatusNotFound, ErrNotFound
+			}
+			return item.ID, http.StatusNoContent, nil
+		default:
+			tag, err := s.delete(ctx, tx, item.ID)
+			if err != nil {
+				return item.ID, http.StatusInternalServerError, fmt.Errorf("failed to delete user")
+			}
+			if tag.RowsAffected() == 0 {
+				return item.ID, http.StatusNotFound, ErrNotFound
+			}
+			return item.ID, http.StatusNoContent, nil
+		}
+	})
+}
+
+// batchError переводит ошибку PostgreSQL в статус операции пакета
+func batchError(err error, message string) (int, error) {
+	switch err = constraintError(err); {
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict, ErrConflict
+	case errors.Is(err, ErrFilmNotFound):
+		return http.StatusUnprocessableEntity, ErrFilmNotFound
+	}
+	return http.StatusInternalServerError, errors.New(message)
+}
+
+// constraintError заменяет нарушение ограничений PostgreSQL на ErrConflict или ErrFilmNotFound,
+// остальные ошибки возвращает без изменений
+func constraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return ErrConflict
+		case foreignKeyViolation:
+			return ErrFilmNotFound
+		}
+	}
+	return err
 }
 
 func (s *Storage) FindAll(ctx context.Context) ([]User, error) {
+	var users []User
+	err := s.StreamAll(ctx, func(user User) error {
+		users = append(users, user)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// StreamAll передает пользователей в fn по мере чтения строк, не собирая список в памяти.
+// Ошибка fn прерывает чтение и возвращается без изменений.
+func (s *Storage) StreamAll(ctx context.Context, fn func(User) error) error {
 	q := `SELECT id, name, email, date_of_birth, gender, created_at, updated_at FROM users`
 	rows, err := s.client.Query(ctx, q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get users: %w", err)
+		return fmt.Errorf("failed to get users: %w", err)
 	}
 	defer rows.Close()
 
-	var users []User
 	for rows.Next() {
 		var user User
 		if err := rows.Scan(
@@ -150,10 +419,288 @@ func (s *Storage) FindAll(ctx context.Context) ([]User, error) {
 			&user.Gender,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan user: %w", err)
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	return nil
+}
+
+// FindByFilmIDs одним запросом находит пользователей, связанных с каждым из фильмов
+// filmIDs. Используется пакетными загрузчиками GraphQL; FilmUUID не заполняется.
+func (s *Storage) FindByFilmIDs(ctx context.Context, filmIDs []string) (map[string][]User, error) {
+	q := `
+        SELECT user_film.film_id, users.id, users.name, users.email, users.date_of_birth, users.gender, users.created_at, users.updated_at
+        FROM users
+        JOIN user_film ON users.id = user_film.user_id
+        WHERE user_film.film_id = ANY($1)
+        ORDER BY users.name, users.id
+    `
+	rows, err := s.client.Query(ctx, q, filmIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get film viewers: %w", err)
+	}
+	defer rows.Close()
+
+	viewers := make(map[string][]User, len(filmIDs))
+	for rows.Next() {
+		var (
+			filmID string
+			user   User
+		)
+		if err := rows.Scan(
+			&filmID,
+			&user.ID,
+			&user.Name,
+			&user.Email,
+			&user.DateOfBirth,
+			&user.Gender,
+			&user.CreatedAt,
+			&user.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
-		users = append(users, user)
+		viewers[filmID] = append(viewers[filmID], user)
 	}
-	return users, nil
+	return viewers, rows.Err()
+}
+
+// Import загружает пользователей из reader через COPY во временную таблицу и переносит
+// их в users одним запросом. В режиме upsert существующие пользователи обновляются по
+// ограничению users_tenant_email_key, иначе конфликтующие строки отклоняются.
+// При DryRun транзакция откатывается, но результат содержит те же счетчики и ошибки.
+func (s *Storage) Import(ctx context.Context, reader *bulk.Reader, result *bulk.Result) error {
+	tx, err := s.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qTemp := `
+        CREATE TEMP TABLE users_import (
+            row_num integer NOT NULL,
+            id uuid NOT NULL,
+            name character varying(255) NOT NULL,
+            email character varying(255) NOT NULL,
+            date_of_birth timestamp without time zone NOT NULL,
+            gender character varying(1) NOT NULL
+        ) ON COMMIT DROP
+    `
+	if _, err := tx.Exec(ctx, qTemp); err != nil {
+		return fmt.Errorf("failed to create import table: %w", err)
+	}
+
+	src := bulk.NewCopySource(reader, result, decodeUserRow)
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"users_import"}, importColumns, src); err != nil {
+		if src.Err() != nil {
+			return src.Err()
+		}
+		return fmt.Errorf("failed to copy users: %w", err)
+	}
+
+	if result.Upsert {
+		err = s.importUpsert(ctx, tx, result)
+	} else {
+		err = s.importInsert(ctx, tx, result)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrConflict, pgErr.Detail)
+		}
+		return err
+	}
+
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Row < result.Errors[j].Row
+	})
+
+	if result.DryRun {
+		return nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) importInsert(ctx context.Context, tx pgx.Tx, result *bulk.Result) error {
+	q := `
+        INSERT INTO users (id, name, email, date_of_birth, gender, created_at, updated_at)
+        SELECT id, name, email, date_of_birth, gender, NOW(), NOW()
+        FROM users_import
+        ORDER BY row_num
+        ON CONFLICT DO NOTHING
+        RETURNING id::text
+    `
+	rows, err := tx.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to insert users: %w", err)
+	}
+	inserted := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan user id: %w", err)
+		}
+		inserted[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to insert users: %w", err)
+	}
+
+	// Строки, чей id не вернулся из INSERT, конфликтовали по id или email.
+	// Повтор одного id в файле засчитывается только первой строке.
+	rows, err = tx.Query(ctx, `SELECT row_num, id::text, email FROM users_import ORDER BY row_num`)
+	if err != nil {
+		return fmt.Errorf("failed to read import table: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rowNum    int
+			id, email string
+		)
+		if err := rows.Scan(&rowNum, &id, &email); err != nil {
+			return fmt.Errorf("failed to scan import row: %w", err)
+		}
+		if inserted[id] {
+			result.Inserted++
+			delete(inserted, id)
+			continue
+		}
+		result.AddError(rowNum, fmt.Errorf("user %q or id %s already exists", email, id))
+	}
+	return rows.Err()
+}
+
+func (s *Storage) importUpsert(ctx context.Context, tx pgx.Tx, result *bulk.Result) error {
+	// ON CONFLICT DO UPDATE не может изменить одну строку дважды,
+	// поэтому из повторяющихся email в файле берется последний
+	qDup := `
+        SELECT row_num, email
+        FROM users_import i
+        WHERE EXISTS (
+            SELECT 1 FROM users_import j WHERE j.email = i.email AND j.row_num > i.row_num
+        )
+    `
+	rows, err := tx.Query(ctx, qDup)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate emails: %w", err)
+	}
+	for rows.Next() {
+		var (
+			rowNum int
+			email  string
+		)
+		if err := rows.Scan(&rowNum, &email); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan import row: %w", err)
+		}
+		result.AddError(rowNum, fmt.Errorf("user %q is superseded by a later row", email))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to find duplicate emails: %w", err)
+	}
+
+	q := `
+        INSERT INTO users (id, name, email, date_of_birth, gender, created_at, updated_at)
+        SELECT DISTINCT ON (email) id, name, email, date_of_birth, gender, NOW(), NOW()
+        FROM users_import
+        ORDER BY email, row_num DESC
+        ON CONFLICT ON CONSTRAINT users_tenant_email_key DO UPDATE
+        SET
+            name = EXCLUDED.name,
+            date_of_birth = EXCLUDED.date_of_birth,
+            gender = EXCLUDED.gender,
+            updated_at = NOW()
+        RETURNING (xmax = 0) AS inserted
+    `
+	rows, err = tx.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to upsert users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return fmt.Errorf("failed to scan upsert result: %w", err)
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to upsert users: %w", err)
+	}
+	return nil
+}
+
+// Export пишет всех пользователей в w. CSV отдается напрямую из COPY TO STDOUT,
+// JSON и NDJSON кодируются построчно по мере чтения курсора.
+func (s *Storage) Export(ctx context.Context, format bulk.Format, w io.Writer) error {
+	if format == bulk.FormatCSV {
+		conn, err := s.client.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to acquire connection: %w", err)
+		}
+		defer conn.Release()
+
+		q := `
+            COPY (
+                SELECT id, name, email, date_of_birth, gender, created_at, updated_at
+                FROM users
+                ORDER BY email
+            ) TO STDOUT WITH (FORMAT csv, HEADER true)
+        `
+		if _, err := conn.Conn().PgConn().CopyTo(ctx, w, q); err != nil {
+			return fmt.Errorf("failed to export users: %w", err)
+		}
+		return nil
+	}
+
+	q := `SELECT id, name, email, date_of_birth, gender, created_at, updated_at
+        FROM users
+        ORDER BY email`
+	rows, err := s.client.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+	defer rows.Close()
+
+	out := bulk.NewWriter(format, w)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Email,
+			&user.DateOfBirth,
+			&user.Gender,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan user: %w", err)
+		}
+		if err := out.Write(user); err != nil {
+			return fmt.Errorf("failed to write user: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+	return out.Close()
 }
@@ -0,0 +1,70 @@
+package user_test
+
+import (
+	"github.com/gofrs/uuid"
+	"os"
+	"rest-api-tutorial/internal/pgtest"
+	"rest-api-tutorial/internal/user"
+	"rest-api-tutorial/pkg/logging"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(pgtest.Main(m, pgtest.Options{}))
+}
+
+func TestStoragePatchKeepsAddedAt(t *testing.T) {
+	pool := pgtest.DB(t)
+	fixtures := pgtest.NewFixtures(t, pool)
+	storage := user.NewUserStorage(pool, logging.GetLogger())
+	ctx := pgtest.Context()
+
+	kept, removed, added := fixtures.Film(), fixtures.Film(), fixtures.Film()
+	u := fixtures.User()
+	fixtures.Link(u.ID, kept.ID)
+	fixtures.Link(u.ID, removed.ID)
+
+	addedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := pool.Exec(ctx, `UPDATE user_film SET added_at = $2 WHERE user_id = $1`, u.ID, addedAt); err != nil {
+		t.Fatalf("set added_at: %v", err)
+	}
+
+	err := storage.Patch(ctx, u.ID, func(u *user.User) error {
+		u.FilmUUID = []uuid.UUID{uuid.FromStringOrNil(added.ID), uuid.FromStringOrNil(kept.ID)}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+
+	rows, err := pool.Query(ctx, `SELECT film_id::text, added_at FROM user_film WHERE user_id = $1`, u.ID)
+	if err != nil {
+		t.Fatalf("query user_film: %v", err)
+	}
+	defer rows.Close()
+	links := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			filmID string
+			at     time.Time
+		)
+		if err := rows.Scan(&filmID, &at); err != nil {
+			t.Fatalf("scan user_film: %v", err)
+		}
+		links[filmID] = at
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("read user_film: %v", err)
+	}
+
+	if len(links) != 2 {
+		t.Fatalf("links after Patch = %v, want %s and %s", links, kept.ID, added.ID)
+	}
+	if at, ok := links[kept.ID]; !ok || !at.Equal(addedAt) {
+		t.Errorf("added_at of a kept film = %v, want %v", at, addedAt)
+	}
+	if at, ok := links[added.ID]; !ok || !at.After(addedAt) {
+		t.Errorf("added_at of a new film = %v, want the time of the patch", at)
+	}
+}
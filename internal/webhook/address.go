@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// reservedNets диапазоны, которые не покрывают методы net.IP: общий адрес NAT
+// провайдера, служебные сети IETF и бенчмарков, зарезервированные и широковещательные
+var reservedNets = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",
+		"100.64.0.0/10",
+		"192.0.0.0/24",
+		"198.18.0.0/15",
+		"240.0.0.0/4",
+		"64:ff9b::/96",
+	}
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}()
+
+// publicIP сообщает, что адрес можно вызывать из диспетчера: не loopback, не частная
+// сеть, не link-local (в том числе метаданные облака 169.254.169.254), не multicast
+// и не зарезервированный диапазон
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkHost разрешает имя хоста и отклоняет его, если хотя бы один адрес не публичный
+func checkHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("url host %q cannot be resolved", host)
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("url host %q resolves to a private or reserved address", host)
+		}
+	}
+	return nil
+}
+
+// publicDialer соединяется только с публичными адресами. Проверка выполняется
+// после разрешения имени, перед connect, поэтому ее не обходит DNS, который
+// при доставке отвечает иначе, чем при регистрации подписки.
+func publicDialer() *net.Dialer {
+	return &net.Dialer{
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("address %s is not public", host)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		// Метаданные облака
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		// Общий адрес NAT провайдера
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"198.18.0.1", false},
+		{"255.255.255.255", false},
+		// IPv4 внутри IPv6 проверяется как IPv4
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		ip := net.ParseIP(tt.ip)
+		if ip == nil {
+			t.Fatalf("invalid test address %q", tt.ip)
+		}
+		if got := publicIP(ip); got != tt.want {
+			t.Errorf("publicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestPublicDialerRefusesPrivateAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	conn, err := publicDialer().Dial("tcp", listener.Addr().String())
+	if err == nil {
+		conn.Close()
+		t.Fatal("dial to a loopback address succeeded")
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"film.created"}`)
+	timestamp := time.Unix(1700000000, 0)
+	// Подпись получателя: HMAC-SHA256 от "42.1700000000.<тело>" на секрете подписки
+	want := "v1=c1a111653eb3e365923d1189c4d594db3c4bd14958d4db4fb067a484309d9157"
+
+	tests := []struct {
+		name      string
+		secret    string
+		id        int64
+		timestamp time.Time
+		body      []byte
+		same      bool
+	}{
+		{"same input", "whsec_test", 42, timestamp, body, true},
+		{"other secret", "whsec_other", 42, timestamp, body, false},
+		{"other event", "whsec_test", 43, timestamp, body, false},
+		{"replayed later", "whsec_test", 42, timestamp.Add(time.Second), body, false},
+		{"modified body", "whsec_test", 42, timestamp, []byte(`{"event":"film.deleted"}`), false},
+	}
+	for _, tt := range tests {
+		got := Sign(tt.secret, tt.id, tt.timestamp, tt.body)
+		if (got == want) != tt.same {
+			t.Errorf("%s: Sign = %s, equal to %s: %v, want %v", tt.name, got, want, got == want, tt.same)
+		}
+	}
+}
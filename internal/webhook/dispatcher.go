@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"rest-api-tutorial/internal/tenant"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dispatchBatch = 100
+	deliveryBatch = 20
+	pruneInterval = time.Hour
+	// maxResponseBytes сколько байт ответа получателя читается ради переиспользования соединения
+	maxResponseBytes = 64 << 10
+	// maxErrorLength сколько символов ошибки сохраняется в last_error
+	maxErrorLength = 512
+)
+
+// DispatcherOptions настройки доставки
+type DispatcherOptions struct {
+	// PollInterval как часто проверяются новые сообщения outbox и доставки с наступившим сроком
+	PollInterval time.Duration
+	// Timeout ожидания ответа получателя
+	Timeout time.Duration
+	// MaxAttempts после стольких неудачных попыток доставка переходит в dead
+	MaxAttempts int
+	// Backoff задержка перед второй попыткой; каждая следующая вдвое дольше, но не больше MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retention сколько хранятся разосланные сообщения outbox и их доставки
+	Retention time.Duration
+}
+
+// Validate проверяет, что интервалы положительны и разрешена хотя бы одна попытка
+func (o DispatcherOptions) Validate() error {
+	if o.PollInterval <= 0 || o.Timeout <= 0 || o.Backoff <= 0 || o.MaxBackoff < o.Backoff || o.Retention <= 0 {
+		return fmt.Errorf("webhook intervals must be positive and max backoff must not be less than backoff")
+	}
+	if o.MaxAttempts < 1 {
+		return fmt.Errorf("webhook max attempts must be at least 1, got %d", o.MaxAttempts)
+	}
+	return nil
+}
+
+// Dispatcher раскладывает сообщения outbox по подпискам и доставляет их.
+// Реплики работают параллельно: сообщения и доставки захватываются через SKIP LOCKED.
+type Dispatcher struct {
+	storage *Storage
+	client  *http.Client
+	opts    DispatcherOptions
+	logger  *logging.Logger
+}
+
+func NewDispatcher(storage *Storage, opts DispatcherOptions, logger *logging.Logger) *Dispatcher {
+	return &Dispatcher{
+		storage: storage,
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			// Соединения только с публичными адресами и без прокси из окружения
+			Transport: &http.Transport{
+				DialContext:         publicDialer().DialContext,
+				TLSHandshakeTimeout: opts.Timeout,
+				MaxIdleConnsPerHost: 4,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// Редирект считается неудачной попыткой: подпись выдана для исходного URL
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Run обрабатывает outbox до отмены ctx. Диспетчер обслуживает всех арендаторов,
+// поэтому работает в системном контексте.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ctx = tenant.SystemContext(ctx)
+	poll := time.NewTicker(d.opts.PollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-prune.C:
+			deleted, err := d.storage.Prune(ctx, d.opts.Retention)
+			if err != nil {
+				d.logger.Warnf("Failed to prune webhook outbox: %v", err)
+			} else if deleted > 0 {
+				d.logger.Infof("Pruned %d webhook outbox messages older than %s", deleted, d.opts.Retention)
+			}
+		case <-poll.C:
+			if err := d.tick(ctx); err != nil && ctx.Err() == nil {
+				d.logger.Errorf("Webhook dispatcher: %v", err)
+			}
+		}
+	}
+}
+
+// tick раскладывает все новые сообщения и выполняет все доставки с наступившим сроком
+func (d *Dispatcher) tick(ctx context.Context) error {
+	for {
+		n, err := d.storage.Dispatch(ctx, dispatchBatch)
+		if err != nil {
+			return err
+		}
+		if n < dispatchBatch {
+			break
+		}
+	}
+
+	for {
+		jobs, err := d.storage.claim(ctx, deliveryBatch, d.opts.Timeout+d.opts.PollInterval)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, j := range jobs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, j)
+			}()
+		}
+		wg.Wait()
+
+		if len(jobs) < deliveryBatch {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	statusCode, err := d.send(ctx, j)
+	if err == nil {
+		if err := d.storage.markSucceeded(ctx, j.id, statusCode); err != nil {
+			d.logger.Errorf("Webhook delivery %d: %v", j.id, err)
+		}
+		return
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	retryIn := d.backoff(j.attempts)
+	if retryIn == 0 {
+		d.logger.Warnf("Webhook delivery %d to %s failed permanently after %d attempts: %v", j.id, j.url, j.attempts, err)
+	}
+	if err := d.storage.markFailed(ctx, j.id, code, truncate(err.Error(), maxErrorLength), retryIn); err != nil {
+		d.logger.Errorf("Webhook delivery %d: %v", j.id, err)
+	}
+}
+
+// send отправляет событие и возвращает код ответа; ответ не 2xx считается ошибкой
+func (d *Dispatcher) send(ctx context.Context, j job) (int, error) {
+	body, err := json.Marshal(j.event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "movies-webhooks/1.0")
+	req.Header.Set(HeaderID, strconv.FormatInt(j.event.ID, 10))
+	req.Header.Set(HeaderEvent, j.event.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(j.secret, j.event.ID, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Тело ответа не сохраняется: last_error доступен через API доставок
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff возвращает задержку до следующей попытки после attempts неудачных
+// или 0, если попытки исчерпаны
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	if attempts >= d.opts.MaxAttempts {
+		return 0
+	}
+	delay := d.opts.Backoff
+	for i := 1; i < attempts && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.opts.MaxBackoff)
+}
+
+// truncate обрезает s до n байт; недопустимые в UTF-8 байты удаляются,
+// чтобы строку можно было сохранить в text
+func truncate(s string, n int) string {
+	if len(s) > n {
+		s = s[:n]
+	}
+	return strings.ToValidUTF8(s, "")
+}
@@ -0,0 +1,349 @@
+package webhook
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"net/http"
+	"rest-api-tutorial/pkg/envelope"
+	"rest-api-tutorial/pkg/logging"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+type Handler struct {
+	logger  *logging.Logger
+	storage *Storage
+}
+
+func NewHandler(storage *Storage, logger *logging.Logger) *Handler {
+	return &Handler{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Subscribe a URL to film and user events. Every event is sent as a POST with the Event JSON body and the headers Webhook-Id (event id, stable across retries), Webhook-Event, Webhook-Timestamp and Webhook-Signature: "v1=" followed by the hex HMAC-SHA256 of "<Webhook-Id>.<Webhook-Timestamp>.<body>" keyed with the secret. The secret is returned only in this response. A delivery succeeds on any 2xx response; otherwise it is retried with exponential backoff and marked dead after WEBHOOK_MAX_ATTEMPTS attempts. The URL host must resolve to public addresses: loopback, private, link-local and other reserved targets are rejected.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param webhook body CreateWebhook true "Target URL and event types"
+// @Success 201 {object} envelope.Resource[webhook.IssuedWebhook] "Registered webhook with secret"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body, URL or event type"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var input CreateWebhook
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := validateURL(c.Request.Context(), input.URL); err != nil {
+		envelope.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateEvents(input.Events); err != nil {
+		envelope.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to generate ID")
+		return
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to generate webhook secret")
+		return
+	}
+
+	issued := IssuedWebhook{
+		Webhook: Webhook{
+			ID:        id.String(),
+			URL:       input.URL,
+			Events:    input.Events,
+			Active:    input.Active == nil || *input.Active,
+			CreatedAt: time.Now(),
+		},
+		Secret: secret,
+	}
+	if issued.Events == nil {
+		issued.Events = make([]string, 0)
+	}
+	issued.UpdatedAt = issued.CreatedAt
+
+	if err := h.storage.Create(c.Request.Context(), issued.Webhook, secret); err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+	envelope.Data(c, http.StatusCreated, issued)
+}
+
+// GetList godoc
+// @Summary List webhooks
+// @Description Retrieve all webhook subscriptions without secrets
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} envelope.Collection[webhook.Webhook] "List of webhooks"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks [get]
+func (h *Handler) GetList(c *gin.Context) {
+	list, err := h.storage.FindAll(c.Request.Context())
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Description Retrieve a webhook subscription by ID without its secret
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID (UUID)"
+// @Success 200 {object} envelope.Resource[webhook.Webhook] "Webhook"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Webhook not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [get]
+func (h *Handler) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	webhook, err := h.storage.FindByID(c.Request.Context(), id)
+	if err != nil {
+		h.storageError(c, err, "Failed to fetch webhook")
+		return
+	}
+	envelope.Data(c, http.StatusOK, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Change the URL, event types or active flag of a webhook. Omitted fields keep their values. Deactivated webhooks receive no new events; their pending deliveries wait until reactivation.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID (UUID)"
+// @Param webhook body UpdateWebhook true "Fields to change"
+// @Success 200 {object} envelope.Resource[webhook.Webhook] "Updated webhook"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body, URL or event type"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Webhook not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [patch]
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	var input UpdateWebhook
+	if err := c.ShouldBindJSON(&input); err != nil {
+		envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.URL != nil {
+		if err := validateURL(c.Request.Context(), *input.URL); err != nil {
+			envelope.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if input.Events != nil {
+		if err := validateEvents(*input.Events); err != nil {
+			envelope.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	webhook, err := h.storage.Update(c.Request.Context(), id, input)
+	if err != nil {
+		h.storageError(c, err, "Failed to update webhook")
+		return
+	}
+	envelope.Data(c, http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook subscription together with its delivery history
+// @Tags webhooks
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID (UUID)"
+// @Success 204 "Webhook deleted"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Webhook not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	if err := h.storage.Delete(c.Request.Context(), id); err != nil {
+		h.storageError(c, err, "Failed to delete webhook")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Retrieve the most recent deliveries of a webhook, newest first
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID (UUID)"
+// @Param status query string false "Delivery status" Enums(pending, succeeded, dead)
+// @Param limit query int false "Maximum number of deliveries" default(50) maximum(500)
+// @Success 200 {object} envelope.Collection[webhook.Delivery] "List of deliveries"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid status or limit"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Webhook not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", StatusPending, StatusSucceeded, StatusDead:
+	default:
+		envelope.Error(c, http.StatusBadRequest, "Invalid status, expected pending, succeeded or dead")
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxDeliveriesLimit {
+			envelope.Error(c, http.StatusBadRequest, "Invalid limit, expected 1 to 500")
+			return
+		}
+		limit = n
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.storage.FindByID(ctx, id); err != nil {
+		h.storageError(c, err, "Failed to fetch webhook")
+		return
+	}
+	list, err := h.storage.FindDeliveries(ctx, id, status, limit)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to fetch webhook deliveries")
+		return
+	}
+	envelope.List(c, http.StatusOK, list)
+}
+
+// ReplayDelivery godoc
+// @Summary Replay a webhook delivery
+// @Description Queue one delivery again with a fresh attempt counter, whatever its current status. The receiver gets the same Webhook-Id as before.
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID (UUID)"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} envelope.Resource[webhook.Delivery] "Queued delivery"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Webhook or delivery not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/deliveries/{delivery_id}/replay [post]
+func (h *Handler) ReplayDelivery(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		envelope.Error(c, http.StatusNotFound, "Webhook delivery not found")
+		return
+	}
+
+	delivery, err := h.storage.ReplayDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			envelope.Error(c, http.StatusNotFound, "Webhook delivery not found")
+			return
+		}
+		envelope.Error(c, http.StatusInternalServerError, "Failed to replay webhook delivery")
+		return
+	}
+	envelope.Data(c, http.StatusAccepted, delivery)
+}
+
+// Replay godoc
+// @Summary Replay webhook deliveries
+// @Description Queue deliveries of a webhook again with fresh attempt counters. Without since only dead deliveries are replayed; with since every delivery of events created at or after since is replayed, including succeeded ones. Events are kept for WEBHOOK_RETENTION.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID (UUID)"
+// @Param replay body Replay false "Replay window"
+// @Success 202 {object} envelope.Resource[webhook.ReplayResult] "Number of queued deliveries"
+// @Failure 400 {object} envelope.ErrorResponse "Invalid request body"
+// @Failure 403 {object} envelope.ErrorResponse "Missing webhooks:admin scope"
+// @Failure 404 {object} envelope.ErrorResponse "Webhook not found"
+// @Failure 500 {object} envelope.ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/replay [post]
+func (h *Handler) Replay(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	var input Replay
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			envelope.Error(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.storage.FindByID(ctx, id); err != nil {
+		h.storageError(c, err, "Failed to fetch webhook")
+		return
+	}
+	n, err := h.storage.Replay(ctx, id, input.Since)
+	if err != nil {
+		envelope.Error(c, http.StatusInternalServerError, "Failed to replay webhook deliveries")
+		return
+	}
+	envelope.Data(c, http.StatusAccepted, ReplayResult{Replayed: n})
+}
+
+// webhookID проверяет параметр id; для некорректного UUID отвечает 404
+func webhookID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.FromString(id); err != nil {
+		envelope.Error(c, http.StatusNotFound, "Webhook not found")
+		return "", false
+	}
+	return id, true
+}
+
+func (h *Handler) storageError(c *gin.Context, err error, message string) {
+	if errors.Is(err, ErrNotFound) {
+		envelope.Error(c, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	h.logger.Errorf("%s: %v", message, err)
+	envelope.Error(c, http.StatusInternalServerError, message)
+}
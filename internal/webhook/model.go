@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"rest-api-tutorial/internal/events"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Состояния доставки
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// Заголовки запроса доставки
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// Webhook подписка партнера на события без секрета
+// @description URL, на который доставляются события фильмов и пользователей
+type Webhook struct {
+	// @format uuid
+	ID string `json:"id"`
+
+	URL string `json:"url" example:"https://partner.example.com/hooks/movies"`
+
+	// Типы событий (film.updated) или ресурсы (film); пустой список — все события
+	Events []string `json:"events" example:"film"`
+
+	// Неактивной подписке новые события не раскладываются и не доставляются
+	Active bool `json:"active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateWebhook модель запроса на создание подписки
+// @description URL получателя, типы событий и признак активности
+type CreateWebhook struct {
+	// @maxLength 2048
+	URL string `json:"url" binding:"required,url,max=2048" example:"https://partner.example.com/hooks/movies"`
+
+	Events []string `json:"events" example:"film.created,film.updated"`
+
+	// По умолчанию true
+	Active *bool `json:"active"`
+}
+
+// UpdateWebhook модель запроса на частичное изменение подписки
+// @description Переданные поля заменяют текущие значения
+type UpdateWebhook struct {
+	// @maxLength 2048
+	URL *string `json:"url" binding:"omitempty,url,max=2048"`
+
+	Events *[]string `json:"events"`
+
+	Active *bool `json:"active"`
+}
+
+// IssuedWebhook подписка вместе с секретом подписи. Секрет возвращается только при создании.
+type IssuedWebhook struct {
+	Webhook
+	Secret string `json:"secret" example:"whsec_3q2-7wAAAAA"`
+}
+
+// Delivery доставка одного события одной подписке
+// @description Состояние доставки: pending — ждет попытки, succeeded — получатель ответил 2xx, dead — попытки исчерпаны
+type Delivery struct {
+	ID int64 `json:"id"`
+
+	// @format uuid
+	WebhookID string `json:"webhook_id"`
+
+	// Id события, он же заголовок Webhook-Id; не меняется при повторах
+	EventID int64 `json:"event_id"`
+
+	EventType string `json:"event_type"`
+
+	Status string `json:"status" enums:"pending,succeeded,dead"`
+
+	Attempts int `json:"attempts"`
+
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Replay модель запроса на повторную доставку
+// @description Без since повторяются доставки в состоянии dead, с since — все доставки событий начиная с этого момента
+type Replay struct {
+	Since *time.Time `json:"since"`
+}
+
+// ReplayResult количество доставок, поставленных в очередь повторно
+type ReplayResult struct {
+	Replayed int64 `json:"replayed"`
+}
+
+// job доставка, захваченная диспетчером, вместе с адресом, секретом и событием
+type job struct {
+	id       int64
+	attempts int
+	url      string
+	secret   string
+	event    events.Event
+}
+
+// validateURL допускает только абсолютные http и https адреса, хост которых
+// разрешается в публичные адреса: подписка не должна вызывать внутренние сервисы
+func validateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return checkHost(ctx, u.Hostname())
+}
+
+// validateEvents проверяет, что все элементы списка — известные ресурсы или типы событий
+func validateEvents(list []string) error {
+	for _, item := range list {
+		if !events.ValidSelector(item) {
+			return fmt.Errorf("unknown event type %q, expected one of: film, user, %s", item, strings.Join(events.Types, ", "))
+		}
+	}
+	return nil
+}
+
+// generateSecret выпускает секрет подписи вида "whsec_<base64url>"
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign возвращает значение заголовка Webhook-Signature: "v1=" и HMAC-SHA256
+// в hex от строки "<Webhook-Id>.<Webhook-Timestamp>.<тело запроса>" на секрете подписки.
+// Получатель вычисляет ту же подпись и сравнивает ее за постоянное время.
+func Sign(secret string, id int64, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(id, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,355 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+var (
+	// ErrNotFound подписка не существует
+	ErrNotFound = errors.New("webhook not found")
+	// ErrDeliveryNotFound доставка не существует или принадлежит другой подписке
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+const webhookColumns = `id, url, events, active, created_at, updated_at`
+
+const deliveryColumns = `d.id, d.webhook_id, d.outbox_id, o.type, d.status, d.attempts,
+            CASE WHEN d.status = 'pending' THEN d.next_attempt_at END,
+            d.last_status_code, d.last_error, d.delivered_at, d.created_at, d.updated_at`
+
+type Storage struct {
+	client *pgxpool.Pool
+	logger *logging.Logger
+}
+
+func NewStorage(pool *pgxpool.Pool, logger *logging.Logger) *Storage {
+	return &Storage{
+		client: pool,
+		logger: logger,
+	}
+}
+
+func (s *Storage) Create(ctx context.Context, webhook Webhook, secret string) error {
+	q := `
+        INSERT INTO webhooks (id, url, secret, events, active, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+	_, err := s.client.Exec(
+		ctx,
+		q,
+		webhook.ID,
+		webhook.URL,
+		secret,
+		webhook.Events,
+		webhook.Active,
+		webhook.CreatedAt,
+		webhook.UpdatedAt,
+	)
+	if err != nil {
+		s.logger.Errorf("Failed to create webhook: %v", err)
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) FindAll(ctx context.Context) ([]Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY created_at`
+	rows, err := s.client.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *webhook)
+	}
+	return list, rows.Err()
+}
+
+func (s *Storage) FindByID(ctx context.Context, id string) (*Webhook, error) {
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	return scanWebhook(s.client.QueryRow(ctx, q, id))
+}
+
+// Update сохраняет переданные поля подписки и возвращает результат
+func (s *Storage) Update(ctx context.Context, id string, input UpdateWebhook) (*Webhook, error) {
+	q := `
+        UPDATE webhooks
+        SET
+            url = COALESCE($2, url),
+            events = COALESCE($3, events),
+            active = COALESCE($4, active),
+            updated_at = NOW()
+        WHERE id = $1
+        RETURNING ` + webhookColumns
+	return scanWebhook(s.client.QueryRow(ctx, q, id, input.URL, input.Events, input.Active))
+}
+
+// Delete удаляет подписку вместе с историей ее доставок
+func (s *Storage) Delete(ctx context.Context, id string) error {
+	tag, err := s.client.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		s.logger.Errorf("Failed to delete webhook: %v", err)
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindDeliveries возвращает последние limit доставок подписки, новые первыми.
+// Пустой status означает все состояния.
+func (s *Storage) FindDeliveries(ctx context.Context, webhookID, status string, limit int) ([]Delivery, error) {
+	q := `
+        SELECT ` + deliveryColumns + `
+        FROM webhook_deliveries d
+        JOIN outbox o ON o.id = d.outbox_id
+        WHERE d.webhook_id = $1 AND ($2 = '' OR d.status = $2)
+        ORDER BY d.id DESC
+        LIMIT $3
+    `
+	rows, err := s.client.Query(ctx, q, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]Delivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *delivery)
+	}
+	return list, rows.Err()
+}
+
+// ReplayDelivery ставит одну доставку в очередь заново с обнуленным счетчиком попыток.
+// Подзапрос к webhooks проходит через политики арендатора: доставки чужой подписки не меняются.
+func (s *Storage) ReplayDelivery(ctx context.Context, webhookID string, deliveryID int64) (*Delivery, error) {
+	q := `
+        WITH replayed AS (
+            UPDATE webhook_deliveries
+            SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+            WHERE id = $2 AND webhook_id = (SELECT id FROM webhooks WHERE id = $1)
+            RETURNING *
+        )
+        SELECT ` + deliveryColumns + `
+        FROM replayed d
+        JOIN outbox o ON o.id = d.outbox_id
+    `
+	delivery, err := scanDelivery(s.client.QueryRow(ctx, q, webhookID, deliveryID))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery, err
+}
+
+// Replay ставит в очередь заново доставки подписки: с since — все доставки событий
+// начиная с since, без него — только исчерпавшие попытки
+func (s *Storage) Replay(ctx context.Context, webhookID string, since *time.Time) (int64, error) {
+	q := `
+        UPDATE webhook_deliveries d
+        SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+        FROM outbox o, webhooks w
+        WHERE o.id = d.outbox_id
+          AND w.id = d.webhook_id
+          AND d.webhook_id = $1
+          AND CASE WHEN $2::timestamptz IS NULL THEN d.status = 'dead' ELSE o.created_at >= $2 END
+    `
+	tag, err := s.client.Exec(ctx, q, webhookID, since)
+	if err != nil {
+		s.logger.Errorf("Failed to replay webhook deliveries: %v", err)
+		return 0, fmt.Errorf("failed to replay webhook deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// Dispatch раскладывает до limit новых сообщений outbox по активным подпискам того же
+// арендатора и отмечает их разосланными. Несколько реплик не обработают одно сообщение
+// дважды. Вызывается в системном контексте (tenant.SystemContext), чтобы видеть подписки
+// всех арендаторов.
+func (s *Storage) Dispatch(ctx context.Context, limit int) (int64, error) {
+	q := `
+        WITH pending AS (
+            SELECT id, type, tenant_id
+            FROM outbox
+            WHERE dispatched_at IS NULL
+            ORDER BY id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        ), fanout AS (
+            INSERT INTO webhook_deliveries (webhook_id, outbox_id)
+            SELECT w.id, p.id
+            FROM pending p
+            JOIN webhooks w ON w.active AND w.tenant_id = p.tenant_id AND (
+                cardinality(w.events) = 0
+                OR p.type = ANY(w.events)
+                OR split_part(p.type, '.', 1) = ANY(w.events)
+            )
+            ON CONFLICT DO NOTHING
+        )
+        UPDATE outbox o
+        SET dispatched_at = NOW()
+        FROM pending p
+        WHERE o.id = p.id
+    `
+	tag, err := s.client.Exec(ctx, q, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to dispatch outbox: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// claim захватывает до limit доставок, срок которых наступил, и засчитывает попытку.
+// На время lease доставка скрыта от других реплик; если процесс упадет,
+// она вернется в очередь по истечении lease.
+func (s *Storage) claim(ctx context.Context, limit int, lease time.Duration) ([]job, error) {
+	q := `
+        WITH due AS (
+            SELECT d.id
+            FROM webhook_deliveries d
+            JOIN webhooks w ON w.id = d.webhook_id
+            WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+            ORDER BY d.next_attempt_at
+            LIMIT $1
+            FOR UPDATE OF d SKIP LOCKED
+        ), claimed AS (
+            UPDATE webhook_deliveries d
+            SET attempts = d.attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2), updated_at = NOW()
+            FROM due
+            WHERE d.id = due.id
+            RETURNING d.id, d.attempts, d.webhook_id, d.outbox_id
+        )
+        SELECT c.id, c.attempts, w.url, w.secret, o.id, o.type, o.resource_id, o.payload, o.created_at
+        FROM claimed c
+        JOIN webhooks w ON w.id = c.webhook_id
+        JOIN outbox o ON o.id = c.outbox_id
+    `
+	rows, err := s.client.Query(ctx, q, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []job
+	for rows.Next() {
+		var j job
+		if err := rows.Scan(
+			&j.id,
+			&j.attempts,
+			&j.url,
+			&j.secret,
+			&j.event.ID,
+			&j.event.Type,
+			&j.event.ResourceID,
+			&j.event.Data,
+			&j.event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *Storage) markSucceeded(ctx context.Context, id int64, statusCode int) error {
+	q := `
+        UPDATE webhook_deliveries
+        SET status = 'succeeded', last_status_code = $2, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+        WHERE id = $1
+    `
+	if _, err := s.client.Exec(ctx, q, id, statusCode); err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// markFailed записывает неудачную попытку: следующая попытка через retryIn
+// или состояние dead, если retryIn равен нулю
+func (s *Storage) markFailed(ctx context.Context, id int64, statusCode *int, message string, retryIn time.Duration) error {
+	q := `
+        UPDATE webhook_deliveries
+        SET
+            status = CASE WHEN $4::float8 > 0 THEN 'pending' ELSE 'dead' END,
+            next_attempt_at = NOW() + make_interval(secs => $4),
+            last_status_code = $2,
+            last_error = $3,
+            updated_at = NOW()
+        WHERE id = $1
+    `
+	if _, err := s.client.Exec(ctx, q, id, statusCode, message, retryIn.Seconds()); err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// Prune удаляет разосланные сообщения outbox старше retention вместе с их доставками
+func (s *Storage) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	q := `
+        DELETE FROM outbox
+        WHERE dispatched_at IS NOT NULL AND created_at < NOW() - make_interval(secs => $1)
+    `
+	tag, err := s.client.Exec(ctx, q, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanWebhook(row pgx.Row) (*Webhook, error) {
+	var webhook Webhook
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Events,
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+func scanDelivery(row pgx.Row) (*Delivery, error) {
+	var delivery Delivery
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,18 @@
+--
+-- Ключи идемпотентности для POST-запросов
+--
+
+CREATE TABLE IF NOT EXISTS public.idempotency_keys (
+    scope character varying(255) NOT NULL,
+    key character varying(255) NOT NULL,
+    fingerprint character(64) NOT NULL,
+    status_code integer,
+    response_header jsonb,
+    response_body bytea,
+    locked_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    CONSTRAINT idempotency_keys_pkey PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON public.idempotency_keys (expires_at);
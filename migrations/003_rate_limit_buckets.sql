@@ -0,0 +1,13 @@
+--
+-- Корзины токенов для общего между репликами rate limiting
+--
+
+CREATE UNLOGGED TABLE IF NOT EXISTS public.rate_limit_buckets (
+    key character varying(512) NOT NULL,
+    tokens double precision NOT NULL,
+    allowed boolean NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT rate_limit_buckets_pkey PRIMARY KEY (key)
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_updated_at_idx ON public.rate_limit_buckets (updated_at);
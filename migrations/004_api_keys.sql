@@ -0,0 +1,18 @@
+--
+-- API-ключи сервисных клиентов. Хранится только SHA-256 секретной части ключа.
+--
+
+CREATE TABLE IF NOT EXISTS public.api_keys (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    name character varying(255) NOT NULL,
+    prefix character varying(32) NOT NULL,
+    key_hash character(64) NOT NULL,
+    scopes text[] DEFAULT '{}'::text[] NOT NULL,
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT api_keys_pkey PRIMARY KEY (id),
+    CONSTRAINT api_keys_prefix_key UNIQUE (prefix)
+);
@@ -0,0 +1,56 @@
+--
+-- Журнал изменений фильмов и пользователей для потока SSE /api/events.
+-- События пишут триггеры, поэтому в журнал попадает любое изменение (REST, gRPC,
+-- импорт, batch). После фиксации транзакции pg_notify сообщает id события всем
+-- репликам, подписанным на канал catalog_events.
+--
+
+CREATE TABLE IF NOT EXISTS public.catalog_events (
+    id bigserial NOT NULL,
+    type character varying(32) NOT NULL,
+    resource_id uuid NOT NULL,
+    data jsonb,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT catalog_events_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS catalog_events_created_at_idx ON public.catalog_events (created_at);
+
+CREATE OR REPLACE FUNCTION public.catalog_event() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    resource text := TG_ARGV[0];
+    resource_id uuid;
+    action text;
+    payload jsonb;
+    event_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        action := 'deleted';
+        payload := NULL;
+        resource_id := (to_jsonb(OLD) ->> TG_ARGV[1])::uuid;
+    ELSE
+        action := CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END;
+        payload := to_jsonb(NEW);
+        resource_id := (payload ->> TG_ARGV[1])::uuid;
+    END IF;
+
+    INSERT INTO public.catalog_events (type, resource_id, data)
+    VALUES (resource || '.' || action, resource_id, payload)
+    RETURNING id INTO event_id;
+
+    PERFORM pg_notify('catalog_events', event_id::text);
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS films_catalog_event ON public.films;
+CREATE TRIGGER films_catalog_event
+    AFTER INSERT OR UPDATE OR DELETE ON public.films
+    FOR EACH ROW EXECUTE FUNCTION public.catalog_event('film', 'film_id');
+
+DROP TRIGGER IF EXISTS users_catalog_event ON public.users;
+CREATE TRIGGER users_catalog_event
+    AFTER INSERT OR UPDATE OR DELETE ON public.users
+    FOR EACH ROW EXECUTE FUNCTION public.catalog_event('user', 'id');
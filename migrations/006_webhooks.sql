@@ -0,0 +1,86 @@
+--
+-- Webhook-подписки партнеров и transactional outbox для их доставки.
+-- Строку outbox пишет триггер catalog_event в той же транзакции, что и изменение
+-- фильма или пользователя, поэтому сообщение не теряется и не появляется для
+-- откатившихся изменений. Диспетчер раскладывает сообщения по подпискам
+-- в webhook_deliveries и доставляет их с повторами.
+--
+
+CREATE TABLE IF NOT EXISTS public.webhooks (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    url character varying(2048) NOT NULL,
+    secret character varying(255) NOT NULL,
+    events text[] DEFAULT '{}'::text[] NOT NULL,
+    active boolean DEFAULT true NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT webhooks_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS public.outbox (
+    id bigserial NOT NULL,
+    type character varying(32) NOT NULL,
+    resource_id uuid NOT NULL,
+    payload jsonb,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    dispatched_at timestamp with time zone,
+    CONSTRAINT outbox_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON public.outbox (id) WHERE dispatched_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_created_at_idx ON public.outbox (created_at);
+
+CREATE TABLE IF NOT EXISTS public.webhook_deliveries (
+    id bigserial NOT NULL,
+    webhook_id uuid NOT NULL,
+    outbox_id bigint NOT NULL,
+    status character varying(16) DEFAULT 'pending' NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    next_attempt_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    last_status_code integer,
+    last_error text,
+    delivered_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT webhook_deliveries_webhook_outbox_key UNIQUE (webhook_id, outbox_id),
+    CONSTRAINT webhook_deliveries_status_check CHECK (status IN ('pending', 'succeeded', 'dead')),
+    CONSTRAINT webhook_deliveries_webhook_id_fkey FOREIGN KEY (webhook_id) REFERENCES public.webhooks(id) ON DELETE CASCADE,
+    CONSTRAINT webhook_deliveries_outbox_id_fkey FOREIGN KEY (outbox_id) REFERENCES public.outbox(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON public.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON public.webhook_deliveries (webhook_id, id);
+
+-- Триггер журнала событий дополнительно пишет сообщение в outbox
+CREATE OR REPLACE FUNCTION public.catalog_event() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    resource text := TG_ARGV[0];
+    resource_id uuid;
+    action text;
+    payload jsonb;
+    event_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        action := 'deleted';
+        payload := NULL;
+        resource_id := (to_jsonb(OLD) ->> TG_ARGV[1])::uuid;
+    ELSE
+        action := CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END;
+        payload := to_jsonb(NEW);
+        resource_id := (payload ->> TG_ARGV[1])::uuid;
+    END IF;
+
+    INSERT INTO public.catalog_events (type, resource_id, data)
+    VALUES (resource || '.' || action, resource_id, payload)
+    RETURNING id INTO event_id;
+
+    INSERT INTO public.outbox (type, resource_id, payload)
+    VALUES (resource || '.' || action, resource_id, payload);
+
+    PERFORM pg_notify('catalog_events', event_id::text);
+    RETURN NULL;
+END;
+$$;
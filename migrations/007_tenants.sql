@@ -0,0 +1,157 @@
+--
+-- Несколько независимых каталогов (кинотеатров, клубов) в одной базе.
+-- Каждая строка users, films, user_film и webhooks принадлежит арендатору (tenant).
+-- Приложение задает арендатора запроса параметром сеанса app.tenant_id при выдаче
+-- соединения из пула, а политики row-level security показывают и разрешают менять
+-- только строки этого арендатора. FORCE ROW LEVEL SECURITY распространяет политики
+-- и на владельца таблиц; суперпользователь и роли с BYPASSRLS их не соблюдают,
+-- поэтому приложение должно подключаться обычной ролью.
+--
+
+CREATE TABLE IF NOT EXISTS public.tenants (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    slug character varying(63) NOT NULL,
+    name character varying(255) NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT tenants_pkey PRIMARY KEY (id),
+    CONSTRAINT tenants_slug_key UNIQUE (slug)
+);
+
+-- Арендатор по умолчанию получает все существующие данные и запросы, в которых
+-- арендатор не указан
+INSERT INTO public.tenants (id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default')
+ON CONFLICT DO NOTHING;
+
+-- current_tenant_id арендатор текущего сеанса или NULL. Значение '*' означает
+-- системный контекст фоновых задач: он видит строки всех арендаторов, но не
+-- может их создавать.
+CREATE OR REPLACE FUNCTION public.current_tenant_id() RETURNS uuid
+    LANGUAGE sql STABLE
+    AS $$
+    SELECT CASE
+        WHEN current_setting('app.tenant_id', true) ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$'
+        THEN current_setting('app.tenant_id', true)::uuid
+    END
+$$;
+
+CREATE OR REPLACE FUNCTION public.tenant_visible(tenant uuid) RETURNS boolean
+    LANGUAGE sql STABLE
+    AS $$
+    SELECT tenant = public.current_tenant_id() OR current_setting('app.tenant_id', true) = '*'
+$$;
+
+-- Колонка tenant_id заполняется из сеанса, поэтому запросы INSERT не меняются
+ALTER TABLE public.films ADD COLUMN IF NOT EXISTS tenant_id uuid;
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS tenant_id uuid;
+ALTER TABLE public.user_film ADD COLUMN IF NOT EXISTS tenant_id uuid;
+ALTER TABLE public.webhooks ADD COLUMN IF NOT EXISTS tenant_id uuid;
+
+UPDATE public.films SET tenant_id = '00000000-0000-0000-0000-000000000001' WHERE tenant_id IS NULL;
+UPDATE public.users SET tenant_id = '00000000-0000-0000-0000-000000000001' WHERE tenant_id IS NULL;
+UPDATE public.user_film SET tenant_id = '00000000-0000-0000-0000-000000000001' WHERE tenant_id IS NULL;
+UPDATE public.webhooks SET tenant_id = '00000000-0000-0000-0000-000000000001' WHERE tenant_id IS NULL;
+
+ALTER TABLE public.films
+    ALTER COLUMN tenant_id SET DEFAULT COALESCE(public.current_tenant_id(), '00000000-0000-0000-0000-000000000001'),
+    ALTER COLUMN tenant_id SET NOT NULL,
+    ADD CONSTRAINT films_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES public.tenants(id);
+ALTER TABLE public.users
+    ALTER COLUMN tenant_id SET DEFAULT COALESCE(public.current_tenant_id(), '00000000-0000-0000-0000-000000000001'),
+    ALTER COLUMN tenant_id SET NOT NULL,
+    ADD CONSTRAINT users_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES public.tenants(id);
+ALTER TABLE public.user_film
+    ALTER COLUMN tenant_id SET DEFAULT COALESCE(public.current_tenant_id(), '00000000-0000-0000-0000-000000000001'),
+    ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE public.webhooks
+    ALTER COLUMN tenant_id SET DEFAULT COALESCE(public.current_tenant_id(), '00000000-0000-0000-0000-000000000001'),
+    ALTER COLUMN tenant_id SET NOT NULL,
+    ADD CONSTRAINT webhooks_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES public.tenants(id);
+
+-- Названия фильмов и email пользователей уникальны в пределах арендатора
+ALTER TABLE public.films DROP CONSTRAINT IF EXISTS films_title_key;
+ALTER TABLE public.users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE public.films ADD CONSTRAINT films_tenant_title_key UNIQUE (tenant_id, title);
+ALTER TABLE public.users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenant_id, email);
+
+-- Проверка внешних ключей не соблюдает RLS, поэтому связь ссылается на фильм
+-- и пользователя вместе с арендатором и не может соединить разные каталоги
+ALTER TABLE public.films ADD CONSTRAINT films_tenant_film_key UNIQUE (tenant_id, film_id);
+ALTER TABLE public.users ADD CONSTRAINT users_tenant_id_key UNIQUE (tenant_id, id);
+ALTER TABLE public.user_film DROP CONSTRAINT IF EXISTS user_film_film_id_fkey;
+ALTER TABLE public.user_film DROP CONSTRAINT IF EXISTS user_film_user_id_fkey;
+ALTER TABLE public.user_film
+    ADD CONSTRAINT user_film_film_id_fkey FOREIGN KEY (tenant_id, film_id) REFERENCES public.films(tenant_id, film_id) ON DELETE CASCADE,
+    ADD CONSTRAINT user_film_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES public.users(tenant_id, id) ON DELETE CASCADE;
+
+ALTER TABLE public.films ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.films FORCE ROW LEVEL SECURITY;
+ALTER TABLE public.users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.users FORCE ROW LEVEL SECURITY;
+ALTER TABLE public.user_film ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.user_film FORCE ROW LEVEL SECURITY;
+ALTER TABLE public.webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.webhooks FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON public.films
+    USING (public.tenant_visible(tenant_id))
+    WITH CHECK (tenant_id = public.current_tenant_id());
+CREATE POLICY tenant_isolation ON public.users
+    USING (public.tenant_visible(tenant_id))
+    WITH CHECK (tenant_id = public.current_tenant_id());
+CREATE POLICY tenant_isolation ON public.user_film
+    USING (public.tenant_visible(tenant_id))
+    WITH CHECK (tenant_id = public.current_tenant_id());
+CREATE POLICY tenant_isolation ON public.webhooks
+    USING (public.tenant_visible(tenant_id))
+    WITH CHECK (tenant_id = public.current_tenant_id());
+
+-- Ключ, привязанный к арендатору, работает только с его каталогом;
+-- ключ без арендатора (платформенный) выбирает арендатора заголовком
+ALTER TABLE public.api_keys ADD COLUMN IF NOT EXISTS tenant_id uuid;
+ALTER TABLE public.api_keys
+    ADD CONSTRAINT api_keys_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES public.tenants(id) ON DELETE CASCADE;
+
+-- События и сообщения outbox помечаются арендатором измененной строки: поток SSE
+-- и доставка webhook не выходят за пределы каталога
+ALTER TABLE public.catalog_events ADD COLUMN IF NOT EXISTS tenant_id uuid;
+ALTER TABLE public.outbox ADD COLUMN IF NOT EXISTS tenant_id uuid;
+UPDATE public.catalog_events SET tenant_id = '00000000-0000-0000-0000-000000000001' WHERE tenant_id IS NULL;
+UPDATE public.outbox SET tenant_id = '00000000-0000-0000-0000-000000000001' WHERE tenant_id IS NULL;
+CREATE INDEX IF NOT EXISTS catalog_events_tenant_id_idx ON public.catalog_events (tenant_id, id);
+
+CREATE OR REPLACE FUNCTION public.catalog_event() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    resource text := TG_ARGV[0];
+    resource_id uuid;
+    tenant uuid;
+    action text;
+    payload jsonb;
+    event_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        action := 'deleted';
+        payload := NULL;
+        resource_id := (to_jsonb(OLD) ->> TG_ARGV[1])::uuid;
+        tenant := OLD.tenant_id;
+    ELSE
+        action := CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END;
+        payload := to_jsonb(NEW) - 'tenant_id';
+        resource_id := (payload ->> TG_ARGV[1])::uuid;
+        tenant := NEW.tenant_id;
+    END IF;
+
+    INSERT INTO public.catalog_events (type, resource_id, tenant_id, data)
+    VALUES (resource || '.' || action, resource_id, tenant, payload)
+    RETURNING id INTO event_id;
+
+    INSERT INTO public.outbox (type, resource_id, tenant_id, payload)
+    VALUES (resource || '.' || action, resource_id, tenant, payload);
+
+    PERFORM pg_notify('catalog_events', event_id::text);
+    RETURN NULL;
+END;
+$$;
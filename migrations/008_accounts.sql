@@ -0,0 +1,91 @@
+--
+-- Учетные записи пользователей: пароль, подтверждение email и восстановление пароля.
+-- Ссылки из писем несут одноразовые токены; в базе хранится только их SHA-256,
+-- поэтому утечка таблицы не дает войти по чужой ссылке.
+--
+
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS password_hash text;
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS email_verified_at timestamp with time zone;
+
+-- Смена email снимает подтверждение: новый адрес нужно подтвердить заново
+CREATE OR REPLACE FUNCTION public.reset_email_verification() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF NEW.email IS DISTINCT FROM OLD.email THEN
+        NEW.email_verified_at := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER users_reset_email_verification
+    BEFORE UPDATE OF email ON public.users
+    FOR EACH ROW EXECUTE FUNCTION public.reset_email_verification();
+
+-- purpose: verify_email или reset_password. email — адрес, на который ушло письмо:
+-- после смены email старая ссылка подтверждения перестает действовать.
+CREATE TABLE IF NOT EXISTS public.user_tokens (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    tenant_id uuid DEFAULT COALESCE(public.current_tenant_id(), '00000000-0000-0000-0000-000000000001') NOT NULL,
+    user_id uuid NOT NULL,
+    purpose character varying(32) NOT NULL,
+    email character varying(255) NOT NULL,
+    token_hash bytea NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT user_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT user_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT user_tokens_purpose_check CHECK (purpose IN ('verify_email', 'reset_password')),
+    CONSTRAINT user_tokens_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES public.users(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_tokens_user_id_idx ON public.user_tokens (user_id, purpose);
+
+ALTER TABLE public.user_tokens ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.user_tokens FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON public.user_tokens
+    USING (public.tenant_visible(tenant_id))
+    WITH CHECK (tenant_id = public.current_tenant_id());
+
+-- Хеш пароля и отметка подтверждения не попадают в события и webhook.
+-- Изменение только этих колонок (смена пароля, подтверждение email) события не создает.
+CREATE OR REPLACE FUNCTION public.catalog_event() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    resource text := TG_ARGV[0];
+    resource_id uuid;
+    tenant uuid;
+    action text;
+    payload jsonb;
+    event_id bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        action := 'deleted';
+        payload := NULL;
+        resource_id := (to_jsonb(OLD) ->> TG_ARGV[1])::uuid;
+        tenant := OLD.tenant_id;
+    ELSE
+        action := CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END;
+        payload := to_jsonb(NEW) - 'tenant_id' - 'password_hash' - 'email_verified_at';
+        IF TG_OP = 'UPDATE' AND payload = to_jsonb(OLD) - 'tenant_id' - 'password_hash' - 'email_verified_at' THEN
+            RETURN NULL;
+        END IF;
+        resource_id := (payload ->> TG_ARGV[1])::uuid;
+        tenant := NEW.tenant_id;
+    END IF;
+
+    INSERT INTO public.catalog_events (type, resource_id, tenant_id, data)
+    VALUES (resource || '.' || action, resource_id, tenant, payload)
+    RETURNING id INTO event_id;
+
+    INSERT INTO public.outbox (type, resource_id, tenant_id, payload)
+    VALUES (resource || '.' || action, resource_id, tenant, payload);
+
+    PERFORM pg_notify('catalog_events', event_id::text);
+    RETURN NULL;
+END;
+$$;
@@ -0,0 +1,46 @@
+--
+-- Вход через внешних провайдеров OpenID Connect (корпоративный SSO).
+-- Внешняя учетная запись (провайдер + subject) связывается с пользователем по email
+-- при первом входе или создает пользователя, если он еще не зарегистрирован.
+--
+
+CREATE TABLE IF NOT EXISTS public.user_identities (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    tenant_id uuid DEFAULT COALESCE(public.current_tenant_id(), '00000000-0000-0000-0000-000000000001') NOT NULL,
+    user_id uuid NOT NULL,
+    provider character varying(64) NOT NULL,
+    subject character varying(255) NOT NULL,
+    email character varying(255),
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    last_login_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT user_identities_pkey PRIMARY KEY (id),
+    CONSTRAINT user_identities_subject_key UNIQUE (tenant_id, provider, subject),
+    CONSTRAINT user_identities_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES public.users(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id_idx ON public.user_identities (user_id);
+
+ALTER TABLE public.user_identities ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.user_identities FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON public.user_identities
+    USING (public.tenant_visible(tenant_id))
+    WITH CHECK (tenant_id = public.current_tenant_id());
+
+-- Незавершенные входы: state из адреса провайдера, PKCE code_verifier и nonce.
+-- Провайдер возвращает браузер на общий callback без заголовка X-Tenant-ID,
+-- поэтому арендатор хранится здесь, а политики RLS на таблицу не ставятся.
+CREATE TABLE IF NOT EXISTS public.oidc_login_states (
+    state_hash bytea NOT NULL,
+    tenant_id uuid NOT NULL,
+    provider character varying(64) NOT NULL,
+    code_verifier text NOT NULL,
+    nonce text NOT NULL,
+    return_to text,
+    expires_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT oidc_login_states_pkey PRIMARY KEY (state_hash),
+    CONSTRAINT oidc_login_states_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES public.tenants(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS oidc_login_states_expires_at_idx ON public.oidc_login_states (expires_at);
@@ -0,0 +1,38 @@
+--
+-- Сеансы пользователей: вход через провайдера создает сеанс и refresh token.
+-- Refresh token меняется при каждом обновлении; все токены сеанса образуют
+-- семейство, и повторное предъявление уже использованного токена отзывает сеанс.
+--
+-- Как и api_keys, таблицы читаются при аутентификации до определения арендатора,
+-- поэтому политики RLS на них не ставятся: запросы фильтруют tenant_id явно.
+--
+
+CREATE TABLE IF NOT EXISTS public.user_sessions (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    tenant_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    device character varying(255) NOT NULL,
+    user_agent text,
+    ip inet,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    last_seen_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone,
+    revoked_reason character varying(32),
+    CONSTRAINT user_sessions_pkey PRIMARY KEY (id),
+    CONSTRAINT user_sessions_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES public.users(tenant_id, id) ON DELETE CASCADE,
+    CONSTRAINT user_sessions_revoked_reason_check CHECK (revoked_reason IN ('logout', 'admin', 'reuse', 'password_reset'))
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_user_id_idx ON public.user_sessions (tenant_id, user_id);
+
+CREATE TABLE IF NOT EXISTS public.refresh_tokens (
+    token_hash bytea NOT NULL,
+    session_id uuid NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    used_at timestamp with time zone,
+    CONSTRAINT refresh_tokens_pkey PRIMARY KEY (token_hash),
+    CONSTRAINT refresh_tokens_session_id_fkey FOREIGN KEY (session_id) REFERENCES public.user_sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON public.refresh_tokens (session_id);
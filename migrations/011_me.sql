@@ -0,0 +1,27 @@
+--
+-- Данные вошедшего пользователя: когда фильм добавлен в его список и настройки.
+--
+
+-- Связям, созданным до миграции, достается время миграции
+ALTER TABLE public.user_film ADD COLUMN IF NOT EXISTS added_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL;
+
+-- Настройки пользователя; строки нет, пока пользователь их не менял.
+-- language NULL означает язык по умолчанию (ACCOUNT_DEFAULT_LANGUAGE).
+CREATE TABLE IF NOT EXISTS public.user_preferences (
+    tenant_id uuid DEFAULT COALESCE(public.current_tenant_id(), '00000000-0000-0000-0000-000000000001') NOT NULL,
+    user_id uuid NOT NULL,
+    language character varying(8),
+    genres text[] DEFAULT '{}' NOT NULL,
+    notify_new_films boolean DEFAULT false NOT NULL,
+    notify_recommendations boolean DEFAULT false NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT user_preferences_pkey PRIMARY KEY (tenant_id, user_id),
+    CONSTRAINT user_preferences_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES public.users(tenant_id, id) ON DELETE CASCADE
+);
+
+ALTER TABLE public.user_preferences ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.user_preferences FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON public.user_preferences
+    USING (public.tenant_visible(tenant_id))
+    WITH CHECK (tenant_id = public.current_tenant_id());
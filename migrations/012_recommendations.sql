@@ -0,0 +1,25 @@
+--
+-- Рекомендации фильмов: похожесть фильмов по тому, как часто их выбирают
+-- одни и те же пользователи (user_film). Таблицу целиком пересобирает фоновая
+-- задача, запросы рекомендаций только читают ее.
+--
+-- Задача работает в системном контексте, который не может создавать строки
+-- под политиками RLS, поэтому политики на таблицу не ставятся: запросы
+-- фильтруют tenant_id явно.
+--
+
+CREATE TABLE IF NOT EXISTS public.film_similarity (
+    tenant_id uuid NOT NULL,
+    film_id uuid NOT NULL,
+    similar_film_id uuid NOT NULL,
+    -- Косинусная мера: общие пользователи / sqrt(пользователи film_id * пользователи similar_film_id)
+    score double precision NOT NULL,
+    common_users integer NOT NULL,
+    computed_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT film_similarity_pkey PRIMARY KEY (tenant_id, film_id, similar_film_id),
+    CONSTRAINT film_similarity_film_id_fkey FOREIGN KEY (tenant_id, film_id) REFERENCES public.films(tenant_id, film_id) ON DELETE CASCADE,
+    CONSTRAINT film_similarity_similar_film_id_fkey FOREIGN KEY (tenant_id, similar_film_id) REFERENCES public.films(tenant_id, film_id) ON DELETE CASCADE
+);
+
+-- Пары строятся соединением user_film по пользователю
+CREATE INDEX IF NOT EXISTS user_film_user_id_idx ON public.user_film (tenant_id, user_id);
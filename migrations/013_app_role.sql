@@ -0,0 +1,24 @@
+--
+-- Роль приложения. Политики row-level security не действуют на суперпользователя
+-- и роли с BYPASSRLS даже с FORCE ROW LEVEL SECURITY, поэтому приложение
+-- подключается отдельной обычной ролью, а миграции выполняет владелец схемы.
+-- Роль создается без входа: пароль задает 013_app_role_login.sh в docker-compose
+-- или администратор командой ALTER ROLE movies_app WITH LOGIN PASSWORD '...'.
+--
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'movies_app') THEN
+        CREATE ROLE movies_app WITH NOLOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOBYPASSRLS;
+    END IF;
+END $$;
+
+GRANT USAGE ON SCHEMA public TO movies_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO movies_app;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO movies_app;
+GRANT EXECUTE ON ALL FUNCTIONS IN SCHEMA public TO movies_app;
+
+-- Таблицы следующих миграций доступны роли без отдельных GRANT
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO movies_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO movies_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT EXECUTE ON FUNCTIONS TO movies_app;
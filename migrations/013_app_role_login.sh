@@ -0,0 +1,17 @@
+#!/bin/sh
+# Разрешает вход роли приложения movies_app с паролем APP_DB_PASSWORD.
+# Выполняется образом postgres при первом запуске после 013_app_role.sql;
+# пароль не хранится в миграциях.
+set -e
+
+# Без пароля инициализация базы не прерывается: роль остается без входа,
+# и приложение не запустится, пока ей не выдадут пароль вручную
+if [ -z "$APP_DB_PASSWORD" ]; then
+    echo "WARNING: APP_DB_PASSWORD is not set, role movies_app stays without login" >&2
+    exit 0
+fi
+
+psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" \
+    -v password="$APP_DB_PASSWORD" <<'EOSQL'
+ALTER ROLE movies_app WITH LOGIN PASSWORD :'password';
+EOSQL
@@ -0,0 +1,51 @@
+package apiversion
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// Deprecation сведения об устаревшей версии API
+type Deprecation struct {
+	// DeprecatedAt с какого момента версия считается устаревшей (заголовок Deprecation, RFC 9745)
+	DeprecatedAt time.Time
+	// Sunset когда версия перестанет отвечать (заголовок Sunset, RFC 8594)
+	Sunset time.Time
+	// Successor адрес версии-преемника, например /api/v2
+	Successor string
+}
+
+// Deprecate добавляет в ответы заголовки Deprecation, Sunset и ссылку на версию-преемника.
+// Пустые даты не выводятся.
+func Deprecate(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		if !d.DeprecatedAt.IsZero() {
+			h.Set("Deprecation", fmt.Sprintf("@%d", d.DeprecatedAt.Unix()))
+		}
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != "" {
+			h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Successor))
+		}
+		c.Next()
+	}
+}
+
+// ParseDate разбирает дату конфигурации (2006-01-02 или RFC 3339); пустая строка дает нулевое время
+func ParseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", value)
+	}
+	return t, nil
+}
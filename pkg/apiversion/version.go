@@ -0,0 +1,174 @@
+package apiversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"rest-api-tutorial/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// Version мажорная версия API
+type Version int
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+)
+
+// HeaderVersion заголовок ответа с версией, которая обработала запрос
+const HeaderVersion = "API-Version"
+
+func (v Version) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+// Options настройки согласования версии
+type Options struct {
+	// Prefix общий префикс API, например /api
+	Prefix string
+	// Vendor базовый vendor-тип, например application/vnd.movies. Версия запрашивается
+	// типом application/vnd.movies.v2+json или application/vnd.movies+json; version=2
+	Vendor string
+	// Default версия для запросов без версии в пути и в Accept
+	Default Version
+	// Supported доступные версии
+	Supported []Version
+	// Unversioned подпути Prefix, которые не версионируются (например /admin)
+	Unversioned []string
+}
+
+// Negotiate выбирает версию API до маршрутизации: путь без версии (/api/films)
+// переписывается в /api/v{N}/films, где N берется из Accept или Options.Default.
+// Если версия указана и в пути, и в Accept и они расходятся, отвечает 406.
+func Negotiate(next http.Handler, opts Options) http.Handler {
+	prefix := strings.TrimSuffix(opts.Prefix, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) || opts.unversioned(rest) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		accepted, err := FromAccept(r.Header.Values("Accept"), opts.Vendor)
+		if err == nil && accepted != 0 && !opts.supports(accepted) {
+			err = fmt.Errorf("API version %s is not supported", accepted)
+		}
+		if err != nil {
+			notAcceptable(w, err.Error())
+			return
+		}
+
+		version, tail, explicit := fromPath(rest)
+		switch {
+		case explicit && !opts.supports(version):
+			// Неизвестную версию в пути отдаем роутеру, он ответит 404
+		case explicit && accepted != 0 && accepted != version:
+			notAcceptable(w, fmt.Sprintf("Accept requests API %s but the path is %s", accepted, version))
+			return
+		case !explicit:
+			version = opts.Default
+			if accepted != 0 {
+				version = accepted
+			}
+			w.Header().Add("Vary", "Accept")
+			rewritePath(r, prefix+"/"+version.String()+tail)
+		}
+
+		if opts.supports(version) {
+			w.Header().Set(HeaderVersion, strconv.Itoa(int(version)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Validate проверяет, что версия по умолчанию входит в список поддерживаемых
+func (o Options) Validate() error {
+	if !o.supports(o.Default) {
+		return fmt.Errorf("default API version %s is not supported", o.Default)
+	}
+	return nil
+}
+
+// FromAccept ищет в заголовках Accept vendor-тип с версией. Возвращает 0, если
+// клиент не запрашивал конкретную версию (application/json, */* и т.п.).
+func FromAccept(values []string, vendor string) (Version, error) {
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || !strings.HasPrefix(mediaType, vendor) {
+				continue
+			}
+
+			suffix := strings.TrimPrefix(mediaType, vendor)
+			switch {
+			case suffix == "+json":
+				if raw, ok := params["version"]; ok {
+					return parseVersion(raw)
+				}
+				return 0, nil
+			case strings.HasPrefix(suffix, ".v") && strings.HasSuffix(suffix, "+json"):
+				return parseVersion(strings.TrimSuffix(strings.TrimPrefix(suffix, ".v"), "+json"))
+			}
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(raw string) (Version, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(raw, "v"))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid API version %q", raw)
+	}
+	return Version(n), nil
+}
+
+// fromPath разбирает /v2/films на версию и остаток пути
+func fromPath(rest string) (Version, string, bool) {
+	segment, tail, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+	if !strings.HasPrefix(segment, "v") {
+		return 0, rest, false
+	}
+	n, err := strconv.Atoi(segment[1:])
+	if err != nil || n <= 0 {
+		return 0, rest, false
+	}
+	if tail != "" {
+		tail = "/" + tail
+	}
+	return Version(n), tail, true
+}
+
+func rewritePath(r *http.Request, path string) {
+	r.URL.Path = path
+	r.URL.RawPath = ""
+}
+
+func (o Options) supports(v Version) bool {
+	for _, s := range o.Supported {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Options) unversioned(rest string) bool {
+	for _, p := range o.Unversioned {
+		if rest == p || strings.HasPrefix(rest, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func notAcceptable(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusNotAcceptable)
+	_ = json.NewEncoder(w).Encode(errors.ErrorResponse{
+		Code:    http.StatusNotAcceptable,
+		Message: message,
+	})
+}
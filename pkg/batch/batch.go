@@ -0,0 +1,275 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
+	"net/http"
+)
+
+// Op тип операции в пакете
+type Op string
+
+const (
+	OpCreate Op = "create"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+// Mode режим выполнения пакета
+type Mode string
+
+const (
+	// ModeAtomic все операции применяются вместе или не применяется ни одна
+	ModeAtomic Mode = "atomic"
+	// ModeBestEffort успешные операции сохраняются, даже если часть пакета завершилась ошибкой
+	ModeBestEffort Mode = "best_effort"
+)
+
+var (
+	// ErrTooLarge пакет превышает лимит на число операций или размер тела
+	ErrTooLarge = errors.New("batch is too large")
+	// ErrInvalidRequest тело запроса не удалось разобрать
+	ErrInvalidRequest = errors.New("invalid batch request")
+)
+
+// Limits ограничения на размер пакета
+type Limits struct {
+	MaxOperations int
+	MaxBodyBytes  int64
+}
+
+// Request тело запроса пакетной операции
+// @description Список операций create/update/delete над ресурсами одного типа
+type Request struct {
+	// @Enum atomic best_effort
+	Mode       Mode        `json:"mode" example:"atomic"`
+	Operations []Operation `json:"operations"`
+}
+
+// Operation одна операция пакета
+// @description Для create в data передается ресурс целиком, для update — изменяемые поля, для delete — только id
+type Operation struct {
+	// @Enum create update delete
+	Op   Op              `json:"op" example:"create"`
+	ID   string          `json:"id,omitempty"`
+	Data json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+// ItemResult результат одной операции
+type ItemResult struct {
+	Index  int    `json:"index"`
+	Op     Op     `json:"op"`
+	ID     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Response результат выполнения пакета
+// @description Статус и ошибка по каждой операции в порядке запроса
+type Response struct {
+	Mode      Mode         `json:"mode"`
+	Committed bool         `json:"committed"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []ItemResult `json:"results"`
+}
+
+// Bind читает и проверяет тело пакетного запроса с учетом лимитов.
+// Режим по умолчанию — atomic.
+func Bind(c *gin.Context, limits Limits) (*Request, error) {
+	if limits.MaxBodyBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxBodyBytes)
+	}
+
+	var req Request
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrTooLarge, maxBytesErr.Limit)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	switch req.Mode {
+	case "":
+		req.Mode = ModeAtomic
+	case ModeAtomic, ModeBestEffort:
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidRequest, req.Mode)
+	}
+
+	if len(req.Operations) == 0 {
+		return nil, fmt.Errorf("%w: operations are required", ErrInvalidRequest)
+	}
+	if limits.MaxOperations > 0 && len(req.Operations) > limits.MaxOperations {
+		return nil, fmt.Errorf("%w: got %d operations, limit is %d", ErrTooLarge, len(req.Operations), limits.MaxOperations)
+	}
+
+	return &req, nil
+}
+
+// NewResponse готовит результаты для всех операций запроса
+func NewResponse(req *Request) *Response {
+	resp := &Response{
+		Mode:    req.Mode,
+		Results: make([]ItemResult, len(req.Operations)),
+	}
+	for i, op := range req.Operations {
+		resp.Results[i] = ItemResult{Index: i, Op: op.Op, ID: op.ID}
+	}
+	return resp
+}
+
+// Reject помечает операцию как отклоненную до обращения к базе
+func (r *Response) Reject(index, status int, err error) {
+	r.Results[index].Status = status
+	r.Results[index].Error = err.Error()
+}
+
+// StatusCode HTTP-код ответа на весь пакет
+func (r *Response) StatusCode() int {
+	if !r.Committed {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusOK
+}
+
+// Executor выполняет операцию с номером index в транзакции tx и возвращает
+// идентификатор ресурса и HTTP-статус результата (в том числе при ошибке)
+type Executor func(ctx context.Context, tx pgx.Tx, index int) (id string, status int, err error)
+
+// TxBeginner источник транзакций (пул соединений или внешняя транзакция)
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Run выполняет еще не отклоненные операции в одной транзакции, каждую в своей
+// точке сохранения. В режиме atomic первая ошибка откатывает весь пакет,
+// а оставшиеся операции получают статус 424. В режиме best_effort откатывается
+// только неудавшаяся операция.
+func Run(ctx context.Context, db TxBeginner, resp *Response, exec Executor) error {
+	if resp.Mode == ModeAtomic && resp.hasFailures() {
+		resp.abortPending()
+		resp.count()
+		return nil
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range resp.Results {
+		item := &resp.Results[i]
+		if item.Status != 0 {
+			continue
+		}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		id, status, err := exec(ctx, savepoint, i)
+		if id != "" {
+			item.ID = id
+		}
+		item.Status = status
+		if err != nil {
+			item.Error = err.Error()
+			if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+				return fmt.Errorf("failed to rollback savepoint: %w", rbErr)
+			}
+			if resp.Mode == ModeAtomic {
+				resp.abortPending()
+				resp.count()
+				return nil
+			}
+			continue
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	resp.Committed = true
+	resp.count()
+	return nil
+}
+
+// Apply выполняет еще не отклоненные операции без транзакции PostgreSQL — для
+// хранилищ, которые применяют изменения сразу. Семантика режимов та же, что у Run:
+// в режиме atomic после первой ошибки вызывается rollback, который должен вернуть
+// хранилище к состоянию до пакета.
+func Apply(resp *Response, exec func(index int) (id string, status int, err error), rollback func()) {
+	if resp.Mode == ModeAtomic && resp.hasFailures() {
+		resp.abortPending()
+		resp.count()
+		return
+	}
+
+	for i := range resp.Results {
+		item := &resp.Results[i]
+		if item.Status != 0 {
+			continue
+		}
+
+		id, status, err := exec(i)
+		if id != "" {
+			item.ID = id
+		}
+		item.Status = status
+		if err != nil {
+			item.Error = err.Error()
+			if resp.Mode == ModeAtomic {
+				rollback()
+				resp.abortPending()
+				resp.count()
+				return
+			}
+		}
+	}
+
+	resp.Committed = true
+	resp.count()
+}
+
+func (r *Response) hasFailures() bool {
+	for _, item := range r.Results {
+		if item.Status >= http.StatusBadRequest {
+			return true
+		}
+	}
+	return false
+}
+
+// abortPending помечает операции, которые не были выполнены или были откачены вместе с пакетом
+func (r *Response) abortPending() {
+	for i := range r.Results {
+		item := &r.Results[i]
+		if item.Status < http.StatusBadRequest {
+			item.Status = http.StatusFailedDependency
+			item.Error = "batch aborted"
+		}
+	}
+}
+
+func (r *Response) count() {
+	r.Succeeded, r.Failed = 0, 0
+	for _, item := range r.Results {
+		if item.Status < http.StatusBadRequest {
+			r.Succeeded++
+		} else {
+			r.Failed++
+		}
+	}
+}
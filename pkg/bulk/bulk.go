@@ -0,0 +1,277 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Format формат файла импорта/экспорта
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ErrUnknownFormat запрошен неподдерживаемый формат
+var ErrUnknownFormat = errors.New("unknown format, expected csv, json or ndjson")
+
+// maxLineSize максимальная длина одной строки NDJSON
+const maxLineSize = 1 << 20
+
+// ParseFormat разбирает значение параметра format
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// FormatFromContentType определяет формат по заголовку Content-Type
+func FormatFromContentType(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnknownFormat
+	}
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/json":
+		return FormatJSON, nil
+	case "application/x-ndjson", "application/ndjson":
+		return FormatNDJSON, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// DetectFormat берет формат из параметра запроса, а при его отсутствии — из Content-Type
+func DetectFormat(param, contentType string) (Format, error) {
+	if param != "" {
+		return ParseFormat(param)
+	}
+	return FormatFromContentType(contentType)
+}
+
+// ContentType возвращает MIME-тип для ответа в данном формате
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Row одна строка входного файла. Для JSON/NDJSON заполнено поле JSON,
+// для CSV — Fields (значения по именам колонок из заголовка).
+type Row struct {
+	Num    int
+	JSON   json.RawMessage
+	Fields map[string]string
+}
+
+// Reader последовательно читает строки из тела запроса, не буферизуя весь файл
+type Reader struct {
+	format  Format
+	num     int
+	csv     *csv.Reader
+	header  []string
+	lines   *bufio.Scanner
+	decoder *json.Decoder
+}
+
+func NewReader(format Format, r io.Reader) (*Reader, error) {
+	reader := &Reader{format: format}
+
+	switch format {
+	case FormatCSV:
+		reader.csv = csv.NewReader(r)
+		reader.csv.FieldsPerRecord = -1
+		header, err := reader.csv.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("csv header is missing")
+			}
+			return nil, fmt.Errorf("failed to read csv header: %w", err)
+		}
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		}
+		reader.header = header
+	case FormatNDJSON:
+		reader.lines = bufio.NewScanner(r)
+		reader.lines.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	case FormatJSON:
+		reader.decoder = json.NewDecoder(r)
+		tok, err := reader.decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read json array: %w", err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("json body must be an array")
+		}
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	return reader, nil
+}
+
+// Next возвращает следующую строку или io.EOF. Ошибки разбора CSV и NDJSON
+// относятся к одной строке и возвращаются как *RowError; после них чтение
+// можно продолжать. Любая другая ошибка означает, что поток испорчен.
+func (r *Reader) Next() (Row, error) {
+	switch r.format {
+	case FormatCSV:
+		return r.nextCSV()
+	case FormatNDJSON:
+		return r.nextNDJSON()
+	default:
+		return r.nextJSON()
+	}
+}
+
+func (r *Reader) nextCSV() (Row, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Row{}, io.EOF
+		}
+		r.num++
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Row{}, &RowError{Row: r.num, Message: parseErr.Err.Error()}
+		}
+		return Row{}, err
+	}
+	r.num++
+
+	if len(record) != len(r.header) {
+		return Row{}, &RowError{
+			Row:     r.num,
+			Message: fmt.Sprintf("expected %d columns, got %d", len(r.header), len(record)),
+		}
+	}
+
+	fields := make(map[string]string, len(record))
+	for i, value := range record {
+		fields[r.header[i]] = value
+	}
+	return Row{Num: r.num, Fields: fields}, nil
+}
+
+func (r *Reader) nextNDJSON() (Row, error) {
+	for r.lines.Scan() {
+		line := bytes.TrimSpace(r.lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		r.num++
+		if !json.Valid(line) {
+			return Row{}, &RowError{Row: r.num, Message: "malformed json"}
+		}
+		return Row{Num: r.num, JSON: append(json.RawMessage(nil), line...)}, nil
+	}
+	if err := r.lines.Err(); err != nil {
+		return Row{}, fmt.Errorf("failed to read ndjson: %w", err)
+	}
+	return Row{}, io.EOF
+}
+
+func (r *Reader) nextJSON() (Row, error) {
+	if !r.decoder.More() {
+		if _, err := r.decoder.Token(); err != nil {
+			return Row{}, fmt.Errorf("failed to read json array: %w", err)
+		}
+		return Row{}, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return Row{}, fmt.Errorf("malformed json at element %d: %w", r.num+1, err)
+	}
+	r.num++
+	return Row{Num: r.num, JSON: raw}, nil
+}
+
+// RowError ошибка обработки одной строки импорта
+type RowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"error"`
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+// Result итог импорта
+// @description Количество обработанных строк и ошибки по каждой отклоненной строке
+type Result struct {
+	Format   Format     `json:"format"`
+	Upsert   bool       `json:"upsert"`
+	DryRun   bool       `json:"dry_run"`
+	Total    int        `json:"total"`
+	Inserted int        `json:"inserted"`
+	Updated  int        `json:"updated"`
+	Failed   int        `json:"failed"`
+	Errors   []RowError `json:"errors"`
+}
+
+func NewResult(format Format, upsert, dryRun bool) *Result {
+	return &Result{
+		Format: format,
+		Upsert: upsert,
+		DryRun: dryRun,
+		Errors: make([]RowError, 0),
+	}
+}
+
+// AddError помечает строку как отклоненную
+func (r *Result) AddError(row int, err error) {
+	r.Failed++
+
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		r.Errors = append(r.Errors, RowError{Row: row, Message: rowErr.Message})
+		return
+	}
+	r.Errors = append(r.Errors, RowError{Row: row, Message: err.Error()})
+}
+
+// timeLayouts форматы дат, которые принимаются при импорте: RFC 3339 из JSON API
+// и текстовое представление timestamp/timestamptz, которое отдает COPY ... CSV
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// ParseTime разбирает дату из CSV-колонки
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
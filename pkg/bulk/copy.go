@@ -0,0 +1,70 @@
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMalformedInput входной поток нельзя дочитать до конца
+var ErrMalformedInput = errors.New("malformed input")
+
+// DecodeFunc разбирает и валидирует строку импорта, возвращая значения колонок для COPY
+type DecodeFunc func(row Row) ([]interface{}, error)
+
+// CopySource адаптирует Reader к pgx.CopyFromSource: строки читаются из запроса
+// по одной и сразу уходят в COPY, а отклоненные строки попадают в Result.
+// Первой колонкой всегда передается номер строки.
+type CopySource struct {
+	reader *Reader
+	result *Result
+	decode DecodeFunc
+	values []interface{}
+	err    error
+}
+
+func NewCopySource(reader *Reader, result *Result, decode DecodeFunc) *CopySource {
+	return &CopySource{
+		reader: reader,
+		result: result,
+		decode: decode,
+	}
+}
+
+func (s *CopySource) Next() bool {
+	for {
+		row, err := s.reader.Next()
+		if errors.Is(err, io.EOF) {
+			return false
+		}
+
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			s.result.Total++
+			s.result.AddError(rowErr.Row, rowErr)
+			continue
+		}
+		if err != nil {
+			s.err = fmt.Errorf("%w: %v", ErrMalformedInput, err)
+			return false
+		}
+
+		s.result.Total++
+		values, err := s.decode(row)
+		if err != nil {
+			s.result.AddError(row.Num, err)
+			continue
+		}
+
+		s.values = append([]interface{}{row.Num}, values...)
+		return true
+	}
+}
+
+func (s *CopySource) Values() ([]interface{}, error) {
+	return s.values, nil
+}
+
+func (s *CopySource) Err() error {
+	return s.err
+}
@@ -0,0 +1,49 @@
+package bulk
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Writer пишет записи экспорта в JSON-массив или NDJSON по мере чтения из базы
+type Writer struct {
+	format  Format
+	w       io.Writer
+	encoder *json.Encoder
+	count   int
+}
+
+func NewWriter(format Format, w io.Writer) *Writer {
+	return &Writer{
+		format:  format,
+		w:       w,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (w *Writer) Write(v interface{}) error {
+	if w.format == FormatJSON {
+		sep := ","
+		if w.count == 0 {
+			sep = "["
+		}
+		if _, err := io.WriteString(w.w, sep); err != nil {
+			return err
+		}
+	}
+	w.count++
+	return w.encoder.Encode(v)
+}
+
+// Close завершает JSON-массив; для NDJSON ничего не делает
+func (w *Writer) Close() error {
+	if w.format != FormatJSON {
+		return nil
+	}
+	if w.count == 0 {
+		_, err := io.WriteString(w.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(w.w, "]\n")
+	return err
+}
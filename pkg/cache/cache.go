@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Store хранилище закешированных ответов. Записи группируются в пространства
+// имен (например "films"), которые сбрасываются целиком: Invalidate увеличивает
+// поколение пространства, и записи прежних поколений больше не читаются.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Generation возвращает текущее поколение пространства имен
+	Generation(ctx context.Context, namespace string) (int64, error)
+	// Invalidate сбрасывает все записи пространства имен
+	Invalidate(ctx context.Context, namespace string) error
+}
+
+// Key строит ключ записи из раздела, пути и параметров запроса. Порядок параметров
+// не важен: ?sort=title&page=2 и ?page=2&sort=title дают один ключ.
+func Key(namespace string, generation int64, partition, path string, query url.Values) string {
+	sum := sha256.Sum256([]byte(partition + "\x00" + path + "?" + query.Encode()))
+	return namespace + ":" + strconv.FormatInt(generation, 10) + ":" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore LRU-кеш в памяти процесса с ограничением на число записей и TTL.
+// Кеш у каждой реплики свой, поэтому изменения, сделанные другими репликами,
+// нужно передавать в Invalidate отдельно (например из потока событий каталога).
+type MemoryStore struct {
+	mu          sync.Mutex
+	maxEntries  int
+	items       map[string]*list.Element
+	order       *list.List
+	generations map[string]int64
+	now         func() time.Time
+}
+
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries:  maxEntries,
+		items:       make(map[string]*list.Element),
+		order:       list.New(),
+		generations: make(map[string]int64),
+		now:         time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if !s.now().Before(e.expiresAt) {
+		s.remove(el)
+		return nil, false, nil
+	}
+	s.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := s.now().Add(ttl)
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		s.order.MoveToFront(el)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+func (s *MemoryStore) Generation(_ context.Context, namespace string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generations[namespace], nil
+}
+
+// Invalidate увеличивает поколение и сразу освобождает память записей пространства
+func (s *MemoryStore) Invalidate(_ context.Context, namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generations[namespace]++
+	prefix := namespace + ":"
+	for key, el := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.items, el.Value.(*entry).key)
+}
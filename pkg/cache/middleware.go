@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+	"net/http"
+	"rest-api-tutorial/pkg/logging"
+	"time"
+)
+
+// HeaderCache сообщает, взят ли ответ из кеша: HIT или MISS
+const HeaderCache = "X-Cache"
+
+// Options пространство имен и время жизни записей
+type Options struct {
+	// Namespace пространство имен записей; Invalidate(Namespace) сбрасывает их все
+	Namespace string
+	// TTL сколько запись живет в хранилище
+	TTL time.Duration
+	// MaxAge значение max-age в Cache-Control для клиентов. Клиентский кеш нельзя
+	// сбросить, поэтому MaxAge обычно меньше TTL.
+	MaxAge time.Duration
+	// Skip запросы, которые проходят мимо кеша, например потоковые списки:
+	// их нельзя собирать в памяти и раздавать ожидающим запросам
+	Skip func(c *gin.Context) bool
+	// Partition разделяет записи одного пути, например по арендатору запроса
+	Partition func(c *gin.Context) string
+}
+
+// response закешированный ответ
+type response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Middleware кеширует успешные (200) ответы на GET по пути и параметрам запроса.
+// Одновременные промахи по одному ключу выполняют обработчик один раз (singleflight),
+// остальные запросы получают тот же ответ. Если хранилище недоступно, запрос
+// обрабатывается без кеша.
+func Middleware(store Store, opts Options, logger *logging.Logger) gin.HandlerFunc {
+	var group singleflight.Group
+	cacheControl := fmt.Sprintf("private, max-age=%d", int(opts.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || (opts.Skip != nil && opts.Skip(c)) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		generation, err := store.Generation(ctx, opts.Namespace)
+		if err != nil {
+			logger.Errorf("Cache is unavailable, request served without cache: %v", err)
+			c.Next()
+			return
+		}
+		var partition string
+		if opts.Partition != nil {
+			partition = opts.Partition(c)
+		}
+		key := Key(opts.Namespace, generation, partition, c.Request.URL.Path, c.Request.URL.Query())
+
+		if cached, ok := lookup(ctx, store, key, logger); ok {
+			cached.write(c, cacheControl, "HIT")
+			return
+		}
+
+		leader := false
+		v, _, _ := group.Do(key, func() (interface{}, error) {
+			leader = true
+			// Ответ нужен и ожидающим запросам, поэтому обработчик не прерывается,
+			// если клиент ведущего запроса отключился
+			c.Request = c.Request.WithContext(context.WithoutCancel(ctx))
+			rec := &recorder{ResponseWriter: c.Writer, cacheControl: cacheControl}
+			c.Writer = rec
+			c.Header(HeaderCache, "MISS")
+			c.Header("Cache-Control", cacheControl)
+			c.Next()
+			c.Writer = rec.ResponseWriter
+
+			resp := &response{Status: rec.Status(), ContentType: rec.Header().Get("Content-Type"), Body: rec.body.Bytes()}
+			if resp.Status == http.StatusOK {
+				data, err := json.Marshal(resp)
+				if err == nil {
+					err = store.Set(context.WithoutCancel(ctx), key, data, opts.TTL)
+				}
+				if err != nil {
+					logger.Warnf("Failed to store cache entry: %v", err)
+				}
+			}
+			return resp, nil
+		})
+		if !leader {
+			v.(*response).write(c, cacheControl, "HIT")
+		}
+	}
+}
+
+func lookup(ctx context.Context, store Store, key string, logger *logging.Logger) (*response, bool) {
+	data, ok, err := store.Get(ctx, key)
+	if err != nil {
+		logger.Warnf("Failed to read cache entry: %v", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		logger.Warnf("Invalid cache entry %s: %v", key, err)
+		return nil, false
+	}
+	return &resp, true
+}
+
+func (r *response) write(c *gin.Context, cacheControl, status string) {
+	c.Header(HeaderCache, status)
+	c.Header("Cache-Control", cacheControlFor(r.Status, cacheControl))
+	c.Data(r.Status, r.ContentType, r.Body)
+	c.Abort()
+}
+
+// cacheControlFor разрешает клиенту кешировать только успешные ответы
+func cacheControlFor(status int, cacheControl string) string {
+	if status == http.StatusOK {
+		return cacheControl
+	}
+	return "no-store"
+}
+
+// recorder копирует тело ответа и проставляет Cache-Control по статусу
+type recorder struct {
+	gin.ResponseWriter
+	body         bytes.Buffer
+	cacheControl string
+}
+
+func (r *recorder) WriteHeader(code int) {
+	r.Header().Set("Cache-Control", cacheControlFor(code, r.cacheControl))
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
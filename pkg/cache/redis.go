@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// RedisStore кеш в Redis или совместимом сервере (Valkey, KeyDB). Кеш и поколения
+// общие для всех реплик, поэтому сброс на одной реплике сразу виден остальным.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore подключается к серверу по адресу addr ("localhost:6379").
+// Все ключи получают префикс prefix, чтобы кеш мог делить сервер с другими данными.
+func NewRedisStore(ctx context.Context, addr, password string, db int, prefix string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", addr, err)
+	}
+	return &RedisStore{client: client, prefix: prefix}, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get cache entry: %w", err)
+	}
+	return value, true, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set cache entry: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Generation(ctx context.Context, namespace string) (int64, error) {
+	generation, err := s.client.Get(ctx, s.generationKey(namespace)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get cache generation: %w", err)
+	}
+	return generation, nil
+}
+
+// Invalidate увеличивает поколение; записи прежних поколений удаляет Redis по TTL
+func (s *RedisStore) Invalidate(ctx context.Context, namespace string) error {
+	if err := s.client.Incr(ctx, s.generationKey(namespace)).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisStore) generationKey(namespace string) string {
+	return s.prefix + namespace + ":generation"
+}
@@ -18,17 +18,25 @@ type Client interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
-func NewClient(ctx context.Context, cfg config.User, maxAttempts int) (*pgxpool.Pool, error) {
+// NewClient подключается к базе, повторяя попытки. configure может дополнить
+// настройки пула, например хуками выдачи соединений.
+func NewClient(ctx context.Context, cfg config.User, maxAttempts int, configure ...func(*pgxpool.Config)) (*pgxpool.Pool, error) {
 	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable",
 		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database config: %w", err)
+	}
+	for _, fn := range configure {
+		fn(poolCfg)
+	}
 
 	var pool *pgxpool.Pool
-	var err error
 
 	for i := 0; i < maxAttempts; i++ {
 		attemptCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 
-		pool, err = pgxpool.Connect(attemptCtx, dsn)
+		pool, err = pgxpool.ConnectConfig(attemptCtx, poolCfg)
 		cancel()
 
 		if err == nil {
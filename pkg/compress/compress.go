@@ -0,0 +1,284 @@
+package compress
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Поддерживаемые кодировки в порядке предпочтения сервера при равном q
+const (
+	Brotli = "br"
+	Zstd   = "zstd"
+	Gzip   = "gzip"
+)
+
+// DefaultTypes типы содержимого, которые сжимаются по умолчанию
+var DefaultTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/msgpack",
+	"application/yaml",
+	"application/javascript",
+	"text/csv",
+	"text/html",
+	"text/plain",
+	"text/css",
+}
+
+// Options настройки сжатия
+type Options struct {
+	// MinSize ответы меньше этого размера отправляются без сжатия
+	MinSize int
+	// Encodings разрешенные кодировки в порядке предпочтения; по умолчанию br, zstd, gzip
+	Encodings []string
+	// Types сжимаемые типы содержимого; типы с суффиксом +json сжимаются всегда
+	Types []string
+}
+
+// Validate проверяет порог и список кодировок
+func (o Options) Validate() error {
+	if o.MinSize < 0 {
+		return fmt.Errorf("compression min size must not be negative, got %d", o.MinSize)
+	}
+	for _, encoding := range o.Encodings {
+		if _, ok := encoders[encoding]; !ok {
+			return fmt.Errorf("unknown compression encoding %q, expected br, zstd or gzip", encoding)
+		}
+	}
+	return nil
+}
+
+// encoder сжимающий writer, который можно переиспользовать через Reset
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type zstdEncoder struct{ *zstd.Encoder }
+
+func (e zstdEncoder) Reset(w io.Writer) { e.Encoder.Reset(w) }
+
+// encoders пулы кодировщиков; уровни выбраны в пользу скорости, как принято для динамических ответов
+var encoders = map[string]*sync.Pool{
+	Brotli: {New: func() interface{} { return brotli.NewWriterLevel(nil, 4) }},
+	Zstd: {New: func() interface{} {
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return zstdEncoder{e}
+	}},
+	Gzip: {New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}},
+}
+
+// Handler сжимает ответы next кодировкой из Accept-Encoding. Тело накапливается до
+// MinSize байт: короткие ответы уходят как есть, длинные сжимаются. Flush (потоковые
+// ответы) начинает сжатие сразу и сбрасывает кодировщик, чтобы клиент получил данные.
+// Не сжимаются ответы с Content-Encoding, Cache-Control: no-transform, запросы с Range
+// и типы содержимого вне Types, в том числе text/event-stream.
+func Handler(next http.Handler, opts Options) http.Handler {
+	if opts.Encodings == nil {
+		opts.Encodings = []string{Brotli, Zstd, Gzip}
+	}
+	if opts.Types == nil {
+		opts.Types = DefaultTypes
+	}
+	types := make(map[string]bool, len(opts.Types))
+	for _, t := range opts.Types {
+		types[t] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := Negotiate(r.Header.Get("Accept-Encoding"), opts.Encodings)
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &writer{ResponseWriter: w, encoding: encoding, minSize: opts.MinSize, types: types}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// Negotiate выбирает кодировку с наибольшим q из Accept-Encoding; при равном q —
+// первую в списке сервера. "*" разрешает любую, q=0 запрещает. Пустая строка — без сжатия.
+func Negotiate(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supported {
+		q, ok := weights[encoding]
+		if !ok {
+			q = max(wildcard, 0)
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// writer откладывает решение о сжатии до MinSize байт тела или Flush
+type writer struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	types    map[string]bool
+
+	status      int
+	buf         []byte
+	decided     bool
+	passthrough bool
+	enc         encoder
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.decided || w.status != 0 {
+		if w.decided && w.passthrough {
+			w.ResponseWriter.WriteHeader(code)
+		}
+		return
+	}
+	w.status = code
+	// Ответы без тела и информационные статусы не сжимаются
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified || !w.compressible() {
+		w.pass()
+	}
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.passthrough {
+			return w.ResponseWriter.Write(b)
+		}
+		return w.enc.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush начинает сжатие, не дожидаясь MinSize, и отправляет накопленное клиенту
+func (w *writer) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.WriteHeader(http.StatusOK)
+		}
+		if !w.decided {
+			if err := w.start(); err != nil {
+				return
+			}
+		}
+	}
+	if !w.passthrough {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap нужен http.ResponseController, например для снятия дедлайна записи у потоков
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// compressible проверяет заголовки ответа, выставленные обработчиком
+func (w *writer) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || strings.Contains(h.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return w.types[mediaType] || strings.HasSuffix(mediaType, "+json")
+}
+
+// pass отправляет ответ без сжатия
+func (w *writer) pass() {
+	w.decided, w.passthrough = true, true
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) > 0 {
+		w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// start отправляет заголовки сжатого ответа и сжимает накопленное тело
+func (w *writer) start() error {
+	w.decided = true
+	h := w.Header()
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	// ETag сжатого представления отличается от исходного
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.enc = encoders[w.encoding].Get().(encoder)
+	w.enc.Reset(w.ResponseWriter)
+	buf := w.buf
+	w.buf = nil
+	_, err := w.enc.Write(buf)
+	return err
+}
+
+// close завершает ответ: короткое тело отправляется как есть, кодировщик возвращается в пул
+func (w *writer) close() {
+	if !w.decided {
+		if w.status == 0 {
+			// Обработчик ничего не написал
+			return
+		}
+		w.pass()
+		return
+	}
+	if w.enc != nil {
+		w.enc.Close()
+		w.enc.Reset(nil)
+		encoders[w.encoding].Put(w.enc)
+	}
+}
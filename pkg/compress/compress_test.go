@@ -0,0 +1,94 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	supported := []string{Brotli, Zstd, Gzip}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", Gzip},
+		{"GZIP", Gzip},
+		// При равном q побеждает порядок сервера
+		{"gzip, zstd, br", Brotli},
+		{"gzip;q=1, br;q=0.5", Gzip},
+		{"br;q=0, gzip", Gzip},
+		{"*", Brotli},
+		{"*;q=0.1, zstd;q=0.5", Zstd},
+		{"*, br;q=0", Zstd},
+		{"*;q=0", ""},
+		{"gzip;q=0", ""},
+		{"deflate, compress", ""},
+		{"gzip; q=0.8, br; q=0.9", Brotli},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header, supported); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+	if got := Negotiate("br, gzip", []string{Gzip}); got != Gzip {
+		t.Errorf("Negotiate with gzip only = %q, want gzip", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	large := strings.Repeat(`{"title": "Сталкер"},`, 100)
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.URL.Query().Get("type")
+		w.Header().Set("Content-Type", contentType)
+		if r.URL.Query().Get("small") != "" {
+			io.WriteString(w, "{}")
+			return
+		}
+		io.WriteString(w, large)
+	}), Options{MinSize: 1024, Encodings: []string{Gzip}})
+
+	tests := []struct {
+		name     string
+		path     string
+		accept   string
+		encoding string
+	}{
+		{"large JSON", "/?type=application/json", "gzip", Gzip},
+		{"vendor JSON", "/?type=application/problem%2Bjson", "gzip", Gzip},
+		{"client without gzip", "/?type=application/json", "br", ""},
+		{"below MinSize", "/?type=application/json&small=1", "gzip", ""},
+		{"event stream", "/?type=text/event-stream", "gzip", ""},
+		{"image", "/?type=image/png", "gzip", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept-Encoding", tt.accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("%s: Content-Encoding %q, want %q", tt.name, got, tt.encoding)
+			continue
+		}
+		if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+			t.Errorf("%s: Vary %q, want Accept-Encoding", tt.name, w.Header().Get("Vary"))
+		}
+		if tt.encoding != Gzip {
+			continue
+		}
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("%s: invalid gzip: %v", tt.name, err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil || string(body) != large {
+			t.Errorf("%s: decompressed body differs from the original: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// FetchFunc загружает значения сразу для нескольких ключей. Ключ, которого нет
+// в результате, получает нулевое значение.
+type FetchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader собирает ключи, запрошенные резолверами одного уровня запроса, и загружает
+// их одним вызовом fetch при первом обращении к результату. Так вложенные поля
+// списка (фильмы каждого пользователя) стоят один запрос, а не N.
+// Loader живет в пределах одного запроса и кеширует загруженные значения.
+type Loader[K comparable, V any] struct {
+	fetch FetchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]struct{}
+	values  map[K]V
+	errors  map[K]error
+}
+
+func New[K comparable, V any](fetch FetchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:  fetch,
+		queued: make(map[K]struct{}),
+		values: make(map[K]V),
+		errors: make(map[K]error),
+	}
+}
+
+// Load ставит ключ в очередь и возвращает thunk; вызов thunk загружает
+// всю накопленную очередь, если значение еще не получено
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if !l.done(key) {
+		if _, ok := l.queued[key]; !ok {
+			l.queued[key] = struct{}{}
+			l.pending = append(l.pending, key)
+		}
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if !l.done(key) {
+			l.flush(ctx)
+		}
+		return l.values[key], l.errors[key]
+	}
+}
+
+// done вызывается под блокировкой
+func (l *Loader[K, V]) done(key K) bool {
+	if _, ok := l.values[key]; ok {
+		return true
+	}
+	_, ok := l.errors[key]
+	return ok
+}
+
+// flush вызывается под блокировкой
+func (l *Loader[K, V]) flush(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	l.queued = make(map[K]struct{})
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errors[key] = err
+			continue
+		}
+		l.values[key] = values[key]
+	}
+}
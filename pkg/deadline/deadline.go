@@ -0,0 +1,64 @@
+package deadline
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"rest-api-tutorial/pkg/logging"
+	"strings"
+	"time"
+)
+
+// ParseRoutes разбирает переопределения времени записи ответа для маршрутов в формате
+// "GET /api/v1/films/export=30m;GET /api/v1/films=5m". Маршрут указывается так же,
+// как он зарегистрирован в gin. Значение 0 снимает ограничение.
+func ParseRoutes(s string) (map[string]time.Duration, error) {
+	routes := make(map[string]time.Duration)
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid route write timeout %q, expected <METHOD> <path>=<duration>", entry)
+		}
+
+		fields := strings.Fields(entry[:i])
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid route %q, expected <METHOD> <path>", entry[:i])
+		}
+
+		timeout, err := time.ParseDuration(strings.TrimSpace(entry[i+1:]))
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid write timeout in %q", entry)
+		}
+		routes[strings.ToUpper(fields[0])+" "+fields[1]] = timeout
+	}
+	return routes, nil
+}
+
+// Middleware заменяет WriteTimeout сервера для маршрутов routes: срок записи ответа
+// отсчитывается заново от начала обработки. Так долгие выгрузки и потоковые списки
+// не обрываются общим таймаутом, а остальные маршруты остаются под ним.
+func Middleware(routes map[string]time.Duration, logger *logging.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		var deadline time.Time
+		if timeout > 0 {
+			deadline = time.Now().Add(timeout)
+		}
+		err := http.NewResponseController(c.Writer).SetWriteDeadline(deadline)
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logger.Warnf("Failed to set write deadline for %s: %v", c.FullPath(), err)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package envelope
+
+import (
+	"github.com/gin-gonic/gin"
+	"rest-api-tutorial/pkg/errors"
+)
+
+// Resource ответ API v2 с одним ресурсом
+type Resource[T any] struct {
+	Data T `json:"data"`
+}
+
+// Collection ответ API v2 со списком ресурсов
+type Collection[T any] struct {
+	Data []T  `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// Meta сведения о списке
+type Meta struct {
+	// Количество элементов в data
+	Count int `json:"count"`
+}
+
+// ErrorResponse ошибка API v2; формат общий с middleware
+type ErrorResponse = errors.ErrorResponse
+
+// Data отвечает ресурсом в конверте {"data": ...}
+func Data[T any](c *gin.Context, status int, v T) {
+	c.JSON(status, Resource[T]{Data: v})
+}
+
+// List отвечает списком в конверте {"data": [...], "meta": {...}}. nil отдается пустым массивом.
+func List[T any](c *gin.Context, status int, items []T) {
+	if items == nil {
+		items = make([]T, 0)
+	}
+	c.JSON(status, Collection[T]{Data: items, Meta: Meta{Count: len(items)}})
+}
+
+// Error отвечает ошибкой в общем формате errors.ErrorResponse, как и middleware
+func Error(c *gin.Context, status int, message string) {
+	c.JSON(status, ErrorResponse{Code: status, Message: message})
+}
@@ -0,0 +1,453 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"io"
+	"mime"
+	"net/http"
+	"rest-api-tutorial/pkg/envelope"
+	"strconv"
+	"strings"
+)
+
+// Форматы ответа, которые можно запросить через Accept
+const (
+	JSON    = "application/json"
+	MsgPack = "application/msgpack"
+	CSV     = "text/csv"
+	NDJSON  = "application/x-ndjson"
+)
+
+// aliases другие названия тех же форматов, встречающиеся в Accept
+var aliases = map[string]string{
+	"application/json":         JSON,
+	"application/x-msgpack":    MsgPack,
+	"application/msgpack":      MsgPack,
+	"application/vnd.msgpack":  MsgPack,
+	"text/csv":                 CSV,
+	"application/x-ndjson":     NDJSON,
+	"application/ndjson":       NDJSON,
+	"application/jsonl":        NDJSON,
+	"application/json-seq":     "",
+	"application/*":            JSON,
+	"*/*":                      JSON,
+	"application/problem+json": JSON,
+}
+
+// ndjsonFlushEvery через сколько строк NDJSON ответ отправляется клиенту
+const ndjsonFlushEvery = 100
+
+// errTooLarge перехваченный ответ превысил Options.MaxBufferBytes
+var errTooLarge = errors.New("response exceeds the transcoding buffer")
+
+// Options настройки Middleware
+type Options struct {
+	// MaxBufferBytes предел перехваченного JSON-ответа; 0 — без предела.
+	// Ответ больше предела не перекодируется, клиент получает 406.
+	MaxBufferBytes int64
+}
+
+// errNotList ответ не является списком, поэтому его нельзя отдать как CSV или NDJSON
+var errNotList = errors.New("response is not a list")
+
+// Negotiate выбирает формат ответа по Accept: наибольший q, при равном q — порядок
+// в заголовке. Vendor-типы с суффиксом +json считаются JSON. Без Accept или если
+// ни один тип не поддерживается, ответ остается в JSON.
+func Negotiate(accept string) string {
+	best, bestQ := JSON, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := aliases[mediaType]
+		if !ok && strings.HasSuffix(mediaType, "+json") {
+			format, ok = JSON, true
+		}
+		if !ok || format == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > bestQ && q > 0 {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// Middleware перекодирует JSON-ответы обработчиков в формат из Accept:
+// MessagePack — любой ответ, CSV и NDJSON — списки (массив или конверт {"data": [...]}).
+// Обработчики продолжают вызывать c.JSON. Ответы других типов (экспорт, SSE)
+// и ответы с ошибкой в CSV и NDJSON проходят без изменений.
+// CSV и NDJSON для ответа, который не является списком, дают 406; чтобы не
+// выполнять изменение, ответ на которое нельзя отдать, такие запросы разрешены только для GET.
+// Перекодируемый ответ собирается в памяти, поэтому его размер ограничен opts.MaxBufferBytes;
+// большие списки CSV и NDJSON отдаются потоком обработчиками (пакет stream) и сюда не попадают.
+func Middleware(opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := Negotiate(c.GetHeader("Accept"))
+		if format == JSON {
+			c.Next()
+			return
+		}
+		if format != MsgPack && c.Request.Method != http.MethodGet {
+			envelope.Error(c, http.StatusNotAcceptable, fmt.Sprintf("%s is available only for lists", format))
+			c.Abort()
+			return
+		}
+
+		buf := &buffer{ResponseWriter: c.Writer, limit: opts.MaxBufferBytes}
+		c.Writer = buf
+		c.Next()
+		c.Writer = buf.ResponseWriter
+
+		if !buf.capturing {
+			return
+		}
+		if buf.overflow {
+			c.Writer.Header().Del("Content-Type")
+			envelope.Error(c, http.StatusNotAcceptable, fmt.Sprintf("%s is available for responses up to %d bytes, request application/json", format, opts.MaxBufferBytes))
+			return
+		}
+		status := buf.Status()
+		// Ошибки остаются в JSON: клиент CSV все равно не сможет их разобрать
+		if format != MsgPack && (status < 200 || status > 299) {
+			buf.flushJSON()
+			return
+		}
+		if err := transcode(c, format, status, buf.body.Bytes()); err != nil {
+			if errors.Is(err, errNotList) {
+				c.Writer.Header().Del("Content-Type")
+				envelope.Error(c, http.StatusNotAcceptable, fmt.Sprintf("%s is available only for lists", format))
+				return
+			}
+			buf.flushJSON()
+		}
+	}
+}
+
+func transcode(c *gin.Context, format string, status int, body []byte) error {
+	// Content-Type перехваченного JSON заменяется типом нового формата
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Add("Vary", "Accept")
+	switch format {
+	case MsgPack:
+		value, err := decode(body)
+		if err != nil {
+			return err
+		}
+		c.Render(status, render.MsgPack{Data: value})
+		return nil
+	case CSV:
+		items, err := listItems(body)
+		if err != nil {
+			return err
+		}
+		c.Status(status)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		return writeCSV(c.Writer, items)
+	default:
+		items, err := listItems(body)
+		if err != nil {
+			return err
+		}
+		c.Status(status)
+		c.Header("Content-Type", NDJSON)
+		for i, item := range items {
+			var line bytes.Buffer
+			if err := json.Compact(&line, item); err != nil {
+				return err
+			}
+			line.WriteByte('\n')
+			if _, err := c.Writer.Write(line.Bytes()); err != nil {
+				return nil
+			}
+			if (i+1)%ndjsonFlushEvery == 0 {
+				c.Writer.Flush()
+			}
+		}
+		return nil
+	}
+}
+
+// listItems достает элементы списка из массива или конверта {"data": [...]}
+func listItems(body []byte) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err == nil {
+		return items, nil
+	}
+	var wrapped struct {
+		Data *[]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err != nil || wrapped.Data == nil {
+		return nil, errNotList
+	}
+	return *wrapped.Data, nil
+}
+
+// writeCSV пишет объекты списка строками CSV. Колонки идут в порядке полей первого
+// объекта, новые поля следующих объектов добавляются в конец. Вложенные массивы
+// и объекты записываются как JSON, null — пустой ячейкой.
+func writeCSV(w io.Writer, items []json.RawMessage) error {
+	rows := make([]map[string]json.RawMessage, 0, len(items))
+	var columns []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		keys, values, err := orderedObject(item)
+		if err != nil {
+			return errNotList
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+		rows = append(rows, values)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = cell(row[column])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// CSVWriter пишет объекты списка строками CSV по одному, не собирая список в памяти.
+// Колонки берутся из первого объекта, поля, которых в нем не было, пропускаются:
+// в отличие от Middleware, потоку неизвестны следующие объекты.
+type CSVWriter struct {
+	cw      *csv.Writer
+	columns []string
+	record  []string
+}
+
+// NewCSVWriter создает CSVWriter поверх w
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{cw: csv.NewWriter(w)}
+}
+
+// Write пишет объект item строкой CSV, перед первой строкой — заголовок
+func (w *CSVWriter) Write(item json.RawMessage) error {
+	keys, values, err := orderedObject(item)
+	if err != nil {
+		return errNotList
+	}
+	if w.columns == nil {
+		w.columns = keys
+		w.record = make([]string, len(keys))
+		if err := w.cw.Write(w.columns); err != nil {
+			return err
+		}
+	}
+	for i, column := range w.columns {
+		w.record[i] = cell(values[column])
+	}
+	return w.cw.Write(w.record)
+}
+
+// Flush отправляет накопленные строки в нижележащий io.Writer
+func (w *CSVWriter) Flush() error {
+	w.cw.Flush()
+	return w.cw.Error()
+}
+
+// orderedObject разбирает JSON-объект, сохраняя порядок полей
+func orderedObject(raw json.RawMessage) ([]string, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, errNotList
+	}
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values, nil
+}
+
+func cell(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return string(raw)
+	}
+	return compact.String()
+}
+
+// decode разбирает JSON для MessagePack: целые числа остаются целыми
+func decode(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return numbers(value), nil
+}
+
+func numbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	}
+	return value
+}
+
+// buffer перехватывает JSON-ответ, остальные типы пропускает клиенту без изменений
+type buffer struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int64
+	overflow  bool
+	status    int
+	decided   bool
+	capturing bool
+}
+
+func (b *buffer) WriteHeader(code int) {
+	b.status = code
+	if b.decided && !b.capturing {
+		b.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (b *buffer) WriteHeaderNow() {
+	b.decide()
+	if !b.capturing {
+		b.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (b *buffer) Write(data []byte) (int, error) {
+	b.decide()
+	if b.capturing {
+		if err := b.reserve(len(data)); err != nil {
+			return 0, err
+		}
+		return b.body.Write(data)
+	}
+	return b.ResponseWriter.Write(data)
+}
+
+func (b *buffer) WriteString(s string) (int, error) {
+	b.decide()
+	if b.capturing {
+		if err := b.reserve(len(s)); err != nil {
+			return 0, err
+		}
+		return b.body.WriteString(s)
+	}
+	return b.ResponseWriter.WriteString(s)
+}
+
+func (b *buffer) Flush() {
+	b.decide()
+	if !b.capturing {
+		b.ResponseWriter.Flush()
+	}
+}
+
+func (b *buffer) Status() int {
+	if b.status != 0 {
+		return b.status
+	}
+	return b.ResponseWriter.Status()
+}
+
+func (b *buffer) Written() bool {
+	return b.decided || b.ResponseWriter.Written()
+}
+
+func (b *buffer) Size() int {
+	if b.capturing {
+		return b.body.Len()
+	}
+	return b.ResponseWriter.Size()
+}
+
+// reserve проверяет, помещаются ли еще n байт в буфер. После превышения предела
+// собранное отбрасывается, а запись возвращает ошибку, чтобы обработчик остановился.
+func (b *buffer) reserve(n int) error {
+	if b.overflow {
+		return errTooLarge
+	}
+	if b.limit > 0 && int64(b.body.Len()+n) > b.limit {
+		b.overflow = true
+		b.body = bytes.Buffer{}
+		return errTooLarge
+	}
+	return nil
+}
+
+// decide при первой записи определяет по Content-Type, перехватывать ли ответ
+func (b *buffer) decide() {
+	if b.decided {
+		return
+	}
+	b.decided = true
+	mediaType, _, _ := mime.ParseMediaType(b.Header().Get("Content-Type"))
+	b.capturing = mediaType == JSON
+	if !b.capturing && b.status != 0 {
+		b.ResponseWriter.WriteHeader(b.status)
+	}
+}
+
+// flushJSON отправляет перехваченный JSON без изменений
+func (b *buffer) flushJSON() {
+	b.ResponseWriter.WriteHeader(b.Status())
+	b.ResponseWriter.Write(b.body.Bytes())
+}
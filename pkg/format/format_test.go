@@ -0,0 +1,89 @@
+package format
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", JSON},
+		{"*/*", JSON},
+		{"application/json", JSON},
+		{"application/vnd.api+json", JSON},
+		{"text/csv", CSV},
+		{"application/x-msgpack", MsgPack},
+		{"application/jsonl", NDJSON},
+		{"text/csv;q=0.5, application/msgpack", MsgPack},
+		// При равном q побеждает порядок в заголовке
+		{"text/csv, application/x-ndjson", CSV},
+		{"text/csv;q=0, application/json", JSON},
+		{"application/xml", JSON},
+		{"application/json-seq", JSON},
+		{"text/csv;q=abc", CSV},
+		{"not a media type, text/csv", CSV},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.accept); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestMiddlewareBufferLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(Options{MaxBufferBytes: 64}))
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, []gin.H{{"title": "Сталкер"}})
+	})
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, []gin.H{{"title": strings.Repeat("x", 100)}})
+	})
+
+	tests := []struct {
+		path, accept string
+		want         int
+	}{
+		{"/small", CSV, http.StatusOK},
+		{"/large", CSV, http.StatusNotAcceptable},
+		{"/large", MsgPack, http.StatusNotAcceptable},
+		// JSON не перекодируется и не ограничивается
+		{"/large", JSON, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("GET %s as %s: status %d, want %d: %s", tt.path, tt.accept, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var out strings.Builder
+	w := NewCSVWriter(&out)
+	for _, item := range []string{`{"title": "Сталкер", "rating": 8.1}`, `{"rating": null, "title": "Солярис", "extra": 1}`} {
+		if err := w.Write([]byte(item)); err != nil {
+			t.Fatalf("Write(%s): %v", item, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	want := "title,rating\nСталкер,8.1\nСолярис,\n"
+	if out.String() != want {
+		t.Errorf("CSV = %q, want %q", out.String(), want)
+	}
+	if err := w.Write([]byte(`[1]`)); err == nil {
+		t.Error("Write of a non-object must fail")
+	}
+}
@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer сохраняет письма вместо отправки: каждое письмо — отдельный файл .eml
+// в каталоге или запись в поток (например os.Stdout). Файлы открываются любым
+// почтовым клиентом, а тесты читают из них ссылки с токенами.
+type FileMailer struct {
+	from *mail.Address
+	dir  string
+
+	mu  sync.Mutex
+	out io.Writer
+	seq atomic.Int64
+}
+
+// NewFileMailer пишет письма в каталог dir, создавая его при необходимости
+func NewFileMailer(from, dir string) (*FileMailer, error) {
+	addr, err := ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileMailer{from: addr, dir: dir}, nil
+}
+
+// NewWriterMailer пишет письма одно за другим в out
+func NewWriterMailer(from string, out io.Writer) (*FileMailer, error) {
+	addr, err := ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	return &FileMailer{from: addr, out: out}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	data, err := compose(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if m.out != nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, err := fmt.Fprintf(m.out, "%s\r\n\r\n", data); err != nil {
+			return fmt.Errorf("failed to write message: %w", err)
+		}
+		return nil
+	}
+
+	// Имя начинается со времени, поэтому файлы сортируются в порядке отправки
+	name := time.Now().UTC().Format("20060102T150405.000000000") + "-" + strconv.FormatInt(m.seq.Add(1), 10) + ".eml"
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message письмо в одну текстовую часть
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer отправляет письма. Реализации: SMTPMailer для почтового сервера,
+// FileMailer для локальной разработки и тестов.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ParseAddress проверяет адрес отправителя или получателя вида
+// "name@example.com" или "Movies <name@example.com>"
+func ParseAddress(address string) (*mail.Address, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address %q: %w", address, err)
+	}
+	return addr, nil
+}
+
+// compose собирает письмо в формате RFC 5322. Тема кодируется по RFC 2047,
+// текст — quoted-printable, поэтому кириллица проходит через любой сервер.
+func compose(from *mail.Address, msg Message) ([]byte, error) {
+	to, err := ParseAddress(msg.To)
+	if err != nil {
+		return nil, err
+	}
+	id, err := messageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", id)
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	text := strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := body.Write([]byte(text)); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := body.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func messageID(from string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
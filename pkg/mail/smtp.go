@@ -0,0 +1,107 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPOptions параметры почтового сервера
+type SMTPOptions struct {
+	Host string
+	Port string
+	// Username и Password для AUTH PLAIN; пустой Username — без аутентификации
+	Username string
+	Password string
+	// Timeout ограничивает отправку одного письма, если у контекста нет своего срока
+	Timeout time.Duration
+}
+
+// SMTPMailer отправляет письма через SMTP. Если сервер поддерживает STARTTLS,
+// соединение шифруется до аутентификации.
+type SMTPMailer struct {
+	from *mail.Address
+	opts SMTPOptions
+}
+
+func NewSMTPMailer(from string, opts SMTPOptions) (*SMTPMailer, error) {
+	addr, err := ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if opts.Timeout <= 0 {
+		return nil, fmt.Errorf("smtp timeout must be positive, got %s", opts.Timeout)
+	}
+	return &SMTPMailer{from: addr, opts: opts}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+	data, err := compose(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.opts.Timeout)
+		defer cancel()
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(m.opts.Host, m.opts.Port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set smtp deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, m.opts.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.opts.Host}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if m.opts.Username != "" {
+		auth := smtp.PlainAuth("", m.opts.Username, m.opts.Password, m.opts.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("smtp server rejected sender: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp server rejected recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,154 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidToken подпись или содержимое ID token не прошли проверку
+var ErrInvalidToken = errors.New("invalid id token")
+
+// jwk открытый ключ из JWKS провайдера (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// publicKey переводит JWK в ключ crypto. Поддерживаются RSA и EC P-256.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa modulus: %w", err)
+		}
+		if len(n) < 256 {
+			return nil, errors.New("rsa key is shorter than 2048 bits")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid ec point")
+		}
+		// ecdh проверяет, что точка лежит на кривой
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("invalid ec point: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// parseJWT разбирает компактный JWS на заголовок, полезную нагрузку, подпись
+// и подписанную часть
+func parseJWT(raw string) (header jwtHeader, payload, signature []byte, signed string, err error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return header, nil, nil, "", fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return header, nil, nil, "", fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return header, nil, nil, "", fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if payload, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return header, nil, nil, "", fmt.Errorf("%w: malformed payload", ErrInvalidToken)
+	}
+	if signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return header, nil, nil, "", fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	return header, payload, signature, parts[0] + "." + parts[1], nil
+}
+
+// verifySignature проверяет подпись RS256 или ES256. Алгоритм берется из заголовка
+// токена, но должен соответствовать типу ключа: none и HMAC не принимаются.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	hash := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match algorithm %s", ErrInvalidToken, alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], signature); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return fmt.Errorf("%w: key does not match algorithm %s", ErrInvalidToken, alg)
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, hash[:], r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+}
+
+// audience поле aud: строка или массив строк
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
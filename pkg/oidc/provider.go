@@ -0,0 +1,338 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrProviderUnavailable провайдер не ответил или ответил не по протоколу
+var ErrProviderUnavailable = errors.New("identity provider unavailable")
+
+const (
+	// clockSkew допустимое расхождение часов с провайдером при проверке exp и iat
+	clockSkew = time.Minute
+	// jwksRefreshInterval не чаще этого интервала JWKS перечитывается из-за неизвестного kid
+	jwksRefreshInterval = time.Minute
+	// maxResponseBytes ограничение ответа провайдера
+	maxResponseBytes = 1 << 20
+)
+
+// Config регистрация клиента у провайдера
+type Config struct {
+	// Issuer идентификатор провайдера; метаданные читаются из Issuer/.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL адрес callback приложения, зарегистрированный у провайдера
+	RedirectURL string
+	// Scopes запрашиваемые области; openid добавляется всегда
+	Scopes []string
+}
+
+// Claims утверждения о пользователе из проверенного ID token
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// Birthdate дата рождения YYYY-MM-DD (стандартное утверждение birthdate)
+	Birthdate string
+	// Gender стандартное утверждение gender: female, male или другое значение провайдера
+	Gender string
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider клиент OpenID Connect для одного провайдера: поток authorization code с PKCE.
+// Метаданные и ключи читаются при первом обращении и кешируются, поэтому
+// недоступный провайдер не мешает запуску приложения.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	return &Provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL адрес страницы входа провайдера с state, nonce и code_challenge (S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	scopes := []string{"openid"}
+	for _, scope := range p.cfg.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return md.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на токены и возвращает ID token
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret == "" {
+		// Публичный клиент: секрета нет, защищает только PKCE
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &body)
+	if err != nil {
+		return "", err
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("token endpoint returned %s: %s", body.Error, body.ErrorDescription)
+	}
+	if status != http.StatusOK || body.IDToken == "" {
+		return "", fmt.Errorf("%w: token endpoint returned status %d without id_token", ErrProviderUnavailable, status)
+	}
+	return body.IDToken, nil
+}
+
+// VerifyIDToken проверяет подпись ID token ключами провайдера, издателя, получателя,
+// срок действия и nonce запроса входа
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	header, payload, signature, signed, err := parseJWT(raw)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, signed, signature); err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Issuer        string   `json:"iss"`
+		Subject       string   `json:"sub"`
+		Audience      audience `json:"aud"`
+		AuthorizedBy  string   `json:"azp"`
+		ExpiresAt     int64    `json:"exp"`
+		IssuedAt      int64    `json:"iat"`
+		Nonce         string   `json:"nonce"`
+		Email         string   `json:"email"`
+		EmailVerified flexBool `json:"email_verified"`
+		Name          string   `json:"name"`
+		Birthdate     string   `json:"birthdate"`
+		Gender        string   `json:"gender"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	now := time.Now()
+	switch {
+	case claims.Issuer != p.cfg.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	case !claims.Audience.contains(p.cfg.ClientID):
+		return nil, fmt.Errorf("%w: token is not issued for this client", ErrInvalidToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedBy != p.cfg.ClientID:
+		return nil, fmt.Errorf("%w: token is authorized for another client", ErrInvalidToken)
+	case claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)):
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	case claims.IssuedAt != 0 && time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)):
+		return nil, fmt.Errorf("%w: token issued in the future", ErrInvalidToken)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &Claims{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Birthdate:     claims.Birthdate,
+		Gender:        claims.Gender,
+	}, nil
+}
+
+// discover читает метаданные провайдера при первом обращении
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	md := p.metadata
+	p.mu.Unlock()
+	if md != nil {
+		return md, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	md = &metadata{}
+	status, err := p.doJSON(req, md)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery returned status %d", ErrProviderUnavailable, status)
+	}
+	if md.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match configured issuer %q", md.Issuer, p.cfg.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document is missing endpoints", ErrProviderUnavailable)
+	}
+
+	p.mu.Lock()
+	p.metadata = md
+	p.mu.Unlock()
+	return md, nil
+}
+
+// key возвращает ключ подписи kid. Неизвестный kid перечитывает JWKS: провайдер
+// мог сменить ключи.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.lookupKey(kid)
+	stale := time.Since(p.keysFetchedAt) >= jwksRefreshInterval
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+	}
+
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, md.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build jwks request: %w", err)
+	}
+	var set jwks
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: jwks returned status %d", ErrProviderUnavailable, status)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// Ключи неподдерживаемых типов пропускаются: провайдер может публиковать разные
+		if pub, err := k.publicKey(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+}
+
+// lookupKey ищет ключ по kid. Токен без kid подходит, только если ключ один.
+func (p *Provider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// doJSON выполняет запрос и разбирает JSON-ответ в v. Возвращает код ответа.
+func (p *Provider) doJSON(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(v); err != nil {
+		return resp.StatusCode, fmt.Errorf("%w: status %d with invalid JSON response", ErrProviderUnavailable, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// flexBool булево утверждение, которое часть провайдеров передает строкой "true"
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		*b = flexBool(v == "true")
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// Version версия OpenAPI, в которую конвертируется спецификация
+const Version = "3.1.0"
+
+// ErrUnsupportedVersion на вход передана не спецификация Swagger 2.0
+var ErrUnsupportedVersion = errors.New("expected a swagger 2.0 document")
+
+const (
+	swaggerRefPrefix = "#/definitions/"
+	openapiRefPrefix = "#/components/schemas/"
+)
+
+// Document документ OpenAPI в виде дерева JSON-значений
+type Document map[string]interface{}
+
+// JSON кодирует документ в JSON с отступами
+func (d Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "    ")
+}
+
+// YAML кодирует документ в YAML
+func (d Document) YAML() ([]byte, error) {
+	return yaml.Marshal(map[string]interface{}(d))
+}
+
+// Convert переводит спецификацию Swagger 2.0, которую генерирует swag, в OpenAPI 3.1:
+// host/basePath/schemes становятся servers, body-параметры — requestBody,
+// definitions и securityDefinitions переезжают в components, а x-nullable
+// заменяется на тип с "null".
+func Convert(swagger []byte) (Document, error) {
+	var src map[string]interface{}
+	if err := json.Unmarshal(swagger, &src); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger: %w", err)
+	}
+	if src["swagger"] != "2.0" {
+		return nil, ErrUnsupportedVersion
+	}
+
+	doc := Document{
+		"openapi": Version,
+		"info":    src["info"],
+		"servers": servers(src),
+		"paths":   map[string]interface{}{},
+	}
+	copyKeys(doc, src, "tags", "externalDocs", "security")
+
+	globalConsumes := stringList(src["consumes"])
+	globalProduces := stringList(src["produces"])
+
+	paths := doc["paths"].(map[string]interface{})
+	for path, rawItem := range object(src["paths"]) {
+		item := object(rawItem)
+		out := make(map[string]interface{}, len(item))
+		shared := list(item["parameters"])
+
+		for method, rawOp := range item {
+			if method == "parameters" {
+				continue
+			}
+			if strings.HasPrefix(method, "x-") || method == "$ref" {
+				out[method] = rawOp
+				continue
+			}
+			out[method] = convertOperation(object(rawOp), shared, globalConsumes, globalProduces)
+		}
+		paths[path] = out
+	}
+
+	components := map[string]interface{}{}
+	if definitions := object(src["definitions"]); len(definitions) > 0 {
+		schemas := make(map[string]interface{}, len(definitions))
+		for name, schema := range definitions {
+			schemas[name] = convertSchema(schema)
+		}
+		components["schemas"] = schemas
+	}
+	if definitions := object(src["securityDefinitions"]); len(definitions) > 0 {
+		schemes := make(map[string]interface{}, len(definitions))
+		for name, scheme := range definitions {
+			schemes[name] = convertSecurityScheme(object(scheme))
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+
+	return doc, nil
+}
+
+func servers(src map[string]interface{}) []interface{} {
+	host, _ := src["host"].(string)
+	basePath, _ := src["basePath"].(string)
+	if host == "" {
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+
+	schemes := stringList(src["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"http"}
+	}
+	out := make([]interface{}, 0, len(schemes))
+	for _, scheme := range schemes {
+		out = append(out, map[string]interface{}{"url": scheme + "://" + host + basePath})
+	}
+	return out
+}
+
+func convertOperation(op map[string]interface{}, shared []interface{}, globalConsumes, globalProduces []string) map[string]interface{} {
+	out := map[string]interface{}{}
+	copyKeys(out, op, "tags", "summary", "description", "externalDocs", "operationId", "deprecated", "security")
+	for key, value := range op {
+		if strings.HasPrefix(key, "x-") {
+			out[key] = value
+		}
+	}
+
+	consumes := stringList(op["consumes"])
+	if len(consumes) == 0 {
+		consumes = globalConsumes
+	}
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	produces := stringList(op["produces"])
+	if len(produces) == 0 {
+		produces = globalProduces
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	var params []interface{}
+	for _, raw := range append(append([]interface{}{}, shared...), list(op["parameters"])...) {
+		param := object(raw)
+		if param["in"] == "body" {
+			out["requestBody"] = convertBody(param, consumes)
+			continue
+		}
+		params = append(params, convertParameter(param))
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	responses := map[string]interface{}{}
+	for code, raw := range object(op["responses"]) {
+		responses[code] = convertResponse(object(raw), produces)
+	}
+	out["responses"] = responses
+	return out
+}
+
+func convertBody(param map[string]interface{}, consumes []string) map[string]interface{} {
+	schema := convertSchema(param["schema"])
+	content := make(map[string]interface{}, len(consumes))
+	for _, mediaType := range consumes {
+		content[mediaType] = map[string]interface{}{"schema": schema}
+	}
+
+	body := map[string]interface{}{"content": content}
+	copyKeys(body, param, "description", "required")
+	return body
+}
+
+// schemaKeys поля параметра Swagger 2.0, которые в OpenAPI 3 переезжают в schema
+var schemaKeys = []string{
+	"type", "format", "items", "enum", "default",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+	"minLength", "maxLength", "pattern", "minItems", "maxItems", "uniqueItems", "multipleOf",
+}
+
+func convertParameter(param map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	copyKeys(out, param, "name", "in", "description", "required", "allowEmptyValue")
+	if param["in"] == "path" {
+		out["required"] = true
+	}
+
+	schema := map[string]interface{}{}
+	copyKeys(schema, param, schemaKeys...)
+	if param["type"] == "array" && param["collectionFormat"] == "multi" {
+		out["explode"] = true
+	}
+	out["schema"] = convertSchema(schema)
+	return out
+}
+
+func convertResponse(resp map[string]interface{}, produces []string) map[string]interface{} {
+	out := map[string]interface{}{"description": resp["description"]}
+
+	if schema, ok := resp["schema"]; ok {
+		converted := convertSchema(schema)
+		content := make(map[string]interface{}, len(produces))
+		for _, mediaType := range produces {
+			content[mediaType] = map[string]interface{}{"schema": converted}
+		}
+		out["content"] = content
+	}
+
+	if headers := object(resp["headers"]); len(headers) > 0 {
+		converted := make(map[string]interface{}, len(headers))
+		for name, raw := range headers {
+			header := object(raw)
+			h := map[string]interface{}{}
+			copyKeys(h, header, "description")
+			schema := map[string]interface{}{}
+			copyKeys(schema, header, schemaKeys...)
+			h["schema"] = convertSchema(schema)
+			converted[name] = h
+		}
+		out["headers"] = converted
+	}
+	return out
+}
+
+// convertSchema переписывает ссылки на definitions и x-nullable во всем дереве схемы
+func convertSchema(raw interface{}) interface{} {
+	switch v := raw.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			switch key {
+			case "$ref":
+				ref, _ := value.(string)
+				out[key] = strings.Replace(ref, swaggerRefPrefix, openapiRefPrefix, 1)
+			case "x-nullable":
+			default:
+				out[key] = convertSchema(value)
+			}
+		}
+		if nullable, _ := v["x-nullable"].(bool); nullable {
+			if t, ok := v["type"].(string); ok {
+				out["type"] = []interface{}{t, "null"}
+			} else {
+				out = map[string]interface{}{"anyOf": []interface{}{out, map[string]interface{}{"type": "null"}}}
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = convertSchema(value)
+		}
+		return out
+	default:
+		return raw
+	}
+}
+
+func convertSecurityScheme(scheme map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	copyKeys(out, scheme, "description")
+
+	switch scheme["type"] {
+	case "basic":
+		out["type"] = "http"
+		out["scheme"] = "basic"
+	case "oauth2":
+		out["type"] = "oauth2"
+		flow := map[string]interface{}{"scopes": scheme["scopes"]}
+		copyKeys(flow, scheme, "authorizationUrl", "tokenUrl")
+		if flow["scopes"] == nil {
+			flow["scopes"] = map[string]interface{}{}
+		}
+		name := map[string]string{
+			"implicit":    "implicit",
+			"password":    "password",
+			"application": "clientCredentials",
+			"accessCode":  "authorizationCode",
+		}[fmt.Sprint(scheme["flow"])]
+		out["flows"] = map[string]interface{}{name: flow}
+	default:
+		copyKeys(out, scheme, "type", "name", "in")
+	}
+	return out
+}
+
+func copyKeys(dst, src map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		if value, ok := src[key]; ok {
+			dst[key] = value
+		}
+	}
+}
+
+func object(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func list(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+func stringList(v interface{}) []string {
+	var out []string
+	for _, item := range list(v) {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package openapi
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/swaggest/swgui"
+	"github.com/swaggest/swgui/v5emb"
+	"net/http"
+	"strings"
+)
+
+// Spec спецификация API во всех отдаваемых представлениях. Конвертация
+// выполняется один раз при старте, обработчики отдают готовые байты.
+type Spec struct {
+	swagger []byte
+	json    []byte
+	yaml    []byte
+}
+
+// NewSpec готовит OpenAPI 3.1 в JSON и YAML из спецификации Swagger 2.0
+func NewSpec(swagger []byte) (*Spec, error) {
+	doc, err := Convert(swagger)
+	if err != nil {
+		return nil, err
+	}
+	jsonDoc, err := doc.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi json: %w", err)
+	}
+	yamlDoc, err := doc.YAML()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi yaml: %w", err)
+	}
+	return &Spec{swagger: swagger, json: jsonDoc, yaml: yamlDoc}, nil
+}
+
+// Register подключает /openapi.json, /openapi.yaml, исходный /swagger.json
+// и Swagger UI по пути uiPath. Статика UI встроена в бинарник и не требует сети.
+func (s *Spec) Register(router gin.IRoutes, title, uiPath string) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", s.json)
+	})
+	router.GET("/openapi.yaml", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/yaml; charset=utf-8", s.yaml)
+	})
+	router.GET("/swagger.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", s.swagger)
+	})
+
+	uiPath = strings.TrimSuffix(uiPath, "/")
+	ui := v5emb.NewWithConfig(swgui.Config{
+		Title:       title,
+		SwaggerJSON: "/openapi.json",
+		BasePath:    uiPath + "/",
+		ShowTopBar:  true,
+	})(title, "/openapi.json", uiPath+"/")
+	router.GET(uiPath, func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, uiPath+"/")
+	})
+	router.GET(uiPath+"/*any", gin.WrapH(ui))
+}
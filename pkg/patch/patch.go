@@ -0,0 +1,82 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// MIMEJSONPatch тип содержимого запроса с JSON Patch (RFC 6902)
+const MIMEJSONPatch = "application/json-patch+json"
+
+// MaxBodyBytes предел документа патча: патч меняет один ресурс, мегабайта хватает с запасом
+const MaxBodyBytes = 1 << 20
+
+var (
+	// ErrInvalidPatch документ патча не удалось разобрать или применить
+	ErrInvalidPatch = errors.New("invalid json patch")
+	// ErrTestFailed операция test не совпала с текущим состоянием ресурса
+	ErrTestFailed = errors.New("json patch test operation failed")
+	// ErrTooLarge документ патча больше MaxBodyBytes
+	ErrTooLarge = errors.New("json patch document is too large")
+	// ErrValidation результат применения патча не прошел валидацию модели
+	ErrValidation = errors.New("patched model is invalid")
+)
+
+// Read читает документ патча из тела запроса, не больше MaxBodyBytes
+func Read(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrTooLarge, maxBytesErr.Limit)
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+// Decode разбирает тело запроса в патч, не применяя его
+func Decode(body []byte) (jsonpatch.Patch, error) {
+	p, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return p, nil
+}
+
+// Apply применяет патч к модели: модель сериализуется в JSON,
+// патч применяется к документу, результат декодируется обратно в модель
+// и проверяется теми же правилами binding, что и тело обычного запроса.
+func Apply(p jsonpatch.Patch, model interface{}) error {
+	doc, err := json.Marshal(model)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model: %w", err)
+	}
+
+	patched, err := p.Apply(doc)
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return fmt.Errorf("%w: %v", ErrTestFailed, err)
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	// Обнуляем модель, чтобы удаленные патчем поля не сохранили старые значения
+	v := reflect.ValueOf(model).Elem()
+	v.Set(reflect.Zero(v.Type()))
+
+	if err := json.Unmarshal(patched, model); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	if err := binding.Validator.ValidateStruct(model); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	return nil
+}
@@ -0,0 +1,632 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: movies/v1/films.proto
+
+package moviesv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Film struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// UUID фильма, назначается сервером
+	Id          string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title       string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	// Рейтинг от 0 до 10
+	Rating        float64                `protobuf:"fixed64,4,opt,name=rating,proto3" json:"rating,omitempty"`
+	ReleaseDate   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=release_date,json=releaseDate,proto3" json:"release_date,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Film) Reset() {
+	*x = Film{}
+	mi := &file_movies_v1_films_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Film) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Film) ProtoMessage() {}
+
+func (x *Film) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Film.ProtoReflect.Descriptor instead.
+func (*Film) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Film) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Film) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Film) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Film) GetRating() float64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *Film) GetReleaseDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReleaseDate
+	}
+	return nil
+}
+
+func (x *Film) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Film) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// FilmUpdate частичное обновление: меняются только заданные поля
+type FilmUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         *string                `protobuf:"bytes,1,opt,name=title,proto3,oneof" json:"title,omitempty"`
+	Description   *string                `protobuf:"bytes,2,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Rating        *float64               `protobuf:"fixed64,3,opt,name=rating,proto3,oneof" json:"rating,omitempty"`
+	ReleaseDate   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=release_date,json=releaseDate,proto3" json:"release_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilmUpdate) Reset() {
+	*x = FilmUpdate{}
+	mi := &file_movies_v1_films_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilmUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilmUpdate) ProtoMessage() {}
+
+func (x *FilmUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilmUpdate.ProtoReflect.Descriptor instead.
+func (*FilmUpdate) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FilmUpdate) GetTitle() string {
+	if x != nil && x.Title != nil {
+		return *x.Title
+	}
+	return ""
+}
+
+func (x *FilmUpdate) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *FilmUpdate) GetRating() float64 {
+	if x != nil && x.Rating != nil {
+		return *x.Rating
+	}
+	return 0
+}
+
+func (x *FilmUpdate) GetReleaseDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReleaseDate
+	}
+	return nil
+}
+
+type CreateFilmRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Film          *Film                  `protobuf:"bytes,1,opt,name=film,proto3" json:"film,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFilmRequest) Reset() {
+	*x = CreateFilmRequest{}
+	mi := &file_movies_v1_films_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFilmRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFilmRequest) ProtoMessage() {}
+
+func (x *CreateFilmRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFilmRequest.ProtoReflect.Descriptor instead.
+func (*CreateFilmRequest) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateFilmRequest) GetFilm() *Film {
+	if x != nil {
+		return x.Film
+	}
+	return nil
+}
+
+type ListFilmsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Порядок сортировки: пусто или "title" (название, рейтинг, дата выхода)
+	Sort          string `protobuf:"bytes,1,opt,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFilmsRequest) Reset() {
+	*x = ListFilmsRequest{}
+	mi := &file_movies_v1_films_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilmsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilmsRequest) ProtoMessage() {}
+
+func (x *ListFilmsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFilmsRequest.ProtoReflect.Descriptor instead.
+func (*ListFilmsRequest) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListFilmsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+type ListFilmsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Films         []*Film                `protobuf:"bytes,1,rep,name=films,proto3" json:"films,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFilmsResponse) Reset() {
+	*x = ListFilmsResponse{}
+	mi := &file_movies_v1_films_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilmsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilmsResponse) ProtoMessage() {}
+
+func (x *ListFilmsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFilmsResponse.ProtoReflect.Descriptor instead.
+func (*ListFilmsResponse) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListFilmsResponse) GetFilms() []*Film {
+	if x != nil {
+		return x.Films
+	}
+	return nil
+}
+
+type GetFilmRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFilmRequest) Reset() {
+	*x = GetFilmRequest{}
+	mi := &file_movies_v1_films_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFilmRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFilmRequest) ProtoMessage() {}
+
+func (x *GetFilmRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFilmRequest.ProtoReflect.Descriptor instead.
+func (*GetFilmRequest) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetFilmRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListUserFilmsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserFilmsRequest) Reset() {
+	*x = ListUserFilmsRequest{}
+	mi := &file_movies_v1_films_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserFilmsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserFilmsRequest) ProtoMessage() {}
+
+func (x *ListUserFilmsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserFilmsRequest.ProtoReflect.Descriptor instead.
+func (*ListUserFilmsRequest) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListUserFilmsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type PartiallyUpdateFilmRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Film          *FilmUpdate            `protobuf:"bytes,2,opt,name=film,proto3" json:"film,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartiallyUpdateFilmRequest) Reset() {
+	*x = PartiallyUpdateFilmRequest{}
+	mi := &file_movies_v1_films_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartiallyUpdateFilmRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartiallyUpdateFilmRequest) ProtoMessage() {}
+
+func (x *PartiallyUpdateFilmRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartiallyUpdateFilmRequest.ProtoReflect.Descriptor instead.
+func (*PartiallyUpdateFilmRequest) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PartiallyUpdateFilmRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PartiallyUpdateFilmRequest) GetFilm() *FilmUpdate {
+	if x != nil {
+		return x.Film
+	}
+	return nil
+}
+
+type DeleteFilmRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFilmRequest) Reset() {
+	*x = DeleteFilmRequest{}
+	mi := &file_movies_v1_films_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFilmRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFilmRequest) ProtoMessage() {}
+
+func (x *DeleteFilmRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movies_v1_films_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFilmRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFilmRequest) Descriptor() ([]byte, []int) {
+	return file_movies_v1_films_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteFilmRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_movies_v1_films_proto protoreflect.FileDescriptor
+
+const file_movies_v1_films_proto_rawDesc = "" +
+	"\n" +
+	"\x15movies/v1/films.proto\x12\tmovies.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9b\x02\n" +
+	"\x04Film\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06rating\x18\x04 \x01(\x01R\x06rating\x12=\n" +
+	"\frelease_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vreleaseDate\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xcf\x01\n" +
+	"\n" +
+	"FilmUpdate\x12\x19\n" +
+	"\x05title\x18\x01 \x01(\tH\x00R\x05title\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x02 \x01(\tH\x01R\vdescription\x88\x01\x01\x12\x1b\n" +
+	"\x06rating\x18\x03 \x01(\x01H\x02R\x06rating\x88\x01\x01\x12=\n" +
+	"\frelease_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vreleaseDateB\b\n" +
+	"\x06_titleB\x0e\n" +
+	"\f_descriptionB\t\n" +
+	"\a_rating\"8\n" +
+	"\x11CreateFilmRequest\x12#\n" +
+	"\x04film\x18\x01 \x01(\v2\x0f.movies.v1.FilmR\x04film\"&\n" +
+	"\x10ListFilmsRequest\x12\x12\n" +
+	"\x04sort\x18\x01 \x01(\tR\x04sort\":\n" +
+	"\x11ListFilmsResponse\x12%\n" +
+	"\x05films\x18\x01 \x03(\v2\x0f.movies.v1.FilmR\x05films\" \n" +
+	"\x0eGetFilmRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"/\n" +
+	"\x14ListUserFilmsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"W\n" +
+	"\x1aPartiallyUpdateFilmRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12)\n" +
+	"\x04film\x18\x02 \x01(\v2\x15.movies.v1.FilmUpdateR\x04film\"#\n" +
+	"\x11DeleteFilmRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id2\xe1\x04\n" +
+	"\vFilmService\x12X\n" +
+	"\n" +
+	"CreateFilm\x12\x1c.movies.v1.CreateFilmRequest\x1a\x0f.movies.v1.Film\"\x1b\x82\xd3\xe4\x93\x02\x15:\x04film\"\r/api/v2/films\x12]\n" +
+	"\tListFilms\x12\x1b.movies.v1.ListFilmsRequest\x1a\x1c.movies.v1.ListFilmsResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/api/v2/films\x12Q\n" +
+	"\aGetFilm\x12\x19.movies.v1.GetFilmRequest\x1a\x0f.movies.v1.Film\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/api/v2/films/{id}\x12u\n" +
+	"\rListUserFilms\x12\x1f.movies.v1.ListUserFilmsRequest\x1a\x1c.movies.v1.ListFilmsResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/api/v2/users/{user_id}/films\x12o\n" +
+	"\x13PartiallyUpdateFilm\x12%.movies.v1.PartiallyUpdateFilmRequest\x1a\x0f.movies.v1.Film\" \x82\xd3\xe4\x93\x02\x1a:\x04film2\x12/api/v2/films/{id}\x12^\n" +
+	"\n" +
+	"DeleteFilm\x12\x1c.movies.v1.DeleteFilmRequest\x1a\x16.google.protobuf.Empty\"\x1a\x82\xd3\xe4\x93\x02\x14*\x12/api/v2/films/{id}B-Z+rest-api-tutorial/pkg/pb/movies/v1;moviesv1b\x06proto3"
+
+var (
+	file_movies_v1_films_proto_rawDescOnce sync.Once
+	file_movies_v1_films_proto_rawDescData []byte
+)
+
+func file_movies_v1_films_proto_rawDescGZIP() []byte {
+	file_movies_v1_films_proto_rawDescOnce.Do(func() {
+		file_movies_v1_films_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_movies_v1_films_proto_rawDesc), len(file_movies_v1_films_proto_rawDesc)))
+	})
+	return file_movies_v1_films_proto_rawDescData
+}
+
+var file_movies_v1_films_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_movies_v1_films_proto_goTypes = []any{
+	(*Film)(nil),                       // 0: movies.v1.Film
+	(*FilmUpdate)(nil),                 // 1: movies.v1.FilmUpdate
+	(*CreateFilmRequest)(nil),          // 2: movies.v1.CreateFilmRequest
+	(*ListFilmsRequest)(nil),           // 3: movies.v1.ListFilmsRequest
+	(*ListFilmsResponse)(nil),          // 4: movies.v1.ListFilmsResponse
+	(*GetFilmRequest)(nil),             // 5: movies.v1.GetFilmRequest
+	(*ListUserFilmsRequest)(nil),       // 6: movies.v1.ListUserFilmsRequest
+	(*PartiallyUpdateFilmRequest)(nil), // 7: movies.v1.PartiallyUpdateFilmRequest
+	(*DeleteFilmRequest)(nil),          // 8: movies.v1.DeleteFilmRequest
+	(*timestamppb.Timestamp)(nil),      // 9: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),              // 10: google.protobuf.Empty
+}
+var file_movies_v1_films_proto_depIdxs = []int32{
+	9,  // 0: movies.v1.Film.release_date:type_name -> google.protobuf.Timestamp
+	9,  // 1: movies.v1.Film.created_at:type_name -> google.protobuf.Timestamp
+	9,  // 2: movies.v1.Film.updated_at:type_name -> google.protobuf.Timestamp
+	9,  // 3: movies.v1.FilmUpdate.release_date:type_name -> google.protobuf.Timestamp
+	0,  // 4: movies.v1.CreateFilmRequest.film:type_name -> movies.v1.Film
+	0,  // 5: movies.v1.ListFilmsResponse.films:type_name -> movies.v1.Film
+	1,  // 6: movies.v1.PartiallyUpdateFilmRequest.film:type_name -> movies.v1.FilmUpdate
+	2,  // 7: movies.v1.FilmService.CreateFilm:input_type -> movies.v1.CreateFilmRequest
+	3,  // 8: movies.v1.FilmService.ListFilms:input_type -> movies.v1.ListFilmsRequest
+	5,  // 9: movies.v1.FilmService.GetFilm:input_type -> movies.v1.GetFilmRequest
+	6,  // 10: movies.v1.FilmService.ListUserFilms:input_type -> movies.v1.ListUserFilmsRequest
+	7,  // 11: movies.v1.FilmService.PartiallyUpdateFilm:input_type -> movies.v1.PartiallyUpdateFilmRequest
+	8,  // 12: movies.v1.FilmService.DeleteFilm:input_type -> movies.v1.DeleteFilmRequest
+	0,  // 13: movies.v1.FilmService.CreateFilm:output_type -> movies.v1.Film
+	4,  // 14: movies.v1.FilmService.ListFilms:output_type -> movies.v1.ListFilmsResponse
+	0,  // 15: movies.v1.FilmService.GetFilm:output_type -> movies.v1.Film
+	4,  // 16: movies.v1.FilmService.ListUserFilms:output_type -> movies.v1.ListFilmsResponse
+	0,  // 17: movies.v1.FilmService.PartiallyUpdateFilm:output_type -> movies.v1.Film
+	10, // 18: movies.v1.FilmService.DeleteFilm:output_type -> google.protobuf.Empty
+	13, // [13:19] is the sub-list for method output_type
+	7,  // [7:13] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_movies_v1_films_proto_init() }
+func file_movies_v1_films_proto_init() {
+	if File_movies_v1_films_proto != nil {
+		return
+	}
+	file_movies_v1_films_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_movies_v1_films_proto_rawDesc), len(file_movies_v1_films_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_movies_v1_films_proto_goTypes,
+		DependencyIndexes: file_movies_v1_films_proto_depIdxs,
+		MessageInfos:      file_movies_v1_films_proto_msgTypes,
+	}.Build()
+	File_movies_v1_films_proto = out.File
+	file_movies_v1_films_proto_goTypes = nil
+	file_movies_v1_films_proto_depIdxs = nil
+}
@@ -0,0 +1,322 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: movies/v1/films.proto
+
+package moviesv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FilmService_CreateFilm_FullMethodName          = "/movies.v1.FilmService/CreateFilm"
+	FilmService_ListFilms_FullMethodName           = "/movies.v1.FilmService/ListFilms"
+	FilmService_GetFilm_FullMethodName             = "/movies.v1.FilmService/GetFilm"
+	FilmService_ListUserFilms_FullMethodName       = "/movies.v1.FilmService/ListUserFilms"
+	FilmService_PartiallyUpdateFilm_FullMethodName = "/movies.v1.FilmService/PartiallyUpdateFilm"
+	FilmService_DeleteFilm_FullMethodName          = "/movies.v1.FilmService/DeleteFilm"
+)
+
+// FilmServiceClient is the client API for FilmService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FilmService сервис фильмов gRPC API. Методы повторяют обработчики REST
+// (internal/films.HandlerV2), аннотации google.api.http совместимы с grpc-gateway
+// и соответствуют маршрутам /api/v2. Массовые операции (import, export, batch)
+// доступны только через REST.
+type FilmServiceClient interface {
+	CreateFilm(ctx context.Context, in *CreateFilmRequest, opts ...grpc.CallOption) (*Film, error)
+	ListFilms(ctx context.Context, in *ListFilmsRequest, opts ...grpc.CallOption) (*ListFilmsResponse, error)
+	GetFilm(ctx context.Context, in *GetFilmRequest, opts ...grpc.CallOption) (*Film, error)
+	ListUserFilms(ctx context.Context, in *ListUserFilmsRequest, opts ...grpc.CallOption) (*ListFilmsResponse, error)
+	PartiallyUpdateFilm(ctx context.Context, in *PartiallyUpdateFilmRequest, opts ...grpc.CallOption) (*Film, error)
+	DeleteFilm(ctx context.Context, in *DeleteFilmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type filmServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFilmServiceClient(cc grpc.ClientConnInterface) FilmServiceClient {
+	return &filmServiceClient{cc}
+}
+
+func (c *filmServiceClient) CreateFilm(ctx context.Context, in *CreateFilmRequest, opts ...grpc.CallOption) (*Film, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Film)
+	err := c.cc.Invoke(ctx, FilmService_CreateFilm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filmServiceClient) ListFilms(ctx context.Context, in *ListFilmsRequest, opts ...grpc.CallOption) (*ListFilmsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFilmsResponse)
+	err := c.cc.Invoke(ctx, FilmService_ListFilms_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filmServiceClient) GetFilm(ctx context.Context, in *GetFilmRequest, opts ...grpc.CallOption) (*Film, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Film)
+	err := c.cc.Invoke(ctx, FilmService_GetFilm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filmServiceClient) ListUserFilms(ctx context.Context, in *ListUserFilmsRequest, opts ...grpc.CallOption) (*ListFilmsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFilmsResponse)
+	err := c.cc.Invoke(ctx, FilmService_ListUserFilms_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filmServiceClient) PartiallyUpdateFilm(ctx context.Context, in *PartiallyUpdateFilmRequest, opts ...grpc.CallOption) (*Film, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Film)
+	err := c.cc.Invoke(ctx, FilmService_PartiallyUpdateFilm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filmServiceClient) DeleteFilm(ctx context.Context, in *DeleteFilmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, FilmService_DeleteFilm_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FilmServiceServer is the server API for FilmService service.
+// All implementations must embed UnimplementedFilmServiceServer
+// for forward compatibility.
+//
+// FilmService сервис фильмов gRPC API. Методы повторяют обработчики REST
+// (internal/films.HandlerV2), аннотации google.api.http совместимы с grpc-gateway
+// и соответствуют маршрутам /api/v2. Массовые операции (import, export, batch)
+// доступны только через REST.
+type FilmServiceServer interface {
+	CreateFilm(context.Context, *CreateFilmRequest) (*Film, error)
+	ListFilms(context.Context, *ListFilmsRequest) (*ListFilmsResponse, error)
+	GetFilm(context.Context, *GetFilmRequest) (*Film, error)
+	ListUserFilms(context.Context, *ListUserFilmsRequest) (*ListFilmsResponse, error)
+	PartiallyUpdateFilm(context.Context, *PartiallyUpdateFilmRequest) (*Film, error)
+	DeleteFilm(context.Context, *DeleteFilmRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedFilmServiceServer()
+}
+
+// UnimplementedFilmServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFilmServiceServer struct{}
+
+func (UnimplementedFilmServiceServer) CreateFilm(context.Context, *CreateFilmRequest) (*Film, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFilm not implemented")
+}
+func (UnimplementedFilmServiceServer) ListFilms(context.Context, *ListFilmsRequest) (*ListFilmsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFilms not implemented")
+}
+func (UnimplementedFilmServiceServer) GetFilm(context.Context, *GetFilmRequest) (*Film, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFilm not implemented")
+}
+func (UnimplementedFilmServiceServer) ListUserFilms(context.Context, *ListUserFilmsRequest) (*ListFilmsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUserFilms not implemented")
+}
+func (UnimplementedFilmServiceServer) PartiallyUpdateFilm(context.Context, *PartiallyUpdateFilmRequest) (*Film, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PartiallyUpdateFilm not implemented")
+}
+func (UnimplementedFilmServiceServer) DeleteFilm(context.Context, *DeleteFilmRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFilm not implemented")
+}
+func (UnimplementedFilmServiceServer) mustEmbedUnimplementedFilmServiceServer() {}
+func (UnimplementedFilmServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeFilmServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FilmServiceServer will
+// result in compilation errors.
+type UnsafeFilmServiceServer interface {
+	mustEmbedUnimplementedFilmServiceServer()
+}
+
+func RegisterFilmServiceServer(s grpc.ServiceRegistrar, srv FilmServiceServer) {
+	// If the following call pancis, it indicates UnimplementedFilmServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FilmService_ServiceDesc, srv)
+}
+
+func _FilmService_CreateFilm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFilmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilmServiceServer).CreateFilm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilmService_CreateFilm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilmServiceServer).CreateFilm(ctx, req.(*CreateFilmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilmService_ListFilms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFilmsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilmServiceServer).ListFilms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilmService_ListFilms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilmServiceServer).ListFilms(ctx, req.(*ListFilmsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilmService_GetFilm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFilmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilmServiceServer).GetFilm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilmService_GetFilm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilmServiceServer).GetFilm(ctx, req.(*GetFilmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilmService_ListUserFilms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUserFilmsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilmServiceServer).ListUserFilms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilmService_ListUserFilms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilmServiceServer).ListUserFilms(ctx, req.(*ListUserFilmsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilmService_PartiallyUpdateFilm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PartiallyUpdateFilmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilmServiceServer).PartiallyUpdateFilm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilmService_PartiallyUpdateFilm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilmServiceServer).PartiallyUpdateFilm(ctx, req.(*PartiallyUpdateFilmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FilmService_DeleteFilm_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFilmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FilmServiceServer).DeleteFilm(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FilmService_DeleteFilm_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FilmServiceServer).DeleteFilm(ctx, req.(*DeleteFilmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FilmService_ServiceDesc is the grpc.ServiceDesc for FilmService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FilmService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "movies.v1.FilmService",
+	HandlerType: (*FilmServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateFilm",
+			Handler:    _FilmService_CreateFilm_Handler,
+		},
+		{
+			MethodName: "ListFilms",
+			Handler:    _FilmService_ListFilms_Handler,
+		},
+		{
+			MethodName: "GetFilm",
+			Handler:    _FilmService_GetFilm_Handler,
+		},
+		{
+			MethodName: "ListUserFilms",
+			Handler:    _FilmService_ListUserFilms_Handler,
+		},
+		{
+			MethodName: "PartiallyUpdateFilm",
+			Handler:    _FilmService_PartiallyUpdateFilm_Handler,
+		},
+		{
+			MethodName: "DeleteFilm",
+			Handler:    _FilmService_DeleteFilm_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "movies/v1/films.proto",
+}
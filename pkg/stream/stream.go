@@ -0,0 +1,166 @@
+package stream
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"rest-api-tutorial/pkg/errors"
+	"rest-api-tutorial/pkg/format"
+	"strconv"
+)
+
+// Layout форма JSON-ответа со списком
+type Layout int
+
+const (
+	// Array массив [...], как в API v1
+	Array Layout = iota
+	// Envelope конверт {"data": [...], "meta": {"count": N}}, как в API v2
+	Envelope
+)
+
+// flushEvery через сколько элементов накопленная часть ответа отправляется клиенту
+const flushEvery = 100
+
+// Requested сообщает, просит ли клиент потоковый ответ: NDJSON или CSV по Accept
+// или JSON с ?stream=true. MessagePack собирается целиком middleware format.
+func Requested(c *gin.Context) bool {
+	switch format.Negotiate(c.GetHeader("Accept")) {
+	case format.NDJSON, format.CSV:
+		return true
+	case format.JSON:
+		v, _ := strconv.ParseBool(c.Query("stream"))
+		return v
+	default:
+		return false
+	}
+}
+
+// Writer пишет элементы списка в ответ по мере их появления, не собирая список в памяти.
+// Запись блокируется, пока клиент не примет данные, поэтому источник (строки pgx.Rows)
+// читается со скоростью клиента. Ответ начинается при первом элементе: до этого
+// обработчик еще может ответить ошибкой.
+type Writer struct {
+	c       *gin.Context
+	layout  Layout
+	ndjson  bool
+	csv     *format.CSVWriter
+	started bool
+	count   int
+}
+
+// New создает Writer для запроса. Формат (JSON, NDJSON или CSV) выбирается по Accept.
+func New(c *gin.Context, layout Layout) *Writer {
+	w := &Writer{c: c, layout: layout}
+	switch format.Negotiate(c.GetHeader("Accept")) {
+	case format.NDJSON:
+		w.ndjson = true
+	case format.CSV:
+		w.csv = format.NewCSVWriter(c.Writer)
+	}
+	return w
+}
+
+// Write кодирует и отправляет элемент. Ошибка означает, что клиент отключился
+// или элемент не удалось закодировать; источник нужно остановить.
+func (w *Writer) Write(v interface{}) error {
+	if err := w.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.start()
+
+	if w.csv != nil {
+		if err := w.csv.Write(data); err != nil {
+			return err
+		}
+		w.count++
+		if w.count%flushEvery == 0 {
+			if err := w.csv.Flush(); err != nil {
+				return err
+			}
+			w.c.Writer.Flush()
+		}
+		return nil
+	}
+	if w.ndjson {
+		data = append(data, '\n')
+	} else if w.count > 0 {
+		if _, err := w.c.Writer.WriteString(","); err != nil {
+			return err
+		}
+	}
+	if _, err := w.c.Writer.Write(data); err != nil {
+		return err
+	}
+	w.count++
+	if w.count%flushEvery == 0 {
+		w.c.Writer.Flush()
+	}
+	return nil
+}
+
+// Finish завершает ответ после того, как источник закончился с ошибкой err или без нее.
+// Если клиенту еще ничего не отправлено, err возвращается, и обработчик отвечает ей
+// как обычно. Начатый ответ изменить нельзя: NDJSON заканчивается строкой с ошибкой,
+// а JSON остается незакрытым, чтобы клиент не принял обрезанный список за полный.
+// В CSV ошибку записать некуда, поэтому ответ просто обрывается после последней строки.
+func (w *Writer) Finish(err error) error {
+	if err != nil && !w.started {
+		return err
+	}
+	w.start()
+	// Строки CSV, записанные до ошибки, целые, поэтому отправляются в любом случае
+	if w.csv != nil {
+		w.csv.Flush()
+	}
+
+	switch {
+	case err != nil:
+		// Отключившемуся клиенту сообщать об ошибке некому
+		if w.ndjson && w.c.Request.Context().Err() == nil {
+			line, _ := json.Marshal(errors.ErrorResponse{Code: http.StatusInternalServerError, Message: "Stream interrupted"})
+			w.c.Writer.Write(append(line, '\n'))
+		}
+	case w.ndjson, w.csv != nil:
+	case w.layout == Envelope:
+		w.c.Writer.WriteString(`],"meta":{"count":` + strconv.Itoa(w.count) + `}}`)
+	default:
+		w.c.Writer.WriteString("]")
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// start отправляет заголовки и начало списка
+func (w *Writer) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+
+	if w.csv != nil {
+		w.c.Header("Content-Type", "text/csv; charset=utf-8")
+		w.c.Header("Vary", "Accept")
+		w.c.Status(http.StatusOK)
+		w.c.Writer.WriteHeaderNow()
+		return
+	}
+	if w.ndjson {
+		w.c.Header("Content-Type", format.NDJSON)
+		w.c.Header("Vary", "Accept")
+		w.c.Status(http.StatusOK)
+		w.c.Writer.WriteHeaderNow()
+		return
+	}
+	w.c.Header("Content-Type", "application/json; charset=utf-8")
+	w.c.Status(http.StatusOK)
+	if w.layout == Envelope {
+		w.c.Writer.WriteString(`{"data":[`)
+	} else {
+		w.c.Writer.WriteString("[")
+	}
+}
@@ -0,0 +1,54 @@
+package stream
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type film struct {
+	Title  string  `json:"title"`
+	Rating float64 `json:"rating"`
+}
+
+func TestWriterCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"complete list", nil, "title,rating\nСталкер,8.1\nСолярис,8\n"},
+		// Строки до ошибки отправляются, сообщить об ошибке в CSV нельзя
+		{"interrupted list", errors.New("connection lost"), "title,rating\nСталкер,8.1\nСолярис,8\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/films", nil)
+			c.Request.Header.Set("Accept", "text/csv")
+			if !Requested(c) {
+				t.Fatal("Requested() = false for Accept: text/csv")
+			}
+
+			sw := New(c, Array)
+			for _, f := range []film{{"Сталкер", 8.1}, {"Солярис", 8}} {
+				if err := sw.Write(f); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := sw.Finish(tt.err); err != nil {
+				t.Fatalf("Finish: %v", err)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if w.Body.String() != tt.want {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.want)
+			}
+		})
+	}
+}